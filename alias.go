@@ -0,0 +1,98 @@
+package flag
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Alias adds short as another name for the defined flag long, typically a
+// one-letter form: after Alias("p", "port"), -p 8080 and --port 8080 set the
+// same Value. Help lists the flag once as "-p, --port int". Sources other
+// than the command line (environment, config files, secrets) only know the
+// flag by its own name. Alias panics if long is not defined or short is
+// already in use.
+func (f *FlagSet) Alias(short, long string) {
+	fl := f.formal[long]
+	if fl == nil {
+		panic(fmt.Sprintf("flag: alias %s for undefined flag %s", short, long))
+	}
+	if _, taken := f.formal[short]; taken || f.aliases[short] != "" {
+		var msg string
+		if f.name == "" {
+			msg = fmt.Sprintf("flag redefined: %s", short)
+		} else {
+			msg = fmt.Sprintf("%s flag redefined: %s", f.name, short)
+		}
+		fmt.Fprintln(f.out(), msg)
+		panic(msg)
+	}
+	if f.aliases == nil {
+		f.aliases = make(map[string]string)
+	}
+	f.aliases[short] = long
+	fl.Aliases = append(fl.Aliases, short)
+	sort.Slice(fl.Aliases, func(i, j int) bool {
+		if len(fl.Aliases[i]) != len(fl.Aliases[j]) {
+			return len(fl.Aliases[i]) < len(fl.Aliases[j])
+		}
+		return fl.Aliases[i] < fl.Aliases[j]
+	})
+}
+
+// Alias adds another name for a flag of the default CommandLine FlagSet.
+func Alias(short, long string) { CommandLine.Alias(short, long) }
+
+// resolveAlias returns the name of the flag name is an alias for, or name
+// itself.
+func (f *FlagSet) resolveAlias(name string) string {
+	if long, ok := f.aliases[name]; ok {
+		return long
+	}
+	return name
+}
+
+// expandBundle splits a bundle of one-letter flags such as -xvf into -x -v
+// -f, POSIX style. It returns nil unless name has several letters, each
+// naming a flag, all but the last boolean; the last may take a value, from
+// the next argument or after "=" as in -xvf=archive.tar.
+func (f *FlagSet) expandBundle(name, value string, hasValue bool) []string {
+	if len(name) < 2 {
+		return nil
+	}
+	out := make([]string, 0, len(name))
+	for i := 0; i < len(name); i++ {
+		c := name[i : i+1]
+		fl, _ := f.LookupOrigin(c)
+		if fl == nil {
+			return nil
+		}
+		if i < len(name)-1 {
+			if bf, ok := fl.Value.(boolFlag); !ok || !bf.IsBoolFlag() {
+				return nil
+			}
+		}
+		out = append(out, "-"+c)
+	}
+	if hasValue {
+		out[len(out)-1] += "=" + value
+	}
+	return out
+}
+
+// SetNoOptDefVal lets the non-boolean flag name be given without an
+// argument, taking value then: after SetNoOptDefVal("color", "auto"), -color
+// means -color=auto while -color=never still works. An argument must then be
+// attached with "=", as -color never leaves "never" as a positional argument.
+// Help shows the flag as "-color string[=auto]". It panics if name is not
+// defined.
+func (f *FlagSet) SetNoOptDefVal(name, value string) {
+	fl := f.formal[f.resolveAlias(name)]
+	if fl == nil {
+		panic(fmt.Sprintf("flag: SetNoOptDefVal for undefined flag %s", name))
+	}
+	fl.NoOptDefVal = value
+}
+
+// SetNoOptDefVal sets the no-argument value of a flag of the default
+// CommandLine FlagSet.
+func SetNoOptDefVal(name, value string) { CommandLine.SetNoOptDefVal(name, value) }
@@ -0,0 +1,188 @@
+package flag_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	. "github.com/machship/flag"
+)
+
+func TestAlias(t *testing.T) {
+	var out strings.Builder
+	f := NewFlagSet("test", ContinueOnError)
+	f.SetOutput(&out)
+	port := f.Int("port", 8080, "listen `port`")
+	verbose := f.Bool("verbose", false, "verbose output")
+	f.Alias("p", "port")
+	f.Alias("v", "verbose")
+
+	if err := f.Parse([]string{"-p", "9000", "-v", "rest"}); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if *port != 9000 || !*verbose || f.Arg(0) != "rest" {
+		t.Fatalf("port=%d verbose=%t args=%v", *port, *verbose, f.Args())
+	}
+	var set []string
+	f.Visit(func(fl *Flag) { set = append(set, fl.Name) })
+	if strings.Join(set, ",") != "port,verbose" {
+		t.Fatalf("Visit saw %v", set)
+	}
+	if f.Lookup("p") != f.Lookup("port") {
+		t.Fatal("Lookup(p) is not -port")
+	}
+	if err := f.Set("p", "7000"); err != nil || *port != 7000 {
+		t.Fatalf("Set(p): %v, port=%d", err, *port)
+	}
+	if err := f.Parse([]string{"--port=6000"}); err != nil || *port != 6000 {
+		t.Fatalf("--port: %v, port=%d", err, *port)
+	}
+
+	f.PrintDefaults()
+	if !strings.Contains(out.String(), "  -p, --port port\n    \tlisten port (default 8080)\n") || !strings.Contains(out.String(), "  -v, --verbose\n") {
+		t.Fatalf("defaults:\n%s", out.String())
+	}
+	for _, m := range f.Introspect() {
+		if m.Name == "port" && (len(m.Aliases) != 1 || m.Aliases[0] != "p") {
+			t.Fatalf("FlagMeta = %+v", m)
+		}
+	}
+
+	for _, tc := range []struct{ short, long, want string }{
+		{"p", "verbose", "flag redefined: p"},
+		{"port", "verbose", "flag redefined: port"},
+		{"x", "missing", "alias x for undefined flag missing"},
+	} {
+		func() {
+			defer func() {
+				if r := recover(); r == nil || !strings.Contains(r.(string), tc.want) {
+					t.Errorf("Alias(%s, %s) recovered %v, want %q", tc.short, tc.long, r, tc.want)
+				}
+			}()
+			f.Alias(tc.short, tc.long)
+		}()
+	}
+}
+
+func TestAliasInheritedAndStruct(t *testing.T) {
+	root := NewCommand("tool", "", nil)
+	verbose := root.Flags.Bool("verbose", false, "")
+	root.Flags.Alias("v", "verbose")
+	root.Flags.MarkPersistent("verbose")
+	serve := NewCommand("serve", "", func([]string) error { return nil })
+	root.AddCommand(serve)
+	if err := root.Execute([]string{"serve", "-v"}); err != nil || !*verbose {
+		t.Fatalf("serve -v: %v, verbose=%t", err, *verbose)
+	}
+
+	ResetForTesting(nil)
+	saved := os.Args
+	os.Args = []string{"cmd", "-n", "3"}
+	defer func() { os.Args = saved }()
+	var cfg struct {
+		Count int `flag:"count" short:"n"`
+	}
+	if err := ParseStruct(&cfg); err != nil || cfg.Count != 3 {
+		t.Fatalf("ParseStruct: %v, count=%d", err, cfg.Count)
+	}
+
+	ResetForTesting(nil)
+	var clash struct {
+		Count int  `flag:"count"`
+		Dry   bool `flag:"dry-run" short:"count"`
+	}
+	err := ParseStruct(&clash)
+	if err == nil || !strings.Contains(err.Error(), `short name -count of flag "dry-run" is already in use`) {
+		t.Fatalf("ParseStruct err = %v", err)
+	}
+}
+
+func TestShortFlagBundles(t *testing.T) {
+	newSet := func() (*FlagSet, *bool, *bool, *string) {
+		f := NewFlagSet("tar", ContinueOnError)
+		f.SetOutput(new(strings.Builder))
+		x := f.Bool("extract", false, "")
+		v := f.Bool("v", false, "")
+		file := f.String("file", "", "")
+		f.Alias("x", "extract")
+		f.Alias("f", "file")
+		return f, x, v, file
+	}
+	for _, args := range [][]string{
+		{"-xvf", "a.tar", "rest"},
+		{"-xvf=a.tar", "rest"},
+		{"-vx", "-f", "a.tar", "rest"},
+	} {
+		f, x, v, file := newSet()
+		if err := f.Parse(args); err != nil {
+			t.Fatalf("%q: %v", args, err)
+		}
+		if !*x || !*v || *file != "a.tar" || f.NArg() != 1 || f.Arg(0) != "rest" {
+			t.Fatalf("%q: x=%t v=%t file=%q args=%v", args, *x, *v, *file, f.Args())
+		}
+	}
+
+	for args, want := range map[string]string{
+		"-xfv a.tar": "flag provided but not defined: -xfv", // -f takes a value, so it must come last
+		"-xq":        "flag provided but not defined: -xq",
+		"--xv":       "flag provided but not defined: -xv", // bundles take a single dash
+		"-xvf":       "flag needs an argument: -file",
+	} {
+		f, _, _, _ := newSet()
+		if err := f.Parse(strings.Fields(args)); err == nil || err.Error() != want {
+			t.Errorf("%s: err = %v, want %s", args, err, want)
+		}
+	}
+}
+
+func TestNoOptDefVal(t *testing.T) {
+	newSet := func() (*FlagSet, *string, *strings.Builder) {
+		var out strings.Builder
+		f := NewFlagSet("test", ContinueOnError)
+		f.SetOutput(&out)
+		color := f.String("color", "never", "colorize `when`")
+		f.Alias("c", "color")
+		f.Bool("v", false, "")
+		f.SetNoOptDefVal("color", "auto")
+		return f, color, &out
+	}
+	for args, want := range map[string]string{
+		"":                  "never",
+		"-color":            "auto",
+		"--color=always":    "always",
+		"-color never rest": "auto", // the argument must be attached
+		"-c":                "auto",
+		"-vc":               "auto",
+		"-vc=always":        "always",
+	} {
+		f, color, _ := newSet()
+		if err := f.Parse(strings.Fields(args)); err != nil {
+			t.Fatalf("%q: %v", args, err)
+		}
+		if *color != want {
+			t.Errorf("%q: color = %q, want %q", args, *color, want)
+		}
+	}
+
+	f, _, out := newSet()
+	f.PrintDefaults()
+	if !strings.Contains(out.String(), "  -c, --color when[=auto]\n    \tcolorize when (default \"never\")\n") {
+		t.Fatalf("defaults:\n%s", out.String())
+	}
+	for _, m := range f.Introspect() {
+		if m.Name == "color" && m.NoOptDefVal != "auto" {
+			t.Fatalf("FlagMeta = %+v", m)
+		}
+	}
+
+	ResetForTesting(nil)
+	saved := os.Args
+	os.Args = []string{"cmd", "-color"}
+	defer func() { os.Args = saved }()
+	var cfg struct {
+		Color string `flag:"color" default:"never" noOptDefVal:"auto"`
+	}
+	if err := ParseStruct(&cfg); err != nil || cfg.Color != "auto" {
+		t.Fatalf("ParseStruct: %v, color=%q", err, cfg.Color)
+	}
+}
@@ -0,0 +1,168 @@
+package flag
+
+import (
+	"os/user"
+	"sync"
+	"time"
+)
+
+// Attempt records one value a source tried to apply to a flag.
+type Attempt struct {
+	Source Source
+	// Value is the value as handed to the flag (after @file expansion), or
+	// "******" for sensitive flags.
+	Value string
+	// Applied is false when the attempt failed or was skipped because a
+	// higher-precedence source had already set the flag.
+	Applied bool
+	// Err is the error returned by Set, if any.
+	Err error
+}
+
+// EnableAudit turns recording of per-flag value attempts on or off. While on,
+// every value a source supplies is appended to the flag's History, including
+// values skipped because of precedence, which helps answer "why is this flag
+// X" in production. Turning audit off keeps existing history.
+func (f *FlagSet) EnableAudit(enabled bool) { f.auditEnabled = enabled }
+
+// EnableAudit turns attempt recording on or off for the default CommandLine FlagSet.
+func EnableAudit(enabled bool) { CommandLine.EnableAudit(enabled) }
+
+// History returns the attempts recorded for the flag while audit was enabled,
+// oldest first.
+func (fl *Flag) History() []Attempt {
+	return append([]Attempt(nil), fl.history...)
+}
+
+// AuditEvent records a value applied to a flag, for an audit trail of
+// configuration changes. Old and New are "******" for sensitive flags.
+type AuditEvent struct {
+	Time      time.Time `json:"time"`
+	Flag      string    `json:"flag"`
+	Source    Source    `json:"source"`
+	Actor     string    `json:"actor,omitempty"` // the OS user for cli and env values, the file for config and secret values, the provider for remote values
+	Old       string    `json:"old"`
+	New       string    `json:"new"`
+	Sensitive bool      `json:"sensitive,omitempty"`
+	Reload    bool      `json:"reload,omitempty"` // applied by Reload, the watcher, ReloadOnSignal or SubscribeRemote rather than Parse
+}
+
+// SetAuditSink sets fn to receive an AuditEvent for every value applied to
+// a flag, whether or not EnableAudit is on, e.g. to ship configuration
+// changes to a compliance log. While parsing, fn is called as each value is
+// applied; for a reload, once it has succeeded, for each flag whose value
+// changed, including flags falling back to their default. Rejected reloads
+// emit nothing. fn is called synchronously and must not block for long. A
+// nil fn removes the sink.
+func (f *FlagSet) SetAuditSink(fn func(AuditEvent)) { f.auditSink = fn }
+
+// SetAuditSink sets the audit sink of the default CommandLine FlagSet.
+func SetAuditSink(fn func(AuditEvent)) { CommandLine.SetAuditSink(fn) }
+
+// audit appends an attempt to flag's history when auditing is enabled, and
+// reports applied values to the audit sink.
+func (f *FlagSet) audit(flag *Flag, source Source, value string, applied bool, err error) {
+	if flag == nil {
+		return
+	}
+	sensitive := f.isSensitive(flag.Name) || flag.Sensitive
+	if applied && f.auditSink != nil {
+		f.auditApplied(flag, source, sensitive)
+	}
+	if !f.auditEnabled {
+		return
+	}
+	if sensitive {
+		value = "******"
+	}
+	flag.history = append(flag.history, Attempt{Source: source, Value: value, Applied: applied, Err: err})
+}
+
+// auditApplied sends the audit sink an event for a value just applied to
+// flag, or notes its actor when a reload is collecting them.
+func (f *FlagSet) auditApplied(flag *Flag, source Source, sensitive bool) {
+	actor := f.auditActor
+	if actor == "" && (source == SourceCLI || source == SourceEnv) {
+		actor = processUser()
+	}
+	if f.auditBatch != nil {
+		f.auditBatch[flag.Name] = actor
+		return
+	}
+	old, ok := f.auditSeen[flag.Name]
+	if !ok {
+		old = flag.DefValue
+	}
+	cur := flag.Value.String()
+	f.noteAudited(flag.Name, cur)
+	ev := AuditEvent{Time: f.now(), Flag: flag.Name, Source: source, Actor: actor, Old: old, New: cur}
+	if sensitive {
+		ev.Old, ev.New, ev.Sensitive = "******", "******", true
+	}
+	f.auditSink(ev)
+}
+
+// noteAudited records value as the one the audit sink last saw for name.
+func (f *FlagSet) noteAudited(name, value string) {
+	if f.auditSeen == nil {
+		f.auditSeen = make(map[string]string)
+	}
+	f.auditSeen[name] = value
+}
+
+// setAuditActor makes actor the one reported for the values applied until
+// the returned function restores the previous one.
+func (f *FlagSet) setAuditActor(actor string) (restore func()) {
+	prev := f.auditActor
+	f.auditActor = actor
+	return func() { f.auditActor = prev }
+}
+
+// beginAuditBatch starts collecting the actors of a reload's values. The
+// caller holds watchMu.
+func (f *FlagSet) beginAuditBatch() {
+	if f.auditSink != nil {
+		f.auditBatch = make(map[string]string)
+	}
+}
+
+// endAuditBatch stops collecting and returns the events for changes, the
+// outcome of a successful reload, or nil if the reload failed. The caller
+// holds watchMu and sends the events once it has released it.
+func (f *FlagSet) endAuditBatch(changes []Change, ok bool) []AuditEvent {
+	actors := f.auditBatch
+	f.auditBatch = nil
+	if actors == nil || !ok {
+		return nil
+	}
+	now := f.now()
+	events := make([]AuditEvent, 0, len(changes))
+	for _, c := range changes {
+		events = append(events, AuditEvent{
+			Time: now, Flag: c.Name, Source: Source(c.Source), Actor: actors[c.Name],
+			Old: c.Old, New: c.New, Sensitive: c.Sensitive, Reload: true,
+		})
+		if fl := f.formal[c.Name]; fl != nil {
+			f.noteAudited(c.Name, fl.Value.String())
+		}
+	}
+	return events
+}
+
+// sendAudit passes events to the audit sink.
+func (f *FlagSet) sendAudit(events []AuditEvent) {
+	for _, ev := range events {
+		f.auditSink(ev)
+	}
+}
+
+var processUserName = sync.OnceValue(func() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return ""
+})
+
+// processUser returns the name of the user running the process, who
+// supplied its command line and environment, or "" if it is unknown.
+func processUser() string { return processUserName() }
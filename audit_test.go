@@ -0,0 +1,162 @@
+package flag_test
+
+import (
+	"context"
+	"io"
+	"os"
+	"os/user"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/machship/flag"
+)
+
+func TestAuditHistory(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "port"), []byte("7000\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "password"), []byte("hunter2"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cfg := filepath.Join(t.TempDir(), "app.conf")
+	if err := os.WriteFile(cfg, []byte("port=6000\nhost=cfg.internal\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	f := NewFlagSet("test", ContinueOnError)
+	f.EnableAudit(true)
+	f.Int("port", 8080, "")
+	f.String("host", "localhost", "")
+	f.String("password", "", "")
+	f.MarkSensitive("password")
+	untouched := f.Bool("debug", false, "")
+
+	if err := f.Parse([]string{"-port", "9000"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.ParseEnv([]string{"PORT=8000", "PASSWORD=env-secret"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.ParseSecretDir(dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.ParseFile(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Attempt{
+		{Source: SourceCLI, Value: "9000", Applied: true},
+		{Source: SourceEnv, Value: "8000"},
+		{Source: SourceSecret, Value: "7000"},
+		{Source: SourceConfig, Value: "6000"},
+	}
+	got := f.Lookup("port").History()
+	if len(got) != len(want) {
+		t.Fatalf("port history = %+v", got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("port attempt %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	pw := f.Lookup("password").History()
+	if len(pw) != 2 || pw[0].Value != "******" || !pw[0].Applied || pw[1].Value != "******" || pw[1].Applied {
+		t.Errorf("password history = %+v", pw)
+	}
+	if h := f.Lookup("host").History(); len(h) != 1 || h[0].Source != SourceConfig || !h[0].Applied {
+		t.Errorf("host history = %+v", h)
+	}
+	if h := f.Lookup("debug").History(); len(h) != 0 || *untouched {
+		t.Errorf("debug history = %+v", h)
+	}
+}
+
+func TestAuditFailedAttempt(t *testing.T) {
+	f := NewFlagSet("test", ContinueOnError)
+	f.EnableAudit(true)
+	f.Int("port", 0, "")
+	if err := f.ParseEnv([]string{"PORT=eighty"}); err == nil {
+		t.Fatal("expected parse error")
+	}
+	h := f.Lookup("port").History()
+	if len(h) != 1 || h[0].Applied || h[0].Err == nil || h[0].Value != "eighty" {
+		t.Fatalf("history = %+v", h)
+	}
+
+	g := NewFlagSet("test", ContinueOnError)
+	g.Int("port", 0, "")
+	if err := g.Parse([]string{"-port", "1"}); err != nil {
+		t.Fatal(err)
+	}
+	if h := g.Lookup("port").History(); len(h) != 0 {
+		t.Fatalf("history recorded without audit: %+v", h)
+	}
+}
+
+func TestAuditSink(t *testing.T) {
+	cfg := filepath.Join(t.TempDir(), "app.conf")
+	if err := os.WriteFile(cfg, []byte("host cfg.internal\npassword hunter2\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	f := NewFlagSet("test", ContinueOnError)
+	f.SetOutput(io.Discard)
+	f.String(DefaultConfigFlagname, "", "")
+	f.Int("port", 8080, "")
+	f.String("host", "localhost", "")
+	f.String("password", "", "")
+	f.MarkSensitive("password")
+	clock := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	f.SetClock(func() time.Time { return clock })
+	var events []AuditEvent
+	f.SetAuditSink(func(ev AuditEvent) { events = append(events, ev) })
+
+	if err := f.Parse([]string{"-port", "9000", "-" + DefaultConfigFlagname, cfg}); err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]AuditEvent)
+	for _, ev := range events {
+		got[ev.Flag] = ev
+	}
+	if ev := got["port"]; ev.Source != SourceCLI || ev.Old != "8080" || ev.New != "9000" || ev.Reload || !ev.Time.Equal(clock) {
+		t.Errorf("port event = %+v", ev)
+	}
+	if u, err := user.Current(); err == nil && got["port"].Actor != u.Username {
+		t.Errorf("port actor = %q, want %q", got["port"].Actor, u.Username)
+	}
+	if ev := got["host"]; ev.Source != SourceConfig || ev.Actor != cfg || ev.Old != "localhost" || ev.New != "cfg.internal" {
+		t.Errorf("host event = %+v", ev)
+	}
+	if ev := got["password"]; !ev.Sensitive || ev.Old != "******" || ev.New != "******" {
+		t.Errorf("password event = %+v", ev)
+	}
+
+	// A reload reports the flags it changed, including those falling back
+	// to their default, once it has succeeded.
+	events = nil
+	os.WriteFile(cfg, []byte("host new.internal\n"), 0600)
+	if _, err := f.Reload(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("reload events = %+v", events)
+	}
+	if ev := events[0]; ev.Flag != "host" || !ev.Reload || !ev.Time.Equal(clock) || ev.Actor != cfg || ev.Old != "cfg.internal" || ev.New != "new.internal" {
+		t.Errorf("host reload event = %+v", ev)
+	}
+	if ev := events[1]; ev.Flag != "password" || ev.Source != SourceDefault || !ev.Sensitive || ev.New != "******" {
+		t.Errorf("password reload event = %+v", ev)
+	}
+
+	// A rejected reload reports nothing.
+	events = nil
+	os.WriteFile(cfg, []byte("host other.internal\nport-typo 1\n"), 0600)
+	if _, err := f.Reload(context.Background()); err == nil {
+		t.Fatal("expected reload error")
+	}
+	if len(events) != 0 {
+		t.Fatalf("rejected reload events = %+v", events)
+	}
+}
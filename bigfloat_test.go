@@ -0,0 +1,62 @@
+package flag_test
+
+import (
+	"math/big"
+	"os"
+	"strings"
+	"testing"
+
+	. "github.com/machship/flag"
+)
+
+func TestBigFloatFlag(t *testing.T) {
+	f := NewFlagSet("test", ContinueOnError)
+	x := f.BigFloat("x", big.NewFloat(1.5), 200, big.ToZero, "")
+	if got := f.Lookup("x").DefValue; got != "1.5" {
+		t.Fatalf("DefValue = %q", got)
+	}
+	if err := f.Parse([]string{"-x", "0.1"}); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if x.Prec() != 200 || x.Mode() != big.ToZero {
+		t.Fatalf("prec=%d mode=%v", x.Prec(), x.Mode())
+	}
+	// 0.1 at 200 bits carries far more digits than float64 can hold
+	if got := x.Text('f', 30); got != "0.100000000000000000000000000000" {
+		t.Fatalf("0.1 at 200 bits = %s", got)
+	}
+	if err := f.Set("x", "abc"); err == nil || !strings.Contains(err.Error(), "invalid big.Float") {
+		t.Fatalf("expected parse error, got %v", err)
+	}
+}
+
+func TestBigFloatStructTags(t *testing.T) {
+	ResetForTesting(nil)
+	saved := os.Args
+	os.Args = []string{"cmd", "-ratio", "2.5"}
+	defer func() { os.Args = saved }()
+	var cfg struct {
+		Ratio big.Float `flag:"ratio" prec:"128" rounding:"AwayFromZero"`
+		Scale big.Float `flag:"scale" default:"1e-30"`
+	}
+	if err := ParseStruct(&cfg); err != nil {
+		t.Fatalf("ParseStruct: %v", err)
+	}
+	if cfg.Ratio.Prec() != 128 || cfg.Ratio.Mode() != big.AwayFromZero {
+		t.Errorf("ratio prec=%d mode=%v", cfg.Ratio.Prec(), cfg.Ratio.Mode())
+	}
+	if f, _ := cfg.Ratio.Float64(); f != 2.5 {
+		t.Errorf("ratio = %v", f)
+	}
+	if f, _ := cfg.Scale.Float64(); f != 1e-30 {
+		t.Errorf("scale = %v", f)
+	}
+
+	ResetForTesting(nil)
+	var bad struct {
+		X big.Float `flag:"x" rounding:"sideways"`
+	}
+	if err := ParseStructWithOptions(&bad, ParseStructOptions{}); err == nil || !strings.Contains(err.Error(), "unknown rounding mode") {
+		t.Errorf("expected rounding error, got %v", err)
+	}
+}
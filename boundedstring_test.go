@@ -0,0 +1,50 @@
+package flag_test
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+
+	. "github.com/machship/flag"
+)
+
+func TestBoundedStringFlag(t *testing.T) {
+	f := NewFlagSet("test", ContinueOnError)
+	s := f.BoundedString("id", "def", 5, "")
+	if err := f.Parse([]string{"-id", "héllo"}); err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if *s != "héllo" {
+		t.Fatalf("expected multi-byte value within character limit, got %q", *s)
+	}
+	if err := f.Set("id", "toolong"); err == nil || !strings.Contains(err.Error(), "7 characters (max 5)") {
+		t.Fatalf("expected length error, got %v", err)
+	}
+	if err := f.Set("id", strings.Repeat("x", 1<<20)); err == nil || !strings.Contains(err.Error(), "too long") {
+		t.Fatalf("expected early rejection of huge value, got %v", err)
+	}
+	if err := f.Set("id", "a\xffb"); err == nil || !strings.Contains(err.Error(), "UTF-8") {
+		t.Fatalf("expected UTF-8 error, got %v", err)
+	}
+	if *s != "héllo" {
+		t.Fatalf("rejected values must not overwrite, got %q", *s)
+	}
+	u := f.BoundedString("free", "", -1, "")
+	if err := f.Set("free", strings.Repeat("y", 100)); err != nil || len(*u) != 100 {
+		t.Fatalf("expected unlimited value accepted, err=%v", err)
+	}
+	m := f.BoundedString("huge", "", math.MaxInt, "")
+	if err := f.Set("huge", "abc"); err != nil || *m != "abc" {
+		t.Fatalf("expected value within a huge limit accepted, err=%v", err)
+	}
+}
+
+func TestBoundedStringInvalidDefault(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil || !strings.Contains(fmt.Sprint(r), "invalid default for -id") {
+			t.Fatalf("expected panic for oversized default, got %v", r)
+		}
+	}()
+	NewFlagSet("test", ContinueOnError).BoundedString("id", "toolong", 5, "")
+}
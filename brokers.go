@@ -0,0 +1,142 @@
+package flag
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// BrokerListOptions adjusts how BrokerListVarWithOptions checks brokers.
+type BrokerListOptions struct {
+	// DefaultPort is added to brokers given without a port, such as 9092 for
+	// Kafka. With 0 every broker needs a port.
+	DefaultPort int
+	// ResolveDNS looks up each host name when the flag is set and rejects
+	// names that do not resolve, so a typo fails at startup rather than on
+	// the first connection attempt. IP addresses are not looked up.
+	ResolveDNS bool
+}
+
+// parseBrokerList splits a comma-separated list of host:port brokers,
+// validating each and dropping repeats (host names compare without regard to
+// case). Empty elements are ignored.
+func parseBrokerList(elems []string, opts BrokerListOptions) ([]string, error) {
+	out := make([]string, 0, len(elems))
+	seen := make(map[string]bool)
+	for _, e := range elems {
+		e = strings.TrimSpace(e)
+		if e == "" {
+			continue
+		}
+		b, err := normalizeBroker(e, opts)
+		if err != nil {
+			return nil, err
+		}
+		if key := strings.ToLower(b); !seen[key] {
+			seen[key] = true
+			out = append(out, b)
+		}
+	}
+	return out, nil
+}
+
+func normalizeBroker(s string, opts BrokerListOptions) (string, error) {
+	host, port, err := net.SplitHostPort(s)
+	if err != nil {
+		if opts.DefaultPort == 0 || strings.Count(s, ":") == 1 {
+			return "", fmt.Errorf("invalid broker %q: expected host:port", s)
+		}
+		host, port = strings.Trim(s, "[]"), strconv.Itoa(opts.DefaultPort)
+	}
+	if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+		return "", fmt.Errorf("invalid broker %q: invalid port %q", s, port)
+	}
+	if net.ParseIP(host) == nil {
+		if !validHostname(host) {
+			return "", fmt.Errorf("invalid broker %q: invalid host %q", s, host)
+		}
+		if opts.ResolveDNS {
+			if _, err := net.LookupHost(host); err != nil {
+				return "", fmt.Errorf("invalid broker %q: %v", s, err)
+			}
+		}
+	}
+	return net.JoinHostPort(host, port), nil
+}
+
+// validHostname reports whether s is a syntactically valid DNS host name.
+func validHostname(s string) bool {
+	s = strings.TrimSuffix(s, ".")
+	if s == "" || len(s) > 253 {
+		return false
+	}
+	for _, label := range strings.Split(s, ".") {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for i := 0; i < len(label); i++ {
+			if c := label[i]; !isAlnum(c) && c != '-' && c != '_' {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+type brokerListValue struct {
+	p    *[]string
+	opts BrokerListOptions
+}
+
+func newBrokerListValue(val []string, opts BrokerListOptions, p *[]string) *brokerListValue {
+	*p = append((*p)[:0], val...)
+	return &brokerListValue{p: p, opts: opts}
+}
+func (bv *brokerListValue) Set(s string) error {
+	return bv.setElements(strings.Split(s, ","))
+}
+func (bv *brokerListValue) setElements(elems []string) error {
+	brokers, err := parseBrokerList(elems, bv.opts)
+	if err != nil {
+		return err
+	}
+	*bv.p = brokers
+	return nil
+}
+func (bv *brokerListValue) String() string {
+	if bv.p == nil {
+		return ""
+	}
+	return strings.Join(*bv.p, ",")
+}
+func (bv *brokerListValue) Get() interface{} { return *bv.p }
+
+// BrokerListVar registers a flag holding a comma-separated list of host:port
+// brokers, such as "kafka-1:9092,kafka-2:9092". Each element must have a port
+// and a valid host; repeats are dropped. The default value is stored as given.
+func (f *FlagSet) BrokerListVar(p *[]string, name string, value []string, usage string) {
+	f.BrokerListVarWithOptions(p, name, value, BrokerListOptions{}, usage)
+}
+func BrokerListVar(p *[]string, name string, value []string, usage string) {
+	CommandLine.BrokerListVar(p, name, value, usage)
+}
+
+// BrokerListVarWithOptions is BrokerListVar with a default port and optional
+// DNS checks.
+func (f *FlagSet) BrokerListVarWithOptions(p *[]string, name string, value []string, opts BrokerListOptions, usage string) {
+	f.Var(newBrokerListValue(value, opts, p), name, usage)
+}
+func BrokerListVarWithOptions(p *[]string, name string, value []string, opts BrokerListOptions, usage string) {
+	CommandLine.BrokerListVarWithOptions(p, name, value, opts, usage)
+}
+
+// BrokerList defines a broker list flag and returns a pointer to it.
+func (f *FlagSet) BrokerList(name string, value []string, usage string) *[]string {
+	p := new([]string)
+	f.BrokerListVar(p, name, value, usage)
+	return p
+}
+func BrokerList(name string, value []string, usage string) *[]string {
+	return CommandLine.BrokerList(name, value, usage)
+}
@@ -0,0 +1,71 @@
+package flag_test
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	. "github.com/machship/flag"
+)
+
+func TestBrokerListFlag(t *testing.T) {
+	f := NewFlagSet("test", ContinueOnError)
+	brokers := f.BrokerList("brokers", []string{"localhost:9092"}, "")
+	if err := f.Parse([]string{"-brokers", " kafka-1:9092, Kafka-1:9092,10.0.0.7:9093,,[::1]:9094,kafka-1:9092 "}); err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	want := []string{"kafka-1:9092", "10.0.0.7:9093", "[::1]:9094"}
+	if !reflect.DeepEqual(*brokers, want) {
+		t.Fatalf("brokers = %q, want %q", *brokers, want)
+	}
+	if s := f.Lookup("brokers").Value.String(); s != "kafka-1:9092,10.0.0.7:9093,[::1]:9094" {
+		t.Fatalf("String() = %q", s)
+	}
+
+	cases := map[string]string{
+		"kafka-1":             "expected host:port",
+		"kafka-1:0":           "invalid port \"0\"",
+		"kafka-1:http":        "invalid port",
+		":9092":               "invalid host \"\"",
+		"kafka_1.-bad:9092":   "invalid host",
+		"kafka://kafka-1:909": "invalid",
+	}
+	for in, want := range cases {
+		if err := f.Set("brokers", in); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Set(%q): expected %q, got %v", in, want, err)
+		}
+	}
+}
+
+func TestBrokerListOptions(t *testing.T) {
+	f := NewFlagSet("test", ContinueOnError)
+	var brokers []string
+	f.BrokerListVarWithOptions(&brokers, "brokers", nil, BrokerListOptions{DefaultPort: 9092, ResolveDNS: true}, "")
+	if err := f.Set("brokers", "localhost,127.0.0.1:9093,::1"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if want := []string{"localhost:9092", "127.0.0.1:9093", "[::1]:9092"}; !reflect.DeepEqual(brokers, want) {
+		t.Fatalf("brokers = %q, want %q", brokers, want)
+	}
+	if err := f.Set("brokers", "localhost,no-such-broker.invalid"); err == nil || !strings.Contains(err.Error(), "no-such-broker.invalid") {
+		t.Fatalf("unresolvable broker err = %v", err)
+	}
+}
+
+func TestBrokerListStructTag(t *testing.T) {
+	ResetForTesting(nil)
+	saved := os.Args
+	os.Args = []string{"cmd", "-events", "kafka-1,kafka-2:9093"}
+	defer func() { os.Args = saved }()
+	var cfg struct {
+		Events []string `flag:"events" brokers:"true" defaultPort:"9092"`
+		Audit  []string `flag:"audit" brokers:"true" defaultPort:"9092" default:"audit-1, audit-2"`
+	}
+	if err := ParseStruct(&cfg); err != nil {
+		t.Fatalf("ParseStruct: %v", err)
+	}
+	if !reflect.DeepEqual(cfg.Events, []string{"kafka-1:9092", "kafka-2:9093"}) || !reflect.DeepEqual(cfg.Audit, []string{"audit-1:9092", "audit-2:9092"}) {
+		t.Fatalf("got %+v", cfg)
+	}
+}
@@ -0,0 +1,203 @@
+package flag
+
+import (
+	"fmt"
+	"strings"
+)
+
+// BusinessNumberFunc validates a business identifier with its separators
+// already removed and returns it in canonical form, or an error saying what
+// is wrong with it.
+type BusinessNumberFunc func(s string) (string, error)
+
+var businessNumberSchemes = map[string]BusinessNumberFunc{
+	"ABN":  checkABN,
+	"ACN":  checkACN,
+	"NZBN": checkNZBN,
+}
+
+// RegisterBusinessNumber adds a business number scheme, such as a VAT or
+// company registration number of another jurisdiction, for
+// BusinessNumberVar and the businessNumber struct tag. kind is matched
+// without regard to case; registering a built-in kind (ABN, ACN, NZBN)
+// replaces it. Like RegisterStructHandler it is meant to be called from init.
+func RegisterBusinessNumber(kind string, check BusinessNumberFunc) {
+	if kind == "" || check == nil {
+		panic("flag: RegisterBusinessNumber needs a kind and a check function")
+	}
+	businessNumberSchemes[strings.ToUpper(kind)] = check
+}
+
+func lookupBusinessNumber(kind string) (BusinessNumberFunc, bool) {
+	check, ok := businessNumberSchemes[strings.ToUpper(kind)]
+	return check, ok
+}
+
+// normalizeBusinessNumber removes spaces, dashes and dots from s and checks
+// it as a number of the given kind.
+func normalizeBusinessNumber(s, kind string) (string, error) {
+	check, ok := lookupBusinessNumber(kind)
+	if !ok {
+		return "", fmt.Errorf("unknown business number kind %q", kind)
+	}
+	compact := strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '.', '\t':
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(s))
+	n, err := check(compact)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s %q: %v", strings.ToUpper(kind), s, err)
+	}
+	return n, nil
+}
+
+// digits converts s to its digit values if it has exactly n decimal digits.
+func digits(s string, n int) ([]int, error) {
+	if len(s) != n || strings.Trim(s, "0123456789") != "" {
+		return nil, fmt.Errorf("expected %d digits", n)
+	}
+	d := make([]int, n)
+	for i := range s {
+		d[i] = int(s[i] - '0')
+	}
+	return d, nil
+}
+
+// checkABN validates an Australian Business Number: subtract 1 from the
+// first digit and the weighted sum of the 11 digits must divide by 89.
+func checkABN(s string) (string, error) {
+	d, err := digits(s, 11)
+	if err != nil {
+		return "", err
+	}
+	if d[0] == 0 {
+		return "", fmt.Errorf("cannot start with 0")
+	}
+	d[0]--
+	sum := 0
+	for i, w := range [11]int{10, 1, 3, 5, 7, 9, 11, 13, 15, 17, 19} {
+		sum += d[i] * w
+	}
+	if sum%89 != 0 {
+		return "", fmt.Errorf("checksum mismatch")
+	}
+	return s, nil
+}
+
+// checkACN validates an Australian Company Number (also the ARBN): the last
+// of its 9 digits complements the weighted sum of the others to a multiple
+// of 10.
+func checkACN(s string) (string, error) {
+	d, err := digits(s, 9)
+	if err != nil {
+		return "", err
+	}
+	sum := 0
+	for i := 0; i < 8; i++ {
+		sum += d[i] * (8 - i)
+	}
+	if (10-sum%10)%10 != d[8] {
+		return "", fmt.Errorf("checksum mismatch")
+	}
+	return s, nil
+}
+
+// checkNZBN validates a New Zealand Business Number, a 13-digit GS1 global
+// location number starting 94 with a mod-10 check digit.
+func checkNZBN(s string) (string, error) {
+	d, err := digits(s, 13)
+	if err != nil {
+		return "", err
+	}
+	if d[0] != 9 || d[1] != 4 {
+		return "", fmt.Errorf("expected a number starting 94")
+	}
+	sum := 0
+	for i := 0; i < 12; i++ {
+		w := 1
+		if i%2 == 1 {
+			w = 3
+		}
+		sum += d[i] * w
+	}
+	if (10-sum%10)%10 != d[12] {
+		return "", fmt.Errorf("checksum mismatch")
+	}
+	return s, nil
+}
+
+type businessNumberValue struct {
+	p    *string
+	kind string
+}
+
+func (bv *businessNumberValue) Set(s string) error {
+	n, err := normalizeBusinessNumber(s, bv.kind)
+	if err != nil {
+		return err
+	}
+	*bv.p = n
+	return nil
+}
+func (bv *businessNumberValue) String() string {
+	if bv.p == nil {
+		return ""
+	}
+	return *bv.p
+}
+func (bv *businessNumberValue) Get() interface{} { return *bv.p }
+
+// BusinessNumberVar registers a flag holding a business identifier of the
+// given kind: "ABN", "ACN", "NZBN" or one added with RegisterBusinessNumber.
+// Spaces, dashes and dots are removed before the check, so "51 824 753 556"
+// is stored as "51824753556". It panics if kind is not registered. The
+// default value is stored as given.
+func (f *FlagSet) BusinessNumberVar(p *string, name string, value string, kind string, usage string) {
+	if _, ok := lookupBusinessNumber(kind); !ok {
+		panic(fmt.Sprintf("flag: unknown business number kind %q for -%s", kind, name))
+	}
+	*p = value
+	f.Var(&businessNumberValue{p: p, kind: strings.ToUpper(kind)}, name, usage)
+}
+func BusinessNumberVar(p *string, name string, value string, kind string, usage string) {
+	CommandLine.BusinessNumberVar(p, name, value, kind, usage)
+}
+
+// ABNVar registers an Australian Business Number flag, checksum validated.
+func (f *FlagSet) ABNVar(p *string, name string, value string, usage string) {
+	f.BusinessNumberVar(p, name, value, "ABN", usage)
+}
+func ABNVar(p *string, name string, value string, usage string) {
+	CommandLine.ABNVar(p, name, value, usage)
+}
+
+// ACNVar registers an Australian Company Number flag, checksum validated.
+func (f *FlagSet) ACNVar(p *string, name string, value string, usage string) {
+	f.BusinessNumberVar(p, name, value, "ACN", usage)
+}
+func ACNVar(p *string, name string, value string, usage string) {
+	CommandLine.ACNVar(p, name, value, usage)
+}
+
+// ABN defines an Australian Business Number flag and returns a pointer to it.
+func (f *FlagSet) ABN(name string, value string, usage string) *string {
+	p := new(string)
+	f.ABNVar(p, name, value, usage)
+	return p
+}
+func ABN(name string, value string, usage string) *string {
+	return CommandLine.ABN(name, value, usage)
+}
+
+// ACN defines an Australian Company Number flag and returns a pointer to it.
+func (f *FlagSet) ACN(name string, value string, usage string) *string {
+	p := new(string)
+	f.ACNVar(p, name, value, usage)
+	return p
+}
+func ACN(name string, value string, usage string) *string {
+	return CommandLine.ACN(name, value, usage)
+}
@@ -0,0 +1,94 @@
+package flag_test
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	. "github.com/machship/flag"
+)
+
+func TestBusinessNumberFlags(t *testing.T) {
+	f := NewFlagSet("test", ContinueOnError)
+	abn := f.ABN("abn", "", "")
+	acn := f.ACN("acn", "", "")
+	var nzbn string
+	f.BusinessNumberVar(&nzbn, "nzbn", "", "nzbn", "")
+	if err := f.Parse([]string{"-abn", "51 824 753 556", "-acn", "004-085-616", "-nzbn", "9429041525746"}); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if *abn != "51824753556" || *acn != "004085616" || nzbn != "9429041525746" {
+		t.Fatalf("abn=%q acn=%q nzbn=%q", *abn, *acn, nzbn)
+	}
+
+	errs := []struct{ flag, in, want string }{
+		{"abn", "51 824 753 557", `invalid ABN "51 824 753 557": checksum mismatch`},
+		{"abn", "5182475355", "expected 11 digits"},
+		{"abn", "01824753556", "cannot start with 0"},
+		{"abn", "51824753S56", "expected 11 digits"},
+		{"acn", "004 085 617", `invalid ACN "004 085 617": checksum mismatch`},
+		{"nzbn", "9429041525747", "checksum mismatch"},
+		{"nzbn", "9529041525746", "starting 94"},
+	}
+	for _, c := range errs {
+		if err := f.Set(c.flag, c.in); err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("Set(%s, %q): expected %q, got %v", c.flag, c.in, c.want, err)
+		}
+	}
+	if name, _ := UnquoteUsage(f.Lookup("abn")); name != "ABN" {
+		t.Fatalf("UnquoteUsage name = %q", name)
+	}
+}
+
+func TestRegisterBusinessNumber(t *testing.T) {
+	// UK Companies House numbers: 8 characters, digits or a two-letter prefix.
+	RegisterBusinessNumber("uk-crn", func(s string) (string, error) {
+		s = strings.ToUpper(s)
+		if len(s) != 8 {
+			return "", fmt.Errorf("expected 8 characters")
+		}
+		return s, nil
+	})
+	f := NewFlagSet("test", ContinueOnError)
+	crn := new(string)
+	f.BusinessNumberVar(crn, "crn", "", "UK-CRN", "")
+	if err := f.Set("crn", "sc123456"); err != nil || *crn != "SC123456" {
+		t.Fatalf("Set: %v, %q", err, *crn)
+	}
+	if err := f.Set("crn", "123"); err == nil || err.Error() != `invalid UK-CRN "123": expected 8 characters` {
+		t.Fatalf("Set(123) err = %v", err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("unknown kind did not panic")
+		}
+	}()
+	f.BusinessNumberVar(new(string), "vat", "", "EU-VAT", "")
+}
+
+func TestBusinessNumberStructField(t *testing.T) {
+	ResetForTesting(nil)
+	saved := os.Args
+	os.Args = []string{"cmd", "-carrier-abn", "51 824 753 556"}
+	defer func() { os.Args = saved }()
+	var cfg struct {
+		CarrierABN string `flag:"carrier-abn" businessNumber:"ABN"`
+		ShipperACN string `flag:"shipper-acn" businessNumber:"acn" default:"004 085 616"`
+	}
+	if err := ParseStruct(&cfg); err != nil {
+		t.Fatalf("ParseStruct: %v", err)
+	}
+	if cfg.CarrierABN != "51824753556" || cfg.ShipperACN != "004085616" {
+		t.Fatalf("got %+v", cfg)
+	}
+
+	ResetForTesting(nil)
+	var bad struct {
+		VAT string `flag:"vat" businessNumber:"EU-VAT"`
+	}
+	if err := ParseStruct(&bad); err == nil || !strings.Contains(err.Error(), `unknown businessNumber tag "EU-VAT"`) {
+		t.Fatalf("unknown kind err = %v", err)
+	}
+}
@@ -0,0 +1,243 @@
+package flag
+
+import (
+	"context"
+	"time"
+)
+
+// ChangeOptions tunes how OnChangeWithOptions delivers changes to one
+// callback.
+type ChangeOptions struct {
+	// MinInterval rate-limits the callback: it runs at most once per
+	// interval. A change arriving sooner is delivered when the interval has
+	// passed, with the latest value only.
+	MinInterval time.Duration
+}
+
+type changeHandler struct {
+	name string
+	fn   func(string)
+	opts ChangeOptions
+
+	last       time.Time // when fn last ran
+	pending    string    // value held back by MinInterval
+	hasPending bool
+	timer      *time.Timer // delivers pending
+}
+
+// OnChange registers a callback invoked when the named flag's value changes due to hot reload.
+// The callback receives the new string representation (masked not applied; caller should treat sensitive values carefully).
+// Callbacks run one at a time, in the order they were registered, whichever
+// flags they watch; see SetChangeCoalescing to batch bursts of changes.
+func (f *FlagSet) OnChange(name string, fn func(string)) {
+	f.OnChangeWithOptions(name, fn, ChangeOptions{})
+}
+
+// OnChange adds a callback to the default FlagSet.
+func OnChange(name string, fn func(string)) { CommandLine.OnChange(name, fn) }
+
+// OnChangeWithOptions is like OnChange with per-callback delivery options,
+// such as a rate limit for a handler that reconnects to a database.
+func (f *FlagSet) OnChangeWithOptions(name string, fn func(string), opts ChangeOptions) {
+	if fn == nil || name == "" {
+		return
+	}
+	f.changeMu.Lock()
+	defer f.changeMu.Unlock()
+	f.changeHandlers = append(f.changeHandlers, &changeHandler{name: name, fn: fn, opts: opts})
+}
+
+// OnChangeWithOptions adds a callback with options to the default FlagSet.
+func OnChangeWithOptions(name string, fn func(string), opts ChangeOptions) {
+	CommandLine.OnChangeWithOptions(name, fn, opts)
+}
+
+// SetChangeCoalescing makes reloads wait until window has passed without
+// further changes before calling OnChange callbacks, so a burst such as a
+// re-mounted secret volume, which changes many files one by one, is
+// delivered once: each callback gets the latest value of its flag, in
+// registration order. Zero, the default, delivers after every reload.
+func (f *FlagSet) SetChangeCoalescing(window time.Duration) {
+	f.changeMu.Lock()
+	defer f.changeMu.Unlock()
+	f.changeWindow = window
+}
+
+// SetChangeCoalescing sets the coalescing window of the default FlagSet.
+func SetChangeCoalescing(window time.Duration) { CommandLine.SetChangeCoalescing(window) }
+
+// valueSnapshot is the value of every flag after a reload, taken under
+// watchMu so that dispatching never reads a Value another reload may be
+// setting. seq orders snapshots taken by concurrent reloads.
+type valueSnapshot struct {
+	seq    uint64
+	values map[string]string
+}
+
+// currentValues snapshots the flag values. The caller holds watchMu.
+func (f *FlagSet) currentValues() valueSnapshot {
+	f.valuesSeq++
+	s := valueSnapshot{seq: f.valuesSeq, values: make(map[string]string, len(f.formal))}
+	for name, fl := range f.formal {
+		s.values[name] = fl.Value.String()
+	}
+	return s
+}
+
+// diffAndDispatch compares the values of s to lastValues, updates
+// lastValues, and invokes handlers. A snapshot older than one already
+// dispatched is ignored, so a reload finishing late cannot report stale
+// values as changes.
+func (f *FlagSet) diffAndDispatch(s valueSnapshot) {
+	f.changeMu.Lock()
+	if s.seq < f.dispatchedSeq {
+		f.changeMu.Unlock()
+		return
+	}
+	f.dispatchedSeq = s.seq
+	if len(f.changeHandlers) == 0 {
+		f.changeMu.Unlock()
+		return
+	}
+	for name, cur := range s.values {
+		if cur == f.lastValues[name] {
+			continue
+		}
+		f.lastValues[name] = cur
+		if f.changePending == nil {
+			f.changePending = make(map[string]string)
+		}
+		f.changePending[name] = cur
+	}
+	if len(f.changePending) == 0 {
+		f.changeMu.Unlock()
+		return
+	}
+	if f.changeWindow > 0 {
+		if f.changeTimer == nil {
+			f.changeTimer = time.AfterFunc(f.changeWindow, f.flushChanges)
+		} else {
+			f.changeTimer.Reset(f.changeWindow)
+		}
+		f.changeMu.Unlock()
+		return
+	}
+	f.changeMu.Unlock()
+	f.flushChanges()
+}
+
+// flushChanges delivers the pending changes to the callbacks watching them,
+// in registration order.
+func (f *FlagSet) flushChanges() {
+	f.changeMu.Lock()
+	pending := f.changePending
+	f.changePending = nil
+	f.changeTimer = nil
+	handlers := append([]*changeHandler(nil), f.changeHandlers...)
+	if !f.beginDelivery() {
+		f.changeMu.Unlock()
+		return
+	}
+	f.changeMu.Unlock()
+	defer f.endDelivery()
+	for _, h := range handlers {
+		if v, ok := pending[h.name]; ok {
+			f.deliverChange(h, v)
+		}
+	}
+}
+
+// deliverChange calls h with v now, or later if its MinInterval has not
+// passed since the last call.
+func (f *FlagSet) deliverChange(h *changeHandler, v string) {
+	f.changeMu.Lock()
+	if wait := h.opts.MinInterval - time.Since(h.last); !h.last.IsZero() && wait > 0 {
+		h.pending, h.hasPending = v, true
+		if h.timer == nil {
+			h.timer = time.AfterFunc(wait, func() {
+				f.changeMu.Lock()
+				h.timer = nil
+				if !h.hasPending || !f.beginDelivery() {
+					f.changeMu.Unlock()
+					return
+				}
+				v := h.pending
+				h.hasPending = false
+				h.last = time.Now()
+				f.changeMu.Unlock()
+				defer f.endDelivery()
+				f.callChangeHandler(h, v)
+			})
+		}
+		f.changeMu.Unlock()
+		return
+	}
+	h.last = time.Now()
+	f.changeMu.Unlock()
+	f.callChangeHandler(h, v)
+}
+
+func (f *FlagSet) callChangeHandler(h *changeHandler, v string) {
+	f.deliverMu.Lock()
+	defer f.deliverMu.Unlock()
+	defer func() { recover() }()
+	h.fn(v)
+}
+
+// beginDelivery counts a delivery in progress for drainChanges, or reports
+// false once the watcher has stopped. The caller holds changeMu.
+func (f *FlagSet) beginDelivery() bool {
+	if f.changeStopped {
+		return false
+	}
+	if f.changeBusy == 0 {
+		f.changeIdle = make(chan struct{})
+	}
+	f.changeBusy++
+	return true
+}
+
+func (f *FlagSet) endDelivery() {
+	f.changeMu.Lock()
+	defer f.changeMu.Unlock()
+	if f.changeBusy--; f.changeBusy == 0 {
+		close(f.changeIdle)
+	}
+}
+
+// drainChanges waits until no callback is running or ctx is done.
+func (f *FlagSet) drainChanges(ctx context.Context) error {
+	f.changeMu.Lock()
+	if f.changeBusy == 0 {
+		f.changeMu.Unlock()
+		return nil
+	}
+	idle := f.changeIdle
+	f.changeMu.Unlock()
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// stopChangeTimers drops changes still waiting for the coalescing window or
+// a rate limit, so no callback runs after the watcher stops.
+func (f *FlagSet) stopChangeTimers() {
+	f.changeMu.Lock()
+	defer f.changeMu.Unlock()
+	f.changeStopped = true
+	if f.changeTimer != nil {
+		f.changeTimer.Stop()
+		f.changeTimer = nil
+	}
+	f.changePending = nil
+	for _, h := range f.changeHandlers {
+		if h.timer != nil {
+			h.timer.Stop()
+			h.timer = nil
+		}
+		h.hasPending = false
+	}
+}
@@ -0,0 +1,118 @@
+package flag
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// reloadWith sets values as a reload would and dispatches the changes.
+func reloadWith(fs *FlagSet, values map[string]string) {
+	for name, v := range values {
+		fs.Set(name, v)
+	}
+	fs.diffAndDispatch(fs.currentValues())
+}
+
+func TestOnChangeRegistrationOrder(t *testing.T) {
+	fs := NewFlagSet("test", ContinueOnError)
+	for _, name := range []string{"a", "b", "c", "d"} {
+		fs.String(name, "", "")
+	}
+	fs.lastValues = map[string]string{}
+	var calls []string
+	for _, name := range []string{"d", "b", "a", "b"} {
+		fs.OnChange(name, func(v string) { calls = append(calls, name+"="+v) })
+	}
+	for i := 0; i < 5; i++ {
+		calls = nil
+		reloadWith(fs, map[string]string{"a": fmt.Sprint(i), "b": fmt.Sprint(i), "c": fmt.Sprint(i), "d": fmt.Sprint(i)})
+		want := fmt.Sprintf("[d=%d b=%d a=%d b=%d]", i, i, i, i)
+		if fmt.Sprint(calls) != want {
+			t.Fatalf("reload %d: calls = %v, want %s", i, calls, want)
+		}
+	}
+}
+
+func TestOnChangeCoalescing(t *testing.T) {
+	fs := NewFlagSet("test", ContinueOnError)
+	fs.String("user", "", "")
+	fs.String("password", "", "")
+	fs.lastValues = map[string]string{}
+	fs.SetChangeCoalescing(50 * time.Millisecond)
+	var mu sync.Mutex
+	var calls []string
+	done := make(chan struct{}, 4)
+	for _, name := range []string{"user", "password"} {
+		fs.OnChange(name, func(v string) {
+			mu.Lock()
+			calls = append(calls, name+"="+v)
+			mu.Unlock()
+			done <- struct{}{}
+		})
+	}
+	// a volume re-mount: files change one by one
+	reloadWith(fs, map[string]string{"user": "u1"})
+	reloadWith(fs, map[string]string{"password": "p1"})
+	reloadWith(fs, map[string]string{"user": "u2"})
+	mu.Lock()
+	if len(calls) != 0 {
+		t.Fatalf("delivered before the window passed: %v", calls)
+	}
+	mu.Unlock()
+	for i := 0; i < 2; i++ {
+		select {
+		case <-done:
+		case <-time.After(2 * time.Second):
+			t.Fatal("coalesced changes not delivered")
+		}
+	}
+	time.Sleep(20 * time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	if fmt.Sprint(calls) != "[user=u2 password=p1]" {
+		t.Fatalf("calls = %v", calls)
+	}
+}
+
+func TestOnChangeRateLimit(t *testing.T) {
+	fs := NewFlagSet("test", ContinueOnError)
+	fs.String("dsn", "", "")
+	fs.lastValues = map[string]string{}
+	got := make(chan string, 10)
+	fs.OnChangeWithOptions("dsn", func(v string) { got <- v }, ChangeOptions{MinInterval: 100 * time.Millisecond})
+	var plain []string
+	fs.OnChange("dsn", func(v string) { plain = append(plain, v) })
+
+	reloadWith(fs, map[string]string{"dsn": "one"})
+	if v := <-got; v != "one" {
+		t.Fatalf("first change = %q", v)
+	}
+	reloadWith(fs, map[string]string{"dsn": "two"})
+	reloadWith(fs, map[string]string{"dsn": "three"})
+	select {
+	case v := <-got:
+		t.Fatalf("rate-limited callback ran early with %q", v)
+	case <-time.After(30 * time.Millisecond):
+	}
+	select {
+	case v := <-got:
+		if v != "three" {
+			t.Fatalf("held-back change = %q, want the latest", v)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("held-back change not delivered")
+	}
+	if fmt.Sprint(plain) != "[one two three]" {
+		t.Fatalf("unlimited callback saw %v", plain)
+	}
+
+	reloadWith(fs, map[string]string{"dsn": "four"})
+	fs.stopChangeTimers()
+	select {
+	case v := <-got:
+		t.Fatalf("callback ran after stop with %q", v)
+	case <-time.After(150 * time.Millisecond):
+	}
+}
@@ -0,0 +1,80 @@
+package flag_test
+
+import (
+	"image/color"
+	"os"
+	"strings"
+	"testing"
+
+	. "github.com/machship/flag"
+)
+
+func TestColorFlag(t *testing.T) {
+	f := NewFlagSet("test", ContinueOnError)
+	c := f.Color("ink", color.RGBA{A: 0xff}, "")
+	cases := map[string]color.RGBA{
+		"#1E90FF":                 {R: 0x1e, G: 0x90, B: 0xff, A: 0xff},
+		"#f00":                    {R: 0xff, A: 0xff},
+		"DodgerBlue":              {R: 0x1e, G: 0x90, B: 0xff, A: 0xff},
+		"rgb(30, 144, 255)":       {R: 30, G: 144, B: 255, A: 0xff},
+		"rgb(100%, 0%, 50%)":      {R: 255, B: 128, A: 0xff},
+		"rgba(255, 0, 0, 0.5)":    {R: 128, A: 128},
+		"#ff000080":               {R: 128, A: 128},
+		"#f008":                   {R: 136, A: 136},
+		"transparent":             {},
+		" rgba(0, 0, 255, 100%) ": {B: 255, A: 0xff},
+	}
+	for in, want := range cases {
+		if err := f.Set("ink", in); err != nil {
+			t.Errorf("Set(%q): %v", in, err)
+			continue
+		}
+		if *c != want {
+			t.Errorf("Set(%q) = %+v, want %+v", in, *c, want)
+		}
+	}
+
+	f.Set("ink", "rgba(255, 0, 102, 0.5)")
+	if s := f.Lookup("ink").Value.String(); s != "#ff006680" {
+		t.Fatalf("String() = %q", s)
+	}
+	f.Set("ink", "navy")
+	if s := f.Lookup("ink").Value.String(); s != "#000080" {
+		t.Fatalf("String() = %q", s)
+	}
+
+	errs := map[string]string{
+		"#12345":              "expected 3, 4, 6 or 8 hex digits",
+		"#gg0000":             "bad hex digits",
+		"rgb(1, 2)":           "expected 3 or 4 components",
+		"rgb(256, 0, 0)":      "out of range",
+		"rgba(0, 0, 0, 1.5)":  "out of range",
+		"rgb(0, 0, 0":         "missing )",
+		"blurple":             "web color name",
+		"rgb(-1, 0, 0)":       "out of range",
+		"rgb(red, 0, 0)":      "out of range",
+		"hsl(120, 100%, 50%)": "web color name",
+	}
+	for in, want := range errs {
+		if err := f.Set("ink", in); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Set(%q): expected %q, got %v", in, want, err)
+		}
+	}
+}
+
+func TestColorStructField(t *testing.T) {
+	ResetForTesting(nil)
+	saved := os.Args
+	os.Args = []string{"cmd", "-label-bg", "#fff"}
+	defer func() { os.Args = saved }()
+	var cfg struct {
+		LabelBG color.RGBA `flag:"label-bg"`
+		LabelFG color.RGBA `flag:"label-fg" default:"black"`
+	}
+	if err := ParseStruct(&cfg); err != nil {
+		t.Fatalf("ParseStruct: %v", err)
+	}
+	if cfg.LabelBG != (color.RGBA{R: 255, G: 255, B: 255, A: 255}) || cfg.LabelFG != (color.RGBA{A: 255}) {
+		t.Fatalf("got %+v", cfg)
+	}
+}
@@ -0,0 +1,407 @@
+package flag
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Command is a subcommand with its own flags, such as "serve" in
+// "tool -v serve -port=8080 ./site". Flags before a subcommand's name belong
+// to its parent; the rest of the arguments go to the subcommand, which also
+// accepts the flags its ancestors mark with MarkPersistent.
+type Command struct {
+	Name    string
+	Aliases []string // other names accepted in dispatch, such as "rm" for "remove"
+	Usage   string   // one-line description, shown in the parent's command list
+	Flags   *FlagSet // the command's own flags, parsed by Execute
+
+	// Run is called with the arguments left after the command's flags. A
+	// command without Run only dispatches to its subcommands.
+	Run func(args []string) error
+
+	// Default names the subcommand run when the arguments name none, so
+	// that "tool -port 80" acts as "tool serve -port 80". Flags of c given
+	// first still go to c; the default command gets the arguments from the
+	// first one that is neither such a flag nor its value. It keeps working
+	// binaries that had no subcommands before.
+	Default string
+
+	// Hidden leaves the command out of its parent's command list, Help and
+	// suggestions for mistyped names while it still runs when named, as
+	// suits internal commands such as "__complete" or "debug-dump".
+	Hidden bool
+
+	// Hooks around Run, each given the same arguments. Before Run, the
+	// PersistentPreRun of every command from the root down to this one
+	// runs, then PreRun; PostRun runs after Run succeeds. The first error
+	// stops the sequence and is returned by Execute. Hooks run only when a
+	// Run does, not for help or usage errors.
+	PersistentPreRun func(args []string) error // also runs for all subcommands
+	PreRun           func(args []string) error
+	PostRun          func(args []string) error
+
+	parent       *Command
+	commands     map[string]*Command
+	envPrefixSet bool // SetEnvPrefix was called, so the prefix is not derived
+}
+
+// NewCommand returns a command whose Flags is a new ContinueOnError FlagSet
+// named name. Its usage message lists the flags and any subcommands.
+func NewCommand(name, usage string, run func(args []string) error) *Command {
+	c := &Command{Name: name, Usage: usage, Run: run, Flags: NewFlagSet(name, ContinueOnError)}
+	c.Flags.Usage = c.PrintUsage
+	return c
+}
+
+// AddCommand adds subcommands to c. It panics if a name or alias is empty or
+// already taken, like redefining a flag.
+func (c *Command) AddCommand(cmds ...*Command) {
+	if c.commands == nil {
+		c.commands = make(map[string]*Command)
+	}
+	for _, sub := range cmds {
+		if sub.Name == "" || slices.Contains(sub.Aliases, "") {
+			panic(fmt.Sprintf("%s: subcommand with an empty name", c.Path()))
+		}
+		for _, name := range append([]string{sub.Name}, sub.Aliases...) {
+			if c.subcommand(name) != nil {
+				panic(fmt.Sprintf("%s: subcommand %s redefined", c.Path(), name))
+			}
+		}
+		sub.parent = c
+		sub.Flags.SetParent(c.Flags)
+		c.commands[sub.Name] = sub
+	}
+	c.deriveEnvPrefixes()
+}
+
+// SetEnvPrefix sets the prefix of the environment variables read for c's
+// flags and derives those of its subcommands from their path, so that with
+// "MYAPP" the -port flag of "tool serve" is read from MYAPP_SERVE_PORT and
+// subcommands sharing a flag name do not share a variable. Inherited
+// persistent flags keep the variable of the command defining them. A
+// subcommand's own SetEnvPrefix overrides the derived prefix for it and its
+// subcommands.
+func (c *Command) SetEnvPrefix(prefix string) {
+	c.Flags.envPrefix = prefix
+	c.envPrefixSet = true
+	c.deriveEnvPrefixes()
+}
+
+// deriveEnvPrefixes gives the subcommands of c, recursively, the prefix of
+// c followed by their name, unless c has none or they have their own.
+func (c *Command) deriveEnvPrefixes() {
+	c.Flags.envReserved = nil
+	for _, sub := range c.commands {
+		if !sub.envPrefixSet && c.Flags.envPrefix != "" {
+			sub.Flags.envPrefix = c.Flags.envPrefix + "_" + strings.ReplaceAll(strings.ToUpper(sub.Name), "-", "_")
+		}
+		if sub.Flags.envPrefix != "" {
+			c.Flags.envReserved = append(c.Flags.envReserved, sub.Flags.envPrefix+"_")
+		}
+		sub.deriveEnvPrefixes()
+	}
+}
+
+// subcommand returns the subcommand of c named or aliased name, or nil.
+func (c *Command) subcommand(name string) *Command {
+	if sub := c.commands[name]; sub != nil {
+		return sub
+	}
+	for _, sub := range c.commands {
+		if slices.Contains(sub.Aliases, name) {
+			return sub
+		}
+	}
+	return nil
+}
+
+// Path returns the command's name preceded by those of its parents, such as
+// "tool migrate up".
+func (c *Command) Path() string {
+	if c.parent == nil {
+		return c.Name
+	}
+	return c.parent.Path() + " " + c.Name
+}
+
+// Execute parses c's flags from args, which should not include the program
+// name, then runs the subcommand named by the first remaining argument or, if
+// there is none, c.Run. "help [command...]" prints the usage of a subcommand
+// when c has no subcommand named help, and "version" prints the version of a
+// root command whose flags have SetVersion. Parse errors, including ErrHelp
+// for -h, are returned after the usage has been printed; so is an error for
+// a missing or unknown subcommand.
+func (c *Command) Execute(args []string) error {
+	if c.Default != "" {
+		def := c.subcommand(c.Default)
+		if def == nil {
+			return c.failf("%s: default command %q is not defined", c.Path(), c.Default)
+		}
+		if i := c.defaultSplit(args); i >= 0 {
+			if err := c.Flags.Parse(args[:i]); err != nil {
+				return err
+			}
+			return def.Execute(args[i:])
+		}
+	}
+	if err := c.Flags.Parse(args); err != nil {
+		return err
+	}
+	rest := c.Flags.Args()
+	if len(rest) > 0 && len(c.commands) > 0 {
+		if sub := c.subcommand(rest[0]); sub != nil {
+			return sub.Execute(rest[1:])
+		}
+		if rest[0] == "help" {
+			target := c
+			for _, name := range rest[1:] {
+				sub := target.subcommand(name)
+				if sub == nil {
+					return target.unknownCommand(name)
+				}
+				target = sub
+			}
+			target.PrintUsage()
+			return ErrHelp
+		}
+		if rest[0] == "version" && c.hasVersionCommand() {
+			return c.Flags.PrintVersion()
+		}
+	}
+	if c.Run != nil {
+		return c.run(rest)
+	}
+	if len(rest) == 0 {
+		return c.failf("%s: missing command", c.Path())
+	}
+	return c.unknownCommand(rest[0])
+}
+
+// unknownCommand reports that c has no subcommand name, suggesting the
+// closest names and aliases it does have.
+func (c *Command) unknownCommand(name string) error {
+	var names []string
+	for _, sub := range c.commands {
+		if !sub.Hidden {
+			names = append(names, sub.Name)
+			names = append(names, sub.Aliases...)
+		}
+	}
+	if c.hasVersionCommand() {
+		names = append(names, "version")
+	}
+	if len(c.commands) > 0 {
+		names = append(names, "help")
+	}
+	if near := closestNames(name, names); len(near) > 0 {
+		return c.failf("%s: unknown command %q, did you mean %q?", c.Path(), name, near[0])
+	}
+	return c.failf("%s: unknown command %q", c.Path(), name)
+}
+
+// run calls c.Run with its hooks.
+func (c *Command) run(args []string) error {
+	var chain []*Command
+	for p := c; p != nil; p = p.parent {
+		chain = append(chain, p)
+	}
+	for i := len(chain) - 1; i >= 0; i-- {
+		if hook := chain[i].PersistentPreRun; hook != nil {
+			if err := hook(args); err != nil {
+				return err
+			}
+		}
+	}
+	for _, fn := range []func([]string) error{c.PreRun, c.Run, c.PostRun} {
+		if fn == nil {
+			continue
+		}
+		if err := fn(args); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// defaultSplit returns where the arguments of the default command start in
+// args, or -1 if args name a subcommand, help or version, or ask for help
+// with an undefined -h or -help.
+func (c *Command) defaultSplit(args []string) int {
+	for i := 0; i < len(args); i++ {
+		s := args[i]
+		if len(s) < 2 || s[0] != '-' {
+			if c.subcommand(s) != nil || s == "help" || (s == "version" && c.hasVersionCommand()) {
+				return -1
+			}
+			return i
+		}
+		if s == "--" {
+			return i
+		}
+		name, value, hasValue := strings.Cut(strings.TrimPrefix(s[1:], "-"), "=")
+		fl := c.Flags.Lookup(name)
+		if fl == nil && !strings.HasPrefix(s, "--") {
+			// a bundle such as -vq: only its last flag may take a value
+			if bundle := c.Flags.expandBundle(name, value, hasValue); bundle != nil {
+				fl = c.Flags.Lookup(name[len(name)-1:])
+			}
+		}
+		if fl == nil {
+			if c.Flags.isImplicitHelp(name) {
+				return -1
+			}
+			return i
+		}
+		// like parseOne, a flag with a no-argument value never takes the next one
+		if bf, ok := fl.Value.(boolFlag); hasValue || fl.NoOptDefVal != "" || (ok && bf.IsBoolFlag()) {
+			continue
+		}
+		i++ // the flag's value
+	}
+	return len(args)
+}
+
+func (c *Command) failf(format string, args ...interface{}) error {
+	err := fmt.Errorf(format, args...)
+	fmt.Fprintln(c.Flags.out(), err)
+	c.PrintUsage()
+	return err
+}
+
+// CommandHelp is the help screen of a command in structured form, for
+// tools that render it themselves; PrintUsage prints the same content.
+type CommandHelp struct {
+	Synopsis  string           `json:"synopsis"` // e.g. "tool migrate [flags] <command>"
+	Usage     string           `json:"usage,omitempty"`
+	Aliases   []string         `json:"aliases,omitempty"`
+	Flags     []FlagMeta       `json:"flags,omitempty"`
+	Inherited []FlagMeta       `json:"inherited,omitempty"` // persistent flags of ancestors
+	Commands  []CommandSummary `json:"commands,omitempty"`
+}
+
+// CommandSummary is a subcommand's entry in its parent's command list.
+type CommandSummary struct {
+	Name    string   `json:"name"`
+	Aliases []string `json:"aliases,omitempty"`
+	Usage   string   `json:"usage,omitempty"`
+	Default bool     `json:"default,omitempty"` // run when the arguments name no command
+}
+
+// Help returns the help screen of c: its synopsis, description, flags, the
+// flags it inherits and its subcommands sorted by name.
+func (c *Command) Help() CommandHelp {
+	h := CommandHelp{Synopsis: c.synopsis(), Usage: c.Usage, Aliases: c.Aliases}
+	for _, fl := range sortFlags(c.Flags.formal) {
+		h.Flags = append(h.Flags, c.Flags.flagMeta(fl))
+	}
+	for _, in := range c.Flags.inheritedFlags() {
+		m := in.owner.flagMeta(in.flag)
+		m.InheritedFrom = in.owner.name
+		h.Inherited = append(h.Inherited, m)
+	}
+	for _, sub := range c.sortedCommands() {
+		if sub.Hidden {
+			continue
+		}
+		h.Commands = append(h.Commands, CommandSummary{Name: sub.Name, Aliases: sub.Aliases, Usage: sub.Usage, Default: sub == c.subcommand(c.Default)})
+	}
+	if c.hasVersionCommand() {
+		i := sort.Search(len(h.Commands), func(i int) bool { return h.Commands[i].Name > "version" })
+		h.Commands = slices.Insert(h.Commands, i, CommandSummary{Name: "version", Usage: "print version information"})
+	}
+	return h
+}
+
+// hasVersionCommand reports whether c answers the built-in version command:
+// it is the root, has subcommands, called SetVersion on its flags and has no
+// subcommand of that name.
+func (c *Command) hasVersionCommand() bool {
+	return c.parent == nil && len(c.commands) > 0 && c.Flags.versionSet && c.subcommand("version") == nil
+}
+
+// synopsis returns the usage line of c without the "Usage: " prefix.
+func (c *Command) synopsis() string {
+	line := c.Path()
+	if len(c.Flags.formal) > 0 || len(c.Flags.inheritedFlags()) > 0 {
+		line += " [flags]"
+	}
+	switch {
+	case len(c.commands) > 0 && (c.Run != nil || c.Default != ""):
+		line += " [command] [args]"
+	case len(c.commands) > 0:
+		line += " <command>"
+	default:
+		line += " [args]"
+	}
+	return line
+}
+
+func (c *Command) sortedCommands() []*Command {
+	cmds := make([]*Command, 0, len(c.commands))
+	for _, sub := range c.commands {
+		cmds = append(cmds, sub)
+	}
+	sort.Slice(cmds, func(i, j int) bool { return cmds[i].Name < cmds[j].Name })
+	return cmds
+}
+
+// PrintUsage prints the help screen of the command to the output of its
+// FlagSet: the usage line, description, flags, inherited flags and, last,
+// the subcommands. "tool help <command>" and "tool <command> -h" print it
+// for a subcommand.
+func (c *Command) PrintUsage() {
+	w := c.Flags.out()
+	h := c.Help()
+	fmt.Fprintf(w, "Usage: %s\n", h.Synopsis)
+	if h.Usage != "" {
+		fmt.Fprintf(w, "\n%s\n", h.Usage)
+	}
+	if len(h.Aliases) > 0 {
+		fmt.Fprintf(w, "\nAliases: %s\n", strings.Join(h.Aliases, ", "))
+	}
+	if len(h.Flags) > 0 {
+		fmt.Fprintf(w, "\nFlags:\n")
+		c.Flags.PrintDefaults()
+	}
+	if inherited := c.Flags.inheritedFlags(); len(inherited) > 0 {
+		fmt.Fprintf(w, "\nInherited flags:\n")
+		for _, in := range inherited {
+			fmt.Fprintln(w, in.owner.defaultsEntry(in.flag))
+		}
+	}
+	if c.parent != nil && c.parent.hasLocalFlags() {
+		fmt.Fprintf(w, "\nFlags of %s are given before %q.\n", c.parent.Path(), c.Name)
+	}
+	if len(h.Commands) > 0 {
+		labels := make([]string, len(h.Commands))
+		width := 0
+		for i, cs := range h.Commands {
+			labels[i] = cs.Name
+			if len(cs.Aliases) > 0 {
+				labels[i] += " (" + strings.Join(cs.Aliases, ", ") + ")"
+			}
+			if cs.Default {
+				labels[i] += " [default]"
+			}
+			width = max(width, len(labels[i]))
+		}
+		fmt.Fprintf(w, "\nCommands:\n")
+		for i, cs := range h.Commands {
+			fmt.Fprintf(w, "  %-*s  %s\n", width, labels[i], cs.Usage)
+		}
+		fmt.Fprintf(w, "\nRun '%s help <command>' or '%s <command> -h' for details on a command.\n", c.Path(), c.Path())
+	}
+}
+
+// hasLocalFlags reports whether c has flags its subcommands do not inherit.
+func (c *Command) hasLocalFlags() bool {
+	for name := range c.Flags.formal {
+		if _, ok := c.Flags.persistent[name]; !ok {
+			return true
+		}
+	}
+	return false
+}
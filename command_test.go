@@ -0,0 +1,383 @@
+package flag_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	. "github.com/machship/flag"
+)
+
+func newTestTool(out *strings.Builder) (*Command, *[]string) {
+	var calls []string
+	root := NewCommand("tool", "tool manages the site", nil)
+	verbose := root.Flags.Bool("v", false, "verbose output")
+
+	serve := NewCommand("serve", "serve the site", nil)
+	port := serve.Flags.Int("port", 8080, "listen port")
+	serve.Run = func(args []string) error {
+		calls = append(calls, fmt.Sprintf("serve v=%t port=%d %v", *verbose, *port, args))
+		return nil
+	}
+
+	migrate := NewCommand("migrate", "run database migrations", nil)
+	up := NewCommand("up", "apply pending migrations", func(args []string) error {
+		calls = append(calls, fmt.Sprintf("up %v", args))
+		return nil
+	})
+	migrate.AddCommand(up)
+	root.AddCommand(serve, migrate)
+	for _, c := range []*Command{root, serve, migrate, up} {
+		c.Flags.SetOutput(out)
+	}
+	return root, &calls
+}
+
+func TestCommandExecute(t *testing.T) {
+	var out strings.Builder
+	root, calls := newTestTool(&out)
+	if err := root.Execute([]string{"-v", "serve", "-port", "9000", "./site"}); err != nil {
+		t.Fatalf("serve: %v", err)
+	}
+	if err := root.Execute([]string{"migrate", "up", "42"}); err != nil {
+		t.Fatalf("migrate up: %v", err)
+	}
+	want := []string{"serve v=true port=9000 [./site]", "up [42]"}
+	if strings.Join(*calls, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("calls = %q, want %q", *calls, want)
+	}
+	if out.Len() != 0 {
+		t.Fatalf("unexpected output:\n%s", out.String())
+	}
+}
+
+func TestCommandErrorsAndHelp(t *testing.T) {
+	var out strings.Builder
+	root, calls := newTestTool(&out)
+
+	err := root.Execute([]string{"deploy"})
+	if err == nil || err.Error() != `tool: unknown command "deploy"` {
+		t.Fatalf("unknown command err = %v", err)
+	}
+	if !strings.Contains(out.String(), "Usage: tool [flags] <command>") || !strings.Contains(out.String(), "  migrate  run database migrations") {
+		t.Fatalf("root usage:\n%s", out.String())
+	}
+
+	out.Reset()
+	if err := root.Execute([]string{"migrate"}); err == nil || err.Error() != "tool migrate: missing command" {
+		t.Fatalf("missing command err = %v", err)
+	}
+
+	out.Reset()
+	if err := root.Execute([]string{"help", "serve"}); !errors.Is(err, ErrHelp) {
+		t.Fatalf("help serve err = %v", err)
+	}
+	for _, s := range []string{"Usage: tool serve [flags] [args]", "serve the site", "-port", "Flags of tool are given before \"serve\"."} {
+		if !strings.Contains(out.String(), s) {
+			t.Fatalf("help serve output lacks %q:\n%s", s, out.String())
+		}
+	}
+
+	out.Reset()
+	if err := root.Execute([]string{"serve", "-h"}); !errors.Is(err, ErrHelp) || !strings.Contains(out.String(), "Usage: tool serve") {
+		t.Fatalf("serve -h: %v\n%s", err, out.String())
+	}
+	if err := root.Execute([]string{"help", "migrate", "down"}); err == nil || !strings.Contains(err.Error(), `tool migrate: unknown command "down"`) {
+		t.Fatalf("help migrate down err = %v", err)
+	}
+	if len(*calls) != 0 {
+		t.Fatalf("handlers ran: %q", *calls)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("duplicate subcommand did not panic")
+		}
+	}()
+	root.AddCommand(NewCommand("serve", "", nil))
+}
+
+func TestCommandAliases(t *testing.T) {
+	var out strings.Builder
+	root := NewCommand("tool", "", nil)
+	var removed []string
+	remove := NewCommand("remove", "delete a shipment", func(args []string) error {
+		removed = append(removed, args...)
+		return nil
+	})
+	remove.Aliases = []string{"rm", "del"}
+	root.AddCommand(remove, NewCommand("list", "list shipments", func([]string) error { return nil }))
+	root.Flags.SetOutput(&out)
+	remove.Flags.SetOutput(&out)
+
+	for _, name := range []string{"remove", "rm", "del"} {
+		if err := root.Execute([]string{name, "S-" + name}); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+	}
+	if strings.Join(removed, ",") != "S-remove,S-rm,S-del" {
+		t.Fatalf("removed = %q", removed)
+	}
+
+	root.PrintUsage()
+	if !strings.Contains(out.String(), "  remove (rm, del)  delete a shipment") || !strings.Contains(out.String(), "  list              list shipments") {
+		t.Fatalf("usage:\n%s", out.String())
+	}
+	out.Reset()
+	if err := root.Execute([]string{"help", "rm"}); !errors.Is(err, ErrHelp) || !strings.Contains(out.String(), "Usage: tool remove") || !strings.Contains(out.String(), "Aliases: rm, del") {
+		t.Fatalf("help rm: %v\n%s", err, out.String())
+	}
+
+	defer func() {
+		if r := recover(); r == nil || !strings.Contains(r.(string), "subcommand rm redefined") {
+			t.Fatalf("alias clash recovered %v", r)
+		}
+	}()
+	clash := NewCommand("rmdir", "", nil)
+	clash.Aliases = []string{"rm"}
+	root.AddCommand(clash)
+}
+
+func TestCommandHelpScreen(t *testing.T) {
+	var out strings.Builder
+	root, _ := newTestTool(&out)
+	root.Flags.MarkPersistent("v")
+
+	if err := root.Execute([]string{"-h"}); !errors.Is(err, ErrHelp) {
+		t.Fatalf("-h err = %v", err)
+	}
+	usage := out.String()
+	flags, cmds := strings.Index(usage, "\nFlags:\n"), strings.Index(usage, "\nCommands:\n")
+	if !strings.HasPrefix(usage, "Usage: tool [flags] <command>\n\ntool manages the site\n") || flags < 0 || cmds < flags {
+		t.Fatalf("root usage:\n%s", usage)
+	}
+	if !strings.HasSuffix(usage, "Run 'tool help <command>' or 'tool <command> -h' for details on a command.\n") {
+		t.Fatalf("root usage does not end with the hint:\n%s", usage)
+	}
+
+	for _, name := range []string{"help serve", "serve -h"} {
+		out.Reset()
+		if err := root.Execute(strings.Fields(name)); !errors.Is(err, ErrHelp) {
+			t.Fatalf("%s err = %v", name, err)
+		}
+		if !strings.Contains(out.String(), "Flags:\n  -port int") || !strings.Contains(out.String(), "Inherited flags:\n  -v\tverbose output") {
+			t.Fatalf("%s output:\n%s", name, out.String())
+		}
+	}
+
+	h := root.Help()
+	if h.Synopsis != "tool [flags] <command>" || len(h.Flags) != 1 || h.Flags[0].Name != "v" || len(h.Commands) != 2 || h.Commands[0].Name != "migrate" {
+		t.Fatalf("root Help() = %+v", h)
+	}
+	out.Reset()
+	root.Execute([]string{"help", "migrate", "up"})
+	if !strings.HasPrefix(out.String(), "Usage: tool migrate up [flags] [args]\n\napply pending migrations\n") {
+		t.Fatalf("help migrate up:\n%s", out.String())
+	}
+}
+
+func TestCommandHooks(t *testing.T) {
+	var calls []string
+	hook := func(name string) func([]string) error {
+		return func(args []string) error {
+			calls = append(calls, fmt.Sprintf("%s %v", name, args))
+			return nil
+		}
+	}
+	root := NewCommand("tool", "", nil)
+	root.PersistentPreRun = hook("root persistent")
+	migrate := NewCommand("migrate", "", nil)
+	migrate.PersistentPreRun = hook("migrate persistent")
+	migrate.PreRun = hook("migrate pre") // not run: migrate itself has no Run
+	up := NewCommand("up", "", hook("up run"))
+	up.PreRun = hook("up pre")
+	up.PostRun = hook("up post")
+	migrate.AddCommand(up)
+	root.AddCommand(migrate)
+
+	if err := root.Execute([]string{"migrate", "up", "42"}); err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	want := []string{"root persistent [42]", "migrate persistent [42]", "up pre [42]", "up run [42]", "up post [42]"}
+	if strings.Join(calls, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("calls = %q, want %q", calls, want)
+	}
+
+	calls = nil
+	failed := errors.New("config invalid")
+	migrate.PersistentPreRun = func([]string) error { return failed }
+	if err := root.Execute([]string{"migrate", "up"}); err != failed {
+		t.Fatalf("err = %v, want %v", err, failed)
+	}
+	if strings.Join(calls, "\n") != "root persistent []" {
+		t.Fatalf("calls after failing hook = %q", calls)
+	}
+
+	calls = nil
+	migrate.PersistentPreRun = nil
+	up.Run = func([]string) error { return failed }
+	if err := root.Execute([]string{"migrate", "up"}); err != failed || len(calls) != 2 {
+		t.Fatalf("err = %v, calls = %q", err, calls)
+	}
+}
+
+func TestCommandDefault(t *testing.T) {
+	var out strings.Builder
+	newTool := func() (*Command, *[]string, *string) {
+		root, calls := newTestTool(&out)
+		profile := root.Flags.String("profile", "", "settings profile")
+		root.Flags.String("color", "never", "colorize output")
+		root.Flags.SetNoOptDefVal("color", "auto")
+		root.Flags.Bool("q", false, "quiet")
+		root.Flags.String("o", "", "output file")
+		root.Default = "serve"
+		return root, calls, profile
+	}
+
+	runs := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"-port", "80"}, "serve v=false port=80 []"},
+		{[]string{"-v", "-profile", "prod", "-port=81", "./site"}, "serve v=true port=81 [./site]"},
+		{[]string{"./public"}, "serve v=false port=8080 [./public]"},
+		{nil, "serve v=false port=8080 []"},
+		{[]string{"-v=false", "--", "-odd-name"}, "serve v=false port=8080 [-odd-name]"},
+		{[]string{"migrate", "up", "7"}, "up [7]"},
+		{[]string{"serve", "-port", "82"}, "serve v=false port=82 []"},
+		{[]string{"-color", "serve", "-port", "83"}, "serve v=false port=83 []"},
+		{[]string{"-color", "./site"}, "serve v=false port=8080 [./site]"},
+		{[]string{"-vq", "migrate", "up", "7"}, "up [7]"},
+		{[]string{"-vq", "./site"}, "serve v=true port=8080 [./site]"},
+		{[]string{"-vo", "out.log", "migrate", "up"}, "up []"},
+		{[]string{"-vo=out.log", "./site"}, "serve v=true port=8080 [./site]"},
+	}
+	for _, r := range runs {
+		root, calls, _ := newTool()
+		if err := root.Execute(r.args); err != nil {
+			t.Fatalf("%q: %v\n%s", r.args, err, out.String())
+		}
+		if len(*calls) != 1 || (*calls)[0] != r.want {
+			t.Fatalf("%q ran %q, want %q", r.args, *calls, r.want)
+		}
+	}
+	root, _, profile := newTool()
+	if err := root.Execute([]string{"-profile", "prod", "-port", "80"}); err != nil || *profile != "prod" {
+		t.Fatalf("profile: %v, %q", err, *profile)
+	}
+	if err := root.Execute([]string{"-color", "migrate", "up"}); err != nil || root.Flags.Lookup("color").Value.String() != "auto" {
+		t.Fatalf("-color before a command: %v, color=%s", err, root.Flags.Lookup("color").Value)
+	}
+
+	out.Reset()
+	if err := root.Execute([]string{"-h"}); !errors.Is(err, ErrHelp) || !strings.Contains(out.String(), "Usage: tool [flags] [command] [args]") || !strings.Contains(out.String(), "  serve [default]  serve the site") {
+		t.Fatalf("-h: %v\n%s", err, out.String())
+	}
+	if err := root.Execute([]string{"-port", "x"}); err == nil || !strings.Contains(err.Error(), `invalid value "x" for flag -port`) {
+		t.Fatalf("bad port err = %v", err)
+	}
+
+	root.Default = "deploy"
+	if err := root.Execute(nil); err == nil || err.Error() != `tool: default command "deploy" is not defined` {
+		t.Fatalf("undefined default err = %v", err)
+	}
+}
+
+func TestCommandSuggestions(t *testing.T) {
+	var out strings.Builder
+	root, _ := newTestTool(&out)
+	remove := NewCommand("remove", "", func([]string) error { return nil })
+	remove.Aliases = []string{"rm"}
+	root.AddCommand(remove)
+
+	for args, want := range map[string]string{
+		"serv":            `tool: unknown command "serv", did you mean "serve"?`,
+		"migrat":          `tool: unknown command "migrat", did you mean "migrate"?`,
+		"rmv":             `tool: unknown command "rmv", did you mean "rm"?`,
+		"hepl":            `tool: unknown command "hepl", did you mean "help"?`,
+		"migrate upp":     `tool migrate: unknown command "upp", did you mean "up"?`,
+		"help migrate dn": `tool migrate: unknown command "dn"`,
+		"deploy":          `tool: unknown command "deploy"`,
+	} {
+		out.Reset()
+		err := root.Execute(strings.Fields(args))
+		if err == nil || err.Error() != want {
+			t.Errorf("%s: err = %v, want %s", args, err, want)
+		}
+		if !strings.HasPrefix(out.String(), want+"\n") {
+			t.Errorf("%s: output does not start with the error:\n%s", args, out.String())
+		}
+	}
+}
+
+func TestCommandHidden(t *testing.T) {
+	var out strings.Builder
+	root, _ := newTestTool(&out)
+	var dumped []string
+	dump := NewCommand("debug-dump", "dump internal state", func(args []string) error {
+		dumped = args
+		return nil
+	})
+	dump.Hidden = true
+	root.AddCommand(dump)
+
+	for _, cs := range root.Help().Commands {
+		if cs.Name == "debug-dump" {
+			t.Fatalf("hidden command listed in Help: %+v", cs)
+		}
+	}
+	root.Execute([]string{"-h"})
+	if strings.Contains(out.String(), "debug-dump") {
+		t.Fatalf("hidden command in usage:\n%s", out.String())
+	}
+	if err := root.Execute([]string{"debug-dump", "x"}); err != nil || len(dumped) != 1 || dumped[0] != "x" {
+		t.Fatalf("Execute(debug-dump): err = %v, args = %v", err, dumped)
+	}
+	if err := root.Execute([]string{"debug-dum"}); err == nil || strings.Contains(err.Error(), "did you mean") {
+		t.Fatalf("hidden command suggested: %v", err)
+	}
+}
+
+func TestCommandEnvPrefix(t *testing.T) {
+	var out strings.Builder
+	root, calls := newTestTool(&out)
+	root.Flags.StrictEnv(true)
+	root.SetEnvPrefix("MYAPP")
+	rootPort := root.Flags.Int("port", 1, "root port") // same name as serve's
+	admin := NewCommand("admin-api", "", func([]string) error { return nil })
+	adminPort := admin.Flags.Int("port", 0, "")
+	root.AddCommand(admin)
+
+	t.Setenv("MYAPP_V", "true")
+	t.Setenv("MYAPP_PORT", "2")
+	t.Setenv("MYAPP_SERVE_PORT", "9090")
+	t.Setenv("MYAPP_ADMIN_API_PORT", "9191")
+	if err := root.Execute([]string{"serve"}); err != nil {
+		t.Fatalf("serve: %v\n%s", err, out.String())
+	}
+	if (*calls)[0] != "serve v=true port=9090 []" || *rootPort != 2 {
+		t.Fatalf("calls = %q, root port = %d", *calls, *rootPort)
+	}
+	if err := root.Execute([]string{"admin-api"}); err != nil || *adminPort != 9191 {
+		t.Fatalf("admin-api: %v, port %d", err, *adminPort)
+	}
+
+	sync := NewCommand("sync", "", nil)
+	up := NewCommand("up", "", func([]string) error { return nil })
+	up.Flags.Int("batch", 10, "")
+	sync.AddCommand(up)
+	root.AddCommand(sync)
+	if m := up.Flags.Introspect(); m[0].EnvKey != "MYAPP_SYNC_UP_BATCH" {
+		t.Fatalf("nested env key = %q", m[0].EnvKey)
+	}
+	sync.SetEnvPrefix("SYNCER")
+	if m := up.Flags.Introspect(); m[0].EnvKey != "SYNCER_UP_BATCH" {
+		t.Fatalf("overridden env key = %q", m[0].EnvKey)
+	}
+
+	t.Setenv("MYAPP_PROT", "1")
+	if err := root.Execute([]string{"serve"}); err == nil || !strings.Contains(err.Error(), "not defined: MYAPP_PROT") {
+		t.Fatalf("strict env err = %v", err)
+	}
+}
@@ -0,0 +1,58 @@
+package flag_test
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/machship/flag"
+)
+
+func TestSetConfigFlagPerSet(t *testing.T) {
+	dir := t.TempDir()
+	cfg := filepath.Join(dir, "app.conf")
+	if err := os.WriteFile(cfg, []byte("port=7000\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	secrets := t.TempDir()
+	if err := os.WriteFile(filepath.Join(secrets, "token"), []byte("s3cret"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	a := NewFlagSet("a", ContinueOnError)
+	a.SetConfigFlag("cfg")
+	a.SetSecretDirFlag("secrets")
+	a.String("cfg", "", "")
+	a.String("secrets", "", "")
+	portA := a.Int("port", 80, "")
+	tokenA := a.String("token", "", "")
+
+	// b keeps the package defaults; its "cfg" flag is just a string
+	b := NewFlagSet("b", ContinueOnError)
+	b.String("cfg", "", "")
+	portB := b.Int("port", 80, "")
+
+	if err := a.Parse([]string{"-cfg", cfg, "-secrets", secrets}); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Parse([]string{"-cfg", cfg}); err != nil {
+		t.Fatal(err)
+	}
+	if *portA != 7000 || *tokenA != "s3cret" {
+		t.Errorf("a: port=%d token=%q", *portA, *tokenA)
+	}
+	if *portB != 80 {
+		t.Errorf("b read a config file: port=%d", *portB)
+	}
+}
+
+func TestSetConfigFlagDisabled(t *testing.T) {
+	f := NewFlagSet("test", ContinueOnError)
+	f.SetOutput(io.Discard)
+	f.SetConfigFlag("")
+	f.String("config", filepath.Join(t.TempDir(), "missing.conf"), "")
+	if err := f.Parse(nil); err != nil {
+		t.Fatalf("config loading should be disabled: %v", err)
+	}
+}
@@ -0,0 +1,103 @@
+package flag
+
+import (
+	"io"
+	"strings"
+)
+
+// configFormats maps lower-case file extensions to their parsers. Files with
+// other extensions use the line-based format.
+var configFormats = map[string]func(*FlagSet, io.Reader) error{
+	".conf":       (*FlagSet).parseFlat,
+	".ini":        (*FlagSet).parseINI,
+	".json":       (*FlagSet).ParseJSONConfig,
+	".properties": (*FlagSet).parseProperties,
+	".toml":       (*FlagSet).parseTOML,
+	".yaml":       (*FlagSet).parseYAML,
+	".yml":        (*FlagSet).parseYAML,
+}
+
+// RegisterConfigFormat makes ParseFile, and so the -config flag, read files
+// ending in ext (such as ".hcl"; the leading dot is optional and case is
+// ignored) with fn, replacing any parser already registered for it. A nil fn
+// removes the registration, so such files are read in the line-based format.
+// fn receives the file's text with its encoding and line endings already
+// normalised. Converting the document and handing it to f.ParseJSONConfig
+// keeps the usual precedence and "config" source; f.Set would override flags
+// set by the command line. Like RegisterStructHandler it is meant to be called
+// from init.
+func RegisterConfigFormat(ext string, fn func(*FlagSet, io.Reader) error) {
+	ext = strings.ToLower(ext)
+	if !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	if fn == nil {
+		delete(configFormats, ext)
+		return
+	}
+	configFormats[ext] = fn
+}
+
+// configEntry is a single key of a structured configuration file, with its
+// tables or sections already joined into a dotted flag name.
+type configEntry struct {
+	name   string
+	values []string
+	list   bool // values are the elements of an array
+}
+
+// applyConfigEntries applies the entries of a structured configuration file.
+// Keys below a string map flag become entries of that map, applied together
+// where the first of them appeared.
+func (f *FlagSet) applyConfigEntries(parsed []configEntry) error {
+	var entries []*configEntry
+	maps := make(map[string]*configEntry)
+	for i := range parsed {
+		e := &parsed[i]
+		mapName, key := f.configMapKey(e.name)
+		if mapName == "" {
+			entries = append(entries, e)
+			continue
+		}
+		if e.list {
+			return f.failf("configuration variable %s is an array but -%s is a map of strings", e.name, mapName)
+		}
+		m := maps[mapName]
+		if m == nil {
+			m = &configEntry{name: mapName, list: true}
+			maps[mapName] = m
+			entries = append(entries, m)
+		}
+		m.values = append(m.values, key+"="+e.values[0])
+	}
+
+	for _, e := range entries {
+		if e.list {
+			if err := f.applyConfigList(e.name, e.values, "is an array"); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := f.applyConfigValue(e.name, e.values[0], true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// configMapKey reports the string map flag a dotted key that names no flag
+// of its own belongs to, and the key within that map.
+func (f *FlagSet) configMapKey(name string) (mapName, key string) {
+	if f.formal[name] != nil {
+		return "", ""
+	}
+	for i := strings.LastIndexByte(name, '.'); i > 0; i = strings.LastIndexByte(name[:i], '.') {
+		if fl := f.formal[name[:i]]; fl != nil {
+			if _, ok := fl.Value.(*stringMapValue); ok {
+				return name[:i], name[i+1:]
+			}
+			return "", ""
+		}
+	}
+	return "", ""
+}
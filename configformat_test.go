@@ -0,0 +1,73 @@
+package flag_test
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	. "github.com/machship/flag"
+)
+
+func TestConfigFlagDispatchesOnExtension(t *testing.T) {
+	for name, content := range map[string]string{
+		"app.yaml": "db:\n  host: x\n",
+		"app.toml": "[db]\nhost = 'x'\n",
+		"app.json": `{"db": {"host": "x"}}`,
+		"app.conf": "db.host x\n",
+		"app.CONF": "db.host=x\n",
+	} {
+		f := NewFlagSet("test", ContinueOnError)
+		f.String("config", "", "")
+		host := f.String("db.host", "", "")
+		if err := f.Parse([]string{"-config", writeTempFile(t, name, content)}); err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if *host != "x" {
+			t.Errorf("%s: db.host = %q", name, *host)
+		}
+	}
+}
+
+func TestRegisterConfigFormat(t *testing.T) {
+	defer RestoreState(SaveState())
+
+	// a toy format: "key -> value" lines, converted to JSON
+	RegisterConfigFormat("ARROW", func(f *FlagSet, r io.Reader) error {
+		b, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		doc := map[string]string{}
+		for _, line := range strings.Split(strings.TrimSpace(string(b)), "\n") {
+			k, v, _ := strings.Cut(line, " -> ")
+			doc[k] = v
+		}
+		js, _ := json.Marshal(doc)
+		return f.ParseJSONConfig(strings.NewReader(string(js)))
+	})
+	path := writeTempFile(t, "app.arrow", "db.host -> x\nname -> billing\n")
+
+	f := NewFlagSet("test", ContinueOnError)
+	host := f.String("db.host", "", "")
+	name := f.String("name", "", "")
+	if err := f.Parse([]string{"-name=cli"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.ParseFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if *host != "x" || *name != "cli" {
+		t.Errorf("db.host=%q name=%q", *host, *name)
+	}
+
+	// removing it falls back to the line-based format
+	RegisterConfigFormat(".arrow", nil)
+	f = NewFlagSet("test", ContinueOnError)
+	f.SetOutput(io.Discard)
+	f.String("db.host", "", "")
+	if err := f.ParseFile(path); err == nil || !strings.Contains(err.Error(), "not defined: name") {
+		t.Errorf("err = %v", err)
+	}
+}
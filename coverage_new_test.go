@@ -3,6 +3,7 @@ package flag
 import (
 	"bytes"
 	"encoding/json"
+	"io"
 	"math/big"
 	"net"
 	urlpkg "net/url"
@@ -147,6 +148,15 @@ func TestExitOnErrorBranches(t *testing.T) {
 	if code != 2 {
 		t.Fatalf("expected exit code 2 for missing arg, got %d", code)
 	}
+	code = -1
+	// -version exits successfully
+	fs4 := NewFlagSet("exit4", ExitOnError)
+	fs4.SetOutput(io.Discard)
+	fs4.SetVersion("1.0.0")
+	fs4.Parse([]string{"-version"})
+	if code != 0 {
+		t.Fatalf("expected exit code 0 for -version, got %d", code)
+	}
 }
 
 // TestParseEnvAdditionalBranch covers path where env var supplies boolean without explicit value.
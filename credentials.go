@@ -0,0 +1,82 @@
+package flag
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Credentials is a username/password pair such as used for HTTP basic auth.
+type Credentials struct {
+	Username string
+	Password string
+}
+
+// String returns the pair in user:pass form.
+func (c Credentials) String() string {
+	if c.Username == "" && c.Password == "" {
+		return ""
+	}
+	return c.Username + ":" + c.Password
+}
+
+// credentialsValue parses user:pass splitting on the first ':' only, so
+// passwords may themselves contain colons. A password beginning with '@' is
+// read from a file (see expandAtFile). When only a username is supplied the
+// password is taken from passwordEnv, if that variable is set.
+type credentialsValue struct {
+	p           *Credentials
+	passwordEnv string
+}
+
+func newCredentialsValue(val Credentials, passwordEnv string, p *Credentials) *credentialsValue {
+	*p = val
+	return &credentialsValue{p: p, passwordEnv: passwordEnv}
+}
+func (cv *credentialsValue) Set(s string) error {
+	user, pass, hasPass := strings.Cut(s, ":")
+	if user == "" {
+		return fmt.Errorf("invalid credentials: empty username")
+	}
+	if hasPass {
+		if expanded, err := expandAtFile(pass); err == nil {
+			pass = expanded
+		} else if !errors.Is(err, errNoAtExpansion) {
+			return fmt.Errorf("invalid credentials password: %v", err)
+		}
+	} else if cv.passwordEnv != "" {
+		pass = os.Getenv(cv.passwordEnv)
+	}
+	*cv.p = Credentials{Username: user, Password: pass}
+	return nil
+}
+func (cv *credentialsValue) String() string {
+	if cv.p == nil {
+		return ""
+	}
+	return cv.p.String()
+}
+func (cv *credentialsValue) Get() interface{} { return *cv.p }
+
+// CredentialsVar registers a user:pass flag. The flag is marked sensitive.
+// If only a username is given, the password is read from the flag's
+// environment key suffixed with _PASSWORD (e.g. -db-auth alice consults
+// DB_AUTH_PASSWORD).
+func (f *FlagSet) CredentialsVar(p *Credentials, name string, value Credentials, usage string) {
+	f.Var(newCredentialsValue(value, f.envKey(name)+"_PASSWORD", p), name, usage)
+	f.MarkSensitive(name)
+}
+func CredentialsVar(p *Credentials, name string, value Credentials, usage string) {
+	CommandLine.CredentialsVar(p, name, value, usage)
+}
+
+// CredentialsFlag defines a Credentials flag and returns a pointer to it.
+func (f *FlagSet) CredentialsFlag(name string, value Credentials, usage string) *Credentials {
+	p := new(Credentials)
+	f.CredentialsVar(p, name, value, usage)
+	return p
+}
+func CredentialsFlag(name string, value Credentials, usage string) *Credentials {
+	return CommandLine.CredentialsFlag(name, value, usage)
+}
@@ -0,0 +1,76 @@
+package flag_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/machship/flag"
+)
+
+func TestCredentialsFlag(t *testing.T) {
+	f := NewFlagSet("test", ContinueOnError)
+	c := f.CredentialsFlag("auth", Credentials{}, "")
+	if err := f.Parse([]string{"-auth", "alice:p:a:ss"}); err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if c.Username != "alice" || c.Password != "p:a:ss" {
+		t.Fatalf("expected password containing colons preserved, got %+v", *c)
+	}
+	got := f.Lookup("auth").Value.(Getter).Get().(Credentials)
+	if got.Username != "alice" || got.Password != "p:a:ss" {
+		t.Fatalf("unexpected Get(): %+v", got)
+	}
+	for _, m := range f.Introspect() {
+		if m.Name == "auth" && (!m.Sensitive || m.Value != "******") {
+			t.Fatalf("expected credentials masked: %+v", m)
+		}
+	}
+	if err := f.Set("auth", ":nouser"); err == nil {
+		t.Fatalf("expected empty username error")
+	}
+}
+
+func TestCredentialsFlag_PasswordSources(t *testing.T) {
+	dir := t.TempDir()
+	pf := filepath.Join(dir, "pass")
+	if err := os.WriteFile(pf, []byte("from:file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	f := NewFlagSetWithEnvPrefix("test", "APP", ContinueOnError)
+	c := f.CredentialsFlag("db-auth", Credentials{}, "")
+	if err := f.Set("db-auth", "bob:@"+pf); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	if c.Username != "bob" || c.Password != "from:file" {
+		t.Fatalf("expected password from file, got %+v", *c)
+	}
+	t.Setenv("APP_DB_AUTH_PASSWORD", "envpass")
+	if err := f.Set("db-auth", "carol"); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	if c.Username != "carol" || c.Password != "envpass" {
+		t.Fatalf("expected password from env, got %+v", *c)
+	}
+	if err := f.Set("db-auth", "dave:@"+filepath.Join(dir, "missing")); err == nil || strings.Contains(err.Error(), "dave") {
+		t.Fatalf("expected file error without leaking value, got %v", err)
+	}
+}
+
+func TestParseStruct_Credentials(t *testing.T) {
+	ResetForTesting(nil)
+	type C struct {
+		Auth Credentials `flag:"auth" default:"admin:se:cret"`
+	}
+	var c C
+	old := os.Args
+	os.Args = []string{"cmd"}
+	defer func() { os.Args = old }()
+	if err := ParseStruct(&c); err != nil {
+		t.Fatalf("parse struct: %v", err)
+	}
+	if c.Auth.Username != "admin" || c.Auth.Password != "se:cret" {
+		t.Fatalf("unexpected default: %+v", c.Auth)
+	}
+}
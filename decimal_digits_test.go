@@ -0,0 +1,52 @@
+package flag_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	. "github.com/machship/flag"
+)
+
+type moneyConfig struct {
+	Price  decimal.Decimal `flag:"price" precision:"7" scale:"2" default:"0"`
+	Weight decimal.Decimal `flag:"weight" precision:"4" default:"0"`
+}
+
+func parseMoney(t *testing.T, args ...string) (moneyConfig, error) {
+	t.Helper()
+	ResetForTesting(nil)
+	saved := os.Args
+	os.Args = append([]string{"cmd"}, args...)
+	defer func() { os.Args = saved }()
+	var cfg moneyConfig
+	err := ParseStruct(&cfg)
+	return cfg, err
+}
+
+func TestDecimalPrecisionScale(t *testing.T) {
+	for _, args := range [][]string{
+		{"-price", "12345.67"},
+		{"-price", "-0.5"},
+		{"-price", "19.900"},
+		{"-weight", "12.34"},
+	} {
+		if _, err := parseMoney(t, args...); err != nil {
+			t.Errorf("%v: unexpected error %v", args, err)
+		}
+	}
+
+	cases := map[string][]string{
+		"has 3 decimal places, at most 2 allowed":                  {"-price", "9.999"},
+		"has 6 digits before the decimal point, at most 5 allowed": {"-price", "123456"},
+		"has 5 significant digits, at most 4 allowed":              {"-weight", "1.2345"},
+	}
+	for want, args := range cases {
+		_, err := parseMoney(t, args...)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%v: expected %q, got %v", args, want, err)
+		}
+	}
+}
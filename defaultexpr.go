@@ -0,0 +1,115 @@
+package flag
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// evalDefaultTag expands a struct `default` tag before it is parsed:
+//
+//   - env(NAME) or env(NAME,fallback): the environment variable, or fallback
+//     when it is unset or empty
+//   - file(path) or file(path,fallback): the file contents without trailing
+//     newlines, or fallback when the file cannot be read
+//   - $NAME and ${NAME} anywhere else in the tag are replaced by the
+//     environment variable; $$ is a literal $, and a $ not followed by a name
+//     (such as a regexp anchor) is kept as is
+//
+// Fallbacks and paths are themselves evaluated, so env(A,env(B,8080)) and
+// file($HOME/.token) work. Without a function form or a fallback, a missing
+// file is an error.
+func evalDefaultTag(tag string) (string, error) {
+	if fn, args, ok := splitDefaultCall(tag); ok {
+		arg, fallback, hasFallback := splitDefaultArgs(args)
+		arg, err := evalDefaultTag(strings.TrimSpace(arg))
+		if err != nil {
+			return "", err
+		}
+		switch fn {
+		case "env":
+			if v := os.Getenv(arg); v != "" {
+				return v, nil
+			}
+		case "file":
+			content, err := readValueFile(arg)
+			if err == nil {
+				return content, nil
+			}
+			if !hasFallback {
+				return "", fmt.Errorf("default file(%s): %v", arg, err)
+			}
+		}
+		return evalDefaultTag(fallback)
+	}
+	return expandDefaultVars(tag), nil
+}
+
+// splitDefaultCall recognizes a tag that is entirely env(...) or file(...).
+func splitDefaultCall(tag string) (fn, args string, ok bool) {
+	for _, name := range []string{"env", "file"} {
+		if strings.HasPrefix(tag, name+"(") && strings.HasSuffix(tag, ")") {
+			return name, tag[len(name)+1 : len(tag)-1], true
+		}
+	}
+	return "", "", false
+}
+
+// splitDefaultArgs splits on the first comma outside parentheses.
+func splitDefaultArgs(args string) (first, rest string, hasRest bool) {
+	depth := 0
+	for i, r := range args {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				return args[:i], args[i+1:], true
+			}
+		}
+	}
+	return args, "", false
+}
+
+// expandDefaultVars replaces $NAME and ${NAME} with environment values.
+func expandDefaultVars(s string) string {
+	if !strings.Contains(s, "$") {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		switch next := s[i+1]; {
+		case next == '$':
+			b.WriteByte('$')
+			i++
+		case next == '{':
+			end := strings.IndexByte(s[i+2:], '}')
+			if end < 0 {
+				b.WriteByte(s[i])
+				continue
+			}
+			b.WriteString(os.Getenv(s[i+2 : i+2+end]))
+			i += end + 2
+		case isEnvNameByte(next, true):
+			j := i + 1
+			for j < len(s) && isEnvNameByte(s[j], false) {
+				j++
+			}
+			b.WriteString(os.Getenv(s[i+1 : j]))
+			i = j - 1
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+func isEnvNameByte(c byte, first bool) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || !first && c >= '0' && c <= '9'
+}
@@ -0,0 +1,57 @@
+package flag_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/machship/flag"
+)
+
+func TestDefaultTagExpressions(t *testing.T) {
+	dir := t.TempDir()
+	token := filepath.Join(dir, "token")
+	if err := os.WriteFile(token, []byte("s3cret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("APP_HOME", "/home/app")
+	t.Setenv("TOKEN_DIR", dir)
+	t.Setenv("APP_PORT", "")
+	t.Setenv("APP_FALLBACK_PORT", "9090")
+
+	ResetForTesting(nil)
+	var cfg struct {
+		Cache   string `flag:"cache" default:"$APP_HOME/.cache/app"`
+		Braced  string `flag:"braced" default:"${APP_HOME}_x"`
+		Port    int    `flag:"port" default:"env(APP_PORT,env(APP_FALLBACK_PORT,8080))"`
+		Host    string `flag:"host" default:"env(APP_UNSET_HOST,localhost)"`
+		Token   string `flag:"token" default:"file($TOKEN_DIR/token)"`
+		Missing string `flag:"missing" default:"file(/nonexistent/key,none)"`
+		Price   string `flag:"price" default:"$$5"`
+		Pattern string `flag:"pattern" default:"^[a-z]+$"`
+	}
+	if err := ParseStructWithOptions(&cfg, ParseStructOptions{}); err != nil {
+		t.Fatalf("ParseStructWithOptions: %v", err)
+	}
+	want := map[string]string{
+		"cache": "/home/app/.cache/app", "braced": "/home/app_x", "port": "9090", "host": "localhost",
+		"token": "s3cret", "missing": "none", "price": "$5", "pattern": "^[a-z]+$",
+	}
+	for name, w := range want {
+		if got := Lookup(name).DefValue; got != w {
+			t.Errorf("%s default = %q, want %q", name, got, w)
+		}
+	}
+	if cfg.Port != 9090 || cfg.Token != "s3cret" {
+		t.Errorf("got %+v", cfg)
+	}
+
+	ResetForTesting(nil)
+	var bad struct {
+		Key string `flag:"key" default:"file(/nonexistent/key)"`
+	}
+	if err := ParseStructWithOptions(&bad, ParseStructOptions{}); err == nil || !strings.Contains(err.Error(), "default file(/nonexistent/key)") {
+		t.Fatalf("expected file error, got %v", err)
+	}
+}
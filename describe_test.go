@@ -0,0 +1,108 @@
+package flag_test
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	. "github.com/machship/flag"
+)
+
+func TestRegisterStructAndDescribe(t *testing.T) {
+	ResetForTesting(nil)
+	type C struct {
+		Host    string   `flag:"db-host" default:"localhost" help:"database host"`
+		Port    int      `flag:"port" default:"5432" min:"1" max:"65535"`
+		Mode    string   `flag:"mode" enum:"dev,prod" default:"dev"`
+		Key     string   `flag:"api-key" required:"true" sensitive:"true"`
+		Old     string   `flag:"old" deprecated:"db-host"`
+		Tags    []string `flag:"tags" default:"x,y"`
+		Ignored string
+	}
+	c := C{Host: "orig", Tags: []string{"a", "b"}}
+	metas, err := RegisterStructAndDescribe(&c)
+	if err != nil {
+		t.Fatalf("describe: %v", err)
+	}
+	if c.Host != "orig" || c.Tags[0] != "a" || c.Tags[1] != "b" {
+		t.Fatalf("describe must not modify the struct: %+v", c)
+	}
+	if Lookup("db-host") != nil || CommandLine.NFlag() != 0 {
+		t.Fatalf("describe must not register flags on CommandLine")
+	}
+	byName := make(map[string]FlagMeta)
+	for _, m := range metas {
+		byName[m.Name] = m
+	}
+	if len(byName) != 6 {
+		t.Fatalf("expected 6 flags, got %d: %+v", len(byName), metas)
+	}
+	if m := byName["db-host"]; m.Default != "localhost" || m.EnvKey != "DB_HOST" || m.Usage != "database host" {
+		t.Fatalf("unexpected db-host meta: %+v", m)
+	}
+	if m := byName["port"]; m.Min != "1" || m.Max != "65535" || m.Default != "5432" {
+		t.Fatalf("unexpected port meta: %+v", m)
+	}
+	if m := byName["mode"]; m.Enum != "dev,prod" {
+		t.Fatalf("unexpected mode meta: %+v", m)
+	}
+	if m := byName["api-key"]; !m.Required || !m.Sensitive || m.Default != "******" {
+		t.Fatalf("unexpected api-key meta: %+v", m)
+	}
+	if m := byName["old"]; !m.Deprecated {
+		t.Fatalf("unexpected old meta: %+v", m)
+	}
+	// describing twice must not trip flag redefinition
+	if _, err := RegisterStructAndDescribe(&c); err != nil {
+		t.Fatalf("second describe: %v", err)
+	}
+}
+
+func TestRegisterStructAndDescribeConcurrent(t *testing.T) {
+	ResetForTesting(nil)
+	type C struct {
+		Host  string        `flag:"db-host" default:"localhost"`
+		Port  int           `flag:"port" default:"5432"`
+		Wait  time.Duration `flag:"wait" default:"1s"`
+		Start time.Time     `flag:"start"`
+		Label LabelFormat   `flag:"label"`
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 50; i++ {
+			if metas, err := RegisterStructAndDescribe(&C{}); err != nil || len(metas) != 5 {
+				t.Errorf("describe: %d flags, %v", len(metas), err)
+				return
+			}
+		}
+	}()
+	for i := 0; i < 50; i++ {
+		String(fmt.Sprintf("app-%d", i), "", "")
+	}
+	<-done
+	if n := len(CommandLine.Introspect()); n != 50 {
+		t.Fatalf("CommandLine has %d flags, want 50", n)
+	}
+}
+
+func TestParseStruct_NestedRequiredCheckedAfterParse(t *testing.T) {
+	ResetForTesting(nil)
+	type Inner struct {
+		Token string `flag:"token" required:"true"`
+	}
+	type C struct {
+		Inner Inner
+	}
+	var c C
+	old := os.Args
+	os.Args = []string{"cmd", "-token", "abc"}
+	defer func() { os.Args = old }()
+	if err := ParseStruct(&c); err != nil {
+		t.Fatalf("nested required flag supplied on CLI: %v", err)
+	}
+	if c.Inner.Token != "abc" {
+		t.Fatalf("unexpected token %q", c.Inner.Token)
+	}
+}
@@ -0,0 +1,112 @@
+package flag
+
+import (
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// digestSizes maps supported digest algorithm names to their sum length in bytes.
+var digestSizes = map[string]int{
+	"md5":    16,
+	"sha1":   20,
+	"sha224": 28,
+	"sha256": 32,
+	"sha384": 48,
+	"sha512": 64,
+}
+
+// Digest is an algorithm-prefixed checksum such as sha256:<hex>.
+type Digest struct {
+	algorithm string
+	sum       []byte
+}
+
+// ParseDigest parses algorithm:hex, validating the hex length against the algorithm.
+func ParseDigest(s string) (Digest, error) {
+	algo, hexSum, ok := strings.Cut(strings.TrimSpace(s), ":")
+	if !ok {
+		return Digest{}, fmt.Errorf("invalid digest %q: expected algorithm:hex", s)
+	}
+	algo = strings.ToLower(algo)
+	size, ok := digestSizes[algo]
+	if !ok {
+		return Digest{}, fmt.Errorf("unsupported digest algorithm %q (supported: %s)", algo, digestAlgorithms())
+	}
+	sum, err := hex.DecodeString(hexSum)
+	if err != nil {
+		return Digest{}, fmt.Errorf("invalid %s digest: %v", algo, err)
+	}
+	if len(sum) != size {
+		return Digest{}, fmt.Errorf("invalid %s digest: expected %d hex characters, got %d", algo, size*2, len(hexSum))
+	}
+	return Digest{algorithm: algo, sum: sum}, nil
+}
+
+func digestAlgorithms() string {
+	names := make([]string, 0, len(digestSizes))
+	for n := range digestSizes {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
+
+// Algorithm returns the lower-case algorithm name (e.g. "sha256").
+func (d Digest) Algorithm() string { return d.algorithm }
+
+// Bytes returns a copy of the raw digest sum.
+func (d Digest) Bytes() []byte { return append([]byte(nil), d.sum...) }
+
+// IsZero reports whether the digest is unset.
+func (d Digest) IsZero() bool { return d.algorithm == "" }
+
+// String returns the digest in algorithm:hex form.
+func (d Digest) String() string {
+	if d.IsZero() {
+		return ""
+	}
+	return d.algorithm + ":" + hex.EncodeToString(d.sum)
+}
+
+type digestValue struct{ p *Digest }
+
+func newDigestValue(val Digest, p *Digest) *digestValue {
+	*p = val
+	return &digestValue{p: p}
+}
+func (dv *digestValue) Set(s string) error {
+	d, err := ParseDigest(s)
+	if err != nil {
+		return err
+	}
+	*dv.p = d
+	return nil
+}
+func (dv *digestValue) String() string {
+	if dv.p == nil {
+		return ""
+	}
+	return dv.p.String()
+}
+func (dv *digestValue) Get() interface{} { return *dv.p }
+
+// DigestVar registers an algorithm-prefixed checksum flag (md5, sha1, sha224,
+// sha256, sha384, sha512).
+func (f *FlagSet) DigestVar(p *Digest, name string, value Digest, usage string) {
+	f.Var(newDigestValue(value, p), name, usage)
+}
+func DigestVar(p *Digest, name string, value Digest, usage string) {
+	CommandLine.DigestVar(p, name, value, usage)
+}
+
+// DigestFlag defines a Digest flag and returns a pointer to it.
+func (f *FlagSet) DigestFlag(name string, value Digest, usage string) *Digest {
+	p := new(Digest)
+	f.DigestVar(p, name, value, usage)
+	return p
+}
+func DigestFlag(name string, value Digest, usage string) *Digest {
+	return CommandLine.DigestFlag(name, value, usage)
+}
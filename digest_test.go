@@ -0,0 +1,55 @@
+package flag_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"strings"
+	"testing"
+
+	. "github.com/machship/flag"
+)
+
+func TestDigestFlag(t *testing.T) {
+	sum := sha256.Sum256([]byte("artifact"))
+	hexSum := hex.EncodeToString(sum[:])
+	f := NewFlagSet("test", ContinueOnError)
+	d := f.DigestFlag("checksum", Digest{}, "")
+	if err := f.Parse([]string{"-checksum", "SHA256:" + hexSum}); err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if d.Algorithm() != "sha256" || string(d.Bytes()) != string(sum[:]) {
+		t.Fatalf("unexpected digest: %s", d)
+	}
+	if d.String() != "sha256:"+hexSum {
+		t.Fatalf("unexpected String(): %s", d)
+	}
+	cases := map[string]string{
+		"sha256":               "expected algorithm:hex",
+		"crc32:00000000":       "unsupported digest algorithm",
+		"sha1:zz":              "invalid sha1 digest",
+		"sha256:" + hexSum[2:]: "expected 64 hex characters",
+	}
+	for in, want := range cases {
+		if err := f.Set("checksum", in); err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("Set(%q): expected error containing %q, got %v", in, want, err)
+		}
+	}
+}
+
+func TestParseStruct_Digest(t *testing.T) {
+	ResetForTesting(nil)
+	type C struct {
+		Sum Digest `flag:"sum" default:"md5:d41d8cd98f00b204e9800998ecf8427e"`
+	}
+	var c C
+	old := os.Args
+	os.Args = []string{"cmd"}
+	defer func() { os.Args = old }()
+	if err := ParseStruct(&c); err != nil {
+		t.Fatalf("parse struct: %v", err)
+	}
+	if c.Sum.Algorithm() != "md5" || len(c.Sum.Bytes()) != 16 {
+		t.Fatalf("unexpected default digest: %s", c.Sum)
+	}
+}
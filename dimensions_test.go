@@ -0,0 +1,68 @@
+package flag_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	. "github.com/machship/flag"
+)
+
+func TestDimensionsFlag(t *testing.T) {
+	f := NewFlagSet("test", ContinueOnError)
+	d := f.DimensionsFlag("pallet", Dimensions{}, "")
+	cases := map[string]Dimensions{
+		"120x80x100cm":      {1200, 800, 1000},
+		"1.2mx80cmx1m":      {1200, 800, 1000},
+		"120 × 80 × 100 cm": {1200, 800, 1000},
+		"300X200X150":       {300, 200, 150},
+		"12x10x8in":         {304.8, 254, 203.2},
+	}
+	for in, want := range cases {
+		if err := f.Set("pallet", in); err != nil {
+			t.Fatalf("%q: %v", in, err)
+		}
+		if !approx(d.Length, want.Length) || !approx(d.Width, want.Width) || !approx(d.Height, want.Height) {
+			t.Errorf("%q = %+v, want %+v", in, *d, want)
+		}
+	}
+	if err := f.Set("pallet", "120x80x100cm"); err != nil {
+		t.Fatal(err)
+	}
+	if got := f.Lookup("pallet").Value.String(); got != "1200x800x1000mm" {
+		t.Errorf("String() = %q", got)
+	}
+	if v := d.CubicMetres(); !approx(v, 0.96) {
+		t.Errorf("CubicMetres() = %v", v)
+	}
+	for in, want := range map[string]string{
+		"120x80":              "want LxWxH",
+		"10xx20x30":           "want LxWxH",
+		"x10x20x30":           "want LxWxH",
+		"10x20x30x":           "want LxWxH",
+		"10x x30":             "want LxWxH",
+		"120x0x100cm":         "sides must be positive",
+		"120x80x100 furlongs": `unknown unit "furlongs"`,
+	} {
+		if err := f.Set("pallet", in); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%q: expected %q, got %v", in, want, err)
+		}
+	}
+}
+
+func TestDimensionsStructField(t *testing.T) {
+	ResetForTesting(nil)
+	saved := os.Args
+	os.Args = []string{"cmd", "-carton", "40x30x20cm"}
+	defer func() { os.Args = saved }()
+	var cfg struct {
+		Carton Dimensions `flag:"carton" required:"true"`
+		Pallet Dimensions `flag:"pallet" default:"1165x1165x1200"`
+	}
+	if err := ParseStruct(&cfg); err != nil {
+		t.Fatalf("ParseStruct: %v", err)
+	}
+	if cfg.Carton != (Dimensions{400, 300, 200}) || cfg.Pallet.Height != 1200 {
+		t.Fatalf("got %+v", cfg)
+	}
+}
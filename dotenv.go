@@ -0,0 +1,118 @@
+package flag
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParseDotEnv reads KEY=VALUE lines from the .env file in path and applies
+// them exactly as ParseEnv applies the process environment, including the
+// environment prefix, env key overrides and StrictEnv. Flags already set are
+// ignored, so calling it after Parse lets the real environment, command line,
+// secret directory and config file win over the file.
+//
+// Blank lines and lines starting with '#' are skipped and a leading "export "
+// is allowed. Unquoted values are trimmed and end at " #". Single-quoted values
+// are literal; double-quoted values decode \n, \r, \t, \" and \\. Both quoted
+// forms may span lines. Variables are not interpolated. With
+// RequireSignedConfig the file must carry a valid signature.
+func (f *FlagSet) ParseDotEnv(path string) error {
+	text, err := f.readConfigText(path)
+	if err != nil {
+		return err
+	}
+	environ, err := parseDotEnv(text)
+	if err != nil {
+		return f.failf("%s: %v", path, err)
+	}
+	return f.ParseEnv(environ)
+}
+
+// ParseDotEnv applies a .env file to the default CommandLine FlagSet.
+func ParseDotEnv(path string) error { return CommandLine.ParseDotEnv(path) }
+
+// parseDotEnv turns the text of a .env file into KEY=VALUE entries.
+func parseDotEnv(text string) ([]string, error) {
+	var environ []string
+	lines := strings.Split(text, "\n")
+	for n := 0; n < len(lines); n++ {
+		line := strings.TrimSpace(lines[n])
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		i := strings.IndexByte(line, '=')
+		if i < 0 {
+			return nil, fmt.Errorf("missing '=' on line %d", n+1)
+		}
+		key, value := strings.TrimSpace(line[:i]), strings.TrimSpace(line[i+1:])
+		if key == "" || strings.ContainsAny(key, " \t") {
+			return nil, fmt.Errorf("invalid key on line %d", n+1)
+		}
+
+		if value != "" && (value[0] == '"' || value[0] == '\'') {
+			start := n
+			q := value[0]
+			rest := value[1:]
+			// gather lines until the closing quote
+			for closeQuote(rest, q) < 0 {
+				if n+1 >= len(lines) {
+					return nil, fmt.Errorf("unterminated quoted value on line %d", start+1)
+				}
+				n++
+				rest += "\n" + lines[n]
+			}
+			end := closeQuote(rest, q)
+			if tail := strings.TrimSpace(rest[end+1:]); tail != "" && tail[0] != '#' {
+				return nil, fmt.Errorf("unexpected text after quoted value on line %d", n+1)
+			}
+			value = rest[:end]
+			if q == '"' {
+				value = unescapeDotEnv(value)
+			}
+		} else if j := strings.Index(value, " #"); j >= 0 {
+			value = strings.TrimSpace(value[:j])
+		}
+		environ = append(environ, key+"="+value)
+	}
+	return environ, nil
+}
+
+// closeQuote returns the index of the quote q closing s, or -1. Inside double
+// quotes a backslash escapes the next character.
+func closeQuote(s string, q byte) int {
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && q == '"':
+			i++
+		case s[i] == q:
+			return i
+		}
+	}
+	return -1
+}
+
+func unescapeDotEnv(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case '"', '\\':
+			b.WriteByte(s[i])
+		default:
+			b.WriteByte('\\')
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,67 @@
+package flag_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	. "github.com/machship/flag"
+)
+
+func TestParseDotEnv(t *testing.T) {
+	path := writeTempFile(t, ".env", `# local development
+APP_DB_HOST=localhost # trailing comment
+export APP_DB_PORT = 5432
+APP_GREETING="hello\tworld \"quoted\""
+APP_PATTERN='^\d+ # not a comment$'
+APP_CERT="-----BEGIN-----
+abc
+-----END-----"
+APP_NAME=from-file
+OTHER=ignored
+`)
+	f := NewFlagSetWithEnvPrefix("test", "APP", ContinueOnError)
+	host := f.String("db-host", "", "")
+	port := f.Int("db-port", 0, "")
+	greeting := f.String("greeting", "", "")
+	pattern := f.String("pattern", "", "")
+	cert := f.String("cert", "", "")
+	name := f.String("name", "", "")
+	if err := f.Parse([]string{"-name=cli"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.ParseDotEnv(path); err != nil {
+		t.Fatal(err)
+	}
+	if *host != "localhost" || *port != 5432 || *name != "cli" {
+		t.Errorf("db-host=%q db-port=%d name=%q", *host, *port, *name)
+	}
+	if *greeting != "hello\tworld \"quoted\"" || *pattern != `^\d+ # not a comment$` {
+		t.Errorf("greeting=%q pattern=%q", *greeting, *pattern)
+	}
+	if *cert != "-----BEGIN-----\nabc\n-----END-----" {
+		t.Errorf("cert = %q", *cert)
+	}
+}
+
+func TestParseDotEnvErrors(t *testing.T) {
+	tests := []struct {
+		content, want string
+	}{
+		{"APP_X\n", "missing '=' on line 1"},
+		{"\nAPP X=1\n", "invalid key on line 2"},
+		{"APP_X=\"open\nstill open\n", "unterminated quoted value on line 1"},
+		{"APP_X='a' b\n", "unexpected text after quoted value on line 1"},
+		{"APP_UNKNOWN=1\n", "environment variable provided but not defined: APP_UNKNOWN"},
+	}
+	for _, tt := range tests {
+		f := NewFlagSetWithEnvPrefix("test", "APP", ContinueOnError)
+		f.SetOutput(&bytes.Buffer{})
+		f.String("x", "", "")
+		f.StrictEnv(true)
+		err := f.ParseDotEnv(writeTempFile(t, ".env", tt.content))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%q: err = %v, want %q", tt.content, err, tt.want)
+		}
+	}
+}
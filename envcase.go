@@ -0,0 +1,43 @@
+package flag
+
+import "strings"
+
+// SetEnvKeyFunc replaces the derivation of environment variable names. fn
+// receives a flag name and returns the variables to consult, in order of
+// preference; the first one present in the environment is used. The names are
+// used as returned, so fn applies any prefix itself. Returning no names, or
+// passing a nil fn, falls back to the default upper-case, dashes-to-underscores
+// derivation with the flag set's prefix.
+func (f *FlagSet) SetEnvKeyFunc(fn func(flagName string) []string) { f.envKeyFunc = fn }
+
+// SetEnvKeyFunc replaces env key derivation on the default CommandLine FlagSet.
+func SetEnvKeyFunc(fn func(flagName string) []string) { CommandLine.SetEnvKeyFunc(fn) }
+
+// UpperSnakeEnvKeys returns a key function for SetEnvKeyFunc deriving
+// PREFIX_DB_HOST from db.host or db-host.
+func UpperSnakeEnvKeys(prefix string) func(string) []string {
+	return envKeyConvention(prefix, "_", strings.ToUpper)
+}
+
+// LowerSnakeEnvKeys returns a key function for SetEnvKeyFunc deriving
+// prefix_db_host from db.host or db-host.
+func LowerSnakeEnvKeys(prefix string) func(string) []string {
+	return envKeyConvention(prefix, "_", strings.ToLower)
+}
+
+// ScreamingKebabEnvKeys returns a key function for SetEnvKeyFunc deriving
+// PREFIX-DB-HOST from db.host or db-host, for platforms that allow dashes in
+// variable names.
+func ScreamingKebabEnvKeys(prefix string) func(string) []string {
+	return envKeyConvention(prefix, "-", strings.ToUpper)
+}
+
+func envKeyConvention(prefix, sep string, fold func(string) string) func(string) []string {
+	return func(name string) []string {
+		key := strings.NewReplacer("-", sep, "_", sep, ".", sep).Replace(name)
+		if prefix != "" {
+			key = prefix + sep + key
+		}
+		return []string{fold(key)}
+	}
+}
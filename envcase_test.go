@@ -0,0 +1,62 @@
+package flag_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	. "github.com/machship/flag"
+)
+
+func TestEnvKeyConventions(t *testing.T) {
+	tests := []struct {
+		fn   func(string) []string
+		want string
+	}{
+		{UpperSnakeEnvKeys("APP"), "APP_DB_MAX_CONNS"},
+		{LowerSnakeEnvKeys("app"), "app_db_max_conns"},
+		{ScreamingKebabEnvKeys("APP"), "APP-DB-MAX-CONNS"},
+		{UpperSnakeEnvKeys(""), "DB_MAX_CONNS"},
+	}
+	for _, tt := range tests {
+		if got := tt.fn("db.max-conns"); !reflect.DeepEqual(got, []string{tt.want}) {
+			t.Errorf("got %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestSetEnvKeyFunc(t *testing.T) {
+	f := NewFlagSetWithEnvPrefix("test", "APP", ContinueOnError)
+	host := f.String("db.host", "", "")
+	port := f.Int("port", 0, "")
+	tags := f.StringSlice("tags", ",", nil, "")
+	// accept the new lower_snake names but keep honouring the legacy ones
+	f.SetEnvKeyFunc(func(name string) []string {
+		return append(LowerSnakeEnvKeys("app")(name), "LEGACY_"+strings.ToUpper(name))
+	})
+	environ := []string{
+		"app_db_host=new.internal",
+		"LEGACY_DB.HOST=old.internal",
+		"LEGACY_PORT=7000",
+		"app_tags_0=a,b",
+		"app_tags_1=c",
+		"APP_PORT=9999",
+	}
+	if err := f.ParseEnv(environ); err != nil {
+		t.Fatal(err)
+	}
+	if *host != "new.internal" || *port != 7000 {
+		t.Errorf("db.host=%q port=%d", *host, *port)
+	}
+	if want := []string{"a,b", "c"}; !reflect.DeepEqual(*tags, want) {
+		t.Errorf("tags = %q, want %q", *tags, want)
+	}
+	if got := f.EnvKeys()["port"]; got != "app_port" {
+		t.Errorf("EnvKeys()[port] = %q", got)
+	}
+	// the default-derived APP_PORT is no longer consulted
+	t.Setenv("APP_PORT", "9999")
+	if got := f.UnusedEnv("APP_"); !reflect.DeepEqual(got, []string{"APP_PORT"}) {
+		t.Errorf("UnusedEnv = %q", got)
+	}
+}
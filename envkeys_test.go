@@ -0,0 +1,101 @@
+package flag_test
+
+import (
+	"io"
+	"reflect"
+	"testing"
+
+	. "github.com/machship/flag"
+)
+
+func TestEnvKeys(t *testing.T) {
+	f := NewFlagSetWithEnvPrefix("test", "APP", ContinueOnError)
+	f.Int("port", 8080, "")
+	f.String("db.max-conns", "", "")
+	want := map[string]string{"port": "APP_PORT", "db.max-conns": "APP_DB.MAX_CONNS"}
+	if got := f.EnvKeys(); !reflect.DeepEqual(got, want) {
+		t.Errorf("EnvKeys() = %v, want %v", got, want)
+	}
+}
+
+func TestUnusedEnv(t *testing.T) {
+	t.Setenv("APP_PORT", "9000")
+	t.Setenv("APP_PROT", "9000")
+	t.Setenv("APP_HOSTNAME", "x")
+	t.Setenv("OTHER_PORT", "1")
+
+	f := NewFlagSetWithEnvPrefix("test", "APP", ContinueOnError)
+	f.Int("port", 8080, "")
+	f.String("host", "", "")
+	want := []string{"APP_HOSTNAME", "APP_PROT"}
+	if got := f.UnusedEnv("APP_"); !reflect.DeepEqual(got, want) {
+		t.Errorf("UnusedEnv() = %v, want %v", got, want)
+	}
+}
+
+func TestStrictEnv(t *testing.T) {
+	environ := []string{"APP_PORT=9000", "APP_PROT=9000", "APP_HOSTNAME=x", "HOME=/root"}
+
+	f := NewFlagSetWithEnvPrefix("test", "APP", ContinueOnError)
+	f.SetOutput(io.Discard)
+	port := f.Int("port", 8080, "")
+	f.StrictEnv(true)
+	err := f.ParseEnv(environ)
+	if err == nil || err.Error() != "environment variable provided but not defined: APP_HOSTNAME, APP_PROT" {
+		t.Fatalf("err = %v", err)
+	}
+	if *port != 8080 {
+		t.Errorf("port = %d; nothing should be applied on failure", *port)
+	}
+
+	f.StrictEnv(false)
+	if err := f.ParseEnv(environ); err != nil || *port != 9000 {
+		t.Errorf("lenient: err = %v, port = %d", err, *port)
+	}
+
+	// without a prefix there is nothing to be strict about
+	g := NewFlagSet("test", ContinueOnError)
+	g.Int("port", 8080, "")
+	g.StrictEnv(true)
+	if err := g.ParseEnv(environ); err != nil {
+		t.Errorf("no prefix: err = %v", err)
+	}
+}
+
+func TestIndexedEnvLists(t *testing.T) {
+	environ := []string{
+		"APP_GREETINGS_0=hello, world",
+		"APP_GREETINGS_1=goodbye",
+		"APP_GREETINGS_3=unreachable",
+		"APP_LABELS_0=team=core",
+		"APP_LABELS_1=note=a,b",
+		"APP_DELAYS=1s,2s",
+	}
+	f := NewFlagSetWithEnvPrefix("test", "APP", ContinueOnError)
+	greetings := f.StringSlice("greetings", ",", nil, "")
+	labels := f.StringMap("labels", nil, "")
+	delays := f.DurationSlice("delays", ",", nil, "")
+	f.StrictEnv(true)
+	if err := f.ParseEnv(environ); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"hello, world", "goodbye"}; !reflect.DeepEqual(*greetings, want) {
+		t.Errorf("greetings = %q, want %q", *greetings, want)
+	}
+	if want := map[string]string{"team": "core", "note": "a,b"}; !reflect.DeepEqual(*labels, want) {
+		t.Errorf("labels = %v, want %v", *labels, want)
+	}
+	if len(*delays) != 2 {
+		t.Errorf("delays = %v", *delays)
+	}
+}
+
+func TestIndexedEnvConflict(t *testing.T) {
+	f := NewFlagSetWithEnvPrefix("test", "APP", ContinueOnError)
+	f.SetOutput(io.Discard)
+	f.StringSlice("tags", ",", nil, "")
+	err := f.ParseEnv([]string{"APP_TAGS=a,b", "APP_TAGS_0=c"})
+	if err == nil || err.Error() != "environment variables APP_TAGS and APP_TAGS_0 both set for -tags" {
+		t.Errorf("err = %v", err)
+	}
+}
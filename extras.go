@@ -8,8 +8,12 @@ import (
 	"bufio"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 )
 
@@ -18,9 +22,16 @@ import (
 var EnvironmentPrefix = ""
 
 // ParseEnv parses flags from environment variables.
-// Flags already set will be ignored.
+// Flags already set will be ignored. With StrictEnv set, prefixed variables
+// that match no flag are an error.
 func (f *FlagSet) ParseEnv(environ []string) error {
 
+	if f.strictEnv && f.envPrefix != "" {
+		if unused := f.unusedEnv(environ, f.envPrefix+"_"); len(unused) > 0 {
+			return f.failf("environment variable provided but not defined: %s", strings.Join(unused, ", "))
+		}
+	}
+
 	m := f.formal
 
 	env := make(map[string]string)
@@ -34,27 +45,40 @@ func (f *FlagSet) ParseEnv(environ []string) error {
 
 	for _, flag := range m {
 		name := flag.Name
+		if cliOnly(flag.Value) {
+			continue // help and version are only requested on the command line
+		}
 		_, set := f.actual[name]
 		if set {
+			if key, ok := f.envLookup(env, name, false); ok {
+				f.audit(flag, SourceEnv, env[key], false, nil)
+			}
 			continue
 		}
 
 		flag, alreadythere := m[name]
 		if !alreadythere {
-			if name == "help" || name == "h" { // special case for nice help message.
+			if f.isImplicitHelp(name) { // special case for nice help message.
 				f.usage()
 				return ErrHelp
 			}
 			return f.failf("environment variable provided but not defined: %s", name)
 		}
 
-		envKey := strings.ToUpper(flag.Name)
-		if f.envPrefix != "" {
-			envKey = f.envPrefix + "_" + envKey
+		_, isList := flag.Value.(listValue)
+		key, _ := f.envLookup(env, name, isList)
+		value, isSet := env[key]
+		if isList {
+			if items := indexedEnv(env, key); len(items) > 0 {
+				if isSet {
+					return f.failf("environment variables %s and %s_0 both set for -%s", key, key, name)
+				}
+				if err := f.setListValues(flag, items, SourceEnv, "environment variable"); err != nil {
+					return err
+				}
+				continue
+			}
 		}
-		envKey = strings.Replace(envKey, "-", "_", -1)
-
-		value, isSet := env[envKey]
 		if !isSet {
 			continue
 		}
@@ -74,7 +98,7 @@ func (f *FlagSet) ParseEnv(environ []string) error {
 					}
 					return f.failf("invalid value %q for environment variable %s: %v", value, name, err)
 				}
-				if err := fv.Set(value); err != nil {
+				if err := f.setValue(flag, value, SourceEnv); err != nil {
 					if f.isSensitive(name) {
 						return f.failf("invalid boolean value for environment variable %s: %v", name, err)
 					}
@@ -82,6 +106,7 @@ func (f *FlagSet) ParseEnv(environ []string) error {
 				}
 			} else {
 				fv.Set("true")
+				f.audit(flag, SourceEnv, "true", true, nil)
 			}
 		} else {
 			if expanded, err := expandAtFile(value); err == nil {
@@ -92,7 +117,7 @@ func (f *FlagSet) ParseEnv(environ []string) error {
 				}
 				return f.failf("invalid value %q for environment variable %s: %v", value, name, err)
 			}
-			if err := flag.Value.Set(value); err != nil {
+			if err := f.setValue(flag, value, SourceEnv); err != nil {
 				if f.isSensitive(name) {
 					return f.failf("invalid value for environment variable %s: %v", name, err)
 				}
@@ -113,6 +138,142 @@ func (f *FlagSet) ParseEnv(environ []string) error {
 	return nil
 }
 
+// envKey returns the primary environment variable name consulted for the
+// named flag.
+func (f *FlagSet) envKey(name string) string {
+	return f.envKeys(name)[0]
+}
+
+// envKeys returns the environment variable names consulted for the named
+// flag, in order of preference.
+func (f *FlagSet) envKeys(name string) []string {
+	if key, ok := f.envKeyOverrides[name]; ok {
+		return []string{key}
+	}
+	if f.envKeyFunc != nil {
+		if keys := f.envKeyFunc(name); len(keys) > 0 {
+			return keys
+		}
+	}
+	key := strings.ToUpper(name)
+	if f.envPrefix != "" {
+		key = f.envPrefix + "_" + key
+	}
+	return []string{strings.Replace(key, "-", "_", -1)}
+}
+
+// setEnvKey pins the environment variable read for the named flag, as done
+// by envPrefix struct tags.
+func (f *FlagSet) setEnvKey(name, key string) {
+	if f.envKeyOverrides == nil {
+		f.envKeyOverrides = make(map[string]string)
+	}
+	f.envKeyOverrides[name] = key
+}
+
+// envLookup returns the first of the flag's environment keys present in env,
+// also matching key_0 for list flags. It returns the primary key and false
+// when none is present.
+func (f *FlagSet) envLookup(env map[string]string, name string, list bool) (string, bool) {
+	keys := f.envKeys(name)
+	for _, key := range keys {
+		if _, ok := env[key]; ok {
+			return key, true
+		}
+		if _, ok := env[key+"_0"]; ok && list {
+			return key, true
+		}
+	}
+	return keys[0], false
+}
+
+// StrictEnv controls whether ParseEnv rejects environment variables that
+// carry the flag set's prefix but correspond to no defined flag, as ParseFile
+// does for unknown config keys. It has no effect without an env prefix.
+func (f *FlagSet) StrictEnv(strict bool) { f.strictEnv = strict }
+
+// StrictEnv sets strict environment parsing on the default CommandLine FlagSet.
+func StrictEnv(strict bool) { CommandLine.StrictEnv(strict) }
+
+// EnvKeys returns the environment variable each defined flag is read from,
+// keyed by flag name. With a key function returning several names, the first
+// is reported.
+func (f *FlagSet) EnvKeys() map[string]string {
+	keys := make(map[string]string, len(f.formal))
+	for name := range f.formal {
+		keys[name] = f.envKey(name)
+	}
+	return keys
+}
+
+// EnvKeys returns the environment variables of the default CommandLine FlagSet.
+func EnvKeys() map[string]string { return CommandLine.EnvKeys() }
+
+// UnusedEnv lists, sorted, the variables of the process environment that
+// start with prefix but feed no defined flag, catching typos such as
+// APP_PROT that would otherwise do nothing.
+func (f *FlagSet) UnusedEnv(prefix string) []string {
+	return f.unusedEnv(os.Environ(), prefix)
+}
+
+// UnusedEnv lists unused prefixed environment variables for the default
+// CommandLine FlagSet.
+func UnusedEnv(prefix string) []string { return CommandLine.UnusedEnv(prefix) }
+
+func (f *FlagSet) unusedEnv(environ []string, prefix string) []string {
+	used := make(map[string]bool, len(f.formal))
+	lists := make(map[string]bool)
+	for name, fl := range f.formal {
+		_, isList := fl.Value.(listValue)
+		for _, key := range f.envKeys(name) {
+			used[key] = true
+			lists[key] = isList
+		}
+	}
+	var unused []string
+	for _, kv := range environ {
+		key, _, _ := strings.Cut(kv, "=")
+		if key == "" || !strings.HasPrefix(key, prefix) || used[key] {
+			continue
+		}
+		if slices.ContainsFunc(f.envReserved, func(p string) bool { return strings.HasPrefix(key, p) }) {
+			continue
+		}
+		if i := strings.LastIndexByte(key, '_'); i > 0 && lists[key[:i]] && isIndex(key[i+1:]) {
+			continue
+		}
+		unused = append(unused, key)
+	}
+	sort.Strings(unused)
+	return unused
+}
+
+// indexedEnv collects the values of key_0, key_1, ... from env, stopping at
+// the first missing index.
+func indexedEnv(env map[string]string, key string) []string {
+	var items []string
+	for i := 0; ; i++ {
+		v, ok := env[key+"_"+strconv.Itoa(i)]
+		if !ok {
+			return items
+		}
+		items = append(items, v)
+	}
+}
+
+// isIndex reports whether s is a non-empty run of decimal digits.
+func isIndex(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
 // NewFlagSetWithEnvPrefix returns a new empty flag set with the specified name,
 // environment variable prefix, and error handling property.
 func NewFlagSetWithEnvPrefix(name string, prefix string, errorHandling ErrorHandling) *FlagSet {
@@ -123,28 +284,108 @@ func NewFlagSetWithEnvPrefix(name string, prefix string, errorHandling ErrorHand
 
 // DefaultConfigFlagname defines the flag name of the optional config file
 // path. Used to lookup and parse the config file when a default is set and
-// available on disk.
+// available on disk. FlagSet.SetConfigFlag overrides it per set.
 var DefaultConfigFlagname = "config"
 
 // DefaultSecretDirFlagname defines an optional flag name whose value, if set,
 // points to a directory containing secret files (each filename = flag name or
 // underscore variant). If present, it is processed after environment variables
-// and before the config file.
+// and before the config file. FlagSet.SetSecretDirFlag overrides it per set.
 var DefaultSecretDirFlagname = "secret-dir"
 
+// SetConfigFlag names the flag whose value Parse loads as a config file for
+// this set, overriding DefaultConfigFlagname. An empty name disables config
+// file loading.
+func (f *FlagSet) SetConfigFlag(name string) {
+	f.configFlag = name
+	f.configFlagSet = true
+}
+
+// SetConfigFlag names the config file flag of the default CommandLine FlagSet.
+func SetConfigFlag(name string) { CommandLine.SetConfigFlag(name) }
+
+// SetSecretDirFlag names the flag whose value Parse reads as a secret
+// directory for this set, overriding DefaultSecretDirFlagname. An empty name
+// disables secret directory loading.
+func (f *FlagSet) SetSecretDirFlag(name string) {
+	f.secretDirFlag = name
+	f.secretDirFlagSet = true
+}
+
+// SetSecretDirFlag names the secret directory flag of the default CommandLine
+// FlagSet.
+func SetSecretDirFlag(name string) { CommandLine.SetSecretDirFlag(name) }
+
+// configFlagName returns the config file flag name in effect for f.
+func (f *FlagSet) configFlagName() string {
+	if f.configFlagSet {
+		return f.configFlag
+	}
+	return DefaultConfigFlagname
+}
+
+// secretDirFlagName returns the secret directory flag name in effect for f.
+func (f *FlagSet) secretDirFlagName() string {
+	if f.secretDirFlagSet {
+		return f.secretDirFlag
+	}
+	return DefaultSecretDirFlagname
+}
+
+// splitConfigFiles expands the config flag values, each of which may be a
+// comma-separated list, into file names.
+func splitConfigFiles(values []string) []string {
+	var files []string
+	for _, v := range values {
+		for _, p := range strings.Split(v, ",") {
+			if p = strings.TrimSpace(p); p != "" {
+				files = append(files, p)
+			}
+		}
+	}
+	return files
+}
+
+// parseConfigFiles applies layered config files, later files overriding
+// earlier ones. Since ParseFile never overrides a flag that is already set,
+// the files are read last to first.
+func (f *FlagSet) parseConfigFiles(files []string) error {
+	f.configFiles = files
+	for i := len(files) - 1; i >= 0; i-- {
+		if err := f.ParseFile(files[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // ParseFile parses flags from the file in path.
 // Same format as commandline argumens, newlines and lines beginning with a
-// "#" charater are ignored. Flags already set will be ignored.
+// "#" charater are ignored. The file's extension selects another syntax when
+// one is registered for it, see RegisterConfigFormat; ".ini", ".properties",
+// ".toml", ".json", ".yaml" and ".yml" are built in, and ".conf" is the
+// line-based format. Flags already set will be ignored. With
+// RequireSignedConfig the file must carry a valid signature.
 func (f *FlagSet) ParseFile(path string) error {
+	defer f.setAuditActor(path)()
 
 	// Extract arguments from file
-	fp, err := os.Open(path)
+	text, err := f.readConfigText(path)
 	if err != nil {
 		return err
 	}
-	defer fp.Close()
 
-	scanner := bufio.NewScanner(fp)
+	parse := configFormats[strings.ToLower(filepath.Ext(path))]
+	if parse == nil {
+		parse = (*FlagSet).parseFlat
+	}
+	return parse(f, strings.NewReader(text))
+}
+
+// parseFlat reads the line-based format: "key value", "key=value" or a bare
+// boolean key per line.
+func (f *FlagSet) parseFlat(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
 	for scanner.Scan() {
 		line := scanner.Text()
 
@@ -173,71 +414,96 @@ func (f *FlagSet) ParseFile(path string) error {
 			name = line
 		}
 
-		// Ignore flag when already set; arguments have precedence over file
-		if f.actual[name] != nil {
-			continue
+		if err := f.applyConfigValue(name, value, hasValue); err != nil {
+			return err
 		}
+	}
 
-		m := f.formal
-		flag, alreadythere := m[name]
-		if !alreadythere {
-			if name == "help" || name == "h" { // special case for nice help message.
-				f.usage()
-				return ErrHelp
-			}
-			return f.failf("configuration variable provided but not defined: %s", name)
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// applyConfigValue sets the named flag from a configuration file entry,
+// honouring precedence and recording the "config" source.
+func (f *FlagSet) applyConfigValue(name, value string, hasValue bool) error {
+	// Ignore flag when already set; arguments have precedence over file
+	if f.actual[name] != nil {
+		f.audit(f.actual[name], SourceConfig, value, false, nil)
+		return nil
+	}
+
+	m := f.formal
+	flag, alreadythere := m[name]
+	if !alreadythere {
+		if f.isImplicitHelp(name) { // special case for nice help message.
+			f.usage()
+			return ErrHelp
+		}
+		return f.failf("configuration variable provided but not defined: %s", name)
+	}
+	if _, ok := flag.Value.(*helpValue); ok {
+		f.usage()
+		return ErrHelp
+	}
+	if _, ok := flag.Value.(*versionValue); ok {
+		return nil // only the command line asks for the version
+	}
+	if hasValue {
+		expanded, err := f.expandIdentity(value)
+		if err != nil {
+			return f.failf("invalid value for configuration variable %s: %v", name, err)
 		}
+		value = expanded
+	}
 
-		if fv, ok := flag.Value.(boolFlag); ok && fv.IsBoolFlag() { // special case: doesn't need an arg
-			if hasValue {
-				if expanded, err := expandAtFile(value); err == nil {
-					value = expanded
-				} else if !errors.Is(err, errNoAtExpansion) {
-					if f.isSensitive(name) {
-						return f.failf("invalid boolean value for configuration variable %s: %v", name, err)
-					}
-					return f.failf("invalid boolean value %q for configuration variable %s: %v", value, name, err)
-				}
-				if err := fv.Set(value); err != nil {
-					if f.isSensitive(name) {
-						return f.failf("invalid boolean value for configuration variable %s: %v", name, err)
-					}
-					return f.failf("invalid boolean value %q for configuration variable %s: %v", value, name, err)
-				}
-			} else {
-				fv.Set("true")
-			}
-		} else {
+	if fv, ok := flag.Value.(boolFlag); ok && fv.IsBoolFlag() { // special case: doesn't need an arg
+		if hasValue {
 			if expanded, err := expandAtFile(value); err == nil {
 				value = expanded
 			} else if !errors.Is(err, errNoAtExpansion) {
 				if f.isSensitive(name) {
-					return f.failf("invalid value for configuration variable %s: %v", name, err)
+					return f.failf("invalid boolean value for configuration variable %s: %v", name, err)
 				}
-				return f.failf("invalid value %q for configuration variable %s: %v", value, name, err)
+				return f.failf("invalid boolean value %q for configuration variable %s: %v", value, name, err)
 			}
-			if err := flag.Value.Set(value); err != nil {
+			if err := f.setValue(flag, value, SourceConfig); err != nil {
 				if f.isSensitive(name) {
-					return f.failf("invalid value for configuration variable %s: %v", name, err)
+					return f.failf("invalid boolean value for configuration variable %s: %v", name, err)
 				}
-				return f.failf("invalid value %q for configuration variable %s: %v", value, name, err)
+				return f.failf("invalid boolean value %q for configuration variable %s: %v", value, name, err)
 			}
+		} else {
+			fv.Set("true")
+			f.audit(flag, SourceConfig, "true", true, nil)
 		}
-
-		// update f.actual
-		if f.actual == nil {
-			f.actual = make(map[string]*Flag)
+	} else {
+		if expanded, err := expandAtFile(value); err == nil {
+			value = expanded
+		} else if !errors.Is(err, errNoAtExpansion) {
+			if f.isSensitive(name) {
+				return f.failf("invalid value for configuration variable %s: %v", name, err)
+			}
+			return f.failf("invalid value %q for configuration variable %s: %v", value, name, err)
 		}
-		f.actual[name] = flag
-		if f.sources != nil {
-			f.sources[name] = "config"
+		if err := f.setValue(flag, value, SourceConfig); err != nil {
+			if f.isSensitive(name) {
+				return f.failf("invalid value for configuration variable %s: %v", name, err)
+			}
+			return f.failf("invalid value %q for configuration variable %s: %v", value, name, err)
 		}
 	}
 
-	if err := scanner.Err(); err != nil {
-		return err
+	// update f.actual
+	if f.actual == nil {
+		f.actual = make(map[string]*Flag)
+	}
+	f.actual[name] = flag
+	if f.sources != nil {
+		f.sources[name] = "config"
 	}
-
 	return nil
 }
 
@@ -245,10 +511,19 @@ func (f *FlagSet) ParseFile(path string) error {
 
 var errNoAtExpansion = errors.New("no @file expansion")
 
+// rawValuePrefix marks an environment, config or secret value as literal: the
+// prefix is stripped and the remainder is used verbatim, with no @file
+// expansion.
+const rawValuePrefix = "raw:"
+
 // expandAtFile supports indirection syntax: a value beginning with '@path' will be
-// replaced by the file contents (trimmed of a single trailing newline). '@@' escapes
-// to a literal leading '@'. Returns errNoAtExpansion if no expansion occurred.
+// replaced by the file contents (trailing line breaks removed, see readValueFile). '@@' escapes
+// to a literal leading '@', and a "raw:" prefix is stripped with the rest taken
+// as is. Returns errNoAtExpansion if no expansion occurred.
 func expandAtFile(val string) (string, error) {
+	if strings.HasPrefix(val, rawValuePrefix) {
+		return val[len(rawValuePrefix):], nil
+	}
 	if len(val) == 0 || val[0] != '@' {
 		return "", errNoAtExpansion
 	}
@@ -259,61 +534,60 @@ func expandAtFile(val string) (string, error) {
 	if path == "" {
 		return "", fmt.Errorf("invalid @file reference: empty path")
 	}
-	b, err := os.ReadFile(path)
-	if err != nil {
-		return "", err
-	}
-	// Trim a single trailing newline / CR
-	s := string(b)
-	s = strings.TrimRight(s, "\r\n")
-	return s, nil
+	return readValueFile(path)
 }
 
 // ParseSecretDir ingests secret values from a directory where each file's name
-// maps to a flag name (case-insensitive). Filename transformations tried in order:
+// maps to a flag name (case-insensitive). Unless SetSecretNameMapper installs
+// a custom mapping, filename transformations tried in order:
 // 1. raw lower-case filename
 // 2. lower-case with '_' replaced by '-'
-// Existing (already set) flags are not overridden. Subdirectories are ignored.
+// Existing (already set) flags are not overridden. Subdirectories are ignored
+// unless SecretDirOptions.Recursive is set, which reads their files too, so
+// db/reader/password fills -db.reader.password. Files are filtered and read
+// according to SetSecretDirOptions.
 func (f *FlagSet) ParseSecretDir(dir string) error {
-	entries, err := os.ReadDir(dir)
+	defer f.setAuditActor(dir)()
+	files, err := f.secretDirOpts.secretFiles(dir)
 	if err != nil {
 		return err
 	}
-	for _, e := range entries {
-		if e.IsDir() {
+	for _, e := range files {
+		name := e.rel
+		target := f.secretTarget(f.secretDirOpts.flagName(name))
+		if target == nil {
 			continue
 		}
-		name := e.Name()
-		lower := strings.ToLower(name)
-		candidates := []string{lower, strings.ReplaceAll(lower, "_", "-")}
-		var target *Flag
-		for _, cand := range candidates {
-			if fl := f.formal[cand]; fl != nil {
-				target = fl
-				break
-			}
-		}
-		if target == nil {
+		if cliOnly(target.Value) {
 			continue
 		}
 		if f.actual != nil && f.actual[target.Name] != nil {
+			if f.auditEnabled {
+				if val, ok, err := f.readSecretFile(dir, e); ok && err == nil {
+					f.audit(target, SourceSecret, val, false, nil)
+				}
+			}
 			continue
 		} // respect precedence
-		data, err := os.ReadFile(filepath.Join(dir, name))
+		val, ok, err := f.readSecretFile(dir, e)
 		if err != nil {
 			return err
 		}
-		val := strings.TrimRight(string(data), "\r\n")
+		if !ok {
+			continue
+		}
 		if fv, ok := target.Value.(boolFlag); ok && fv.IsBoolFlag() && (val == "" || strings.EqualFold(val, "true")) {
 			// Empty or 'true' sets boolean true
-			if err := fv.Set("true"); err != nil {
+			err := fv.Set("true")
+			f.audit(target, SourceSecret, "true", err == nil, err)
+			if err != nil {
 				return err
 			}
 		} else {
 			if expanded, err := expandAtFile(val); err == nil {
 				val = expanded
 			} // nested @ optional
-			if err := target.Value.Set(val); err != nil {
+			if err := f.setValue(target, val, SourceSecret); err != nil {
 				if f.isSensitive(target.Name) {
 					return fmt.Errorf("secret file %s invalid for -%s: %v", name, target.Name, err)
 				}
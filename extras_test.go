@@ -5,6 +5,7 @@
 package flag_test
 
 import (
+	"io"
 	"os"
 	"syscall"
 	"testing"
@@ -201,3 +202,23 @@ func TestTestingPackageFlags(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestIgnoreTestFlagsDisabled(t *testing.T) {
+	f := NewFlagSet("test", ContinueOnError)
+	f.SetOutput(io.Discard)
+	f.IgnoreTestFlags(false)
+	if err := f.Parse([]string{"-test.v"}); err == nil || err.Error() != "flag provided but not defined: -test.v" {
+		t.Errorf("expected undefined flag error, got %v", err)
+	}
+}
+
+func TestDefinedTestDotFlag(t *testing.T) {
+	f := NewFlagSet("test", ContinueOnError)
+	timeout := f.Duration("test.timeout", 0, "probe timeout")
+	if err := f.Parse([]string{"-test.timeout=3s", "-test.v"}); err != nil {
+		t.Fatal(err)
+	}
+	if *timeout != 3*time.Second {
+		t.Errorf("test.timeout = %v, want 3s", *timeout)
+	}
+}
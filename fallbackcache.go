@@ -0,0 +1,219 @@
+package flag
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// SetFallbackCache keeps the last values each remote provider returned in an
+// encrypted file at path. When a provider fails, ParseRemote falls back to its
+// cached values, printing a warning, as long as they are no older than ttl
+// (0 means cached values never expire). The cache is written after every
+// successful fetch. An empty path disables the cache.
+//
+// Unless SetFallbackCacheKey supplies one, the AES key is generated on first
+// use and stored with mode 0600 in path+".key". A key kept beside the cache
+// only protects a cache file copied on its own; pass a key from a secret
+// store when the directory itself may be exposed.
+func (f *FlagSet) SetFallbackCache(path string, ttl time.Duration) {
+	if path == "" {
+		f.fallbackCache = nil
+		return
+	}
+	key := []byte(nil)
+	if f.fallbackCache != nil {
+		key = f.fallbackCache.key
+	}
+	f.fallbackCache = &fallbackCache{path: path, ttl: ttl, key: key}
+}
+
+// SetFallbackCache configures the remote fallback cache of the default
+// CommandLine FlagSet.
+func SetFallbackCache(path string, ttl time.Duration) { CommandLine.SetFallbackCache(path, ttl) }
+
+// SetFallbackCacheKey sets the AES-128, AES-192 or AES-256 key encrypting the
+// fallback cache; it must be 16, 24 or 32 bytes long. Call it after
+// SetFallbackCache.
+func (f *FlagSet) SetFallbackCacheKey(key []byte) error {
+	if _, err := aes.NewCipher(key); err != nil {
+		return fmt.Errorf("fallback cache key: %v", err)
+	}
+	if f.fallbackCache == nil {
+		return errors.New("fallback cache key set without SetFallbackCache")
+	}
+	f.fallbackCache.key = append([]byte(nil), key...)
+	return nil
+}
+
+// SetFallbackCacheKey sets the fallback cache key of the default CommandLine
+// FlagSet.
+func SetFallbackCacheKey(key []byte) error { return CommandLine.SetFallbackCacheKey(key) }
+
+// fetchRemote fetches rp, unless it pushed its values, falling back to the
+// cache when the provider fails. fresh reports values just fetched, which
+// the caller caches once they have been verified and applied; see
+// cacheRemote.
+func (f *FlagSet) fetchRemote(ctx context.Context, rp remoteProvider) (values map[string]string, fresh bool, err error) {
+	if values, ok := f.pushedValues(rp.name); ok {
+		return values, false, nil // kept current by SubscribeRemote
+	}
+	values, err = rp.p.Fetch(ctx)
+	c := f.fallbackCache
+	if err == nil {
+		return values, c != nil, nil
+	}
+	if c == nil {
+		return nil, false, err
+	}
+	entry, cerr := c.load(rp.name)
+	switch {
+	case cerr != nil:
+		if !errors.Is(cerr, fs.ErrNotExist) {
+			f.warnf("remote provider %s: cannot read fallback cache: %v", rp.name, cerr)
+		}
+		return nil, false, err
+	case entry == nil:
+		return nil, false, err
+	case c.ttl > 0 && f.now().Sub(entry.Saved) > c.ttl:
+		f.warnf("remote provider %s: cached values from %s have expired", rp.name, entry.Saved.Format(time.RFC3339))
+		return nil, false, err
+	}
+	f.warnf("remote provider %s unavailable (%v); using cached values from %s", rp.name, err, entry.Saved.Format(time.RFC3339))
+	return entry.Values, false, nil
+}
+
+// cacheRemote stores fetched provider values, as fetched, in the fallback
+// cache as the last known good ones.
+func (f *FlagSet) cacheRemote(fetched map[string]map[string]string) {
+	c := f.fallbackCache
+	if c == nil {
+		return
+	}
+	for provider, values := range fetched {
+		if err := c.store(provider, values, f.now()); err != nil {
+			f.warnf("remote provider %s: cannot update fallback cache: %v", provider, err)
+		}
+	}
+}
+
+// fallbackCache is the encrypted store of last-known-good remote values.
+type fallbackCache struct {
+	path string
+	ttl  time.Duration
+	key  []byte // nil means the generated key in path+".key"
+}
+
+// fallbackEntry is the cached result of one provider.
+type fallbackEntry struct {
+	Saved  time.Time         `json:"saved"`
+	Values map[string]string `json:"values"`
+}
+
+// load returns the cached entry of provider, or nil if there is none.
+func (c *fallbackCache) load(provider string) (*fallbackEntry, error) {
+	entries, err := c.read()
+	if err != nil {
+		return nil, err
+	}
+	return entries[provider], nil
+}
+
+// store replaces the cached entry of provider.
+func (c *fallbackCache) store(provider string, values map[string]string, now time.Time) error {
+	entries, err := c.read()
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		entries = nil // unreadable caches are rebuilt
+	}
+	if entries == nil {
+		entries = make(map[string]*fallbackEntry)
+	}
+	entries[provider] = &fallbackEntry{Saved: now, Values: values}
+	plain, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+	gcm, err := c.cipher(true)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	return writeFileAtomic(c.path, gcm.Seal(nonce, nonce, plain, nil))
+}
+
+func (c *fallbackCache) read() (map[string]*fallbackEntry, error) {
+	b, err := os.ReadFile(c.path)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := c.cipher(false)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) < gcm.NonceSize() {
+		return nil, fmt.Errorf("%s: truncated", c.path)
+	}
+	plain, err := gcm.Open(nil, b[:gcm.NonceSize()], b[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", c.path, err)
+	}
+	var entries map[string]*fallbackEntry
+	if err := json.Unmarshal(plain, &entries); err != nil {
+		return nil, fmt.Errorf("%s: %v", c.path, err)
+	}
+	return entries, nil
+}
+
+// cipher returns the AEAD for the cache, generating the key file when create
+// is set and neither a key nor a key file exists.
+func (c *fallbackCache) cipher(create bool) (cipher.AEAD, error) {
+	key := c.key
+	if key == nil {
+		keyPath := c.path + ".key"
+		var err error
+		key, err = os.ReadFile(keyPath)
+		if errors.Is(err, fs.ErrNotExist) && create {
+			key = make([]byte, 32)
+			if _, err = rand.Read(key); err == nil {
+				err = writeFileAtomic(keyPath, key)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("fallback cache key: %v", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// writeFileAtomic writes data to path with mode 0600 via a temporary file in
+// the same directory, so readers never see a partial file.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
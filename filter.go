@@ -0,0 +1,57 @@
+package flag
+
+// Source identifies where a flag value came from. The string forms match the
+// sources reported by Introspect and Source.
+type Source string
+
+const (
+	SourceCLI     Source = "cli"
+	SourceEnv     Source = "env"
+	SourceSecret  Source = "secret"
+	SourceConfig  Source = "config"
+	SourceRemote  Source = "remote"
+	SourceDefault Source = "default"
+)
+
+// ValueFilter rewrites or rejects a raw value before it reaches Value.Set.
+type ValueFilter func(raw string, source Source) (string, error)
+
+// SetValueFilter installs fn to run on every value supplied for the named flag
+// by the command line, environment, secret directory, config file or a remote
+// provider, after
+// @file expansion and before Value.Set. The returned string is what gets set;
+// an error is reported like any other invalid value. Typical uses are trimming
+// whitespace, lowercasing host names or rejecting control characters. A nil fn
+// removes the filter. Bare boolean flags (-v) are not filtered.
+func (f *FlagSet) SetValueFilter(name string, fn ValueFilter) {
+	if fn == nil {
+		delete(f.valueFilters, name)
+		return
+	}
+	if f.valueFilters == nil {
+		f.valueFilters = make(map[string]ValueFilter)
+	}
+	f.valueFilters[name] = fn
+}
+
+// SetValueFilter installs a value filter on the default CommandLine FlagSet.
+func SetValueFilter(name string, fn ValueFilter) { CommandLine.SetValueFilter(name, fn) }
+
+// setValue passes raw through the flag's filter, if any, sets the result and
+// records the attempt for auditing.
+func (f *FlagSet) setValue(flag *Flag, raw string, source Source) error {
+	err := f.filterAndSet(flag, raw, source)
+	f.audit(flag, source, raw, err == nil, err)
+	return err
+}
+
+func (f *FlagSet) filterAndSet(flag *Flag, raw string, source Source) error {
+	if fn := f.valueFilters[flag.Name]; fn != nil {
+		v, err := fn(raw, source)
+		if err != nil {
+			return err
+		}
+		raw = v
+	}
+	return flag.Value.Set(raw)
+}
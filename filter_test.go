@@ -0,0 +1,86 @@
+package flag_test
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode"
+
+	. "github.com/machship/flag"
+)
+
+func TestValueFilterSources(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "token"), []byte("  abc \n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cfg := filepath.Join(t.TempDir(), "app.conf")
+	if err := os.WriteFile(cfg, []byte("region=EU-West\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	f := NewFlagSet("test", ContinueOnError)
+	host := f.String("host", "", "")
+	user := f.String("user", "", "")
+	token := f.String("token", "", "")
+	region := f.String("region", "", "")
+	seen := make(map[string]Source)
+	record := func(name string, fn func(string) string) ValueFilter {
+		return func(raw string, src Source) (string, error) {
+			seen[name] = src
+			return fn(raw), nil
+		}
+	}
+	f.SetValueFilter("host", record("host", strings.ToLower))
+	f.SetValueFilter("user", record("user", strings.TrimSpace))
+	f.SetValueFilter("token", record("token", strings.TrimSpace))
+	f.SetValueFilter("region", record("region", strings.ToLower))
+
+	if err := f.Parse([]string{"-host", "DB.Example.COM"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.ParseEnv([]string{"USER= bob "}); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.ParseSecretDir(dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.ParseFile(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	if *host != "db.example.com" || *user != "bob" || *token != "abc" || *region != "eu-west" {
+		t.Fatalf("host=%q user=%q token=%q region=%q", *host, *user, *token, *region)
+	}
+	want := map[string]Source{"host": SourceCLI, "user": SourceEnv, "token": SourceSecret, "region": SourceConfig}
+	for name, src := range want {
+		if seen[name] != src {
+			t.Errorf("%s filtered with source %q, want %q", name, seen[name], src)
+		}
+	}
+}
+
+func TestValueFilterRejects(t *testing.T) {
+	errControl := errors.New("control characters not allowed")
+	f := NewFlagSet("test", ContinueOnError)
+	f.SetOutput(io.Discard)
+	f.String("name", "", "")
+	f.SetValueFilter("name", func(raw string, _ Source) (string, error) {
+		if strings.IndexFunc(raw, unicode.IsControl) >= 0 {
+			return "", errControl
+		}
+		return raw, nil
+	})
+	err := f.Parse([]string{"-name", "a\x1bb"})
+	if err == nil || !strings.Contains(err.Error(), errControl.Error()) {
+		t.Fatalf("expected filter error, got %v", err)
+	}
+
+	f.SetValueFilter("name", nil)
+	if err := f.Parse([]string{"-name", "a\x1bb"}); err != nil {
+		t.Fatalf("filter not removed: %v", err)
+	}
+}
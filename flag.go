@@ -79,20 +79,28 @@ flag set.
 package flag
 
 import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"math"
 	"math/big"
 	"net"
 	neturl "net/url"
 	"os"
 	"reflect"
 	"regexp"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
+	"text/template"
 	"time"
+	"unicode/utf8"
 
 	"sync"
 
@@ -362,18 +370,30 @@ func (b *byteSizeValue) String() string {
 }
 func (b *byteSizeValue) Get() interface{} { return *b.p }
 
-// time.Time value with layout
+// time.Time value with one or more layouts; the first layout is used for String.
+// When now is set, relative expressions ("now-24h", "yesterday") are accepted
+// and evaluated against it.
 type timeValue struct {
-	p      *time.Time
-	layout string
+	p       *time.Time
+	layouts []string
+	now     func() time.Time
 }
 
-func newTimeValue(val time.Time, layout string, p *time.Time) *timeValue {
+func newTimeValue(val time.Time, layouts []string, p *time.Time) *timeValue {
 	*p = val
-	return &timeValue{p: p, layout: layout}
+	return &timeValue{p: p, layouts: layouts}
 }
 func (tv *timeValue) Set(s string) error {
-	t, err := time.Parse(tv.layout, s)
+	if tv.now != nil {
+		if t, ok, err := parseRelativeTime(s, tv.now()); ok {
+			if err != nil {
+				return err
+			}
+			*tv.p = t
+			return nil
+		}
+	}
+	t, err := parseTimeLayouts(s, tv.layouts)
 	if err != nil {
 		return err
 	}
@@ -384,7 +404,7 @@ func (tv *timeValue) String() string {
 	if tv.p == nil || tv.p.IsZero() {
 		return ""
 	}
-	return tv.p.Format(tv.layout)
+	return tv.p.Format(tv.layouts[0])
 }
 func (tv *timeValue) Get() interface{} { return *tv.p }
 
@@ -481,6 +501,29 @@ func (uv *urlValue) String() string {
 }
 func (uv *urlValue) Get() interface{} { return *uv.p }
 
+// url.Values (query string form, repeated keys allowed)
+type urlValuesValue struct{ p *neturl.Values }
+
+func newURLValuesValue(val neturl.Values, p *neturl.Values) *urlValuesValue {
+	*p = val
+	return &urlValuesValue{p: p}
+}
+func (qv *urlValuesValue) Set(s string) error {
+	v, err := neturl.ParseQuery(strings.TrimPrefix(s, "?"))
+	if err != nil {
+		return err
+	}
+	*qv.p = v
+	return nil
+}
+func (qv *urlValuesValue) String() string {
+	if qv.p == nil {
+		return ""
+	}
+	return qv.p.Encode()
+}
+func (qv *urlValuesValue) Get() interface{} { return *qv.p }
+
 // uuid.UUID
 type uuidValue struct{ p *uuid.UUID }
 
@@ -547,6 +590,46 @@ func (rv *bigRatValue) String() string {
 }
 func (rv *bigRatValue) Get() interface{} { return *rv.p }
 
+// big.Float with fixed precision and rounding mode
+type bigFloatValue struct {
+	p    *big.Float
+	prec uint
+	mode big.RoundingMode
+}
+
+func newBigFloatValue(val *big.Float, prec uint, mode big.RoundingMode, p *big.Float) *bigFloatValue {
+	p.SetPrec(prec).SetMode(mode)
+	if val != nil {
+		p.Set(val)
+	}
+	return &bigFloatValue{p: p, prec: prec, mode: mode}
+}
+func (fv *bigFloatValue) Set(s string) error {
+	z := new(big.Float).SetPrec(fv.prec).SetMode(fv.mode)
+	if _, ok := z.SetString(s); !ok {
+		return fmt.Errorf("invalid big.Float %q", s)
+	}
+	fv.p.Set(z)
+	return nil
+}
+func (fv *bigFloatValue) String() string {
+	if fv.p == nil {
+		return "0"
+	}
+	return fv.p.Text('g', -1)
+}
+func (fv *bigFloatValue) Get() interface{} { return *fv.p }
+
+// parseRoundingMode accepts the names printed by big.RoundingMode.String.
+func parseRoundingMode(s string) (big.RoundingMode, error) {
+	for m := big.ToNearestEven; m <= big.ToPositiveInf; m++ {
+		if strings.EqualFold(s, m.String()) {
+			return m, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown rounding mode %q", s)
+}
+
 // regexp
 type regexpValue struct{ p **regexp.Regexp }
 
@@ -575,6 +658,106 @@ func (rv *regexpValue) Get() interface{} {
 	return *rv.p
 }
 
+// regexp slice (separator delimited, each element compiled independently)
+type regexpSliceValue struct {
+	p   *[]*regexp.Regexp
+	sep string
+}
+
+func newRegexpSliceValue(val []*regexp.Regexp, sep string, p *[]*regexp.Regexp) *regexpSliceValue {
+	*p = append((*p)[:0], val...)
+	return &regexpSliceValue{p: p, sep: sep}
+}
+func (rv *regexpSliceValue) Set(s string) error {
+	out, err := compileRegexpList(s, rv.sep)
+	if err != nil {
+		return err
+	}
+	*rv.p = out
+	return nil
+}
+func (rv *regexpSliceValue) String() string {
+	if rv.p == nil {
+		return ""
+	}
+	return joinRegexps(*rv.p, rv.sep)
+}
+func (rv *regexpSliceValue) Get() interface{} { return *rv.p }
+
+// compileRegexpList splits s on sep and compiles each element, reporting the
+// index of the first element that fails to compile.
+func compileRegexpList(s, sep string) ([]*regexp.Regexp, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	parts := strings.Split(s, sep)
+	out := make([]*regexp.Regexp, 0, len(parts))
+	for i, part := range parts {
+		r, err := regexp.Compile(part)
+		if err != nil {
+			return nil, fmt.Errorf("regexp element %d %q: %v", i, part, err)
+		}
+		out = append(out, r)
+	}
+	return out, nil
+}
+
+func joinRegexps(rs []*regexp.Regexp, sep string) string {
+	ss := make([]string, 0, len(rs))
+	for _, r := range rs {
+		ss = append(ss, r.String())
+	}
+	return strings.Join(ss, sep)
+}
+
+// Matcher is a list of regular expressions matched with OR semantics.
+type Matcher []*regexp.Regexp
+
+// MatchString reports whether s matches any of the expressions.
+func (m Matcher) MatchString(s string) bool {
+	for _, r := range m {
+		if r.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// Match reports whether b matches any of the expressions.
+func (m Matcher) Match(b []byte) bool {
+	for _, r := range m {
+		if r.Match(b) {
+			return true
+		}
+	}
+	return false
+}
+
+type matcherValue struct {
+	p   *Matcher
+	sep string
+}
+
+func newMatcherValue(val Matcher, sep string, p *Matcher) *matcherValue {
+	*p = append((*p)[:0], val...)
+	return &matcherValue{p: p, sep: sep}
+}
+func (mv *matcherValue) Set(s string) error {
+	out, err := compileRegexpList(s, mv.sep)
+	if err != nil {
+		return err
+	}
+	*mv.p = out
+	return nil
+}
+func (mv *matcherValue) String() string {
+	if mv.p == nil {
+		return ""
+	}
+	return joinRegexps(*mv.p, mv.sep)
+}
+func (mv *matcherValue) Get() interface{} { return *mv.p }
+
 // string slice
 type stringSliceValue struct {
 	p   *[]string
@@ -715,21 +898,76 @@ func (mv *stringMapValue) String() string {
 func (mv *stringMapValue) Get() interface{} { return *mv.p }
 
 // json.RawMessage
-type jsonValue struct{ p *json.RawMessage }
+type jsonValue struct {
+	p    *json.RawMessage
+	opts JSONOptions
+}
+
+// JSONOptions tunes validation of JSON flag values.
+type JSONOptions struct {
+	// MaxBytes rejects documents larger than this many bytes (0 = no limit).
+	MaxBytes int
+	// Target, if non-nil, is a prototype value (e.g. Config{} or &Config{})
+	// the document must decode into. The decoded result is discarded; the
+	// flag still stores the raw bytes.
+	Target interface{}
+	// DisallowUnknownFields rejects object keys not present in Target.
+	DisallowUnknownFields bool
+}
 
 func newJSONValue(val json.RawMessage, p *json.RawMessage) *jsonValue {
 	*p = val
 	return &jsonValue{p: p}
 }
 func (jv *jsonValue) Set(s string) error {
-	var tmp json.RawMessage = json.RawMessage([]byte(s)) // basic validation
-	var v interface{}
-	if err := json.Unmarshal(tmp, &v); err != nil {
+	if jv.opts.MaxBytes > 0 && len(s) > jv.opts.MaxBytes {
+		return fmt.Errorf("json value too large: %d bytes (max %d)", len(s), jv.opts.MaxBytes)
+	}
+	tmp := json.RawMessage(s)
+	if err := validateJSON(tmp); err != nil {
 		return err
 	}
+	if jv.opts.Target != nil {
+		if err := decodeJSONStrict(tmp, jv.opts.Target, jv.opts.DisallowUnknownFields); err != nil {
+			return err
+		}
+	}
 	*jv.p = tmp
 	return nil
 }
+
+// validateJSON checks b is a single well-formed JSON document without
+// materialising it; the slower unmarshal only runs to describe a failure.
+func validateJSON(b []byte) error {
+	if json.Valid(b) {
+		return nil
+	}
+	var raw json.RawMessage
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	return fmt.Errorf("invalid JSON")
+}
+
+// decodeJSONStrict decodes b into a fresh value of target's type using a
+// streaming decoder, optionally rejecting unknown fields.
+func decodeJSONStrict(b []byte, target interface{}, disallowUnknown bool) error {
+	t := reflect.TypeOf(target)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	if disallowUnknown {
+		dec.DisallowUnknownFields()
+	}
+	if err := dec.Decode(reflect.New(t).Interface()); err != nil {
+		return err
+	}
+	if dec.More() {
+		return fmt.Errorf("unexpected data after JSON document")
+	}
+	return nil
+}
 func (jv *jsonValue) String() string {
 	if jv.p == nil {
 		return ""
@@ -738,6 +976,55 @@ func (jv *jsonValue) String() string {
 }
 func (jv *jsonValue) Get() interface{} { return *jv.p }
 
+// []json.RawMessage (newline-delimited JSON, one document per line)
+type jsonLinesValue struct{ p *[]json.RawMessage }
+
+func newJSONLinesValue(val []json.RawMessage, p *[]json.RawMessage) *jsonLinesValue {
+	*p = append((*p)[:0], val...)
+	return &jsonLinesValue{p: p}
+}
+func (jv *jsonLinesValue) Set(s string) error {
+	if expanded, err := expandAtFile(s); err == nil {
+		s = expanded
+	} else if !errors.Is(err, errNoAtExpansion) {
+		return err
+	}
+	out, err := parseJSONLines(s)
+	if err != nil {
+		return err
+	}
+	*jv.p = out
+	return nil
+}
+func (jv *jsonLinesValue) String() string {
+	if jv.p == nil {
+		return ""
+	}
+	lines := make([]string, 0, len(*jv.p))
+	for _, m := range *jv.p {
+		lines = append(lines, string(m))
+	}
+	return strings.Join(lines, "\n")
+}
+func (jv *jsonLinesValue) Get() interface{} { return *jv.p }
+
+// parseJSONLines validates each non-blank line of s as a JSON document.
+// Errors report the 1-based line number.
+func parseJSONLines(s string) ([]json.RawMessage, error) {
+	var out []json.RawMessage
+	for i, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if err := validateJSON([]byte(line)); err != nil {
+			return nil, fmt.Errorf("invalid JSON on line %d: %v", i+1, err)
+		}
+		out = append(out, json.RawMessage(line))
+	}
+	return out, nil
+}
+
 // enum string wrapper
 type enumStringValue struct {
 	p       *string
@@ -767,6 +1054,47 @@ func (ev *enumStringValue) String() string {
 }
 func (ev *enumStringValue) Get() interface{} { return *ev.p }
 
+func (ev *enumStringValue) allowedValues() []string { return strings.Split(keys(ev.allowed), ",") }
+
+// bounded string: UTF-8 validated and length-limited at Set time
+type boundedStringValue struct {
+	p      *string
+	maxLen int
+}
+
+func newBoundedStringValue(val string, maxLen int, p *string) *boundedStringValue {
+	*p = val
+	return &boundedStringValue{p: p, maxLen: maxLen}
+}
+func (bv *boundedStringValue) Set(s string) error {
+	if err := bv.check(s); err != nil {
+		return err
+	}
+	*bv.p = s
+	return nil
+}
+func (bv *boundedStringValue) check(s string) error {
+	// cheap byte-length guard first so oversized input is never scanned in
+	// full; a limit too large to multiply out cannot be exceeded in bytes
+	if bv.maxLen >= 0 && bv.maxLen <= math.MaxInt/utf8.UTFMax && len(s) > bv.maxLen*utf8.UTFMax {
+		return fmt.Errorf("value too long: more than %d bytes (max %d characters)", bv.maxLen*utf8.UTFMax, bv.maxLen)
+	}
+	if !utf8.ValidString(s) {
+		return fmt.Errorf("value is not valid UTF-8")
+	}
+	if n := utf8.RuneCountInString(s); bv.maxLen >= 0 && n > bv.maxLen {
+		return fmt.Errorf("value too long: %d characters (max %d)", n, bv.maxLen)
+	}
+	return nil
+}
+func (bv *boundedStringValue) String() string {
+	if bv.p == nil {
+		return ""
+	}
+	return *bv.p
+}
+func (bv *boundedStringValue) Get() interface{} { return *bv.p }
+
 func keys(m map[string]struct{}) string {
 	var ks []string
 	for k := range m {
@@ -798,7 +1126,7 @@ func (f *FlagSet) TimeVar(p *time.Time, name, layout string, value time.Time, us
 	if layout == "" {
 		layout = time.RFC3339
 	}
-	f.Var(newTimeValue(value, layout, p), name, usage)
+	f.Var(newTimeValue(value, []string{layout}, p), name, usage)
 }
 func TimeVar(p *time.Time, name, layout string, value time.Time, usage string) {
 	CommandLine.TimeVar(p, name, layout, value, usage)
@@ -812,6 +1140,47 @@ func Time(name, layout string, value time.Time, usage string) *time.Time {
 	return CommandLine.Time(name, layout, value, usage)
 }
 
+// TimeLayoutsVar registers a time.Time flag that accepts any of the given
+// layouts, tried in order. The first layout is used to print the value.
+// Layouts may name a time package constant such as "RFC3339" or "RFC1123".
+// An empty list means RFC3339.
+func (f *FlagSet) TimeLayoutsVar(p *time.Time, name string, layouts []string, value time.Time, usage string) {
+	f.Var(newTimeValue(value, resolveTimeLayouts(layouts), p), name, usage)
+}
+func TimeLayoutsVar(p *time.Time, name string, layouts []string, value time.Time, usage string) {
+	CommandLine.TimeLayoutsVar(p, name, layouts, value, usage)
+}
+func (f *FlagSet) TimeLayouts(name string, layouts []string, value time.Time, usage string) *time.Time {
+	p := new(time.Time)
+	f.TimeLayoutsVar(p, name, layouts, value, usage)
+	return p
+}
+func TimeLayouts(name string, layouts []string, value time.Time, usage string) *time.Time {
+	return CommandLine.TimeLayouts(name, layouts, value, usage)
+}
+
+// RelativeTimeVar registers a time.Time flag like TimeLayoutsVar that also
+// accepts relative expressions: now, today, yesterday, tomorrow or a weekday
+// name, optionally followed by a time of day and a signed offset, e.g.
+// "now-24h", "yesterday 17:00" or "monday 09:00". Expressions are evaluated
+// against the FlagSet's clock when the value is set.
+func (f *FlagSet) RelativeTimeVar(p *time.Time, name string, layouts []string, value time.Time, usage string) {
+	tv := newTimeValue(value, resolveTimeLayouts(layouts), p)
+	tv.now = f.now
+	f.Var(tv, name, usage)
+}
+func RelativeTimeVar(p *time.Time, name string, layouts []string, value time.Time, usage string) {
+	CommandLine.RelativeTimeVar(p, name, layouts, value, usage)
+}
+func (f *FlagSet) RelativeTime(name string, layouts []string, value time.Time, usage string) *time.Time {
+	p := new(time.Time)
+	f.RelativeTimeVar(p, name, layouts, value, usage)
+	return p
+}
+func RelativeTime(name string, layouts []string, value time.Time, usage string) *time.Time {
+	return CommandLine.RelativeTime(name, layouts, value, usage)
+}
+
 func (f *FlagSet) DecimalVar(p *decimal.Decimal, name string, value decimal.Decimal, usage string) {
 	f.Var(newDecimalValue(value, p), name, usage)
 }
@@ -870,6 +1239,23 @@ func URL(name string, value *neturl.URL, usage string) *neturl.URL {
 	return CommandLine.URL(name, value, usage)
 }
 
+// URLValuesVar registers a url.Values flag parsed from query string form
+// (a=1&b=2&b=3). Keys and values are URL-decoded and repeated keys accumulate.
+func (f *FlagSet) URLValuesVar(p *neturl.Values, name string, value neturl.Values, usage string) {
+	f.Var(newURLValuesValue(value, p), name, usage)
+}
+func URLValuesVar(p *neturl.Values, name string, value neturl.Values, usage string) {
+	CommandLine.URLValuesVar(p, name, value, usage)
+}
+func (f *FlagSet) URLValues(name string, value neturl.Values, usage string) *neturl.Values {
+	p := new(neturl.Values)
+	f.URLValuesVar(p, name, value, usage)
+	return p
+}
+func URLValues(name string, value neturl.Values, usage string) *neturl.Values {
+	return CommandLine.URLValues(name, value, usage)
+}
+
 func (f *FlagSet) UUIDVar(p *uuid.UUID, name string, value uuid.UUID, usage string) {
 	f.Var(newUUIDValue(value, p), name, usage)
 }
@@ -921,6 +1307,23 @@ func BigRat(name string, value *big.Rat, usage string) *big.Rat {
 	return CommandLine.BigRat(name, value, usage)
 }
 
+// BigFloatVar registers a big.Float flag. Parsed values are rounded to prec
+// bits using mode; a prec of 0 keeps big.Float's default of 64 bits.
+func (f *FlagSet) BigFloatVar(p *big.Float, name string, value *big.Float, prec uint, mode big.RoundingMode, usage string) {
+	f.Var(newBigFloatValue(value, prec, mode, p), name, usage)
+}
+func BigFloatVar(p *big.Float, name string, value *big.Float, prec uint, mode big.RoundingMode, usage string) {
+	CommandLine.BigFloatVar(p, name, value, prec, mode, usage)
+}
+func (f *FlagSet) BigFloat(name string, value *big.Float, prec uint, mode big.RoundingMode, usage string) *big.Float {
+	p := new(big.Float)
+	f.BigFloatVar(p, name, value, prec, mode, usage)
+	return p
+}
+func BigFloat(name string, value *big.Float, prec uint, mode big.RoundingMode, usage string) *big.Float {
+	return CommandLine.BigFloat(name, value, prec, mode, usage)
+}
+
 func (f *FlagSet) RegexpVar(p **regexp.Regexp, name string, value *regexp.Regexp, usage string) {
 	f.Var(newRegexpValue(value, p), name, usage)
 }
@@ -936,6 +1339,47 @@ func Regexp(name string, value *regexp.Regexp, usage string) **regexp.Regexp {
 	return CommandLine.Regexp(name, value, usage)
 }
 
+// RegexpSliceVar registers a flag holding a sep-delimited list of regular
+// expressions. Since ',' is common inside expressions (e.g. {1,3}) callers
+// will usually want a different separator such as ";" or "|||".
+func (f *FlagSet) RegexpSliceVar(p *[]*regexp.Regexp, name, sep string, value []*regexp.Regexp, usage string) {
+	if sep == "" {
+		sep = ","
+	}
+	f.Var(newRegexpSliceValue(value, sep, p), name, usage)
+}
+func RegexpSliceVar(p *[]*regexp.Regexp, name, sep string, value []*regexp.Regexp, usage string) {
+	CommandLine.RegexpSliceVar(p, name, sep, value, usage)
+}
+func (f *FlagSet) RegexpSlice(name, sep string, value []*regexp.Regexp, usage string) *[]*regexp.Regexp {
+	p := new([]*regexp.Regexp)
+	f.RegexpSliceVar(p, name, sep, value, usage)
+	return p
+}
+func RegexpSlice(name, sep string, value []*regexp.Regexp, usage string) *[]*regexp.Regexp {
+	return CommandLine.RegexpSlice(name, sep, value, usage)
+}
+
+// MatcherVar registers a Matcher flag: a sep-delimited list of regular
+// expressions where a string matches if any expression matches.
+func (f *FlagSet) MatcherVar(p *Matcher, name, sep string, value Matcher, usage string) {
+	if sep == "" {
+		sep = ","
+	}
+	f.Var(newMatcherValue(value, sep, p), name, usage)
+}
+func MatcherVar(p *Matcher, name, sep string, value Matcher, usage string) {
+	CommandLine.MatcherVar(p, name, sep, value, usage)
+}
+func (f *FlagSet) MatcherFlag(name, sep string, value Matcher, usage string) *Matcher {
+	p := new(Matcher)
+	f.MatcherVar(p, name, sep, value, usage)
+	return p
+}
+func MatcherFlag(name, sep string, value Matcher, usage string) *Matcher {
+	return CommandLine.MatcherFlag(name, sep, value, usage)
+}
+
 func (f *FlagSet) StringSliceVar(p *[]string, name, sep string, value []string, usage string) {
 	if sep == "" {
 		sep = ","
@@ -1021,6 +1465,36 @@ func JSON(name string, value json.RawMessage, usage string) *json.RawMessage {
 	return CommandLine.JSON(name, value, usage)
 }
 
+// JSONVarWithOptions registers a json.RawMessage flag with a size limit and/or
+// strict decoding against a target type. Use it for large documents passed via
+// @file, where the default validation is sufficient but a cap is wanted.
+func (f *FlagSet) JSONVarWithOptions(p *json.RawMessage, name string, value json.RawMessage, opts JSONOptions, usage string) {
+	jv := newJSONValue(value, p)
+	jv.opts = opts
+	f.Var(jv, name, usage)
+}
+func JSONVarWithOptions(p *json.RawMessage, name string, value json.RawMessage, opts JSONOptions, usage string) {
+	CommandLine.JSONVarWithOptions(p, name, value, opts, usage)
+}
+
+// JSONLinesVar registers a newline-delimited JSON flag; each non-blank line
+// must be a complete JSON document. The value may be given as @path to read
+// the documents from a file.
+func (f *FlagSet) JSONLinesVar(p *[]json.RawMessage, name string, value []json.RawMessage, usage string) {
+	f.Var(newJSONLinesValue(value, p), name, usage)
+}
+func JSONLinesVar(p *[]json.RawMessage, name string, value []json.RawMessage, usage string) {
+	CommandLine.JSONLinesVar(p, name, value, usage)
+}
+func (f *FlagSet) JSONLines(name string, value []json.RawMessage, usage string) *[]json.RawMessage {
+	p := new([]json.RawMessage)
+	f.JSONLinesVar(p, name, value, usage)
+	return p
+}
+func JSONLines(name string, value []json.RawMessage, usage string) *[]json.RawMessage {
+	return CommandLine.JSONLines(name, value, usage)
+}
+
 // EnumVar registers an enum string flag restricted to the provided allowed values.
 func (f *FlagSet) EnumVar(p *string, name string, value string, allowed []string, usage string) {
 	// Normalize allowed list (trim spaces)
@@ -1045,6 +1519,28 @@ func Enum(name string, value string, allowed []string, usage string) *string {
 	return CommandLine.Enum(name, value, allowed, usage)
 }
 
+// BoundedStringVar registers a string flag that rejects values which are not
+// valid UTF-8 or exceed maxLen characters. A negative maxLen disables the limit.
+// It panics if the default value breaks the same rules.
+func (f *FlagSet) BoundedStringVar(p *string, name string, value string, maxLen int, usage string) {
+	bv := newBoundedStringValue(value, maxLen, p)
+	if err := bv.check(value); err != nil {
+		panic(fmt.Sprintf("flag: invalid default for -%s: %v", name, err))
+	}
+	f.Var(bv, name, usage)
+}
+func BoundedStringVar(p *string, name string, value string, maxLen int, usage string) {
+	CommandLine.BoundedStringVar(p, name, value, maxLen, usage)
+}
+func (f *FlagSet) BoundedString(name string, value string, maxLen int, usage string) *string {
+	p := new(string)
+	f.BoundedStringVar(p, name, value, maxLen, usage)
+	return p
+}
+func BoundedString(name string, value string, maxLen int, usage string) *string {
+	return CommandLine.BoundedString(name, value, maxLen, usage)
+}
+
 // Value is the interface to the dynamic value stored in a flag.
 // (The default value is represented as a string.)
 //
@@ -1077,6 +1573,9 @@ func (f *FlagSet) Var(value Value, name string, usage string) {
 	// Remember the default value as a string; it won't change.
 	flag := &Flag{Name: name, Usage: usage, Value: value, DefValue: value.String(), Sensitive: false}
 	_, alreadythere := f.formal[name]
+	if _, ok := f.aliases[name]; ok {
+		alreadythere = true
+	}
 	if alreadythere {
 		var msg string
 		if f.name == "" {
@@ -1136,7 +1635,20 @@ func (f *FlagSet) parseOne() (bool, error) {
 		return false, nil
 	}
 	s := f.args[0]
+	if s == "help" && f.helpCommand {
+		topic := ""
+		if len(f.args) > 1 {
+			topic = f.args[1]
+		}
+		f.args = nil
+		return false, f.showHelp(topic)
+	}
 	if len(s) == 0 || s[0] != '-' || len(s) == 1 {
+		if f.interspersed {
+			f.positional = append(f.positional, s)
+			f.args = f.args[1:]
+			return true, nil
+		}
 		return false, nil
 	}
 	numMinuses := 1
@@ -1152,7 +1664,7 @@ func (f *FlagSet) parseOne() (bool, error) {
 		return false, f.failf("bad flag syntax: %s", s)
 	}
 	// ignore go test flags
-	if strings.HasPrefix(name, "test.") {
+	if strings.HasPrefix(name, "test.") && f.skipTestFlag(name) {
 		return false, nil
 	}
 	// it's a flag. does it have an argument?
@@ -1168,26 +1680,70 @@ func (f *FlagSet) parseOne() (bool, error) {
 		}
 	}
 	m := f.formal
-	flag, alreadythere := m[name]
+	flag, alreadythere := m[f.resolveAlias(name)]
+	owner := f // the set defining the flag; a parent's for inherited flags
+	if !alreadythere {
+		flag, owner = f.inheritedFlag(name)
+		alreadythere = flag != nil
+	}
+	if alreadythere {
+		name = flag.Name // given as an alias
+	}
+	if !alreadythere && numMinuses == 1 {
+		if bundle := f.expandBundle(name, value, hasValue); bundle != nil {
+			f.args = append(bundle, f.args...)
+			return true, nil
+		}
+	}
 	if !alreadythere {
-		if name == "help" || name == "h" {
+		if f.isImplicitHelp(name) {
 			f.usage()
 			return false, ErrHelp
 		}
+		if f.allowUnknown {
+			f.unknownArgs = append(f.unknownArgs, s)
+			if !hasValue && len(f.args) > 0 && !strings.HasPrefix(f.args[0], "-") {
+				f.unknownArgs, f.args = append(f.unknownArgs, f.args[0]), f.args[1:]
+			}
+			return true, nil
+		}
+		f.noteUnknownFlag(name)
 		return false, f.failf("flag provided but not defined: -%s", name)
 	}
+	if _, ok := flag.Value.(*helpValue); ok {
+		if err := f.requestHelp(value, hasValue); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+	if _, ok := flag.Value.(*versionValue); ok {
+		if err := owner.requestVersion(value, hasValue); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
 	if fv, ok := flag.Value.(boolFlag); ok && fv.IsBoolFlag() { // special case: doesn't need an arg
 		if hasValue {
-			if err := fv.Set(value); err != nil {
+			if err := owner.setValue(flag, value, SourceCLI); err != nil {
 				return false, f.failf("invalid boolean value %q for -%s: %v", value, name, err)
 			}
 		} else {
-			if err := fv.Set("true"); err != nil {
+			if f.strictBooleans {
+				return false, f.failf("boolean flag -%s needs an explicit value: -%s=true or -%s=false", name, name, name)
+			}
+			err := fv.Set("true")
+			owner.audit(flag, SourceCLI, "true", err == nil, err)
+			if err != nil {
 				return false, f.failf("invalid boolean flag %s: %v", name, err)
 			}
 		}
 	} else {
-		// It must have a value, which might be the next argument.
+		// It must have a value, which might be the next argument, unless
+		// the flag has one to use when given alone.
+		if !hasValue && flag.NoOptDefVal != "" {
+			hasValue = true
+			value = flag.NoOptDefVal
+		}
 		if !hasValue && len(f.args) > 0 {
 			hasValue = true
 			value, f.args = f.args[0], f.args[1:]
@@ -1195,24 +1751,114 @@ func (f *FlagSet) parseOne() (bool, error) {
 		if !hasValue {
 			return false, f.failf("flag needs an argument: -%s", name)
 		}
-		if err := flag.Value.Set(value); err != nil {
-			if f.isSensitive(name) {
+		if err := owner.setValue(flag, value, SourceCLI); err != nil {
+			if owner.isSensitive(name) {
 				return false, f.failf("invalid value for flag -%s: %v", name, err) // omit actual value
 			}
 			return false, f.failf("invalid value %q for flag -%s: %v", value, name, err)
 		}
+		if name == owner.configFlagName() {
+			owner.cliConfigFiles = append(owner.cliConfigFiles, value)
+		}
 	}
-	if f.actual == nil {
-		f.actual = make(map[string]*Flag)
+	if owner.actual == nil {
+		owner.actual = make(map[string]*Flag)
 	}
-	f.actual[name] = flag
-	if f.sources != nil {
-		f.sources[name] = "cli"
+	owner.actual[name] = flag
+	if owner.sources != nil {
+		owner.sources[name] = "cli"
 	}
-	f.noteDeprecationIfNeeded(name)
+	owner.noteDeprecationIfNeeded(name)
 	return true, nil
 }
 
+// AllowUnknownFlags controls whether Parse collects flags it does not know
+// instead of failing, for a program that wraps another and forwards them.
+// Each is kept verbatim in UnknownArgs, in order. Without an attached
+// "=value", an unknown flag followed by an argument not starting with "-"
+// is assumed to take it as its value, and it is collected too; write
+// "-flag=value" or put positional arguments after "--" where that guess
+// would be wrong.
+func (f *FlagSet) AllowUnknownFlags(allow bool) { f.allowUnknown = allow }
+
+// AllowUnknownFlags sets unknown flag passthrough on the default CommandLine
+// FlagSet.
+func AllowUnknownFlags(allow bool) { CommandLine.AllowUnknownFlags(allow) }
+
+// UnknownArgs returns the unknown flags, and the values taken to belong to
+// them, that the last Parse collected with AllowUnknownFlags set.
+func (f *FlagSet) UnknownArgs() []string { return f.unknownArgs }
+
+// UnknownArgs returns the unknown flags collected from the command line.
+func UnknownArgs() []string { return CommandLine.unknownArgs }
+
+// SetInterspersed controls whether Parse carries on past positional
+// arguments, GNU style, so flags may follow them: with it set, "cp -r src dst
+// -v" sets -r and -v and leaves "src dst" as the arguments. "--" still ends
+// the flags, the arguments after it being kept in order after those
+// collected before it. Leave it off on a Command that has subcommands, whose
+// names are positional arguments to it.
+func (f *FlagSet) SetInterspersed(interspersed bool) { f.interspersed = interspersed }
+
+// SetInterspersed sets interspersed parsing on the default CommandLine FlagSet.
+func SetInterspersed(interspersed bool) { CommandLine.SetInterspersed(interspersed) }
+
+// StrictBooleans controls whether boolean flags on the command line must be
+// written as -flag=true or -flag=false. With strict set, the bare form -flag is
+// rejected, so "-flag false" can never silently leave "false" as a positional
+// argument. Environment, config and secret sources are unaffected.
+func (f *FlagSet) StrictBooleans(strict bool) { f.strictBooleans = strict }
+
+// StrictBooleans sets strict boolean parsing on the default CommandLine FlagSet.
+func StrictBooleans(strict bool) { CommandLine.StrictBooleans(strict) }
+
+// IgnoreTestFlags controls whether command-line arguments named test.* (as
+// passed by go test) stop flag parsing instead of failing as undefined. By
+// default they are ignored only when running under go test, that is in a
+// binary named *.test given -test.* arguments. Flags registered under a test.*
+// name are always parsed normally.
+func (f *FlagSet) IgnoreTestFlags(ignore bool) {
+	f.ignoreTestFlags = ignore
+	f.ignoreTestFlagsSet = true
+}
+
+// IgnoreTestFlags sets test flag handling on the default CommandLine FlagSet.
+func IgnoreTestFlags(ignore bool) { CommandLine.IgnoreTestFlags(ignore) }
+
+// skipTestFlag reports whether the test.* flag name should be left to go test.
+func (f *FlagSet) skipTestFlag(name string) bool {
+	if i := strings.IndexByte(name, '='); i > 0 {
+		name = name[:i]
+	}
+	if _, defined := f.formal[name]; defined {
+		return false
+	}
+	if f.ignoreTestFlagsSet {
+		return f.ignoreTestFlags
+	}
+	return underGoTest
+}
+
+// underGoTest reports whether the process looks like a test binary run by go
+// test. It is decided from the arguments the process started with, which
+// tests commonly replace, and avoids importing the testing package, which
+// would register its flags in every program.
+var underGoTest = isGoTestBinary(os.Args)
+
+// isGoTestBinary reports whether args are those of a binary named *.test
+// given -test.* arguments, as go test runs it.
+func isGoTestBinary(args []string) bool {
+	if len(args) == 0 || !strings.HasSuffix(strings.TrimSuffix(args[0], ".exe"), ".test") {
+		return false
+	}
+	for _, arg := range args[1:] {
+		if strings.HasPrefix(arg, "-test.") || strings.HasPrefix(arg, "--test.") {
+			return true
+		}
+	}
+	return false
+}
+
 // Parse parses flag definitions from the argument list, which should not
 // include the command name. Must be called after all flags in the FlagSet
 // are defined and before flags are accessed by the program.
@@ -1220,24 +1866,41 @@ func (f *FlagSet) parseOne() (bool, error) {
 func (f *FlagSet) Parse(arguments []string) error {
 	f.parsed = true
 	f.args = arguments
+	f.cliConfigFiles = nil
+	f.positional = nil
+	f.unknownArgs = nil
+	endCLI := f.startPhase(PhaseCLI)
 	for {
 		seen, err := f.parseOne()
 		if seen {
 			continue
 		}
 		if err == nil {
+			if len(f.positional) > 0 {
+				f.args = append(f.positional, f.args...)
+				f.positional = nil
+			}
 			break
 		}
+		endCLI(err)
 		switch f.errorHandling {
 		case ContinueOnError:
 			return err
 		case ExitOnError:
-			exitFunc(2)
+			if err == ErrVersion {
+				exitFunc(0)
+			} else {
+				exitFunc(2)
+			}
 		case PanicOnError:
 			panic(err)
 		}
 	}
-	if err := f.ParseEnv(os.Environ()); err != nil {
+	endCLI(nil)
+	endEnv := f.startPhase(PhaseEnv)
+	err := f.ParseEnv(os.Environ())
+	endEnv(err)
+	if err != nil {
 		switch f.errorHandling {
 		case ContinueOnError:
 			return err
@@ -1249,15 +1912,11 @@ func (f *FlagSet) Parse(arguments []string) error {
 		return err
 	}
 	// Secret directory processing (after env, before config)
-	var sDir string
-	if sf := f.formal[DefaultSecretDirFlagname]; sf != nil { // default value
-		sDir = sf.Value.String()
-	}
-	if sf := f.actual[DefaultSecretDirFlagname]; sf != nil { // CLI or env override
-		sDir = sf.Value.String()
-	}
-	if sDir != "" {
-		if err := f.ParseSecretDir(sDir); err != nil {
+	if sDir := f.secretDirPath(); sDir != "" {
+		endSecret := f.startPhase(PhaseSecretDir)
+		err := f.ParseSecretDir(sDir)
+		endSecret(err)
+		if err != nil {
 			switch f.errorHandling {
 			case ContinueOnError:
 				return err
@@ -1269,15 +1928,41 @@ func (f *FlagSet) Parse(arguments []string) error {
 			return err
 		}
 	}
-	var cFile string
-	if cf := f.formal[DefaultConfigFlagname]; cf != nil {
-		cFile = cf.Value.String()
+	if files := f.configFilePaths(); len(files) > 0 {
+		endConfig := f.startPhase(PhaseConfig)
+		err := f.parseConfigFiles(files)
+		endConfig(err)
+		if err != nil {
+			switch f.errorHandling {
+			case ContinueOnError:
+				return err
+			case ExitOnError:
+				exitFunc(2)
+			case PanicOnError:
+				panic(err)
+			}
+			return err
+		}
 	}
-	if cf := f.actual[DefaultConfigFlagname]; cf != nil {
-		cFile = cf.Value.String()
+	if len(f.remoteProviders) > 0 {
+		endRemote := f.startPhase(PhaseRemote)
+		err := f.ParseRemote(context.Background())
+		endRemote(err)
+		if err != nil {
+			switch f.errorHandling {
+			case ContinueOnError:
+				return err
+			case ExitOnError:
+				exitFunc(2)
+			case PanicOnError:
+				panic(err)
+			}
+			return err
+		}
 	}
-	if cFile != "" {
-		if err := f.ParseFile(cFile); err != nil {
+	for _, fn := range f.afterParse {
+		if err := fn(); err != nil {
+			err = f.failf("%v", err)
 			switch f.errorHandling {
 			case ContinueOnError:
 				return err
@@ -1292,6 +1977,37 @@ func (f *FlagSet) Parse(arguments []string) error {
 	return nil
 }
 
+// secretDirPath returns the secret directory named by the secret directory
+// flag, if any.
+func (f *FlagSet) secretDirPath() string {
+	var sDir string
+	secretDirFlag := f.secretDirFlagName()
+	if sf := f.formal[secretDirFlag]; sf != nil { // default value
+		sDir = sf.Value.String()
+	}
+	if sf := f.actual[secretDirFlag]; sf != nil { // CLI or env override
+		sDir = sf.Value.String()
+	}
+	return sDir
+}
+
+// configFilePaths returns the config files named by the config flag, every
+// -config given on the command line taking part.
+func (f *FlagSet) configFilePaths() []string {
+	var cFiles []string
+	configFlag := f.configFlagName()
+	if cf := f.formal[configFlag]; cf != nil {
+		cFiles = []string{cf.Value.String()}
+	}
+	if cf := f.actual[configFlag]; cf != nil {
+		cFiles = []string{cf.Value.String()}
+	}
+	if len(f.cliConfigFiles) > 0 {
+		cFiles = f.cliConfigFiles
+	}
+	return splitConfigFiles(cFiles)
+}
+
 // Parsed reports whether f.Parse has been called.
 func (f *FlagSet) Parsed() bool { return f.parsed }
 
@@ -1308,14 +2024,17 @@ func (f *FlagSet) Validate() error {
 		f.validationsDone = true
 		return nil
 	}
+	endValidate := f.startPhase(PhaseValidate)
 	var all MultiError
 	for _, fn := range f.deferredValidations {
 		all.Append(fn())
 	}
 	f.validationsDone = true
 	if all.HasErrors() {
+		endValidate(&all)
 		return &all
 	}
+	endValidate(nil)
 	return nil
 }
 
@@ -1381,6 +2100,7 @@ type FlagSet struct {
 	sensitive           map[string]struct{}
 	deferredValidations []func() error
 	required            map[string]struct{}
+	constraints         map[string]flagConstraints // struct tag constraints, for introspection
 	validationsDone     bool
 	deprecated          map[string]string   // flag -> replacement hint
 	deprecationNoted    map[string]struct{} // printed once per deprecated flag
@@ -1389,50 +2109,147 @@ type FlagSet struct {
 	secretProvider interface{}
 
 	// change watch / hot reload
-	watchMu        sync.RWMutex
-	watcher        *fsnotify.Watcher
-	watchStopCh    chan struct{}
-	changeHandlers map[string][]func(string)
-	lastValues     map[string]string      // for diffing
-	watchPaths     map[string]watchTarget // paths we are watching (secret dir, config file)
+	watchMu    sync.RWMutex
+	watchRun   *watchRun              // running watcher, nil when stopped
+	lastValues map[string]string      // for diffing; guarded by changeMu
+	valuesSeq  uint64                 // numbers value snapshots; see currentValues
+	watchPaths map[string]watchTarget // paths we are watching (secret dir, config file)
+	watchErr   error                  // last error reported by the watcher itself
+	watchErrAt time.Time
+
+	// OnChange delivery, guarded by changeMu; see changes.go
+	changeMu       sync.Mutex
+	deliverMu      sync.Mutex // held while a callback runs, so they run one at a time
+	changeHandlers []*changeHandler
+	changeWindow   time.Duration
+	changePending  map[string]string
+	changeTimer    *time.Timer
+	changeStopped  bool          // set when the watcher stops; drops further deliveries
+	changeBusy     int           // deliveries in progress
+	changeIdle     chan struct{} // closed when changeBusy drops to zero
+	rejectHandlers []func(RejectedUpdate)
+	dispatchedSeq  uint64 // newest value snapshot dispatched
+
+	tracer Tracer // optional phase timing hooks
+
+	valueFilters map[string]ValueFilter // per-flag raw value rewriting
+	auditEnabled bool                   // record value attempts in Flag.history
+	auditSink    func(AuditEvent)       // see SetAuditSink
+	auditSeen    map[string]string      // values last reported to auditSink
+	auditActor   string                 // who supplies the values being applied; see setAuditActor
+	auditBatch   map[string]string      // flag to actor while a reload collects audit events
+
+	strictBooleans  bool                  // reject bare boolean flags on the command line
+	strictEnv       bool                  // reject prefixed environment variables matching no flag
+	envKeyFunc      func(string) []string // custom env key derivation; nil uses envPrefix
+	envKeyOverrides map[string]string     // env keys pinned by envPrefix struct tags
+	envReserved     []string              // prefixes of subcommands' variables, which StrictEnv leaves alone
+
+	helpConfigured bool                // EnableHelpFlag or DisableHelpFlag replaced the implicit -h/-help
+	helpCommand    bool                // a leading "help [topic]" argument requests help
+	flagHelp       map[string]FlagHelp // detailed help, see SetFlagHelp
+
+	version     string             // see SetVersion
+	versionSet  bool               // SetVersion was called
+	versionTmpl *template.Template // see SetVersionTemplate; nil uses DefaultVersionTemplate
+
+	configFlag       string // config file flag name, when configFlagSet
+	configFlagSet    bool
+	secretDirFlag    string // secret directory flag name, when secretDirFlagSet
+	secretDirFlagSet bool
+
+	clock func() time.Time // time source for relative times; nil means time.Now
+
+	secretDirOpts    SecretDirOptions    // guardrails for ParseSecretDir
+	secretNameMapper func(string) string // secret file name to flag name; nil uses defaults
+
+	ignoreTestFlags    bool // skip test.* arguments (see IgnoreTestFlags)
+	ignoreTestFlagsSet bool // ignoreTestFlags chosen explicitly
+
+	report *Report // collects unknown flags and warnings during ParseReport
+
+	remoteProviders []remoteProvider // consulted by Parse after the config file
+	remoteMu        sync.Mutex
+	remotePushed    map[string]map[string]string // latest values of subscribed providers; see SubscribeRemote
+	fallbackCache   *fallbackCache               // last-known-good remote values; nil disables
+	identity        Identity                     // this instance, for rollouts and ${identity.*}
+
+	cliConfigFiles []string          // every -config given on the command line, in order
+	configFiles    []string          // config files applied by the last Parse, in order
+	configKey      ed25519.PublicKey // set by RequireSignedConfig; nil accepts unsigned config
+
+	afterParse []func() error // run once every source has been applied, e.g. by DatabaseFlags
+
+	aliases map[string]string // alias to flag name; see Alias
+
+	interspersed bool     // see SetInterspersed
+	positional   []string // arguments passed over while interspersed parsing
+
+	allowUnknown bool     // see AllowUnknownFlags
+	unknownArgs  []string // unknown flags collected by Parse
+
+	parent     *FlagSet            // set whose persistent flags this one inherits
+	persistent map[string]struct{} // flags inherited by child sets
 }
 
 type watchTarget struct {
 	path string
 	kind string // "secret-dir" or "config-file"
-}
 
-// OnChange registers a callback invoked when the named flag's value changes due to hot reload.
-// The callback receives the new string representation (masked not applied; caller should treat sensitive values carefully).
-func (f *FlagSet) OnChange(name string, fn func(string)) {
-	if fn == nil || name == "" {
-		return
-	}
-	f.watchMu.Lock()
-	defer f.watchMu.Unlock()
-	if f.changeHandlers == nil {
-		f.changeHandlers = make(map[string][]func(string))
-	}
-	f.changeHandlers[name] = append(f.changeHandlers[name], fn)
+	loaded    time.Time         // when flags last matched the source; see WatcherStatus
+	sum       [sha256.Size]byte // digest of the source as loaded
+	attempted time.Time         // last reload attempt
+	err       error             // error of the last reload attempt
 }
 
-// OnChange adds a callback to the default FlagSet.
-func OnChange(name string, fn func(string)) { CommandLine.OnChange(name, fn) }
+// watchRun is one started watcher, from StartWatcher until it stops.
+type watchRun struct {
+	w      *fsnotify.Watcher
+	stopCh chan struct{} // closed to stop watchLoop
+	done   chan struct{} // closed when watchLoop returns
+	once   sync.Once     // guards the shutdown
+	err    error         // from closing w
+}
 
-// StartWatcher enables hot reload for the provided secret directory and/or config file.
-// Pass empty strings to skip either. It is safe to call multiple times; subsequent
-// calls update watched paths.
-func (f *FlagSet) StartWatcher(secretDir, configFile string) error {
+// StartWatcher enables hot reload for the provided secret directory and/or
+// config file. Pass empty strings to skip either. Calling it again while the
+// watcher runs adds the new paths to it and returns a stop function for the
+// same watcher; the ctx of such a call is not used, since the watcher belongs
+// to the call that started it. If a path cannot be watched, StartWatcher
+// returns the error and a watcher it started is shut down again.
+//
+// The watcher stops when the ctx of the call that started it is done or stop
+// is called, whichever is first.
+// stop stops watching, drops changes still held back by SetChangeCoalescing
+// or a rate limit, and then waits until a reload or callback in progress has
+// finished or its own ctx is done, returning ctx.Err() in that case. It may
+// be called any number of times, also after ctx ended the watcher, and must
+// not be called from an OnChange callback, which it would wait for.
+func (f *FlagSet) StartWatcher(ctx context.Context, secretDir, configFile string) (stop func(context.Context) error, err error) {
+	var run *watchRun
+	started := false
+	defer func() {
+		// after watchMu is released, which shutdownWatcher takes
+		if err != nil && started {
+			f.shutdownWatcher(run)
+		}
+	}()
 	f.watchMu.Lock()
 	defer f.watchMu.Unlock()
-	if f.watcher == nil {
+	run = f.watchRun
+	if run == nil {
 		w, err := fsnotify.NewWatcher()
 		if err != nil {
-			return err
+			return nil, err
 		}
-		f.watcher = w
-		f.watchStopCh = make(chan struct{})
-		go f.watchLoop()
+		run = &watchRun{w: w, stopCh: make(chan struct{}), done: make(chan struct{})}
+		started = true
+		f.watchRun = run
+		f.watchErr, f.watchErrAt = nil, time.Time{}
+		f.changeMu.Lock()
+		f.changeStopped = false
+		f.changeMu.Unlock()
+		go f.watchLoop(run.w, run.stopCh, run.done)
 	}
 	if f.watchPaths == nil {
 		f.watchPaths = make(map[string]watchTarget)
@@ -1445,58 +2262,105 @@ func (f *FlagSet) StartWatcher(secretDir, configFile string) error {
 		if _, ok := f.watchPaths[p]; ok {
 			return nil
 		} // already
-		if err := f.watcher.Add(p); err != nil {
+		if err := run.w.Add(p); err != nil {
 			return err
 		}
-		f.watchPaths[p] = watchTarget{path: p, kind: kind}
+		if kind == "config-file" && f.configKey != nil {
+			for _, sig := range signatureFiles(p) {
+				_ = run.w.Add(sig) // only those that exist; a new one is seen with the next config change
+			}
+		}
+		wt := watchTarget{path: p, kind: kind, loaded: f.now()}
+		wt.sum, _ = f.sourceDigest(wt)
+		f.watchPaths[p] = wt
 		return nil
 	}
 	if err := addPath(secretDir, "secret-dir"); err != nil {
-		return err
+		return nil, err
 	}
 	if err := addPath(configFile, "config-file"); err != nil {
-		return err
+		return nil, err
 	}
 	// capture initial values for diffing
+	f.changeMu.Lock()
 	if f.lastValues == nil {
 		f.lastValues = make(map[string]string)
 	}
 	for name, fl := range f.formal {
 		f.lastValues[name] = fl.Value.String()
 	}
-	return nil
+	f.changeMu.Unlock()
+	if started {
+		go func() {
+			select {
+			case <-ctx.Done():
+				f.shutdownWatcher(run)
+			case <-run.done:
+			}
+		}()
+	}
+	return func(ctx context.Context) error {
+		f.shutdownWatcher(run)
+		select {
+		case <-run.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if err := f.drainChanges(ctx); err != nil {
+			return err
+		}
+		return run.err
+	}, nil
 }
 
-// StopWatcher stops hot reload watching.
+// StopWatcher stops hot reload watching without waiting for a callback in
+// progress; use the stop function returned by StartWatcher to wait for them.
 func (f *FlagSet) StopWatcher() error {
-	f.watchMu.Lock()
-	defer f.watchMu.Unlock()
-	if f.watcher == nil {
+	f.watchMu.RLock()
+	run := f.watchRun
+	f.watchMu.RUnlock()
+	if run == nil {
 		return nil
 	}
-	close(f.watchStopCh)
-	err := f.watcher.Close()
-	f.watcher = nil
-	f.watchPaths = nil
-	return err
+	f.shutdownWatcher(run)
+	return run.err
+}
+
+// shutdownWatcher stops run once; later calls do nothing.
+func (f *FlagSet) shutdownWatcher(run *watchRun) {
+	run.once.Do(func() {
+		f.watchMu.Lock()
+		if f.watchRun == run {
+			f.watchRun = nil
+			f.watchPaths = nil
+		}
+		f.watchMu.Unlock()
+		close(run.stopCh)
+		f.stopChangeTimers()
+		run.err = run.w.Close()
+	})
 }
 
 // watchLoop listens for fsnotify events and triggers reload of affected layer(s).
-func (f *FlagSet) watchLoop() {
+// Watcher errors are kept for WatcherStatus.
+func (f *FlagSet) watchLoop(w *fsnotify.Watcher, stop, done chan struct{}) {
+	defer close(done)
 	for {
 		select {
-		case <-f.watchStopCh:
+		case <-stop:
 			return
-		case ev, ok := <-f.watcher.Events:
+		case ev, ok := <-w.Events:
 			if !ok {
 				return
 			}
 			f.handleFsEvent(ev)
-		case err, ok := <-f.watcher.Errors:
+		case err, ok := <-w.Errors:
 			if !ok {
 				return
 			}
-			_ = err // swallow; could log via a debug hook later
+			f.watchMu.Lock()
+			f.watchErr, f.watchErrAt = err, f.now()
+			f.watchMu.Unlock()
 		}
 	}
 }
@@ -1517,7 +2381,7 @@ func (f *FlagSet) handleFsEvent(ev fsnotify.Event) {
 				break
 			}
 		} else if wt.kind == "config-file" {
-			if ev.Name == p {
+			if ev.Name == p || slices.Contains(signatureFiles(p), ev.Name) {
 				f.reloadConfig(p)
 				break
 			}
@@ -1527,16 +2391,27 @@ func (f *FlagSet) handleFsEvent(ev fsnotify.Event) {
 
 func (f *FlagSet) reloadSecrets(dir string) {
 	f.watchMu.Lock()
-	defer f.watchMu.Unlock()
-	if err := f.ParseSecretDir(dir); err != nil {
+	sum, same := f.sourceUnchanged(dir)
+	if same {
+		f.watchMu.Unlock()
 		return
 	}
-	f.diffAndDispatch()
+	snap := f.snapshot()
+	f.beginAuditBatch()
+	start := f.now()
+	err := f.ParseSecretDir(dir)
+	f.finishWatchReload(dir, start, sum, snap, err)
 }
 
 func (f *FlagSet) reloadConfig(path string) {
 	f.watchMu.Lock()
-	defer f.watchMu.Unlock()
+	sum, same := f.sourceUnchanged(path)
+	if same {
+		f.watchMu.Unlock()
+		return
+	}
+	snap := f.snapshot()
+	f.beginAuditBatch()
 	// re-parse file but only for flags not set by CLI/env; we simulate by clearing prior config sourced flags
 	for name, src := range f.sources {
 		if src == "config" {
@@ -1544,34 +2419,48 @@ func (f *FlagSet) reloadConfig(path string) {
 			delete(f.sources, name)
 		}
 	}
-	if err := f.ParseFile(path); err != nil {
-		return
+	start := f.now()
+	var err error
+	if slices.Contains(f.configFiles, path) {
+		err = f.parseConfigFiles(f.configFiles)
+	} else {
+		err = f.ParseFile(path)
 	}
-	f.diffAndDispatch()
+	f.finishWatchReload(path, start, sum, snap, err)
 }
 
-// diffAndDispatch compares current values to lastValues, updates lastValues, and invokes handlers.
-func (f *FlagSet) diffAndDispatch() {
-	if f.changeHandlers == nil {
-		return
+// finishWatchReload completes a watcher reload of path begun at start. The
+// new values are checked against the flags' constraints; if that or the
+// reload failed, every flag is restored from snap and the update rejected.
+// The caller holds watchMu, which finishWatchReload releases.
+func (f *FlagSet) finishWatchReload(path string, start time.Time, sum [sha256.Size]byte, snap flagSnapshot, err error) {
+	if err == nil {
+		err = f.checkValues()
 	}
-	for name, fl := range f.formal {
-		cur := fl.Value.String()
-		prev := f.lastValues[name]
-		if cur != prev {
-			f.lastValues[name] = cur
-			if hs := f.changeHandlers[name]; len(hs) > 0 {
-				for _, h := range hs {
-					func(cb func(string), v string) { defer func() { recover() }(); cb(v) }(h, cur)
-				}
-			}
-		}
+	var attempted []Change
+	if err != nil {
+		attempted = f.changesSince(snap)
+		f.restore(snap)
+	}
+	var events []AuditEvent
+	if f.auditBatch != nil {
+		events = f.endAuditBatch(f.changesSince(snap), err == nil)
+	}
+	f.recordReload(path, start, sum, err)
+	values := f.currentValues()
+	f.watchMu.Unlock()
+	if err != nil {
+		f.rejectUpdate(path, attempted, err)
+		return
 	}
+	f.sendAudit(events)
+	// outside watchMu, so a slow callback does not hold up stopping
+	f.diffAndDispatch(values)
 }
 
 // StartWatcher enables watching on default CommandLine FlagSet.
-func StartWatcher(secretDir, configFile string) error {
-	return CommandLine.StartWatcher(secretDir, configFile)
+func StartWatcher(ctx context.Context, secretDir, configFile string) (stop func(context.Context) error, err error) {
+	return CommandLine.StartWatcher(ctx, secretDir, configFile)
 }
 
 // StopWatcher stops watching on default CommandLine FlagSet.
@@ -1615,11 +2504,11 @@ func (f *FlagSet) noteDeprecationIfNeeded(name string) {
 		return
 	}
 	f.deprecationNoted[name] = struct{}{}
-	msg := fmt.Sprintf("warning: flag -%s is deprecated", name)
+	msg := fmt.Sprintf("flag -%s is deprecated", name)
 	if repl != "" {
 		msg += fmt.Sprintf(", use -%s instead", repl)
 	}
-	f.out().Write([]byte(msg + "\n"))
+	f.warnf("%s", msg)
 }
 
 // MarkSensitive marks one or more flag names as sensitive causing their values
@@ -1643,48 +2532,113 @@ func (f *FlagSet) isSensitive(name string) bool {
 
 // FlagMeta represents introspection metadata for a single flag.
 type FlagMeta struct {
-	Name      string `json:"name"`
-	Usage     string `json:"usage"`
-	Default   string `json:"default"`
-	Value     string `json:"value"`
-	Set       bool   `json:"set"`
-	Source    string `json:"source"`
-	Sensitive bool   `json:"sensitive"`
+	Name       string `json:"name"`
+	Usage      string `json:"usage"`
+	Default    string `json:"default"`
+	Value      string `json:"value"`
+	Set        bool   `json:"set"`
+	Source     string `json:"source"`
+	Sensitive  bool   `json:"sensitive"`
+	EnvKey     string `json:"envKey,omitempty"`
+	Required   bool   `json:"required,omitempty"`
+	Deprecated bool   `json:"deprecated,omitempty"`
+	Min        string `json:"min,omitempty"`
+	Max        string `json:"max,omitempty"`
+	Pattern    string `json:"pattern,omitempty"`
+	Enum       string `json:"enum,omitempty"`
+	// Aliases lists the flag's other names; see FlagSet.Alias.
+	Aliases []string `json:"aliases,omitempty"`
+	// NoOptDefVal is the value the flag takes when given without an
+	// argument; see FlagSet.SetNoOptDefVal.
+	NoOptDefVal string `json:"noOptDefVal,omitempty"`
+	// InheritedFrom names the parent FlagSet a persistent flag was defined
+	// on; it is empty for the set's own flags.
+	InheritedFrom string `json:"inheritedFrom,omitempty"`
+}
+
+// flagConstraints holds the raw validation tags a struct field declared.
+type flagConstraints struct {
+	min, max, pattern, enum string
+}
+
+// recordConstraints remembers required/validation metadata for introspection.
+func (f *FlagSet) recordConstraints(name string, required bool, c flagConstraints) {
+	if required {
+		if f.required == nil {
+			f.required = make(map[string]struct{})
+		}
+		f.required[name] = struct{}{}
+	}
+	if c == (flagConstraints{}) {
+		return
+	}
+	if f.constraints == nil {
+		f.constraints = make(map[string]flagConstraints)
+	}
+	f.constraints[name] = c
 }
 
-// Introspect returns metadata for all registered flags (sorted by name).
+// Introspect returns metadata for all registered flags (sorted by name),
+// including the persistent flags inherited from parent sets (see SetParent).
 func (f *FlagSet) Introspect() []FlagMeta {
 	out := make([]FlagMeta, 0, len(f.formal))
 	for _, fl := range sortFlags(f.formal) {
-		src := "default"
-		if f.sources != nil {
-			if s, ok := f.sources[fl.Name]; ok {
-				src = s
-			}
+		out = append(out, f.flagMeta(fl))
+	}
+	for _, in := range f.inheritedFlags() {
+		m := in.owner.flagMeta(in.flag)
+		m.InheritedFrom = in.owner.name
+		out = append(out, m)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// flagMeta describes one of f's own flags.
+func (f *FlagSet) flagMeta(fl *Flag) FlagMeta {
+	src := "default"
+	if f.sources != nil {
+		if s, ok := f.sources[fl.Name]; ok {
+			src = s
 		}
-		set := f.actual != nil && f.actual[fl.Name] != nil
-		valStr := fl.Value.String()
-		defStr := fl.DefValue
-		if fl.Sensitive || f.isSensitive(fl.Name) {
-			// Mask value but still indicate if set
-			if set {
-				valStr = "******"
-			} else {
-				valStr = ""
-			}
-			defStr = "******"
+	}
+	set := f.actual != nil && f.actual[fl.Name] != nil
+	valStr := fl.Value.String()
+	defStr := fl.DefValue
+	if fl.Sensitive || f.isSensitive(fl.Name) {
+		// Mask value but still indicate if set
+		if set {
+			valStr = "******"
+		} else {
+			valStr = ""
 		}
-		out = append(out, FlagMeta{
-			Name:      fl.Name,
-			Usage:     fl.Usage,
-			Default:   defStr,
-			Value:     valStr,
-			Set:       set,
-			Source:    src,
-			Sensitive: fl.Sensitive || f.isSensitive(fl.Name),
-		})
+		defStr = "******"
+	}
+	_, required := f.required[fl.Name]
+	_, deprecated := f.deprecated[fl.Name]
+	c := f.constraints[fl.Name]
+	if av, ok := fl.Value.(interface{ allowedValues() []string }); ok && c.enum == "" {
+		// Values with a fixed set of choices report them as the enum.
+		c.enum = strings.Join(av.allowedValues(), ",")
+	}
+	return FlagMeta{
+		Name:        fl.Name,
+		Usage:       fl.Usage,
+		Default:     defStr,
+		Value:       valStr,
+		Set:         set,
+		Source:      src,
+		Sensitive:   fl.Sensitive || f.isSensitive(fl.Name),
+		EnvKey:      f.envKey(fl.Name),
+		Required:    required,
+		Deprecated:  deprecated,
+		Min:         c.min,
+		Max:         c.max,
+		Pattern:     c.pattern,
+		Enum:        c.enum,
+		Aliases:     fl.Aliases,
+		NoOptDefVal: fl.NoOptDefVal,
 	}
-	return out
 }
 
 // Introspect returns metadata for the default CommandLine FlagSet.
@@ -1692,11 +2646,18 @@ func Introspect() []FlagMeta { return CommandLine.Introspect() }
 
 // A Flag represents the state of a flag.
 type Flag struct {
-	Name      string // name as it appears on command line
-	Usage     string // help message
-	Value     Value  // value as set
-	DefValue  string // default value (as text); for usage message
-	Sensitive bool   // mask in usage / error output
+	Name      string   // name as it appears on command line
+	Usage     string   // help message
+	Value     Value    // value as set
+	DefValue  string   // default value (as text); for usage message
+	Sensitive bool     // mask in usage / error output
+	Aliases   []string // other names of the flag, shortest first; see FlagSet.Alias
+
+	// NoOptDefVal, when not empty, is the value a non-boolean flag takes
+	// when given without an argument; see FlagSet.SetNoOptDefVal.
+	NoOptDefVal string
+
+	history []Attempt // see FlagSet.EnableAudit
 }
 
 // sortFlags returns the flags as a slice in lexicographical sorted order.
@@ -1757,10 +2718,12 @@ func Visit(fn func(*Flag)) {
 }
 
 // Lookup returns the Flag structure of the named flag, returning nil if none exists.
+// Persistent flags of parent sets are found too; LookupOrigin reports where a
+// flag was defined.
 func (f *FlagSet) Lookup(name string) *Flag {
-	fl := f.formal[name]
+	fl, owner := f.LookupOrigin(name)
 	if fl != nil {
-		f.noteDeprecationIfNeeded(name)
+		owner.noteDeprecationIfNeeded(name)
 	}
 	return fl
 }
@@ -1768,24 +2731,27 @@ func (f *FlagSet) Lookup(name string) *Flag {
 // Lookup returns the Flag structure of the named command-line flag,
 // returning nil if none exists.
 func Lookup(name string) *Flag {
-	return CommandLine.formal[name]
+	return CommandLine.formal[CommandLine.resolveAlias(name)]
 }
 
 // Set sets the value of the named flag.
 func (f *FlagSet) Set(name, value string) error {
-	flag, ok := f.formal[name]
-	if !ok {
+	flag, owner := f.LookupOrigin(name)
+	if flag == nil {
 		return fmt.Errorf("no such flag -%v", name)
 	}
 	err := flag.Value.Set(value)
 	if err != nil {
 		return err
 	}
-	if f.actual == nil {
-		f.actual = make(map[string]*Flag)
+	if owner.actual == nil {
+		owner.actual = make(map[string]*Flag)
+	}
+	owner.actual[flag.Name] = flag
+	if owner.auditSink != nil {
+		owner.noteAudited(flag.Name, flag.Value.String())
 	}
-	f.actual[name] = flag
-	f.noteDeprecationIfNeeded(name)
+	owner.noteDeprecationIfNeeded(flag.Name)
 	return nil
 }
 
@@ -1822,11 +2788,18 @@ func isZeroValue(flag *Flag, value string) bool {
 	return false
 }
 
+// typeNamer may be implemented by a custom Value to name its argument in
+// usage output (e.g. "port" or "host:port") instead of the generic "value".
+type typeNamer interface {
+	TypeName() string
+}
+
 // UnquoteUsage extracts a back-quoted name from the usage
 // string for a flag and returns it and the un-quoted usage.
 // Given "a `name` to show" it returns ("name", "a name to show").
-// If there are no back quotes, the name is an educated guess of the
-// type of the flag's value, or the empty string if the flag is boolean.
+// If there are no back quotes, the name is the Value's TypeName() if it
+// has one, otherwise an educated guess of the type of the flag's value,
+// or the empty string if the flag is boolean.
 func UnquoteUsage(flag *Flag) (name string, usage string) {
 	// Look for a back-quoted name, but avoid the strings package.
 	usage = flag.Usage
@@ -1844,11 +2817,90 @@ func UnquoteUsage(flag *Flag) (name string, usage string) {
 	}
 	// No explicit name, so use type if we can find one.
 	name = "value"
-	switch flag.Value.(type) {
+	if tn, ok := flag.Value.(typeNamer); ok {
+		return tn.TypeName(), usage
+	}
+	switch v := flag.Value.(type) {
 	case boolFlag:
 		name = ""
 	case *durationValue:
 		name = "duration"
+	case *byteSizeValue:
+		name = "bytes"
+	case *timeValue:
+		name = "time"
+	case *timeSliceValue:
+		name = "times"
+	case *durationSliceValue:
+		name = "durations"
+	case *decimalValue:
+		name = "decimal"
+	case *ipValue:
+		name = "ip"
+	case *ipNetValue:
+		name = "cidr"
+	case *urlValue:
+		name = "url"
+	case *urlValuesValue:
+		name = "query"
+	case *uuidValue:
+		name = "uuid"
+	case *bigIntValue:
+		name = "int"
+	case *bigRatValue:
+		name = "rat"
+	case *bigFloatValue:
+		name = "float"
+	case *regexpValue:
+		name = "regexp"
+	case *regexpSliceValue, *matcherValue:
+		name = "regexps"
+	case *stringSliceValue:
+		name = "strings"
+	case *stringMapValue:
+		name = "key=value,..."
+	case *jsonValue:
+		name = "json"
+	case *jsonLinesValue:
+		name = "jsonl"
+	case *enumStringValue:
+		name = strings.ReplaceAll(keys(v.allowed), ",", "|")
+	case *boundedStringValue:
+		name = "string"
+	case *credentialsValue:
+		name = "user:pass"
+	case *digestValue:
+		name = "algo:hex"
+	case *isoCodeValue:
+		name = v.kind
+	case *phoneValue:
+		name = "phone"
+	case *businessNumberValue:
+		name = v.kind
+	case *postcodeValue:
+		name = "postcode"
+	case *grpcTargetValue:
+		name = "target"
+	case *brokerListValue:
+		name = "host:port,..."
+	case *mimeTypeValue:
+		name = "type/subtype"
+	case *extListValue:
+		name = ".ext,..."
+	case *colorValue:
+		name = "color"
+	case *timeWindowsValue:
+		name = "windows"
+	case *labelFormatValue:
+		name = strings.Join(LabelFormats(), "|")
+	case *latLonValue:
+		name = "lat,lon"
+	case *boundingBoxValue:
+		name = "s,w,n,e"
+	case *measureValue:
+		name = v.kind
+	case *dimensionsValue:
+		name = "LxWxH"
 	case *numberValue[int], *numberValue[int64]:
 		name = "int"
 	case *numberValue[uint], *numberValue[uint64]:
@@ -1872,34 +2924,46 @@ func UnquoteUsage(flag *Flag) (name string, usage string) {
 // the global function PrintDefaults for more information.
 func (f *FlagSet) PrintDefaults() {
 	f.VisitAll(func(flag *Flag) {
-		s := fmt.Sprintf("  -%s", flag.Name) // Two spaces before -; see next two comments.
-		name, usage := UnquoteUsage(flag)
-		if len(name) > 0 {
-			s += " " + name
+		fmt.Fprint(f.out(), f.defaultsEntry(flag), "\n")
+	})
+}
+
+// defaultsEntry formats flag's entry in PrintDefaults, without the final
+// newline.
+func (f *FlagSet) defaultsEntry(flag *Flag) string {
+	s := fmt.Sprintf("  -%s", flag.Name) // Two spaces before -; see next two comments.
+	if len(flag.Aliases) > 0 {
+		s = "  -" + strings.Join(flag.Aliases, ", -") + ", --" + flag.Name
+	}
+	name, usage := UnquoteUsage(flag)
+	if len(name) > 0 {
+		s += " " + name
+	}
+	if flag.NoOptDefVal != "" {
+		s += "[=" + flag.NoOptDefVal + "]"
+	}
+	// Boolean flags of one ASCII letter are so common we
+	// treat them specially, putting their usage on the same line.
+	if len(s) <= 4 { // space, space, '-', 'x'.
+		s += "\t"
+	} else {
+		// Four spaces before the tab triggers good alignment
+		// for both 4- and 8-space tab stops.
+		s += "\n    \t"
+	}
+	s += usage
+	if !isZeroValue(flag, flag.DefValue) {
+		defOut := flag.DefValue
+		if flag.Sensitive || f.isSensitive(flag.Name) {
+			defOut = "******"
 		}
-		// Boolean flags of one ASCII letter are so common we
-		// treat them specially, putting their usage on the same line.
-		if len(s) <= 4 { // space, space, '-', 'x'.
-			s += "\t"
+		if _, ok := flag.Value.(*stringValue); ok {
+			s += fmt.Sprintf(" (default %q)", defOut)
 		} else {
-			// Four spaces before the tab triggers good alignment
-			// for both 4- and 8-space tab stops.
-			s += "\n    \t"
+			s += fmt.Sprintf(" (default %v)", defOut)
 		}
-		s += usage
-		if !isZeroValue(flag, flag.DefValue) {
-			defOut := flag.DefValue
-			if flag.Sensitive || f.isSensitive(flag.Name) {
-				defOut = "******"
-			}
-			if _, ok := flag.Value.(*stringValue); ok {
-				s += fmt.Sprintf(" (default %q)", defOut)
-			} else {
-				s += fmt.Sprintf(" (default %v)", defOut)
-			}
-		}
-		fmt.Fprint(f.out(), s, "\n")
-	})
+	}
+	return s
 }
 
 // PrintDefaults prints, to standard error unless configured otherwise,
@@ -0,0 +1,183 @@
+package flag
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// LatLon is a WGS84 coordinate in decimal degrees.
+type LatLon struct {
+	Lat, Lon float64
+}
+
+// ParseLatLon parses "lat,lon" (e.g. "-37.8136,144.9631"), checking that the
+// latitude is within ±90 and the longitude within ±180.
+func ParseLatLon(s string) (LatLon, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 2 {
+		return LatLon{}, fmt.Errorf("invalid coordinate %q: want lat,lon", s)
+	}
+	vals, err := parseCoordinates(s, parts)
+	if err != nil {
+		return LatLon{}, err
+	}
+	ll := LatLon{Lat: vals[0], Lon: vals[1]}
+	if err := ll.validate(); err != nil {
+		return LatLon{}, fmt.Errorf("invalid coordinate %q: %v", s, err)
+	}
+	return ll, nil
+}
+
+func (ll LatLon) validate() error {
+	if ll.Lat < -90 || ll.Lat > 90 {
+		return fmt.Errorf("latitude %v out of range [-90, 90]", ll.Lat)
+	}
+	if ll.Lon < -180 || ll.Lon > 180 {
+		return fmt.Errorf("longitude %v out of range [-180, 180]", ll.Lon)
+	}
+	return nil
+}
+
+// String returns the coordinate in lat,lon form.
+func (ll LatLon) String() string {
+	return strconv.FormatFloat(ll.Lat, 'f', -1, 64) + "," + strconv.FormatFloat(ll.Lon, 'f', -1, 64)
+}
+
+// BoundingBox is a latitude/longitude rectangle given by its south-west and
+// north-east corners. A box whose west edge is east of its east edge crosses
+// the antimeridian.
+type BoundingBox struct {
+	SW, NE LatLon
+}
+
+// ParseBoundingBox parses "south,west,north,east" in decimal degrees, e.g.
+// "-38.5,144.5,-37.5,145.5". South must not be north of north.
+func ParseBoundingBox(s string) (BoundingBox, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 4 {
+		return BoundingBox{}, fmt.Errorf("invalid bounding box %q: want south,west,north,east", s)
+	}
+	vals, err := parseCoordinates(s, parts)
+	if err != nil {
+		return BoundingBox{}, err
+	}
+	bb := BoundingBox{SW: LatLon{vals[0], vals[1]}, NE: LatLon{vals[2], vals[3]}}
+	for _, c := range []LatLon{bb.SW, bb.NE} {
+		if err := c.validate(); err != nil {
+			return BoundingBox{}, fmt.Errorf("invalid bounding box %q: %v", s, err)
+		}
+	}
+	if bb.SW.Lat > bb.NE.Lat {
+		return BoundingBox{}, fmt.Errorf("invalid bounding box %q: south %v is north of north %v", s, bb.SW.Lat, bb.NE.Lat)
+	}
+	return bb, nil
+}
+
+// Contains reports whether p lies inside the box, edges included.
+func (bb BoundingBox) Contains(p LatLon) bool {
+	if p.Lat < bb.SW.Lat || p.Lat > bb.NE.Lat {
+		return false
+	}
+	if bb.SW.Lon <= bb.NE.Lon {
+		return p.Lon >= bb.SW.Lon && p.Lon <= bb.NE.Lon
+	}
+	return p.Lon >= bb.SW.Lon || p.Lon <= bb.NE.Lon
+}
+
+// String returns the box in south,west,north,east form.
+func (bb BoundingBox) String() string { return bb.SW.String() + "," + bb.NE.String() }
+
+func parseCoordinates(s string, parts []string) ([]float64, error) {
+	vals := make([]float64, len(parts))
+	for i, p := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+		if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil, fmt.Errorf("invalid coordinate value %q in %q", strings.TrimSpace(p), s)
+		}
+		vals[i] = v
+	}
+	return vals, nil
+}
+
+type latLonValue struct{ p *LatLon }
+
+func newLatLonValue(val LatLon, p *LatLon) *latLonValue {
+	*p = val
+	return &latLonValue{p: p}
+}
+func (lv *latLonValue) Set(s string) error {
+	ll, err := ParseLatLon(s)
+	if err != nil {
+		return err
+	}
+	*lv.p = ll
+	return nil
+}
+func (lv *latLonValue) String() string {
+	if lv.p == nil {
+		return ""
+	}
+	return lv.p.String()
+}
+func (lv *latLonValue) Get() interface{} { return *lv.p }
+
+type boundingBoxValue struct{ p *BoundingBox }
+
+func newBoundingBoxValue(val BoundingBox, p *BoundingBox) *boundingBoxValue {
+	*p = val
+	return &boundingBoxValue{p: p}
+}
+func (bv *boundingBoxValue) Set(s string) error {
+	bb, err := ParseBoundingBox(s)
+	if err != nil {
+		return err
+	}
+	*bv.p = bb
+	return nil
+}
+func (bv *boundingBoxValue) String() string {
+	if bv.p == nil {
+		return ""
+	}
+	return bv.p.String()
+}
+func (bv *boundingBoxValue) Get() interface{} { return *bv.p }
+
+// LatLonVar registers a coordinate flag parsed from "lat,lon".
+func (f *FlagSet) LatLonVar(p *LatLon, name string, value LatLon, usage string) {
+	f.Var(newLatLonValue(value, p), name, usage)
+}
+func LatLonVar(p *LatLon, name string, value LatLon, usage string) {
+	CommandLine.LatLonVar(p, name, value, usage)
+}
+
+// LatLonFlag defines a coordinate flag and returns a pointer to it.
+func (f *FlagSet) LatLonFlag(name string, value LatLon, usage string) *LatLon {
+	p := new(LatLon)
+	f.LatLonVar(p, name, value, usage)
+	return p
+}
+func LatLonFlag(name string, value LatLon, usage string) *LatLon {
+	return CommandLine.LatLonFlag(name, value, usage)
+}
+
+// BoundingBoxVar registers a bounding box flag parsed from
+// "south,west,north,east".
+func (f *FlagSet) BoundingBoxVar(p *BoundingBox, name string, value BoundingBox, usage string) {
+	f.Var(newBoundingBoxValue(value, p), name, usage)
+}
+func BoundingBoxVar(p *BoundingBox, name string, value BoundingBox, usage string) {
+	CommandLine.BoundingBoxVar(p, name, value, usage)
+}
+
+// BoundingBoxFlag defines a bounding box flag and returns a pointer to it.
+func (f *FlagSet) BoundingBoxFlag(name string, value BoundingBox, usage string) *BoundingBox {
+	p := new(BoundingBox)
+	f.BoundingBoxVar(p, name, value, usage)
+	return p
+}
+func BoundingBoxFlag(name string, value BoundingBox, usage string) *BoundingBox {
+	return CommandLine.BoundingBoxFlag(name, value, usage)
+}
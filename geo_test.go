@@ -0,0 +1,76 @@
+package flag_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	. "github.com/machship/flag"
+)
+
+func TestLatLonFlag(t *testing.T) {
+	f := NewFlagSet("test", ContinueOnError)
+	depot := f.LatLonFlag("depot", LatLon{}, "")
+	if err := f.Parse([]string{"-depot", "-37.8136, 144.9631"}); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if *depot != (LatLon{Lat: -37.8136, Lon: 144.9631}) {
+		t.Fatalf("depot = %+v", *depot)
+	}
+	if got := f.Lookup("depot").Value.(Getter).Get(); got != *depot {
+		t.Fatalf("Get() = %#v", got)
+	}
+	if got := f.Lookup("depot").Value.String(); got != "-37.8136,144.9631" {
+		t.Fatalf("String() = %q", got)
+	}
+	for in, want := range map[string]string{
+		"91,0":     "latitude 91 out of range",
+		"0,-180.5": "longitude -180.5 out of range",
+		"1,2,3":    "want lat,lon",
+		"x,2":      `invalid coordinate value "x"`,
+		"NaN,2":    `invalid coordinate value "NaN"`,
+	} {
+		if err := f.Set("depot", in); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%q: expected %q, got %v", in, want, err)
+		}
+	}
+}
+
+func TestBoundingBoxFlag(t *testing.T) {
+	f := NewFlagSet("test", ContinueOnError)
+	area := f.BoundingBoxFlag("area", BoundingBox{}, "")
+	if err := f.Parse([]string{"-area", "-38.5,144.5,-37.5,145.5"}); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if !area.Contains(LatLon{-37.81, 144.96}) || area.Contains(LatLon{-33.87, 151.21}) {
+		t.Fatalf("Contains wrong for %v", *area)
+	}
+	if err := f.Set("area", "-37.5,144.5,-38.5,145.5"); err == nil || !strings.Contains(err.Error(), "is north of north") {
+		t.Fatalf("expected inverted latitude error, got %v", err)
+	}
+
+	// west edge east of the east edge: box spans the antimeridian
+	if err := f.Set("area", "-20,170,-10,-170"); err != nil {
+		t.Fatal(err)
+	}
+	if !area.Contains(LatLon{-15, 179}) || !area.Contains(LatLon{-15, -175}) || area.Contains(LatLon{-15, 0}) {
+		t.Fatalf("antimeridian box %v", *area)
+	}
+}
+
+func TestGeoStructFields(t *testing.T) {
+	ResetForTesting(nil)
+	saved := os.Args
+	os.Args = []string{"cmd", "-origin", "-33.8688,151.2093"}
+	defer func() { os.Args = saved }()
+	var cfg struct {
+		Origin LatLon      `flag:"origin" required:"true"`
+		Region BoundingBox `flag:"region" default:"-44,112,-10,154"`
+	}
+	if err := ParseStruct(&cfg); err != nil {
+		t.Fatalf("ParseStruct: %v", err)
+	}
+	if !cfg.Region.Contains(cfg.Origin) {
+		t.Fatalf("origin %v outside region %v", cfg.Origin, cfg.Region)
+	}
+}
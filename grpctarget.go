@@ -0,0 +1,181 @@
+package flag
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// normalizeGRPCTarget checks s against the gRPC name resolution syntax and
+// adds defaultPort (when non-zero) to host addresses that have none:
+//
+//	host[:port]                   dns:///host:port  (bare addresses use the dns resolver)
+//	dns:[//authority/]host[:port]
+//	passthrough:///host[:port]
+//	ipv4:addr[:port][,addr[:port],...]
+//	ipv6:[addr][:port][,...]
+//	unix:path  unix:///absolute/path  unix-abstract:name
+//
+// Bare addresses are returned without a scheme, as given.
+func normalizeGRPCTarget(s string, defaultPort int) (string, error) {
+	if s == "" {
+		return "", fmt.Errorf("empty gRPC target")
+	}
+	scheme, rest, ok := strings.Cut(s, ":")
+	switch {
+	case !ok:
+		return grpcHostPort(s, defaultPort)
+	case strings.HasPrefix(rest, "//"):
+	case scheme == "dns" || scheme == "passthrough" || scheme == "unix" || scheme == "unix-abstract" || scheme == "ipv4" || scheme == "ipv6":
+	default:
+		// host:port or [v6]:port, not a scheme
+		if strings.Contains(scheme, "/") || strings.HasPrefix(s, "[") || isDigits(rest) {
+			return grpcHostPort(s, defaultPort)
+		}
+		return "", fmt.Errorf("invalid gRPC target %q: expected host:port or scheme:///address", s)
+	}
+
+	switch scheme {
+	case "dns", "passthrough":
+		endpoint := rest
+		if after, ok := strings.CutPrefix(rest, "//"); ok {
+			authority, path, found := strings.Cut(after, "/")
+			if !found {
+				return "", fmt.Errorf("invalid gRPC target %q: expected %s:///host:port", s, scheme)
+			}
+			if authority != "" && scheme == "passthrough" {
+				return "", fmt.Errorf("invalid gRPC target %q: passthrough takes no authority", s)
+			}
+			endpoint = path
+			rest = "//" + authority + "/"
+		} else {
+			rest = ""
+		}
+		addr, err := grpcHostPort(endpoint, defaultPort)
+		if err != nil {
+			return "", fmt.Errorf("invalid gRPC target %q: %v", s, err)
+		}
+		return scheme + ":" + rest + addr, nil
+	case "ipv4", "ipv6":
+		addrs := strings.Split(rest, ",")
+		for i, a := range addrs {
+			addr, err := grpcHostPort(a, defaultPort)
+			if err == nil {
+				host, _, _ := net.SplitHostPort(addr)
+				if host == "" {
+					host = strings.Trim(addr, "[]")
+				}
+				if ip := net.ParseIP(host); ip == nil || (ip.To4() != nil) != (scheme == "ipv4") {
+					err = fmt.Errorf("%q is not an %s address", host, scheme)
+				}
+			}
+			if err != nil {
+				return "", fmt.Errorf("invalid gRPC target %q: %v", s, err)
+			}
+			addrs[i] = addr
+		}
+		return scheme + ":" + strings.Join(addrs, ","), nil
+	case "unix":
+		path := rest
+		if after, ok := strings.CutPrefix(rest, "//"); ok {
+			if !strings.HasPrefix(after, "/") {
+				return "", fmt.Errorf("invalid gRPC target %q: unix:// needs an absolute path (unix:///path)", s)
+			}
+			path = after
+		}
+		if path == "" {
+			return "", fmt.Errorf("invalid gRPC target %q: missing socket path", s)
+		}
+		return s, nil
+	case "unix-abstract":
+		if rest == "" || rest == "//" {
+			return "", fmt.Errorf("invalid gRPC target %q: missing socket name", s)
+		}
+		return s, nil
+	}
+	return "", fmt.Errorf("invalid gRPC target %q: unknown scheme %q (want dns, passthrough, ipv4, ipv6, unix or unix-abstract)", s, scheme)
+}
+
+// grpcHostPort validates host[:port], appending defaultPort when the port is
+// missing and defaultPort is non-zero.
+func grpcHostPort(s string, defaultPort int) (string, error) {
+	host, port, err := net.SplitHostPort(s)
+	if err != nil {
+		// no port; an unbracketed IPv6 literal is a host on its own
+		host = strings.TrimSuffix(strings.TrimPrefix(s, "["), "]")
+		if host == "" || strings.ContainsAny(host, "[]/") {
+			return "", fmt.Errorf("invalid address %q", s)
+		}
+		if defaultPort == 0 {
+			return s, nil
+		}
+		return net.JoinHostPort(host, strconv.Itoa(defaultPort)), nil
+	}
+	if host == "" {
+		return "", fmt.Errorf("missing host in %q", s)
+	}
+	if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+		return "", fmt.Errorf("invalid port %q", port)
+	}
+	return s, nil
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+type grpcTargetValue struct {
+	p           *string
+	defaultPort int
+}
+
+func newGRPCTargetValue(val string, defaultPort int, p *string) *grpcTargetValue {
+	*p = val
+	return &grpcTargetValue{p: p, defaultPort: defaultPort}
+}
+func (gv *grpcTargetValue) Set(s string) error {
+	t, err := normalizeGRPCTarget(s, gv.defaultPort)
+	if err != nil {
+		return err
+	}
+	*gv.p = t
+	return nil
+}
+func (gv *grpcTargetValue) String() string {
+	if gv.p == nil {
+		return ""
+	}
+	return *gv.p
+}
+func (gv *grpcTargetValue) Get() interface{} { return *gv.p }
+
+// GRPCTargetVar registers a gRPC dial target flag such as "dns:///orders:443",
+// "unix:///run/app.sock" or a bare "orders:443", rejecting targets the
+// standard resolvers cannot handle. With a non-zero defaultPort, host
+// addresses without a port gain it, so "orders" becomes "orders:443". The
+// default value is stored as given.
+func (f *FlagSet) GRPCTargetVar(p *string, name string, value string, defaultPort int, usage string) {
+	f.Var(newGRPCTargetValue(value, defaultPort, p), name, usage)
+}
+func GRPCTargetVar(p *string, name string, value string, defaultPort int, usage string) {
+	CommandLine.GRPCTargetVar(p, name, value, defaultPort, usage)
+}
+
+// GRPCTarget defines a gRPC target flag and returns a pointer to it.
+func (f *FlagSet) GRPCTarget(name string, value string, defaultPort int, usage string) *string {
+	p := new(string)
+	f.GRPCTargetVar(p, name, value, defaultPort, usage)
+	return p
+}
+func GRPCTarget(name string, value string, defaultPort int, usage string) *string {
+	return CommandLine.GRPCTarget(name, value, defaultPort, usage)
+}
@@ -0,0 +1,83 @@
+package flag_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	. "github.com/machship/flag"
+)
+
+func TestGRPCTargetFlag(t *testing.T) {
+	f := NewFlagSet("test", ContinueOnError)
+	target := f.GRPCTarget("orders", "", 443, "")
+	cases := map[string]string{
+		"orders":                         "orders:443",
+		"orders:50051":                   "orders:50051",
+		"[::1]":                          "[::1]:443",
+		"[::1]:50051":                    "[::1]:50051",
+		"dns:///orders.svc":              "dns:///orders.svc:443",
+		"dns://8.8.8.8/orders.svc:50051": "dns://8.8.8.8/orders.svc:50051",
+		"dns:orders":                     "dns:orders:443",
+		"passthrough:///10.0.0.5":        "passthrough:///10.0.0.5:443",
+		"ipv4:10.0.0.1,10.0.0.2:8443":    "ipv4:10.0.0.1:443,10.0.0.2:8443",
+		"ipv6:[2001:db8::1]:50051,::2":   "ipv6:[2001:db8::1]:50051,[::2]:443",
+		"unix:///run/orders.sock":        "unix:///run/orders.sock",
+		"unix:run/orders.sock":           "unix:run/orders.sock",
+		"unix-abstract:orders":           "unix-abstract:orders",
+	}
+	for in, want := range cases {
+		if err := f.Set("orders", in); err != nil {
+			t.Errorf("Set(%q): %v", in, err)
+			continue
+		}
+		if *target != want {
+			t.Errorf("Set(%q) = %q, want %q", in, *target, want)
+		}
+	}
+
+	errs := map[string]string{
+		"":                         "empty gRPC target",
+		"orders:http":              "expected host:port or scheme:///address",
+		"orders:0":                 "invalid port",
+		"xds:///orders":            "unknown scheme \"xds\"",
+		"dns://orders":             "expected dns:///host:port",
+		"dns:///":                  "invalid address",
+		"dns:///:443":              "missing host",
+		"passthrough://auth/x":     "passthrough takes no authority",
+		"ipv4:10.0.0.1,[::1]":      "not an ipv4 address",
+		"ipv6:10.0.0.1":            "not an ipv6 address",
+		"unix://run/orders.sock":   "needs an absolute path",
+		"unix:":                    "missing socket path",
+		"unix-abstract:":           "missing socket name",
+		"dns:///orders:443/extra/": "invalid",
+	}
+	for in, want := range errs {
+		if err := f.Set("orders", in); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Set(%q): expected %q, got %v", in, want, err)
+		}
+	}
+
+	g := NewFlagSet("test", ContinueOnError)
+	bare := g.GRPCTarget("peer", "localhost:50051", 0, "")
+	if err := g.Parse([]string{"-peer", "orders"}); err != nil || *bare != "orders" {
+		t.Fatalf("without a default port: %q, %v", *bare, err)
+	}
+}
+
+func TestGRPCTargetStructTag(t *testing.T) {
+	ResetForTesting(nil)
+	saved := os.Args
+	os.Args = []string{"cmd", "-orders", "dns:///orders.svc"}
+	defer func() { os.Args = saved }()
+	var cfg struct {
+		Orders    string `flag:"orders" grpc:"true" defaultPort:"50051"`
+		Inventory string `flag:"inventory" grpc:"true" defaultPort:"443" default:"inventory"`
+	}
+	if err := ParseStruct(&cfg); err != nil {
+		t.Fatalf("ParseStruct: %v", err)
+	}
+	if cfg.Orders != "dns:///orders.svc:50051" || cfg.Inventory != "inventory:443" {
+		t.Fatalf("got %+v", cfg)
+	}
+}
@@ -0,0 +1,181 @@
+package flag
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// helpValue backs flags registered by EnableHelpFlag. Setting it on the
+// command line makes Parse print usage and return a *HelpRequested.
+type helpValue struct{}
+
+func (*helpValue) Set(string) error { return nil }
+func (*helpValue) String() string   { return "false" }
+func (*helpValue) Get() interface{} { return false }
+func (*helpValue) IsBoolFlag() bool { return true }
+func (*helpValue) TypeName() string { return "" }
+
+// HelpRequested is returned by Parse when a help flag registered with
+// EnableHelpFlag, or the help command, was given. Topic is the group or flag
+// asked about ("" for general help). It matches ErrHelp under errors.Is.
+type HelpRequested struct {
+	Topic string
+}
+
+func (e *HelpRequested) Error() string {
+	if e.Topic == "" {
+		return ErrHelp.Error()
+	}
+	return fmt.Sprintf("%s: %s", ErrHelp.Error(), e.Topic)
+}
+
+// Is reports whether target is ErrHelp.
+func (e *HelpRequested) Is(target error) bool { return target == ErrHelp }
+
+// FlagHelp is the detailed help PrintHelpTopic shows for a flag.
+type FlagHelp struct {
+	Group    string   // topic that groups related flags, e.g. "security"
+	Long     string   // longer description shown below the usage line
+	Examples []string // example settings, e.g. "-tls-cert=/etc/tls/cert.pem"
+}
+
+// SetFlagHelp attaches detailed help to the named flag. ParseStruct sets it
+// from the group, longHelp and example tags.
+func (f *FlagSet) SetFlagHelp(name string, h FlagHelp) {
+	if f.flagHelp == nil {
+		f.flagHelp = make(map[string]FlagHelp)
+	}
+	f.flagHelp[name] = h
+}
+
+// SetFlagHelp attaches detailed help to a flag of the default CommandLine FlagSet.
+func SetFlagHelp(name string, h FlagHelp) { CommandLine.SetFlagHelp(name, h) }
+
+// EnableHelpFlag registers help flags under names ("h" and "help" when none
+// are given) so they appear in usage output and completions, replacing the
+// implicit handling of undefined -h and -help. Passing one of them on the
+// command line (or -name=true) prints usage; -name=topic and a leading
+// "help [topic]" argument print PrintHelpTopic for a group or flag instead.
+// Parse then returns a *HelpRequested. -name=false is ignored. Use names
+// such as "?" for Windows-style help.
+func (f *FlagSet) EnableHelpFlag(names ...string) {
+	if len(names) == 0 {
+		names = []string{"h", "help"}
+	}
+	f.helpConfigured = true
+	f.helpCommand = true
+	for _, name := range names {
+		f.Var(&helpValue{}, name, "show this help message and exit; -"+name+"=topic for a group or flag")
+	}
+}
+
+// EnableHelpFlag registers help flags on the default CommandLine FlagSet.
+func EnableHelpFlag(names ...string) { CommandLine.EnableHelpFlag(names...) }
+
+// DisableHelpFlag turns off the implicit handling of undefined -h and -help,
+// which are then reported as undefined flags like any other. Help flags
+// already registered with EnableHelpFlag keep working.
+func (f *FlagSet) DisableHelpFlag() { f.helpConfigured = true }
+
+// DisableHelpFlag turns off implicit help on the default CommandLine FlagSet.
+func DisableHelpFlag() { CommandLine.DisableHelpFlag() }
+
+// isImplicitHelp reports whether the undefined flag name requests help under
+// the default convention.
+func (f *FlagSet) isImplicitHelp(name string) bool {
+	return !f.helpConfigured && (name == "help" || name == "h")
+}
+
+// requestHelp handles a help flag given on the command line with an optional
+// value: "false" cancels it, "true" or none asks for general usage, anything
+// else names a topic. It returns nil when help was cancelled.
+func (f *FlagSet) requestHelp(value string, hasValue bool) error {
+	topic := ""
+	if hasValue {
+		if b, err := strconv.ParseBool(value); err == nil {
+			if !b {
+				return nil
+			}
+		} else {
+			topic = value
+		}
+	}
+	return f.showHelp(topic)
+}
+
+// showHelp prints usage or the help for topic and returns the error Parse
+// reports.
+func (f *FlagSet) showHelp(topic string) error {
+	if topic == "" || !f.PrintHelpTopic(topic) {
+		if topic != "" {
+			fmt.Fprintf(f.out(), "no help topic %q\n", topic)
+		}
+		f.usage()
+	}
+	return &HelpRequested{Topic: topic}
+}
+
+// PrintHelpTopic prints detailed help for topic, either a group named by
+// SetFlagHelp (all of its flags) or a single flag name, and reports whether
+// the topic was found. Each entry shows the usage line, long help, the
+// environment variable and config key that set the flag, and examples.
+func (f *FlagSet) PrintHelpTopic(topic string) bool {
+	name := strings.TrimLeft(topic, "-")
+	if fl := f.formal[name]; fl != nil {
+		fmt.Fprintf(f.out(), "Help for -%s:\n", fl.Name)
+		f.printFlagHelp(fl)
+		return true
+	}
+	var group []*Flag
+	for _, fl := range sortFlags(f.formal) {
+		if f.flagHelp[fl.Name].Group == topic {
+			group = append(group, fl)
+		}
+	}
+	if len(group) == 0 {
+		return false
+	}
+	fmt.Fprintf(f.out(), "Help for %s:\n", topic)
+	for _, fl := range group {
+		f.printFlagHelp(fl)
+	}
+	return true
+}
+
+// PrintHelpTopic prints detailed help for a topic of the default CommandLine FlagSet.
+func PrintHelpTopic(topic string) bool { return CommandLine.PrintHelpTopic(topic) }
+
+func (f *FlagSet) printFlagHelp(fl *Flag) {
+	out := f.out()
+	fmt.Fprintln(out, f.defaultsEntry(fl))
+	h := f.flagHelp[fl.Name]
+	if h.Long != "" {
+		for _, line := range strings.Split(h.Long, "\n") {
+			fmt.Fprintf(out, "    \t%s\n", line)
+		}
+	}
+	if _, ok := fl.Value.(*helpValue); ok {
+		return
+	}
+	fmt.Fprintf(out, "    \tenv: %s\n", strings.Join(f.envKeys(fl.Name), ", "))
+	fmt.Fprintf(out, "    \tconfig: %s\n", fl.Name)
+	for _, ex := range h.Examples {
+		fmt.Fprintf(out, "    \texample: %s\n", ex)
+	}
+}
+
+// HelpGroups returns the sorted names of the help groups in use.
+func (f *FlagSet) HelpGroups() []string {
+	seen := make(map[string]bool)
+	var groups []string
+	for _, h := range f.flagHelp {
+		if h.Group != "" && !seen[h.Group] {
+			seen[h.Group] = true
+			groups = append(groups, h.Group)
+		}
+	}
+	sort.Strings(groups)
+	return groups
+}
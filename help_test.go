@@ -0,0 +1,119 @@
+package flag_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	. "github.com/machship/flag"
+)
+
+func TestEnableHelpFlag(t *testing.T) {
+	var buf bytes.Buffer
+	f := NewFlagSet("test", ContinueOnError)
+	f.SetOutput(&buf)
+	f.Int("port", 80, "listen port")
+	f.EnableHelpFlag()
+
+	f.PrintDefaults()
+	if out := buf.String(); !strings.Contains(out, "-help") || !strings.Contains(out, "-h\t") {
+		t.Errorf("help flags missing from usage:\n%s", out)
+	}
+	for _, args := range [][]string{{"-h"}, {"--help"}, {"-help=true"}} {
+		if err := f.Parse(args); !errors.Is(err, ErrHelp) {
+			t.Errorf("Parse(%q) = %v, want ErrHelp", args, err)
+		}
+	}
+
+	g := NewFlagSet("test", ContinueOnError)
+	g.SetOutput(io.Discard)
+	g.EnableHelpFlag()
+	if err := g.Parse([]string{"-help=false", "rest"}); err != nil || g.NArg() != 1 {
+		t.Errorf("-help=false: err = %v, args = %q", err, g.Args())
+	}
+}
+
+func TestEnableHelpFlagCustomName(t *testing.T) {
+	f := NewFlagSet("test", ContinueOnError)
+	f.SetOutput(io.Discard)
+	f.EnableHelpFlag("?")
+	if err := f.Parse([]string{"-?"}); !errors.Is(err, ErrHelp) {
+		t.Errorf("-? = %v, want ErrHelp", err)
+	}
+	// renaming drops the implicit -h
+	if err := f.Parse([]string{"-h"}); err == nil || errors.Is(err, ErrHelp) {
+		t.Errorf("-h = %v, want undefined flag error", err)
+	}
+	if err := f.ParseEnv([]string{"?=1"}); err != nil {
+		t.Errorf("help flags must ignore the environment: %v", err)
+	}
+}
+
+func TestDisableHelpFlag(t *testing.T) {
+	f := NewFlagSet("test", ContinueOnError)
+	f.SetOutput(io.Discard)
+	f.DisableHelpFlag()
+	err := f.Parse([]string{"-help"})
+	if err == nil || err.Error() != "flag provided but not defined: -help" {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestHelpTopics(t *testing.T) {
+	var buf bytes.Buffer
+	f := NewFlagSetWithEnvPrefix("test", "APP", ContinueOnError)
+	f.SetOutput(&buf)
+	f.EnableHelpFlag()
+	f.String("tls-cert", "", "TLS certificate file")
+	f.String("tls-key", "", "TLS key file")
+	f.Int("port", 80, "listen port")
+	f.SetFlagHelp("tls-cert", FlagHelp{Group: "security", Long: "PEM encoded, leaf first.", Examples: []string{"-tls-cert=/etc/tls/cert.pem"}})
+	f.SetFlagHelp("tls-key", FlagHelp{Group: "security"})
+
+	err := f.Parse([]string{"--help=security"})
+	var hr *HelpRequested
+	if !errors.As(err, &hr) || hr.Topic != "security" || !errors.Is(err, ErrHelp) {
+		t.Fatalf("err = %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{"Help for security:", "-tls-cert", "PEM encoded, leaf first.", "env: APP_TLS_CERT", "config: tls-cert", "example: -tls-cert=/etc/tls/cert.pem", "-tls-key"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "-port") {
+		t.Errorf("group help lists unrelated flag:\n%s", out)
+	}
+
+	buf.Reset()
+	err = f.Parse([]string{"help", "port"})
+	if !errors.As(err, &hr) || hr.Topic != "port" || !strings.Contains(buf.String(), "Help for -port:") {
+		t.Errorf("help port: err = %v, output:\n%s", err, buf.String())
+	}
+
+	buf.Reset()
+	err = f.Parse([]string{"-h=nonsense"})
+	if !errors.As(err, &hr) || !strings.Contains(buf.String(), `no help topic "nonsense"`) || !strings.Contains(buf.String(), "Usage of test:") {
+		t.Errorf("unknown topic: err = %v, output:\n%s", err, buf.String())
+	}
+}
+
+func TestHelpStructTags(t *testing.T) {
+	ResetForTesting(nil)
+	var buf bytes.Buffer
+	CommandLine.SetOutput(&buf)
+	var c struct {
+		Token string `flag:"token" help:"API token" group:"security" longHelp:"Issued by the auth service." example:"-token=@/run/secrets/token"`
+	}
+	if err := ParseStructWithOptions(&c, ParseStructOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if got := CommandLine.HelpGroups(); len(got) != 1 || got[0] != "security" {
+		t.Errorf("HelpGroups() = %q", got)
+	}
+	if !PrintHelpTopic("security") || !strings.Contains(buf.String(), "Issued by the auth service.") {
+		t.Errorf("output:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,93 @@
+package flag
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Identity describes the running instance. Rollout bucketing uses the most
+// specific of Instance, Pod and Host, and configuration values may refer to
+// any field as ${identity.name}, e.g. "db.${identity.region}.internal".
+type Identity struct {
+	Host     string // host name; os.Hostname when empty
+	Pod      string // pod or container name
+	Instance string // cloud instance or other unique ID
+	Region   string
+	Zone     string
+	Env      string // deployment environment, such as "prod"
+	// Extra holds further fields, referenced as ${identity.<key>}.
+	Extra map[string]string
+}
+
+// SetIdentity sets the identity of this instance for rollout bucketing and
+// ${identity.*} references in configuration files.
+func (f *FlagSet) SetIdentity(id Identity) { f.identity = id }
+
+// SetIdentity sets the instance identity of the default CommandLine FlagSet.
+func SetIdentity(id Identity) { CommandLine.SetIdentity(id) }
+
+// identityField returns the named field of the identity, matched without
+// regard to case, and whether it is set.
+func (f *FlagSet) identityField(name string) (string, bool) {
+	id := f.identity
+	var v string
+	switch strings.ToLower(name) {
+	case "host":
+		v = id.Host
+		if v == "" {
+			v, _ = os.Hostname()
+		}
+	case "pod":
+		v = id.Pod
+	case "instance":
+		v = id.Instance
+	case "region":
+		v = id.Region
+	case "zone":
+		v = id.Zone
+	case "env":
+		v = id.Env
+	default:
+		v = id.Extra[name]
+	}
+	return v, v != ""
+}
+
+// expandIdentity replaces ${identity.name} references in a configuration
+// value. Referring to a field that is not set is an error, so a missing
+// region cannot silently produce "db..internal". "$${identity." stands for a
+// literal "${identity.", and a "raw:" value is left for expandAtFile to
+// take verbatim.
+func (f *FlagSet) expandIdentity(value string) (string, error) {
+	const open = "${identity."
+	if !strings.Contains(value, open) || strings.HasPrefix(value, rawValuePrefix) {
+		return value, nil
+	}
+	var b strings.Builder
+	for {
+		i := strings.Index(value, open)
+		if i < 0 {
+			b.WriteString(value)
+			return b.String(), nil
+		}
+		if i > 0 && value[i-1] == '$' { // escaped
+			b.WriteString(value[:i-1])
+			b.WriteString(open)
+			value = value[i+len(open):]
+			continue
+		}
+		end := strings.IndexByte(value[i:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated %s reference", open)
+		}
+		name := value[i+len(open) : i+end]
+		v, ok := f.identityField(name)
+		if !ok {
+			return "", fmt.Errorf("identity.%s is not set", name)
+		}
+		b.WriteString(value[:i])
+		b.WriteString(v)
+		value = value[i+end+1:]
+	}
+}
@@ -0,0 +1,103 @@
+package flag_test
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	. "github.com/machship/flag"
+)
+
+func TestIdentityInterpolation(t *testing.T) {
+	path := writeTempFile(t, "app.yaml", `db:
+  host: db.${identity.region}.internal
+peers: ["${identity.zone}-a", "${identity.zone}-b"]
+cell: ${identity.cell}/${identity.pod}
+`)
+	f := NewFlagSet("test", ContinueOnError)
+	host := f.String("db.host", "", "")
+	peers := f.StringSlice("peers", ",", nil, "")
+	cell := f.String("cell", "", "")
+	f.SetIdentity(Identity{Pod: "api-7", Region: "eu-west-1", Zone: "eu-west-1a", Extra: map[string]string{"cell": "c3"}})
+	if err := f.ParseFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if *host != "db.eu-west-1.internal" || *cell != "c3/api-7" {
+		t.Errorf("db.host=%q cell=%q", *host, *cell)
+	}
+	if want := []string{"eu-west-1a-a", "eu-west-1a-b"}; !reflect.DeepEqual(*peers, want) {
+		t.Errorf("peers = %q, want %q", *peers, want)
+	}
+}
+
+func TestIdentityInterpolationErrors(t *testing.T) {
+	for content, want := range map[string]string{
+		"host db.${identity.region}.internal\n": "identity.region is not set",
+		"host db.${identity.region\n":           "unterminated ${identity. reference",
+	} {
+		f := NewFlagSet("test", ContinueOnError)
+		f.SetOutput(&bytes.Buffer{})
+		f.String("host", "", "")
+		err := f.ParseFile(writeTempFile(t, "app.conf", content))
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%q: err = %v, want %q", content, err, want)
+		}
+	}
+
+	// host falls back to the machine's name
+	hostname, err := os.Hostname()
+	if err != nil {
+		t.Skip(err)
+	}
+	f := NewFlagSet("test", ContinueOnError)
+	host := f.String("host", "", "")
+	if err := f.ParseFile(writeTempFile(t, "app.conf", "host ${identity.host}\n")); err != nil || *host != hostname {
+		t.Errorf("host = %q, err = %v", *host, err)
+	}
+}
+
+func TestIdentityInterpolationVerbatim(t *testing.T) {
+	path := writeTempFile(t, "app.conf", `template $${identity.region}/$${identity.zone}
+raw raw:${identity.unset}
+mixed ${identity.region}-$${identity.region}
+`)
+	f := NewFlagSet("test", ContinueOnError)
+	template := f.String("template", "", "")
+	raw := f.String("raw", "", "")
+	mixed := f.String("mixed", "", "")
+	f.SetIdentity(Identity{Region: "eu-west-1"})
+	if err := f.ParseFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if *template != "${identity.region}/${identity.zone}" {
+		t.Errorf("escaped = %q", *template)
+	}
+	if *raw != "${identity.unset}" {
+		t.Errorf("raw = %q", *raw)
+	}
+	if *mixed != "eu-west-1-${identity.region}" {
+		t.Errorf("mixed = %q", *mixed)
+	}
+}
+
+func TestIdentityRolloutInstance(t *testing.T) {
+	// Pod identifies the instance when Instance is unset, so SetIdentity and
+	// SetInstanceID with the same value bucket alike
+	for i := 1; i <= 50; i++ {
+		id := strings.Repeat("x", i)
+		f, a := rolloutFlagSet("", `{"value": "on", "rollout": 50}`)
+		f.SetIdentity(Identity{Pod: id, Host: "shared-node"})
+		g, b := rolloutFlagSet(id, `{"value": "on", "rollout": 50}`)
+		if err := f.Parse(nil); err != nil {
+			t.Fatal(err)
+		}
+		if err := g.Parse(nil); err != nil {
+			t.Fatal(err)
+		}
+		if *a != *b {
+			t.Fatalf("pod %q: %q with SetIdentity, %q with SetInstanceID", id, *a, *b)
+		}
+	}
+}
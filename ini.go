@@ -0,0 +1,106 @@
+package flag
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// iniEntry is a single key of an INI file, already qualified by its section.
+type iniEntry struct {
+	name     string
+	values   []string
+	hasValue bool
+}
+
+// parseINI reads an INI file and applies its keys like ParseFile does.
+// A "[section]" header prefixes the keys below it, so "[db]" followed by
+// "host=x" sets -db.host. Keys and values are separated by '=' or ':' and
+// trimmed; a key on its own sets a boolean. Lines beginning with ';' or '#'
+// are comments. A key repeated within the file supplies one element per
+// occurrence to its list or map flag; repeating any other key is an error.
+func (f *FlagSet) parseINI(r io.Reader) error {
+	var entries []*iniEntry
+	seen := make(map[string]*iniEntry)
+	section := ""
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == ';' || line[0] == '#' {
+			continue
+		}
+		if line[0] == '[' {
+			if line[len(line)-1] != ']' || strings.TrimSpace(line[1:len(line)-1]) == "" {
+				return f.failf("invalid section header on line %d: %s", n, line)
+			}
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		}
+
+		name, value, hasValue := line, "", false
+		if i := strings.IndexAny(line, "=:"); i >= 0 {
+			name, value, hasValue = strings.TrimSpace(line[:i]), strings.TrimSpace(line[i+1:]), true
+		}
+		if name == "" {
+			return f.failf("missing key on line %d: %s", n, line)
+		}
+		if section != "" {
+			name = section + "." + name
+		}
+
+		if e, ok := seen[name]; ok {
+			e.values = append(e.values, value)
+			continue
+		}
+		e := &iniEntry{name: name, values: []string{value}, hasValue: hasValue}
+		seen[name] = e
+		entries = append(entries, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		if len(e.values) > 1 {
+			if err := f.applyRepeatedKey(e); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := f.applyConfigValue(e.name, e.values[0], e.hasValue); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyRepeatedKey sets a list or map flag from a key repeated in an INI
+// file, one element per occurrence.
+func (f *FlagSet) applyRepeatedKey(e *iniEntry) error {
+	return f.applyConfigList(e.name, e.values, "repeated")
+}
+
+// applyConfigList sets a list or map flag from the separate elements a
+// configuration file supplied for name; how describes the construct in the
+// error reported when the flag is not a list.
+func (f *FlagSet) applyConfigList(name string, values []string, how string) error {
+	if fl := f.actual[name]; fl != nil {
+		f.audit(fl, SourceConfig, strings.Join(values, ","), false, nil)
+		return nil
+	}
+	flag := f.formal[name]
+	if flag == nil {
+		return f.failf("configuration variable provided but not defined: %s", name)
+	}
+	if _, ok := flag.Value.(listValue); !ok {
+		return f.failf("configuration variable %s %s but -%s is not a list", name, how, name)
+	}
+	expanded := make([]string, len(values))
+	for i, v := range values {
+		var err error
+		if expanded[i], err = f.expandIdentity(v); err != nil {
+			return f.failf("invalid value for configuration variable %s: %v", name, err)
+		}
+	}
+	return f.setListValues(flag, expanded, SourceConfig, "configuration variable")
+}
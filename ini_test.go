@@ -0,0 +1,119 @@
+package flag_test
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	. "github.com/machship/flag"
+)
+
+// writeTempFile writes content to a file called name in a fresh temporary
+// directory and returns its path.
+func writeTempFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestParseFileINISections(t *testing.T) {
+	path := writeTempFile(t, "app.ini", `; inherited settings
+name = billing
+verbose
+
+[db]
+host = db.internal
+port: 5433
+
+# repeated keys build up a list
+[cache]
+peer = a:11211
+peer = b:11211
+
+[greeting]
+text = hello, world
+text = goodbye
+`)
+	f := NewFlagSet("test", ContinueOnError)
+	f.SetOutput(io.Discard)
+	name := f.String("name", "", "")
+	verbose := f.Bool("verbose", false, "")
+	host := f.String("db.host", "localhost", "")
+	port := f.Int("db.port", 5432, "")
+	var peers []string
+	f.StringSliceVar(&peers, "cache.peer", ",", nil, "")
+	texts := f.StringSlice("greeting.text", ",", nil, "")
+
+	if err := f.ParseFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if *name != "billing" || !*verbose || *host != "db.internal" || *port != 5433 {
+		t.Errorf("got name=%q verbose=%v host=%q port=%d", *name, *verbose, *host, *port)
+	}
+	if want := []string{"a:11211", "b:11211"}; !reflect.DeepEqual(peers, want) {
+		t.Errorf("peers = %q, want %q", peers, want)
+	}
+	if want := []string{"hello, world", "goodbye"}; !reflect.DeepEqual(*texts, want) {
+		t.Errorf("greeting.text = %q, want %q", *texts, want)
+	}
+	for _, m := range f.Introspect() {
+		if m.Name == "db.host" && m.Source != "config" {
+			t.Errorf("db.host source = %q, want config", m.Source)
+		}
+	}
+}
+
+func TestParseFileINIPrecedence(t *testing.T) {
+	path := writeTempFile(t, "app.ini", "[db]\nhost = from-file\n")
+	f := NewFlagSet("test", ContinueOnError)
+	host := f.String("db.host", "localhost", "")
+	if err := f.Parse([]string{"-db.host", "from-cli"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.ParseFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if *host != "from-cli" {
+		t.Errorf("db.host = %q, want from-cli", *host)
+	}
+}
+
+func TestParseFileINIRepeatedMap(t *testing.T) {
+	path := writeTempFile(t, "app.ini", "label = team=core\nlabel = tier=1\n")
+	f := NewFlagSet("test", ContinueOnError)
+	labels := f.StringMap("label", nil, "")
+	if err := f.ParseFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]string{"team": "core", "tier": "1"}; !reflect.DeepEqual(*labels, want) {
+		t.Errorf("labels = %v, want %v", *labels, want)
+	}
+}
+
+func TestParseFileINIErrors(t *testing.T) {
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{"[db\nhost=x\n", "invalid section header on line 1"},
+		{"[ ]\n", "invalid section header"},
+		{"= x\n", "missing key on line 1"},
+		{"[db]\nhost=a\nhost=b\n", "db.host repeated but -db.host is not a list"},
+		{"[db]\nuser=x\n", "not defined: db.user"},
+	}
+	for _, tt := range tests {
+		f := NewFlagSet("test", ContinueOnError)
+		f.SetOutput(io.Discard)
+		f.String("db.host", "", "")
+		err := f.ParseFile(writeTempFile(t, "app.ini", tt.content))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ParseFile(%q) error = %v, want %q", tt.content, err, tt.want)
+		}
+	}
+}
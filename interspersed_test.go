@@ -0,0 +1,55 @@
+package flag_test
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	. "github.com/machship/flag"
+)
+
+func TestInterspersed(t *testing.T) {
+	for args, want := range map[string]string{
+		"-r src dst -v":          "r v [src dst]",
+		"src -r dst":             "r [src dst]",
+		"src - dst -v":           "v [src - dst]",
+		"src -v -- -r dst":       "v [src -r dst]",
+		"-r -- src":              "r [src]",
+		"src dst":                "[src dst]",
+		"-r -name=x src -name y": "r name=y [src]",
+	} {
+		f := NewFlagSet("cp", ContinueOnError)
+		f.SetInterspersed(true)
+		r := f.Bool("r", false, "")
+		v := f.Bool("v", false, "")
+		name := f.String("name", "", "")
+		if err := f.Parse(strings.Fields(args)); err != nil {
+			t.Fatalf("%s: %v", args, err)
+		}
+		var got []string
+		if *r {
+			got = append(got, "r")
+		}
+		if *v {
+			got = append(got, "v")
+		}
+		if *name != "" {
+			got = append(got, "name="+*name)
+		}
+		got = append(got, "["+strings.Join(f.Args(), " ")+"]")
+		if strings.Join(got, " ") != want {
+			t.Errorf("%s: got %q, want %q", args, strings.Join(got, " "), want)
+		}
+	}
+
+	f := NewFlagSet("cp", ContinueOnError)
+	f.SetOutput(io.Discard)
+	f.Bool("v", false, "")
+	if err := f.Parse([]string{"src", "-v"}); err != nil || f.NArg() != 2 {
+		t.Fatalf("without interspersed: %v, args %v", err, f.Args())
+	}
+	f.SetInterspersed(true)
+	if err := f.Parse([]string{"src", "-x"}); err == nil || !strings.Contains(err.Error(), "-x") {
+		t.Fatalf("unknown flag after positional: %v", err)
+	}
+}
@@ -0,0 +1,153 @@
+package flag
+
+import (
+	"fmt"
+	"strings"
+)
+
+// countryAlpha3 maps ISO 3166-1 alpha-2 codes to their alpha-3 equivalents.
+var countryAlpha3 = map[string]string{
+	"AD": "AND", "AE": "ARE", "AF": "AFG", "AG": "ATG", "AI": "AIA", "AL": "ALB", "AM": "ARM", "AO": "AGO",
+	"AQ": "ATA", "AR": "ARG", "AS": "ASM", "AT": "AUT", "AU": "AUS", "AW": "ABW", "AX": "ALA", "AZ": "AZE",
+	"BA": "BIH", "BB": "BRB", "BD": "BGD", "BE": "BEL", "BF": "BFA", "BG": "BGR", "BH": "BHR", "BI": "BDI",
+	"BJ": "BEN", "BL": "BLM", "BM": "BMU", "BN": "BRN", "BO": "BOL", "BQ": "BES", "BR": "BRA", "BS": "BHS",
+	"BT": "BTN", "BV": "BVT", "BW": "BWA", "BY": "BLR", "BZ": "BLZ",
+	"CA": "CAN", "CC": "CCK", "CD": "COD", "CF": "CAF", "CG": "COG", "CH": "CHE", "CI": "CIV", "CK": "COK",
+	"CL": "CHL", "CM": "CMR", "CN": "CHN", "CO": "COL", "CR": "CRI", "CU": "CUB", "CV": "CPV", "CW": "CUW",
+	"CX": "CXR", "CY": "CYP", "CZ": "CZE",
+	"DE": "DEU", "DJ": "DJI", "DK": "DNK", "DM": "DMA", "DO": "DOM", "DZ": "DZA",
+	"EC": "ECU", "EE": "EST", "EG": "EGY", "EH": "ESH", "ER": "ERI", "ES": "ESP", "ET": "ETH",
+	"FI": "FIN", "FJ": "FJI", "FK": "FLK", "FM": "FSM", "FO": "FRO", "FR": "FRA",
+	"GA": "GAB", "GB": "GBR", "GD": "GRD", "GE": "GEO", "GF": "GUF", "GG": "GGY", "GH": "GHA", "GI": "GIB",
+	"GL": "GRL", "GM": "GMB", "GN": "GIN", "GP": "GLP", "GQ": "GNQ", "GR": "GRC", "GS": "SGS", "GT": "GTM",
+	"GU": "GUM", "GW": "GNB", "GY": "GUY",
+	"HK": "HKG", "HM": "HMD", "HN": "HND", "HR": "HRV", "HT": "HTI", "HU": "HUN",
+	"ID": "IDN", "IE": "IRL", "IL": "ISR", "IM": "IMN", "IN": "IND", "IO": "IOT", "IQ": "IRQ", "IR": "IRN",
+	"IS": "ISL", "IT": "ITA",
+	"JE": "JEY", "JM": "JAM", "JO": "JOR", "JP": "JPN",
+	"KE": "KEN", "KG": "KGZ", "KH": "KHM", "KI": "KIR", "KM": "COM", "KN": "KNA", "KP": "PRK", "KR": "KOR",
+	"KW": "KWT", "KY": "CYM", "KZ": "KAZ",
+	"LA": "LAO", "LB": "LBN", "LC": "LCA", "LI": "LIE", "LK": "LKA", "LR": "LBR", "LS": "LSO", "LT": "LTU",
+	"LU": "LUX", "LV": "LVA", "LY": "LBY",
+	"MA": "MAR", "MC": "MCO", "MD": "MDA", "ME": "MNE", "MF": "MAF", "MG": "MDG", "MH": "MHL", "MK": "MKD",
+	"ML": "MLI", "MM": "MMR", "MN": "MNG", "MO": "MAC", "MP": "MNP", "MQ": "MTQ", "MR": "MRT", "MS": "MSR",
+	"MT": "MLT", "MU": "MUS", "MV": "MDV", "MW": "MWI", "MX": "MEX", "MY": "MYS", "MZ": "MOZ",
+	"NA": "NAM", "NC": "NCL", "NE": "NER", "NF": "NFK", "NG": "NGA", "NI": "NIC", "NL": "NLD", "NO": "NOR",
+	"NP": "NPL", "NR": "NRU", "NU": "NIU", "NZ": "NZL",
+	"OM": "OMN",
+	"PA": "PAN", "PE": "PER", "PF": "PYF", "PG": "PNG", "PH": "PHL", "PK": "PAK", "PL": "POL", "PM": "SPM",
+	"PN": "PCN", "PR": "PRI", "PS": "PSE", "PT": "PRT", "PW": "PLW", "PY": "PRY",
+	"QA": "QAT",
+	"RE": "REU", "RO": "ROU", "RS": "SRB", "RU": "RUS", "RW": "RWA",
+	"SA": "SAU", "SB": "SLB", "SC": "SYC", "SD": "SDN", "SE": "SWE", "SG": "SGP", "SH": "SHN", "SI": "SVN",
+	"SJ": "SJM", "SK": "SVK", "SL": "SLE", "SM": "SMR", "SN": "SEN", "SO": "SOM", "SR": "SUR", "SS": "SSD",
+	"ST": "STP", "SV": "SLV", "SX": "SXM", "SY": "SYR", "SZ": "SWZ",
+	"TC": "TCA", "TD": "TCD", "TF": "ATF", "TG": "TGO", "TH": "THA", "TJ": "TJK", "TK": "TKL", "TL": "TLS",
+	"TM": "TKM", "TN": "TUN", "TO": "TON", "TR": "TUR", "TT": "TTO", "TV": "TUV", "TW": "TWN", "TZ": "TZA",
+	"UA": "UKR", "UG": "UGA", "UM": "UMI", "US": "USA", "UY": "URY", "UZ": "UZB",
+	"VA": "VAT", "VC": "VCT", "VE": "VEN", "VG": "VGB", "VI": "VIR", "VN": "VNM", "VU": "VUT",
+	"WF": "WLF", "WS": "WSM",
+	"YE": "YEM", "YT": "MYT",
+	"ZA": "ZAF", "ZM": "ZMB", "ZW": "ZWE",
+}
+
+// countryCodes accepts both alpha-2 and alpha-3 forms.
+var countryCodes = func() map[string]struct{} {
+	m := make(map[string]struct{}, 2*len(countryAlpha3))
+	for a2, a3 := range countryAlpha3 {
+		m[a2] = struct{}{}
+		m[a3] = struct{}{}
+	}
+	return m
+}()
+
+// currencyCodes lists the active ISO 4217 codes, including funds and precious
+// metals but not the XTS/XXX test and no-currency codes.
+var currencyCodes = func() map[string]struct{} {
+	const list = `AED AFN ALL AMD ANG AOA ARS AUD AWG AZN BAM BBD BDT BGN BHD BIF BMD BND BOB BOV
+BRL BSD BTN BWP BYN BZD CAD CDF CHE CHF CHW CLF CLP CNY COP COU CRC CUC CUP CVE CZK DJF DKK DOP DZD
+EGP ERN ETB EUR FJD FKP GBP GEL GHS GIP GMD GNF GTQ GYD HKD HNL HTG HUF IDR ILS INR IQD IRR ISK JMD
+JOD JPY KES KGS KHR KMF KPW KRW KWD KYD KZT LAK LBP LKR LRD LSL LYD MAD MDL MGA MKD MMK MNT MOP MRU
+MUR MVR MWK MXN MXV MYR MZN NAD NGN NIO NOK NPR NZD OMR PAB PEN PGK PHP PKR PLN PYG QAR RON RSD RUB
+RWF SAR SBD SCR SDG SEK SGD SHP SLE SOS SRD SSP STN SVC SYP SZL THB TJS TMT TND TOP TRY TTD TWD TZS
+UAH UGX USD USN UYI UYU UYW UZS VED VES VND VUV WST XAF XAG XAU XBA XBB XBC XBD XCD XCG XDR XOF XPD
+XPF XPT XSU XUA YER ZAR ZMW ZWG ZWL`
+	m := make(map[string]struct{})
+	for _, c := range strings.Fields(list) {
+		m[c] = struct{}{}
+	}
+	return m
+}()
+
+// isoCodeValue is a string restricted to an ISO code list, normalized to upper case.
+type isoCodeValue struct {
+	p     *string
+	kind  string // "country" or "currency", used in errors and usage
+	codes map[string]struct{}
+}
+
+func newISOCodeValue(val string, kind string, codes map[string]struct{}, p *string) *isoCodeValue {
+	*p = strings.ToUpper(val)
+	return &isoCodeValue{p: p, kind: kind, codes: codes}
+}
+func (iv *isoCodeValue) Set(s string) error {
+	c, err := normalizeISOCode(s, iv.kind, iv.codes)
+	if err != nil {
+		return err
+	}
+	*iv.p = c
+	return nil
+}
+func (iv *isoCodeValue) String() string {
+	if iv.p == nil {
+		return ""
+	}
+	return *iv.p
+}
+func (iv *isoCodeValue) Get() interface{} { return *iv.p }
+
+func normalizeISOCode(s, kind string, codes map[string]struct{}) (string, error) {
+	c := strings.ToUpper(strings.TrimSpace(s))
+	if _, ok := codes[c]; !ok {
+		return "", fmt.Errorf("unknown %s code %q", kind, s)
+	}
+	return c, nil
+}
+
+// CountryCodeVar registers an ISO 3166-1 country code flag. Alpha-2 ("AU") and
+// alpha-3 ("AUS") codes are accepted in any case and stored upper-cased.
+func (f *FlagSet) CountryCodeVar(p *string, name string, value string, usage string) {
+	f.Var(newISOCodeValue(value, "country", countryCodes, p), name, usage)
+}
+func CountryCodeVar(p *string, name string, value string, usage string) {
+	CommandLine.CountryCodeVar(p, name, value, usage)
+}
+
+// CountryCode defines a country code flag and returns a pointer to it.
+func (f *FlagSet) CountryCode(name string, value string, usage string) *string {
+	p := new(string)
+	f.CountryCodeVar(p, name, value, usage)
+	return p
+}
+func CountryCode(name string, value string, usage string) *string {
+	return CommandLine.CountryCode(name, value, usage)
+}
+
+// CurrencyCodeVar registers an ISO 4217 currency code flag. Codes are accepted
+// in any case and stored upper-cased.
+func (f *FlagSet) CurrencyCodeVar(p *string, name string, value string, usage string) {
+	f.Var(newISOCodeValue(value, "currency", currencyCodes, p), name, usage)
+}
+func CurrencyCodeVar(p *string, name string, value string, usage string) {
+	CommandLine.CurrencyCodeVar(p, name, value, usage)
+}
+
+// CurrencyCode defines a currency code flag and returns a pointer to it.
+func (f *FlagSet) CurrencyCode(name string, value string, usage string) *string {
+	p := new(string)
+	f.CurrencyCodeVar(p, name, value, usage)
+	return p
+}
+func CurrencyCode(name string, value string, usage string) *string {
+	return CommandLine.CurrencyCode(name, value, usage)
+}
@@ -0,0 +1,60 @@
+package flag_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	. "github.com/machship/flag"
+)
+
+func TestCountryAndCurrencyCodes(t *testing.T) {
+	f := NewFlagSet("test", ContinueOnError)
+	origin := f.CountryCode("origin", "au", "")
+	dest := f.CountryCode("dest", "", "")
+	cur := f.CurrencyCode("currency", "AUD", "")
+	if *origin != "AU" {
+		t.Fatalf("default not normalized: %q", *origin)
+	}
+	if err := f.Parse([]string{"-dest", " nzl", "-currency", "nzd"}); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if *dest != "NZL" || *cur != "NZD" {
+		t.Fatalf("dest=%q currency=%q", *dest, *cur)
+	}
+	if err := f.Set("dest", "XX"); err == nil || !strings.Contains(err.Error(), `unknown country code "XX"`) {
+		t.Fatalf("expected unknown country error, got %v", err)
+	}
+	if err := f.Set("currency", "XXX"); err == nil || !strings.Contains(err.Error(), "unknown currency code") {
+		t.Fatalf("expected unknown currency error, got %v", err)
+	}
+	if name, _ := UnquoteUsage(f.Lookup("origin")); name != "country" {
+		t.Errorf("usage type name = %q", name)
+	}
+}
+
+func TestISOStructTag(t *testing.T) {
+	ResetForTesting(nil)
+	saved := os.Args
+	os.Args = []string{"cmd", "-to", "gb"}
+	defer func() { os.Args = saved }()
+	var cfg struct {
+		From     string `flag:"from" iso:"country" default:"aus"`
+		To       string `flag:"to" iso:"country"`
+		Currency string `flag:"currency" iso:"currency" default:"eur"`
+	}
+	if err := ParseStruct(&cfg); err != nil {
+		t.Fatalf("ParseStruct: %v", err)
+	}
+	if cfg.From != "AUS" || cfg.To != "GB" || cfg.Currency != "EUR" {
+		t.Fatalf("got %+v", cfg)
+	}
+
+	ResetForTesting(nil)
+	var bad struct {
+		Currency string `flag:"currency" iso:"currency" default:"dollars"`
+	}
+	if err := ParseStructWithOptions(&bad, ParseStructOptions{}); err == nil || !strings.Contains(err.Error(), "unknown currency code") {
+		t.Fatalf("expected default error, got %v", err)
+	}
+}
@@ -0,0 +1,64 @@
+package flag_test
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	. "github.com/machship/flag"
+)
+
+func TestJSONVarWithOptions_MaxBytes(t *testing.T) {
+	f := NewFlagSet("test", ContinueOnError)
+	var raw json.RawMessage
+	f.JSONVarWithOptions(&raw, "blob", nil, JSONOptions{MaxBytes: 16}, "")
+	if err := f.Set("blob", `{"a":1}`); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	err := f.Set("blob", `{"a":"`+strings.Repeat("x", 32)+`"}`)
+	if err == nil || !strings.Contains(err.Error(), "max 16") {
+		t.Fatalf("expected size limit error, got %v", err)
+	}
+	if string(raw) != `{"a":1}` {
+		t.Fatalf("rejected value must not overwrite, got %s", raw)
+	}
+}
+
+func TestJSONVarWithOptions_Target(t *testing.T) {
+	type cfg struct {
+		Name string `json:"name"`
+		Port int    `json:"port"`
+	}
+	f := NewFlagSet("test", ContinueOnError)
+	var raw json.RawMessage
+	f.JSONVarWithOptions(&raw, "cfg", nil, JSONOptions{Target: cfg{}, DisallowUnknownFields: true}, "")
+	if err := f.Set("cfg", `{"name":"x","port":1}`); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	if err := f.Set("cfg", `{"name":"x","extra":true}`); err == nil || !strings.Contains(err.Error(), "unknown field") {
+		t.Fatalf("expected unknown field error, got %v", err)
+	}
+	if err := f.Set("cfg", `{"port":"nope"}`); err == nil {
+		t.Fatalf("expected type mismatch error")
+	}
+	lenient := NewFlagSet("test", ContinueOnError)
+	lenient.JSONVarWithOptions(&raw, "cfg", nil, JSONOptions{Target: &cfg{}}, "")
+	if err := lenient.Set("cfg", `{"name":"x","extra":true}`); err != nil {
+		t.Fatalf("unknown fields allowed without DisallowUnknownFields: %v", err)
+	}
+}
+
+func TestJSONFlag_InvalidReportsSyntaxError(t *testing.T) {
+	f := NewFlagSet("test", ContinueOnError)
+	f.JSON("doc", nil, "")
+	var se *json.SyntaxError
+	if err := f.Set("doc", `{"a":`); !errors.As(err, &se) {
+		t.Fatalf("expected *json.SyntaxError, got %v", err)
+	}
+	for _, in := range []string{`{} {}`, ``} {
+		if err := f.Set("doc", in); err == nil {
+			t.Fatalf("expected error for %q", in)
+		}
+	}
+}
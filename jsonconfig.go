@@ -0,0 +1,117 @@
+package flag
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// ParseJSONConfig parses flags from a JSON object read from r, such as an
+// embedded default config. Nested objects name dotted flags, so
+// {"db": {"host": "x"}} sets -db.host, matching flagPrefix nesting. Arrays
+// fill slice flags one element per item, an object named after a string map
+// flag fills that map and JSON flags receive their value verbatim. Nulls are
+// skipped. Flags already set will be ignored. ParseFile calls it for files
+// ending in ".json".
+func (f *FlagSet) ParseJSONConfig(r io.Reader) error {
+	var obj map[string]json.RawMessage
+	dec := json.NewDecoder(r)
+	if err := dec.Decode(&obj); err != nil {
+		return f.failf("invalid JSON configuration: %v", err)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return f.failf("invalid JSON configuration: unexpected data after the top-level object")
+	}
+	return f.applyJSONObject("", obj)
+}
+
+// applyJSONObject applies the members of obj, in key order, below prefix.
+func (f *FlagSet) applyJSONObject(prefix string, obj map[string]json.RawMessage) error {
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if err := f.applyJSONMember(prefix+k, obj[k]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *FlagSet) applyJSONMember(name string, raw json.RawMessage) error {
+	raw = bytes.TrimSpace(raw)
+	var target Value
+	if flag := f.formal[name]; flag != nil {
+		target = flag.Value
+	}
+	if _, ok := target.(*jsonValue); ok {
+		return f.applyConfigValue(name, string(raw), true)
+	}
+
+	switch raw[0] {
+	case '{':
+		var obj map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &obj); err != nil {
+			return f.failf("invalid value for configuration variable %s: %v", name, err)
+		}
+		if _, ok := target.(*stringMapValue); !ok {
+			return f.applyJSONObject(name+".", obj)
+		}
+		elems := make([]string, 0, len(obj))
+		for k, v := range obj {
+			s, ok, err := jsonScalar(v)
+			if err != nil || !ok {
+				return f.failf("configuration variable %s.%s must be a string, number or boolean", name, k)
+			}
+			elems = append(elems, k+"="+s)
+		}
+		sort.Strings(elems)
+		return f.applyConfigList(name, elems, "is an object")
+	case '[':
+		var items []json.RawMessage
+		if err := json.Unmarshal(raw, &items); err != nil {
+			return f.failf("invalid value for configuration variable %s: %v", name, err)
+		}
+		elems := make([]string, 0, len(items))
+		for i, item := range items {
+			s, ok, err := jsonScalar(item)
+			if err != nil || !ok {
+				return f.failf("configuration variable %s[%d] must be a string, number or boolean", name, i)
+			}
+			elems = append(elems, s)
+		}
+		return f.applyConfigList(name, elems, "is an array")
+	}
+
+	s, ok, err := jsonScalar(raw)
+	if err != nil {
+		return f.failf("invalid value for configuration variable %s: %v", name, err)
+	}
+	if !ok {
+		return nil // null leaves the flag alone
+	}
+	return f.applyConfigValue(name, s, true)
+}
+
+// jsonScalar returns the flag text for a JSON string, number or boolean. ok
+// is false for null, objects and arrays.
+func jsonScalar(raw json.RawMessage) (s string, ok bool, err error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 {
+		return "", false, fmt.Errorf("empty JSON value")
+	}
+	switch raw[0] {
+	case '"':
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return "", false, err
+		}
+		return s, true, nil
+	case 'n', '{', '[':
+		return "", false, nil
+	}
+	return string(raw), true, nil
+}
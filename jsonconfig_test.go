@@ -0,0 +1,92 @@
+package flag_test
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/machship/flag"
+)
+
+func TestParseJSONConfig(t *testing.T) {
+	f := NewFlagSet("test", ContinueOnError)
+	verbose := f.Bool("verbose", false, "")
+	host := f.String("db.host", "", "")
+	port := f.Int("db.port", 0, "")
+	user := f.String("db.auth.user", "", "")
+	timeouts := f.DurationSlice("http.timeouts", ",", nil, "")
+	peers := f.StringSlice("peers", ",", nil, "")
+	labels := f.StringMap("labels", nil, "")
+	extra := f.JSON("extra", nil, "")
+	name := f.String("name", "default", "")
+	if err := f.Parse([]string{"-db.port=6000"}); err != nil {
+		t.Fatal(err)
+	}
+	err := f.ParseJSONConfig(strings.NewReader(`{
+		"verbose": true,
+		"name": null,
+		"db": {"host": "db.internal", "port": 5432, "auth": {"user": "app"}},
+		"http": {"timeouts": ["1s", "250ms"]},
+		"peers": ["a", "b,c"],
+		"labels": {"team": "payments", "tier": 1},
+		"extra": {"nested": [1, 2]}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !*verbose || *host != "db.internal" || *port != 6000 || *user != "app" || *name != "default" {
+		t.Errorf("verbose=%v host=%q port=%d user=%q name=%q", *verbose, *host, *port, *user, *name)
+	}
+	if want := []time.Duration{time.Second, 250 * time.Millisecond}; !reflect.DeepEqual(*timeouts, want) {
+		t.Errorf("timeouts = %v, want %v", *timeouts, want)
+	}
+	if want := []string{"a", "b,c"}; !reflect.DeepEqual(*peers, want) {
+		t.Errorf("peers = %q, want %q", *peers, want)
+	}
+	if want := map[string]string{"team": "payments", "tier": "1"}; !reflect.DeepEqual(*labels, want) {
+		t.Errorf("labels = %v, want %v", *labels, want)
+	}
+	if !json.Valid(*extra) || !strings.Contains(string(*extra), `"nested"`) {
+		t.Errorf("extra = %s", *extra)
+	}
+}
+
+func TestParseFileJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.json")
+	if err := os.WriteFile(path, []byte(`{"db": {"host": "x"}}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	f := NewFlagSet("test", ContinueOnError)
+	host := f.String("db.host", "", "")
+	if err := f.ParseFile(path); err != nil || *host != "x" {
+		t.Errorf("err = %v, host = %q", err, *host)
+	}
+}
+
+func TestParseJSONConfigErrors(t *testing.T) {
+	tests := []struct {
+		doc, want string
+	}{
+		{`[1]`, "invalid JSON configuration"},
+		{`{"name": ["a"]}`, "configuration variable name is an array but -name is not a list"},
+		{`{"peers": [{"a": 1}]}`, "configuration variable peers[0] must be a string, number or boolean"},
+		{`{"db": {"missing": 1}}`, "configuration variable provided but not defined: db.missing"},
+		{`{"name": "a"} garbage`, "unexpected data after the top-level object"},
+		{`{"name": "a"}{"name": "b"}`, "unexpected data after the top-level object"},
+	}
+	for _, tt := range tests {
+		f := NewFlagSet("test", ContinueOnError)
+		f.SetOutput(io.Discard)
+		f.String("name", "", "")
+		f.StringSlice("peers", ",", nil, "")
+		err := f.ParseJSONConfig(strings.NewReader(tt.doc))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: err = %v, want %q", tt.doc, err, tt.want)
+		}
+	}
+}
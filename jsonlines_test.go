@@ -0,0 +1,45 @@
+package flag_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/machship/flag"
+)
+
+func TestJSONLinesFlag(t *testing.T) {
+	f := NewFlagSet("test", ContinueOnError)
+	docs := f.JSONLines("events", nil, "")
+	if err := f.Parse([]string{"-events", "{\"id\":1}\n\n[1,2]\r\n\"x\""}); err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(*docs) != 3 || string((*docs)[1]) != "[1,2]" {
+		t.Fatalf("unexpected documents: %q", *docs)
+	}
+	err := f.Set("events", "{\"ok\":true}\n{broken")
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Fatalf("expected error naming line 2, got %v", err)
+	}
+	if len(*docs) != 3 {
+		t.Fatalf("failed Set must not modify value")
+	}
+}
+
+func TestJSONLinesFlag_AtFile(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "replay.jsonl")
+	if err := os.WriteFile(p, []byte("{\"a\":1}\n{\"a\":2}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f := NewFlagSet("test", ContinueOnError)
+	var docs []json.RawMessage
+	f.JSONLinesVar(&docs, "replay", nil, "")
+	if err := f.Parse([]string{"-replay", "@" + p}); err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(docs) != 2 || string(docs[1]) != `{"a":2}` {
+		t.Fatalf("unexpected documents from file: %q", docs)
+	}
+}
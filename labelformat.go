@@ -0,0 +1,135 @@
+package flag
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LabelFormat is a paper or label stock size. Width and Height are in
+// millimetres, portrait (Width <= Height).
+type LabelFormat struct {
+	Name          string
+	Width, Height float64
+	Thermal       bool // direct thermal or thermal transfer roll stock
+}
+
+// String returns the format's name.
+func (lf LabelFormat) String() string { return lf.Name }
+
+// labelFormats lists the known formats in the order help shows them;
+// labelFormatIndex maps lower-case names, and the inch sizes without "in",
+// to their position.
+var (
+	labelFormats = []LabelFormat{
+		{Name: "A4", Width: 210, Height: 297},
+		{Name: "A5", Width: 148, Height: 210},
+		{Name: "A6", Width: 105, Height: 148},
+		{Name: "Letter", Width: 215.9, Height: 279.4},
+		{Name: "4x6in", Width: 101.6, Height: 152.4, Thermal: true},
+		{Name: "4x4in", Width: 101.6, Height: 101.6, Thermal: true},
+		{Name: "4x8in", Width: 101.6, Height: 203.2, Thermal: true},
+		{Name: "100x150mm", Width: 100, Height: 150, Thermal: true},
+		{Name: "62x100mm", Width: 62, Height: 100, Thermal: true},
+	}
+	labelFormatIndex = make(map[string]int)
+)
+
+func init() {
+	for i, lf := range labelFormats {
+		key := strings.ToLower(lf.Name)
+		labelFormatIndex[key] = i
+		if short, ok := strings.CutSuffix(key, "in"); ok {
+			labelFormatIndex[short] = i
+		}
+	}
+}
+
+// RegisterLabelFormat adds a format, or replaces the one with the same name
+// (compared without regard to case), for every LabelFormatVar flag. Like
+// RegisterStructHandler it is meant to be called from init.
+func RegisterLabelFormat(lf LabelFormat) {
+	if lf.Name == "" || lf.Width <= 0 || lf.Height <= 0 {
+		panic(fmt.Sprintf("flag: invalid label format %+v", lf))
+	}
+	key := strings.ToLower(lf.Name)
+	if i, ok := labelFormatIndex[key]; ok && strings.EqualFold(labelFormats[i].Name, lf.Name) {
+		labelFormats[i] = lf
+		return
+	}
+	labelFormatIndex[key] = len(labelFormats)
+	labelFormats = append(labelFormats, lf)
+}
+
+// LookupLabelFormat returns the format named name, ignoring case; "4x6" is
+// accepted for "4x6in" and likewise for the other inch sizes.
+func LookupLabelFormat(name string) (LabelFormat, bool) {
+	i, ok := labelFormatIndex[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return LabelFormat{}, false
+	}
+	return labelFormats[i], true
+}
+
+// LabelFormats returns the names of the known formats.
+func LabelFormats() []string {
+	names := make([]string, len(labelFormats))
+	for i, lf := range labelFormats {
+		names[i] = lf.Name
+	}
+	return names
+}
+
+type labelFormatValue struct {
+	p *LabelFormat
+}
+
+func newLabelFormatValue(val LabelFormat, p *LabelFormat) *labelFormatValue {
+	*p = val
+	return &labelFormatValue{p: p}
+}
+func (lv *labelFormatValue) Set(s string) error {
+	lf, ok := LookupLabelFormat(s)
+	if !ok {
+		return fmt.Errorf("unknown label format %q (allowed: %s)", s, strings.Join(LabelFormats(), ", "))
+	}
+	*lv.p = lf
+	return nil
+}
+func (lv *labelFormatValue) String() string {
+	if lv.p == nil {
+		return ""
+	}
+	return lv.p.Name
+}
+
+// Get returns the LabelFormat, whose Width and Height give the physical size.
+func (lv *labelFormatValue) Get() interface{} { return *lv.p }
+
+func (lv *labelFormatValue) allowedValues() []string { return LabelFormats() }
+
+// LabelFormatVar registers a label format flag taking one of LabelFormats
+// (A4, A6, 4x6in thermal, ...) and storing its dimensions. value names the
+// default format; an unknown name panics, as it is a programming error.
+func (f *FlagSet) LabelFormatVar(p *LabelFormat, name string, value string, usage string) {
+	var def LabelFormat
+	if value != "" {
+		var ok bool
+		if def, ok = LookupLabelFormat(value); !ok {
+			panic(fmt.Sprintf("flag: unknown default label format %q for -%s", value, name))
+		}
+	}
+	f.Var(newLabelFormatValue(def, p), name, usage)
+}
+func LabelFormatVar(p *LabelFormat, name string, value string, usage string) {
+	CommandLine.LabelFormatVar(p, name, value, usage)
+}
+
+// LabelFormatFlag defines a label format flag and returns a pointer to it.
+func (f *FlagSet) LabelFormatFlag(name string, value string, usage string) *LabelFormat {
+	p := new(LabelFormat)
+	f.LabelFormatVar(p, name, value, usage)
+	return p
+}
+func LabelFormatFlag(name string, value string, usage string) *LabelFormat {
+	return CommandLine.LabelFormatFlag(name, value, usage)
+}
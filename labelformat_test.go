@@ -0,0 +1,86 @@
+package flag_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	. "github.com/machship/flag"
+)
+
+func TestLabelFormatFlag(t *testing.T) {
+	f := NewFlagSet("test", ContinueOnError)
+	lf := f.LabelFormatFlag("label", "A4", "label stock")
+	if lf.Name != "A4" || lf.Width != 210 || lf.Height != 297 || lf.Thermal {
+		t.Fatalf("default = %+v", *lf)
+	}
+	for in, want := range map[string]string{"a6": "A6", "4x6": "4x6in", " 4X6IN ": "4x6in", "letter": "Letter", "62x100mm": "62x100mm"} {
+		if err := f.Set("label", in); err != nil {
+			t.Errorf("Set(%q): %v", in, err)
+			continue
+		}
+		if lf.Name != want {
+			t.Errorf("Set(%q) = %q, want %q", in, lf.Name, want)
+		}
+	}
+
+	f.Set("label", "4x6")
+	got := f.Lookup("label").Value.(Getter).Get().(LabelFormat)
+	if got.Width != 101.6 || got.Height != 152.4 || !got.Thermal {
+		t.Fatalf("Get() = %+v", got)
+	}
+	if s := f.Lookup("label").Value.String(); s != "4x6in" {
+		t.Fatalf("String() = %q", s)
+	}
+
+	err := f.Set("label", "B5")
+	if err == nil || !strings.Contains(err.Error(), `unknown label format "B5" (allowed: A4, A5, A6, Letter, 4x6in`) {
+		t.Fatalf("Set(B5) err = %v", err)
+	}
+
+	name, _ := UnquoteUsage(f.Lookup("label"))
+	if !strings.HasPrefix(name, "A4|A5|A6|Letter|4x6in|") {
+		t.Fatalf("UnquoteUsage name = %q", name)
+	}
+	metas := f.Introspect()
+	if len(metas) != 1 || !strings.HasPrefix(metas[0].Enum, "A4,A5,A6,Letter,4x6in,") {
+		t.Fatalf("Introspect = %+v", metas)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("unknown default did not panic")
+		}
+	}()
+	f.LabelFormatFlag("other", "B5", "")
+}
+
+func TestRegisterLabelFormat(t *testing.T) {
+	RegisterLabelFormat(LabelFormat{Name: "DHL-103x199mm", Width: 103, Height: 199, Thermal: true})
+	f := NewFlagSet("test", ContinueOnError)
+	lf := f.LabelFormatFlag("label", "", "")
+	if err := f.Set("label", "dhl-103x199mm"); err != nil || lf.Width != 103 || lf.Height != 199 {
+		t.Fatalf("Set: %v, %+v", err, *lf)
+	}
+	names := LabelFormats()
+	if names[len(names)-1] != "DHL-103x199mm" {
+		t.Fatalf("LabelFormats() = %v", names)
+	}
+}
+
+func TestLabelFormatStructField(t *testing.T) {
+	ResetForTesting(nil)
+	saved := os.Args
+	os.Args = []string{"cmd", "-packing-slip", "a5"}
+	defer func() { os.Args = saved }()
+	var cfg struct {
+		Label       LabelFormat `flag:"label" default:"4x6in"`
+		PackingSlip LabelFormat `flag:"packing-slip" default:"A4"`
+	}
+	if err := ParseStruct(&cfg); err != nil {
+		t.Fatalf("ParseStruct: %v", err)
+	}
+	if cfg.Label.Name != "4x6in" || !cfg.Label.Thermal || cfg.PackingSlip.Name != "A5" || cfg.PackingSlip.Width != 148 {
+		t.Fatalf("got %+v", cfg)
+	}
+}
@@ -0,0 +1,47 @@
+package flag_test
+
+import (
+	"testing"
+
+	. "github.com/machship/flag"
+)
+
+func TestLayeredConfigFiles(t *testing.T) {
+	base := writeTempFile(t, "base.conf", "host base\nport 5432\nuser app\n")
+	prod := writeTempFile(t, "prod.yaml", "host: prod\nuser: prod-user\n")
+	local := writeTempFile(t, "local.conf", "user local\n")
+
+	tests := []struct {
+		args                         []string
+		envConfig, envUser           string
+		wantHost, wantPort, wantUser string
+	}{
+		{[]string{"-config", base}, "", "", "base", "5432", "app"},
+		{[]string{"-config", base, "-config", prod}, "", "", "prod", "5432", "prod-user"},
+		{[]string{"-config", base + "," + prod + ", " + local}, "", "", "prod", "5432", "local"},
+		{[]string{"-config", base + "," + prod, "-config", local, "-host=cli"}, "", "", "cli", "5432", "local"},
+		{nil, base + "," + prod, "", "prod", "5432", "prod-user"},
+		{[]string{"-config", base, "-config", prod}, "", "env-user", "prod", "5432", "env-user"},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			f := NewFlagSetWithEnvPrefix("test", "LAYERED", ContinueOnError)
+			f.String("config", "", "")
+			host := f.String("host", "", "")
+			port := f.String("port", "", "")
+			user := f.String("user", "", "")
+			if tt.envConfig != "" {
+				t.Setenv("LAYERED_CONFIG", tt.envConfig)
+			}
+			if tt.envUser != "" {
+				t.Setenv("LAYERED_USER", tt.envUser)
+			}
+			if err := f.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+			if *host != tt.wantHost || *port != tt.wantPort || *user != tt.wantUser {
+				t.Errorf("%q: host=%q port=%q user=%q, want %q %q %q", tt.args, *host, *port, *user, tt.wantHost, tt.wantPort, tt.wantUser)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package flag
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// lazyEnumValue is an enum whose allowed values come from a function, called
+// once when they are first needed.
+type lazyEnumValue struct {
+	p       *string
+	load    func() []string
+	once    sync.Once
+	allowed map[string]struct{}
+}
+
+func (ev *lazyEnumValue) resolve() map[string]struct{} {
+	ev.once.Do(func() {
+		ev.allowed = make(map[string]struct{})
+		for _, a := range ev.load() {
+			if a = strings.TrimSpace(a); a != "" {
+				ev.allowed[a] = struct{}{}
+			}
+		}
+	})
+	return ev.allowed
+}
+func (ev *lazyEnumValue) Set(s string) error {
+	allowed := ev.resolve()
+	if _, ok := allowed[s]; !ok {
+		return fmt.Errorf("invalid value %q (allowed: %s)", s, keys(allowed))
+	}
+	*ev.p = s
+	return nil
+}
+func (ev *lazyEnumValue) String() string {
+	if ev.p == nil {
+		return ""
+	}
+	return *ev.p
+}
+func (ev *lazyEnumValue) Get() interface{} { return *ev.p }
+
+func (ev *lazyEnumValue) allowedValues() []string {
+	allowed := ev.resolve()
+	out := make([]string, 0, len(allowed))
+	for a := range allowed {
+		out = append(out, a)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// EnumFromFuncVar is like EnumVar, but the allowed values come from allowed,
+// called once at the first Set or when Introspect lists them for
+// completion, never at definition or for the usage message. It suits large or updatable domains such
+// as a carrier catalog embedded in the binary. The default value is stored
+// as given.
+func (f *FlagSet) EnumFromFuncVar(p *string, name string, value string, allowed func() []string, usage string) {
+	*p = value
+	f.Var(&lazyEnumValue{p: p, load: allowed}, name, usage)
+}
+func EnumFromFuncVar(p *string, name string, value string, allowed func() []string, usage string) {
+	CommandLine.EnumFromFuncVar(p, name, value, allowed, usage)
+}
+
+// EnumFromFunc defines an enum flag with lazily loaded allowed values and
+// returns a pointer to it.
+func (f *FlagSet) EnumFromFunc(name string, value string, allowed func() []string, usage string) *string {
+	p := new(string)
+	f.EnumFromFuncVar(p, name, value, allowed, usage)
+	return p
+}
+func EnumFromFunc(name string, value string, allowed func() []string, usage string) *string {
+	return CommandLine.EnumFromFunc(name, value, allowed, usage)
+}
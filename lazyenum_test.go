@@ -0,0 +1,47 @@
+package flag_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/machship/flag"
+)
+
+func TestEnumFromFunc(t *testing.T) {
+	loads := 0
+	catalog := func() []string {
+		loads++
+		return []string{"TNT", "StarTrack", " Couriers Please ", "", "Toll"}
+	}
+	var out strings.Builder
+	f := NewFlagSet("test", ContinueOnError)
+	f.SetOutput(&out)
+	carrier := f.EnumFromFunc("carrier", "Toll", catalog, "carrier code")
+	f.PrintDefaults()
+	if loads != 0 || *carrier != "Toll" {
+		t.Fatalf("catalog loaded %d times before use, carrier %q", loads, *carrier)
+	}
+
+	if err := f.Parse([]string{"-carrier", "StarTrack"}); err != nil || *carrier != "StarTrack" {
+		t.Fatalf("Parse: %v, %q", err, *carrier)
+	}
+	if err := f.Set("carrier", "Couriers Please"); err != nil {
+		t.Fatalf("Set trimmed entry: %v", err)
+	}
+	err := f.Set("carrier", "DHL")
+	if err == nil || err.Error() != `invalid value "DHL" (allowed: Couriers Please,StarTrack,TNT,Toll)` {
+		t.Fatalf("Set(DHL) err = %v", err)
+	}
+	if m := f.Introspect(); m[0].Enum != "Couriers Please,StarTrack,TNT,Toll" {
+		t.Fatalf("Introspect enum = %q", m[0].Enum)
+	}
+	if loads != 1 {
+		t.Fatalf("catalog loaded %d times", loads)
+	}
+
+	g := NewFlagSet("test", ContinueOnError)
+	g.EnumFromFunc("carrier", "", catalog, "")
+	if m := g.Introspect(); !strings.Contains(m[0].Enum, "TNT") || loads != 2 {
+		t.Fatalf("completion did not load the catalog: %q, %d loads", m[0].Enum, loads)
+	}
+}
@@ -0,0 +1,140 @@
+package flag
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// listValue is implemented by the slice and map values, which can take their
+// elements one by one instead of as a single separator-joined string. Sources
+// that supply several values for one flag (repeated INI keys, APP_TAGS_0,
+// APP_TAGS_1, ...) use it so that elements containing the separator survive.
+type listValue interface {
+	Value
+	setElements(elems []string) error
+}
+
+func (sv *stringSliceValue) setElements(elems []string) error {
+	*sv.p = append((*sv.p)[:0], elems...)
+	return nil
+}
+
+func (dv *durationSliceValue) setElements(elems []string) error {
+	out := make([]time.Duration, 0, len(elems))
+	for _, e := range elems {
+		d, err := time.ParseDuration(strings.TrimSpace(e))
+		if err != nil {
+			return err
+		}
+		out = append(out, d)
+	}
+	*dv.p = out
+	return nil
+}
+
+func (tv *timeSliceValue) setElements(elems []string) error {
+	out := make([]time.Time, 0, len(elems))
+	for _, e := range elems {
+		e = strings.TrimSpace(e)
+		if e == "" {
+			continue
+		}
+		t, err := time.Parse(tv.layout, e)
+		if err != nil {
+			return err
+		}
+		out = append(out, t)
+	}
+	*tv.p = out
+	return nil
+}
+
+func (rv *regexpSliceValue) setElements(elems []string) error {
+	out, err := compileRegexpElements(elems)
+	if err != nil {
+		return err
+	}
+	*rv.p = out
+	return nil
+}
+
+func (mv *matcherValue) setElements(elems []string) error {
+	out, err := compileRegexpElements(elems)
+	if err != nil {
+		return err
+	}
+	*mv.p = out
+	return nil
+}
+
+func (mv *stringMapValue) setElements(elems []string) error {
+	m := make(map[string]string, len(elems))
+	for _, e := range elems {
+		kv := strings.SplitN(strings.TrimSpace(e), "=", 2)
+		if len(kv) != 2 {
+			return fmt.Errorf("invalid map entry %q", e)
+		}
+		m[kv[0]] = kv[1]
+	}
+	*mv.p = m
+	return nil
+}
+
+func compileRegexpElements(elems []string) ([]*regexp.Regexp, error) {
+	out := make([]*regexp.Regexp, 0, len(elems))
+	for i, e := range elems {
+		r, err := regexp.Compile(e)
+		if err != nil {
+			return nil, fmt.Errorf("regexp element %d %q: %v", i, e, err)
+		}
+		out = append(out, r)
+	}
+	return out, nil
+}
+
+// setListValues sets a list or map flag from separately supplied elements.
+// Each element gets its own @file expansion and value filter; kind names the
+// source in errors. The attempt is audited with the resulting value.
+func (f *FlagSet) setListValues(flag *Flag, elems []string, source Source, kind string) error {
+	lv := flag.Value.(listValue)
+	vals := make([]string, len(elems))
+	for i, v := range elems {
+		if expanded, err := expandAtFile(v); err == nil {
+			v = expanded
+		} else if !errors.Is(err, errNoAtExpansion) {
+			if f.isSensitive(flag.Name) {
+				return f.failf("invalid value for %s %s: %v", kind, flag.Name, err)
+			}
+			return f.failf("invalid value %q for %s %s: %v", v, kind, flag.Name, err)
+		}
+		if fn := f.valueFilters[flag.Name]; fn != nil {
+			filtered, err := fn(v, source)
+			if err != nil {
+				f.audit(flag, source, v, false, err)
+				return f.failf("invalid value for %s %s: %v", kind, flag.Name, err)
+			}
+			v = filtered
+		}
+		vals[i] = v
+	}
+	if err := lv.setElements(vals); err != nil {
+		f.audit(flag, source, strings.Join(vals, ","), false, err)
+		if f.isSensitive(flag.Name) {
+			return f.failf("invalid value for %s %s: %v", kind, flag.Name, err)
+		}
+		return f.failf("invalid value %q for %s %s: %v", vals, kind, flag.Name, err)
+	}
+	f.audit(flag, source, flag.Value.String(), true, nil)
+
+	if f.actual == nil {
+		f.actual = make(map[string]*Flag)
+	}
+	f.actual[flag.Name] = flag
+	if f.sources != nil {
+		f.sources[flag.Name] = string(source)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package flag
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+)
+
+// LogResolved emits one structured record per flag (name, value, source and
+// whether the value differs from its default) followed by a summary record.
+// Sensitive values are masked as in Introspect. A nil logger uses
+// slog.Default().
+func (f *FlagSet) LogResolved(logger *slog.Logger) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	ctx := context.Background()
+	bySource := make(map[string]int)
+	changedCount, setCount := 0, 0
+	for _, m := range f.Introspect() {
+		fl := f.formal[m.Name]
+		changed := fl.Value.String() != fl.DefValue
+		if changed {
+			changedCount++
+		}
+		if m.Set {
+			setCount++
+		}
+		bySource[m.Source]++
+		logger.LogAttrs(ctx, slog.LevelInfo, "flag resolved",
+			slog.String("name", m.Name),
+			slog.String("value", m.Value),
+			slog.String("source", m.Source),
+			slog.Bool("changed", changed),
+			slog.Bool("sensitive", m.Sensitive),
+		)
+	}
+	sources := make([]string, 0, len(bySource))
+	for s := range bySource {
+		sources = append(sources, s)
+	}
+	sort.Strings(sources)
+	counts := make([]any, 0, len(sources))
+	for _, s := range sources {
+		counts = append(counts, slog.Int(s, bySource[s]))
+	}
+	logger.LogAttrs(ctx, slog.LevelInfo, "flags resolved",
+		slog.Int("total", len(f.formal)),
+		slog.Int("set", setCount),
+		slog.Int("changed", changedCount),
+		slog.Group("sources", counts...),
+	)
+}
+
+// LogResolved logs the default CommandLine FlagSet's resolved values.
+func LogResolved(logger *slog.Logger) { CommandLine.LogResolved(logger) }
@@ -0,0 +1,56 @@
+package flag_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	. "github.com/machship/flag"
+)
+
+func TestLogResolved(t *testing.T) {
+	t.Setenv("LOGTEST_TOKEN", "s3cret")
+	f := NewFlagSetWithEnvPrefix("test", "LOGTEST", ContinueOnError)
+	f.String("host", "localhost", "")
+	f.Int("port", 80, "")
+	f.String("token", "", "")
+	f.MarkSensitive("token")
+	if err := f.Parse([]string{"-port", "8080"}); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	f.LogResolved(slog.New(slog.NewJSONHandler(&buf, nil)))
+	if strings.Contains(buf.String(), "s3cret") {
+		t.Fatalf("sensitive value leaked: %s", buf.String())
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("expected 3 flag records and a summary, got %d:\n%s", len(lines), buf.String())
+	}
+	var recs []map[string]any
+	for _, l := range lines {
+		var m map[string]any
+		if err := json.Unmarshal([]byte(l), &m); err != nil {
+			t.Fatal(err)
+		}
+		recs = append(recs, m)
+	}
+	if recs[0]["name"] != "host" || recs[0]["changed"] != false || recs[0]["source"] != "default" {
+		t.Fatalf("unexpected host record: %v", recs[0])
+	}
+	if recs[1]["name"] != "port" || recs[1]["value"] != "8080" || recs[1]["changed"] != true || recs[1]["source"] != "cli" {
+		t.Fatalf("unexpected port record: %v", recs[1])
+	}
+	if recs[2]["value"] != "******" || recs[2]["source"] != "env" {
+		t.Fatalf("unexpected token record: %v", recs[2])
+	}
+	sum := recs[3]
+	if sum["total"] != float64(3) || sum["set"] != float64(2) || sum["changed"] != float64(2) {
+		t.Fatalf("unexpected summary: %v", sum)
+	}
+	if src := sum["sources"].(map[string]any); src["cli"] != float64(1) || src["env"] != float64(1) || src["default"] != float64(1) {
+		t.Fatalf("unexpected source counts: %v", src)
+	}
+}
@@ -0,0 +1,109 @@
+package flag
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Format selects the output syntax of MarshalStruct.
+type Format int
+
+const (
+	// FormatConfig produces a config file readable by ParseFile (name=value lines).
+	FormatConfig Format = iota
+	// FormatEnv produces a .env style file (KEY=value lines) using the same
+	// environment keys ParseEnv consults, including CommandLine's prefix.
+	// Values containing spaces, quotes, '#' or '\' are double-quoted.
+	FormatEnv
+	// FormatArgs produces a single line of shell-quoted -name=value arguments.
+	FormatArgs
+)
+
+// MarshalStruct renders the current values of a ParseStruct-style struct in
+// the requested format, sorted by flag name, so that feeding the output back
+// through the matching source reproduces the values. Fields whose value
+// renders empty are omitted. Sensitive values are included verbatim; treat the
+// output accordingly. Values beginning with '@' are escaped as '@@', and values
+// beginning with "raw:" gain a second prefix, for the config and env formats,
+// which perform @file expansion.
+func MarshalStruct(s any, format Format) ([]byte, error) {
+	v := reflect.ValueOf(s)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("MarshalStruct expects a non-nil pointer to a struct, got %T", s)
+	}
+	cp := reflect.New(v.Elem().Type())
+	cloneStructValue(cp.Elem(), v.Elem())
+
+	scratch := NewFlagSet(CommandLine.name, ContinueOnError)
+	scratch.envPrefix = CommandLine.envPrefix
+	scratch.SetOutput(io.Discard)
+	if _, err := scratch.registerStructFields(cp.Elem(), ParseStructOptions{}); err != nil {
+		return nil, err
+	}
+	// registration applied default tags; restore the struct's actual values
+	cloneStructValue(cp.Elem(), v.Elem())
+
+	var buf bytes.Buffer
+	var args []string
+	for _, fl := range sortFlags(scratch.formal) {
+		val := fl.Value.String()
+		if val == "" {
+			continue
+		}
+		if format != FormatArgs && strings.ContainsAny(val, "\r\n") {
+			return nil, fmt.Errorf("flag -%s: value spans multiple lines and cannot be written in this format", fl.Name)
+		}
+		switch format {
+		case FormatConfig:
+			val = escapeSourceValue(val)
+			fmt.Fprintf(&buf, "%s=%s\n", fl.Name, val)
+		case FormatEnv:
+			val = escapeSourceValue(val)
+			if strings.ContainsAny(val, " \t\"'#\\") {
+				val = strconv.Quote(val)
+			}
+			fmt.Fprintf(&buf, "%s=%s\n", scratch.envKey(fl.Name), val)
+		case FormatArgs:
+			args = append(args, shellQuote("-"+fl.Name+"="+val))
+		default:
+			return nil, fmt.Errorf("unknown format %d", format)
+		}
+	}
+	if format == FormatArgs && len(args) > 0 {
+		buf.WriteString(strings.Join(args, " "))
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
+
+// shellQuote single-quotes s for POSIX shells when it contains anything beyond
+// a conservative set of safe characters.
+func shellQuote(s string) string {
+	safe := true
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_=.,:/+@%", r)) {
+			safe = false
+			break
+		}
+	}
+	if safe {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// escapeSourceValue protects a value written for the config or env formats
+// from being reinterpreted as an @file reference or raw: marker on read.
+func escapeSourceValue(val string) string {
+	switch {
+	case strings.HasPrefix(val, "@"):
+		return "@" + val
+	case strings.HasPrefix(val, rawValuePrefix):
+		return rawValuePrefix + val
+	}
+	return val
+}
@@ -0,0 +1,106 @@
+package flag_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/machship/flag"
+)
+
+type marshalConfig struct {
+	Host    string        `flag:"db-host" default:"localhost"`
+	Port    int           `flag:"port" default:"5432"`
+	Debug   bool          `flag:"debug"`
+	Timeout time.Duration `flag:"timeout" default:"5s"`
+	Motto   string        `flag:"motto"`
+	Handle  string        `flag:"handle"`
+	Empty   string        `flag:"empty"`
+	Tags    []string      `flag:"tags"`
+}
+
+func TestMarshalStruct_Formats(t *testing.T) {
+	ResetForTesting(nil)
+	c := marshalConfig{Host: "db.internal", Port: 6543, Debug: true, Timeout: 2 * time.Second,
+		Motto: "it's #1", Handle: "@ops", Tags: []string{"a", "b"}}
+
+	cfg, err := MarshalStruct(&c, FormatConfig)
+	if err != nil {
+		t.Fatalf("config: %v", err)
+	}
+	wantCfg := "db-host=db.internal\ndebug=true\nhandle=@@ops\nmotto=it's #1\nport=6543\ntags=a,b\ntimeout=2s\n"
+	if string(cfg) != wantCfg {
+		t.Fatalf("unexpected config output:\n%s", cfg)
+	}
+
+	env, err := MarshalStruct(&c, FormatEnv)
+	if err != nil {
+		t.Fatalf("env: %v", err)
+	}
+	if !strings.Contains(string(env), "DB_HOST=db.internal\n") || !strings.Contains(string(env), `MOTTO="it's #1"`) {
+		t.Fatalf("unexpected env output:\n%s", env)
+	}
+
+	args, err := MarshalStruct(&c, FormatArgs)
+	if err != nil {
+		t.Fatalf("args: %v", err)
+	}
+	if !strings.Contains(string(args), `'-motto=it'\''s #1'`) || !strings.HasPrefix(string(args), "-db-host=db.internal -debug=true") {
+		t.Fatalf("unexpected args output: %s", args)
+	}
+	if c.Host != "db.internal" || c.Port != 6543 {
+		t.Fatalf("MarshalStruct must not modify its input: %+v", c)
+	}
+	if CommandLine.Lookup("db-host") != nil {
+		t.Fatalf("MarshalStruct must not register flags")
+	}
+}
+
+func TestMarshalStruct_Concurrent(t *testing.T) {
+	ResetForTesting(nil)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 50; i++ {
+			if _, err := MarshalStruct(&marshalConfig{Port: i}, FormatConfig); err != nil {
+				t.Errorf("marshal: %v", err)
+				return
+			}
+		}
+	}()
+	for i := 0; i < 50; i++ {
+		String(fmt.Sprintf("app-%d", i), "", "")
+	}
+	<-done
+	if n := len(CommandLine.Introspect()); n != 50 {
+		t.Fatalf("CommandLine has %d flags, want 50", n)
+	}
+}
+
+func TestMarshalStruct_ConfigRoundTrip(t *testing.T) {
+	src := marshalConfig{Host: "h", Port: 1, Motto: "a b", Handle: "@x", Timeout: time.Minute}
+	out, err := MarshalStruct(&src, FormatConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "golden.conf")
+	if err := os.WriteFile(path, out, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f := NewFlagSet("test", ContinueOnError)
+	host := f.String("db-host", "", "")
+	motto := f.String("motto", "", "")
+	handle := f.String("handle", "", "")
+	timeout := f.Duration("timeout", 0, "")
+	f.Int("port", 0, "")
+	f.Bool("debug", false, "")
+	if err := f.ParseFile(path); err != nil {
+		t.Fatalf("parse file: %v", err)
+	}
+	if *host != "h" || *motto != "a b" || *handle != "@x" || *timeout != time.Minute {
+		t.Fatalf("round trip mismatch: %q %q %q %v", *host, *motto, *handle, *timeout)
+	}
+}
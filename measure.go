@@ -0,0 +1,236 @@
+package flag
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// WeightUnit names a unit of mass accepted by WeightVar.
+type WeightUnit string
+
+// Weight units. Pounds and ounces are avoirdupois.
+const (
+	Milligrams WeightUnit = "mg"
+	Grams      WeightUnit = "g"
+	Kilograms  WeightUnit = "kg"
+	Tonnes     WeightUnit = "t"
+	Ounces     WeightUnit = "oz"
+	Pounds     WeightUnit = "lb"
+)
+
+// LengthUnit names a unit of length accepted by LengthVar.
+type LengthUnit string
+
+// Length units.
+const (
+	Millimetres LengthUnit = "mm"
+	Centimetres LengthUnit = "cm"
+	Metres      LengthUnit = "m"
+	Kilometres  LengthUnit = "km"
+	Inches      LengthUnit = "in"
+	Feet        LengthUnit = "ft"
+	Yards       LengthUnit = "yd"
+)
+
+// gramsPer and millimetresPer give the size of each unit in the base unit;
+// the extra keys are accepted spellings.
+var gramsPer = map[string]float64{
+	"mg": 0.001, "g": 1, "kg": 1000, "t": 1e6,
+	"oz": 28.349523125, "lb": 453.59237, "lbs": 453.59237,
+}
+
+var millimetresPer = map[string]float64{
+	"mm": 1, "cm": 10, "m": 1000, "km": 1e6,
+	"in": 25.4, "ft": 304.8, "yd": 914.4,
+}
+
+// parseMeasure parses "<number>[ ]<unit>" and returns it expressed in unit.
+// A bare number is taken to be in unit already.
+func parseMeasure(s, kind string, factors map[string]float64, unit string) (float64, error) {
+	t := strings.TrimSpace(s)
+	i := strings.LastIndexAny(t, "0123456789.") + 1
+	num, u := strings.TrimSpace(t[:i]), strings.ToLower(strings.TrimSpace(t[i:]))
+	v, err := strconv.ParseFloat(num, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q", kind, s)
+	}
+	if u == "" {
+		return v, nil
+	}
+	from, ok := factors[u]
+	if !ok {
+		return 0, fmt.Errorf("invalid %s %q: unknown unit %q", kind, s, u)
+	}
+	return v * from / factors[unit], nil
+}
+
+// measureValue is a float64 quantity held in a canonical unit.
+type measureValue struct {
+	p       *float64
+	kind    string // "weight" or "length"
+	unit    string
+	factors map[string]float64
+}
+
+func newMeasureValue(val float64, kind, unit string, factors map[string]float64, p *float64) *measureValue {
+	if _, ok := factors[unit]; !ok {
+		panic(fmt.Sprintf("flag: unknown %s unit %q", kind, unit))
+	}
+	*p = val
+	return &measureValue{p: p, kind: kind, unit: unit, factors: factors}
+}
+func (mv *measureValue) Set(s string) error {
+	v, err := parseMeasure(s, mv.kind, mv.factors, mv.unit)
+	if err != nil {
+		return err
+	}
+	if v < 0 {
+		return fmt.Errorf("invalid %s %q: must not be negative", mv.kind, s)
+	}
+	*mv.p = v
+	return nil
+}
+func (mv *measureValue) String() string {
+	if mv.p == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*mv.p, 'f', -1, 64) + mv.unit
+}
+func (mv *measureValue) Get() interface{} { return *mv.p }
+
+// WeightVar registers a weight flag stored in unit (Grams if empty). Input may
+// use any WeightUnit, e.g. "25kg" or "55 lb"; a bare number is in unit.
+func (f *FlagSet) WeightVar(p *float64, name string, value float64, unit WeightUnit, usage string) {
+	if unit == "" {
+		unit = Grams
+	}
+	f.Var(newMeasureValue(value, "weight", string(unit), gramsPer, p), name, usage)
+}
+func WeightVar(p *float64, name string, value float64, unit WeightUnit, usage string) {
+	CommandLine.WeightVar(p, name, value, unit, usage)
+}
+
+// Weight defines a weight flag and returns a pointer to it.
+func (f *FlagSet) Weight(name string, value float64, unit WeightUnit, usage string) *float64 {
+	p := new(float64)
+	f.WeightVar(p, name, value, unit, usage)
+	return p
+}
+func Weight(name string, value float64, unit WeightUnit, usage string) *float64 {
+	return CommandLine.Weight(name, value, unit, usage)
+}
+
+// LengthVar registers a length flag stored in unit (Millimetres if empty).
+// Input may use any LengthUnit, e.g. "1.2m", "45cm" or "12in"; a bare number
+// is in unit.
+func (f *FlagSet) LengthVar(p *float64, name string, value float64, unit LengthUnit, usage string) {
+	if unit == "" {
+		unit = Millimetres
+	}
+	f.Var(newMeasureValue(value, "length", string(unit), millimetresPer, p), name, usage)
+}
+func LengthVar(p *float64, name string, value float64, unit LengthUnit, usage string) {
+	CommandLine.LengthVar(p, name, value, unit, usage)
+}
+
+// Length defines a length flag and returns a pointer to it.
+func (f *FlagSet) Length(name string, value float64, unit LengthUnit, usage string) *float64 {
+	p := new(float64)
+	f.LengthVar(p, name, value, unit, usage)
+	return p
+}
+func Length(name string, value float64, unit LengthUnit, usage string) *float64 {
+	return CommandLine.Length(name, value, unit, usage)
+}
+
+// Dimensions is a length × width × height triple in millimetres.
+type Dimensions struct {
+	Length, Width, Height float64
+}
+
+// ParseDimensions parses "LxWxH" followed by a length unit that applies to
+// every side, e.g. "120x80x100cm"; individual sides may carry their own unit
+// ("1.2mx80cmx1m"). Without any unit the sides are in millimetres. All sides
+// must be positive.
+func ParseDimensions(s string) (Dimensions, error) {
+	parts := strings.Split(strings.ReplaceAll(strings.ToLower(strings.TrimSpace(s)), "×", "x"), "x")
+	if len(parts) != 3 || slices.ContainsFunc(parts, func(p string) bool { return strings.TrimSpace(p) == "" }) {
+		return Dimensions{}, fmt.Errorf("invalid dimensions %q: want LxWxH[unit]", s)
+	}
+	last := strings.TrimSpace(parts[2])
+	unit := strings.TrimSpace(last[strings.LastIndexAny(last, "0123456789.")+1:])
+	var sides [3]float64
+	for i, p := range parts {
+		p = strings.TrimSpace(p)
+		if p != "" && strings.IndexAny(p[len(p)-1:], "0123456789.") == 0 {
+			p += unit
+		}
+		v, err := parseMeasure(p, "dimension", millimetresPer, string(Millimetres))
+		if err != nil {
+			return Dimensions{}, fmt.Errorf("invalid dimensions %q: %v", s, err)
+		}
+		if v <= 0 {
+			return Dimensions{}, fmt.Errorf("invalid dimensions %q: sides must be positive", s)
+		}
+		sides[i] = v
+	}
+	return Dimensions{Length: sides[0], Width: sides[1], Height: sides[2]}, nil
+}
+
+// IsZero reports whether no dimensions are set.
+func (d Dimensions) IsZero() bool { return d == Dimensions{} }
+
+// CubicMetres returns the volume in m³.
+func (d Dimensions) CubicMetres() float64 { return d.Length * d.Width * d.Height / 1e9 }
+
+// String returns the dimensions as "LxWxHmm", or "" when unset.
+func (d Dimensions) String() string {
+	if d.IsZero() {
+		return ""
+	}
+	f := func(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+	return f(d.Length) + "x" + f(d.Width) + "x" + f(d.Height) + "mm"
+}
+
+type dimensionsValue struct{ p *Dimensions }
+
+func newDimensionsValue(val Dimensions, p *Dimensions) *dimensionsValue {
+	*p = val
+	return &dimensionsValue{p: p}
+}
+func (dv *dimensionsValue) Set(s string) error {
+	d, err := ParseDimensions(s)
+	if err != nil {
+		return err
+	}
+	*dv.p = d
+	return nil
+}
+func (dv *dimensionsValue) String() string {
+	if dv.p == nil {
+		return ""
+	}
+	return dv.p.String()
+}
+func (dv *dimensionsValue) Get() interface{} { return *dv.p }
+
+// DimensionsVar registers a length × width × height flag such as
+// "120x80x100cm", stored in millimetres.
+func (f *FlagSet) DimensionsVar(p *Dimensions, name string, value Dimensions, usage string) {
+	f.Var(newDimensionsValue(value, p), name, usage)
+}
+func DimensionsVar(p *Dimensions, name string, value Dimensions, usage string) {
+	CommandLine.DimensionsVar(p, name, value, usage)
+}
+
+// DimensionsFlag defines a Dimensions flag and returns a pointer to it.
+func (f *FlagSet) DimensionsFlag(name string, value Dimensions, usage string) *Dimensions {
+	p := new(Dimensions)
+	f.DimensionsVar(p, name, value, usage)
+	return p
+}
+func DimensionsFlag(name string, value Dimensions, usage string) *Dimensions {
+	return CommandLine.DimensionsFlag(name, value, usage)
+}
@@ -0,0 +1,71 @@
+package flag_test
+
+import (
+	"math"
+	"os"
+	"strings"
+	"testing"
+
+	. "github.com/machship/flag"
+)
+
+func approx(a, b float64) bool { return math.Abs(a-b) < 1e-9*math.Max(1, math.Abs(b)) }
+
+func TestWeightFlag(t *testing.T) {
+	f := NewFlagSet("test", ContinueOnError)
+	w := f.Weight("weight", 0, "", "")
+	kg := f.Weight("max", 0, Kilograms, "")
+	for in, want := range map[string]float64{"25kg": 25000, "55lb": 24947.58035, "8 oz": 226.796185, "1.5t": 1.5e6, "500": 500, "2KG": 2000} {
+		if err := f.Set("weight", in); err != nil {
+			t.Fatalf("%q: %v", in, err)
+		}
+		if !approx(*w, want) {
+			t.Errorf("%q = %vg, want %v", in, *w, want)
+		}
+	}
+	if err := f.Set("max", "2500g"); err != nil || *kg != 2.5 {
+		t.Fatalf("max = %v, err %v", *kg, err)
+	}
+	if got := f.Lookup("max").Value.String(); got != "2.5kg" {
+		t.Errorf("String() = %q", got)
+	}
+	for in, want := range map[string]string{"5 stone": `unknown unit "stone"`, "kg": "invalid weight", "-1kg": "must not be negative"} {
+		if err := f.Set("weight", in); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%q: expected %q, got %v", in, want, err)
+		}
+	}
+}
+
+func TestLengthFlag(t *testing.T) {
+	f := NewFlagSet("test", ContinueOnError)
+	l := f.Length("length", 0, "", "")
+	m := f.Length("reach", 0, Metres, "")
+	for in, want := range map[string]float64{"1.2m": 1200, "45cm": 450, "12in": 304.8, "3ft": 914.4, "80": 80} {
+		if err := f.Set("length", in); err != nil {
+			t.Fatalf("%q: %v", in, err)
+		}
+		if !approx(*l, want) {
+			t.Errorf("%q = %vmm, want %v", in, *l, want)
+		}
+	}
+	if err := f.Set("reach", "250cm"); err != nil || !approx(*m, 2.5) {
+		t.Fatalf("reach = %v, err %v", *m, err)
+	}
+}
+
+func TestMeasureStructTags(t *testing.T) {
+	ResetForTesting(nil)
+	saved := os.Args
+	os.Args = []string{"cmd", "-max-weight", "40lb"}
+	defer func() { os.Args = saved }()
+	var cfg struct {
+		MaxWeight float64 `flag:"max-weight" weight:"kg" default:"22kg"`
+		MaxLength float64 `flag:"max-length" length:"mm" default:"1.2m"`
+	}
+	if err := ParseStruct(&cfg); err != nil {
+		t.Fatalf("ParseStruct: %v", err)
+	}
+	if !approx(cfg.MaxWeight, 18.1436948) || !approx(cfg.MaxLength, 1200) {
+		t.Fatalf("got %+v", cfg)
+	}
+}
@@ -0,0 +1,152 @@
+package flag
+
+import (
+	"fmt"
+	"mime"
+	"strings"
+)
+
+// mimeTopLevelTypes are the IANA registered top-level media types.
+var mimeTopLevelTypes = map[string]bool{
+	"application": true, "audio": true, "example": true, "font": true, "haptics": true,
+	"image": true, "message": true, "model": true, "multipart": true, "text": true, "video": true,
+}
+
+// normalizeMIMEType checks s is a type/subtype media type with an IANA
+// registered top-level type, optionally with parameters, and returns it in
+// canonical form: lower-case type and parameter names, parameters sorted.
+// "image/*" and "*/*" are accepted for matching.
+func normalizeMIMEType(s string) (string, error) {
+	mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(s))
+	if err != nil {
+		return "", fmt.Errorf("invalid MIME type %q: %v", s, err)
+	}
+	top, sub, ok := strings.Cut(mediaType, "/")
+	if !ok || sub == "" {
+		return "", fmt.Errorf("invalid MIME type %q: expected type/subtype", s)
+	}
+	if !mimeTopLevelTypes[top] && !(top == "*" && sub == "*") {
+		return "", fmt.Errorf("invalid MIME type %q: unknown top-level type %q", s, top)
+	}
+	if strings.Contains(sub, "*") && sub != "*" {
+		return "", fmt.Errorf("invalid MIME type %q: wildcard must be the whole subtype", s)
+	}
+	return mime.FormatMediaType(mediaType, params), nil
+}
+
+type mimeTypeValue struct {
+	p *string
+}
+
+func newMIMETypeValue(val string, p *string) *mimeTypeValue {
+	*p = val
+	return &mimeTypeValue{p: p}
+}
+func (mv *mimeTypeValue) Set(s string) error {
+	t, err := normalizeMIMEType(s)
+	if err != nil {
+		return err
+	}
+	*mv.p = t
+	return nil
+}
+func (mv *mimeTypeValue) String() string {
+	if mv.p == nil {
+		return ""
+	}
+	return *mv.p
+}
+func (mv *mimeTypeValue) Get() interface{} { return *mv.p }
+
+// MIMETypeVar registers a media type flag such as "image/png" or
+// "text/csv; charset=utf-8". The top-level type must be one registered with
+// IANA; the value is stored in the canonical form of mime.FormatMediaType.
+// The default value is stored as given.
+func (f *FlagSet) MIMETypeVar(p *string, name string, value string, usage string) {
+	f.Var(newMIMETypeValue(value, p), name, usage)
+}
+func MIMETypeVar(p *string, name string, value string, usage string) {
+	CommandLine.MIMETypeVar(p, name, value, usage)
+}
+
+// MIMEType defines a media type flag and returns a pointer to it.
+func (f *FlagSet) MIMEType(name string, value string, usage string) *string {
+	p := new(string)
+	f.MIMETypeVar(p, name, value, usage)
+	return p
+}
+func MIMEType(name string, value string, usage string) *string {
+	return CommandLine.MIMEType(name, value, usage)
+}
+
+// parseExtList normalizes file extensions to lower case with a leading dot
+// ("JPG" and ".jpg" are both ".jpg"), dropping empty elements and repeats.
+func parseExtList(elems []string) ([]string, error) {
+	out := make([]string, 0, len(elems))
+	seen := make(map[string]bool)
+	for _, e := range elems {
+		e = strings.ToLower(strings.TrimSpace(e))
+		if e == "" {
+			continue
+		}
+		if !strings.HasPrefix(e, ".") {
+			e = "." + e
+		}
+		if e == "." || strings.Contains(e, "..") || strings.ContainsAny(e, `/\*?: `) {
+			return nil, fmt.Errorf("invalid file extension %q", strings.TrimSpace(e))
+		}
+		if !seen[e] {
+			seen[e] = true
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+type extListValue struct {
+	p *[]string
+}
+
+func newExtListValue(val []string, p *[]string) *extListValue {
+	*p = append((*p)[:0], val...)
+	return &extListValue{p: p}
+}
+func (ev *extListValue) Set(s string) error {
+	return ev.setElements(strings.Split(s, ","))
+}
+func (ev *extListValue) setElements(elems []string) error {
+	exts, err := parseExtList(elems)
+	if err != nil {
+		return err
+	}
+	*ev.p = exts
+	return nil
+}
+func (ev *extListValue) String() string {
+	if ev.p == nil {
+		return ""
+	}
+	return strings.Join(*ev.p, ",")
+}
+func (ev *extListValue) Get() interface{} { return *ev.p }
+
+// ExtListVar registers a comma-separated file extension list flag such as
+// "jpg,.PNG,tar.gz", stored as [".jpg" ".png" ".tar.gz"]: lower case, dot
+// prefixed and without repeats, ready to match against lower-cased file
+// names with strings.HasSuffix. The default value is stored as given.
+func (f *FlagSet) ExtListVar(p *[]string, name string, value []string, usage string) {
+	f.Var(newExtListValue(value, p), name, usage)
+}
+func ExtListVar(p *[]string, name string, value []string, usage string) {
+	CommandLine.ExtListVar(p, name, value, usage)
+}
+
+// ExtList defines a file extension list flag and returns a pointer to it.
+func (f *FlagSet) ExtList(name string, value []string, usage string) *[]string {
+	p := new([]string)
+	f.ExtListVar(p, name, value, usage)
+	return p
+}
+func ExtList(name string, value []string, usage string) *[]string {
+	return CommandLine.ExtList(name, value, usage)
+}
@@ -0,0 +1,81 @@
+package flag_test
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	. "github.com/machship/flag"
+)
+
+func TestMIMETypeFlag(t *testing.T) {
+	f := NewFlagSet("test", ContinueOnError)
+	mt := f.MIMEType("type", "application/octet-stream", "")
+	cases := map[string]string{
+		"image/png":                        "image/png",
+		"Text/CSV; Charset=utf-8":          "text/csv; charset=utf-8",
+		"application/vnd.api+json":         "application/vnd.api+json",
+		"multipart/form-data; boundary=x1": "multipart/form-data; boundary=x1",
+		"image/*":                          "image/*",
+		"*/*":                              "*/*",
+	}
+	for in, want := range cases {
+		if err := f.Set("type", in); err != nil {
+			t.Errorf("Set(%q): %v", in, err)
+			continue
+		}
+		if *mt != want {
+			t.Errorf("Set(%q) = %q, want %q", in, *mt, want)
+		}
+	}
+	errs := map[string]string{
+		"png":            "expected type/subtype",
+		"images/png":     "unknown top-level type \"images\"",
+		"*/png":          "unknown top-level type \"*\"",
+		"image/pn*":      "wildcard must be the whole subtype",
+		"image/png; =x":  "invalid MIME type",
+		"text/plain foo": "invalid MIME type",
+	}
+	for in, want := range errs {
+		if err := f.Set("type", in); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Set(%q): expected %q, got %v", in, want, err)
+		}
+	}
+}
+
+func TestExtListFlag(t *testing.T) {
+	f := NewFlagSet("test", ContinueOnError)
+	exts := f.ExtList("ext", []string{".csv"}, "")
+	if err := f.Parse([]string{"-ext", "JPG, .jpeg,.jpg,,tar.GZ"}); err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if want := []string{".jpg", ".jpeg", ".tar.gz"}; !reflect.DeepEqual(*exts, want) {
+		t.Fatalf("exts = %q, want %q", *exts, want)
+	}
+	for _, in := range []string{".", "..jpg", "a/b", "*.png", "j pg"} {
+		if err := f.Set("ext", in); err == nil || !strings.Contains(err.Error(), "invalid file extension") {
+			t.Errorf("Set(%q) err = %v", in, err)
+		}
+	}
+}
+
+func TestMIMETypeAndExtListStructTags(t *testing.T) {
+	ResetForTesting(nil)
+	saved := os.Args
+	os.Args = []string{"cmd", "-accept", "IMAGE/PNG", "-exts", "PNG,png,gif"}
+	defer func() { os.Args = saved }()
+	var cfg struct {
+		Accept string   `flag:"accept" mime:"true"`
+		Export string   `flag:"export" mime:"true" default:"Text/CSV"`
+		Exts   []string `flag:"exts" ext:"true"`
+		Skip   []string `flag:"skip" ext:"true" default:"TMP, bak"`
+	}
+	if err := ParseStruct(&cfg); err != nil {
+		t.Fatalf("ParseStruct: %v", err)
+	}
+	if cfg.Accept != "image/png" || cfg.Export != "text/csv" ||
+		!reflect.DeepEqual(cfg.Exts, []string{".png", ".gif"}) || !reflect.DeepEqual(cfg.Skip, []string{".tmp", ".bak"}) {
+		t.Fatalf("got %+v", cfg)
+	}
+}
@@ -0,0 +1,74 @@
+package flag_test
+
+import (
+	"os"
+	"testing"
+
+	. "github.com/machship/flag"
+)
+
+type prefixDBConfig struct {
+	Host    string `flag:"host" default:"localhost"`
+	Port    int    `flag:"port" default:"5432"`
+	Replica struct {
+		Host string `flag:"host"`
+	} `flagPrefix:"replica"`
+}
+
+type prefixApp struct {
+	Name  string         `flag:"name"`
+	DB    prefixDBConfig `flagPrefix:"db" envPrefix:"DATABASE"`
+	Cache struct {
+		Peers []string `flag:"peers"`
+	} `flagPrefix:"cache"`
+}
+
+func TestNestedFlagPrefix(t *testing.T) {
+	ResetForTesting(nil)
+	var c prefixApp
+	old := os.Args
+	os.Args = []string{"cmd", "-db.port", "6432", "-cache.peers", "a,b"}
+	defer func() { os.Args = old }()
+	if err := ParseStruct(&c); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"name", "db.host", "db.port", "db.replica.host", "cache.peers"} {
+		if Lookup(name) == nil {
+			t.Errorf("flag -%s not registered", name)
+		}
+	}
+	if c.DB.Port != 6432 || c.DB.Host != "localhost" || len(c.Cache.Peers) != 2 {
+		t.Errorf("unexpected values: %+v", c)
+	}
+
+	var out prefixApp
+	if err := Unmarshal(&out); err != nil {
+		t.Fatal(err)
+	}
+	if out.DB.Port != 6432 || len(out.Cache.Peers) != 2 {
+		t.Errorf("Unmarshal: %+v", out)
+	}
+}
+
+func TestNestedEnvPrefix(t *testing.T) {
+	ResetForTesting(nil)
+	CommandLine = NewFlagSetWithEnvPrefix("cmd", "APP", ContinueOnError)
+	t.Setenv("APP_NAME", "billing")
+	t.Setenv("DATABASE_HOST", "db.internal")
+	t.Setenv("DATABASE_REPLICA_HOST", "replica.internal")
+	t.Setenv("APP_DB.PORT", "1111") // ignored: db.* reads DATABASE_*
+	var c prefixApp
+	old := os.Args
+	os.Args = []string{"cmd"}
+	defer func() { os.Args = old }()
+	if err := ParseStruct(&c); err != nil {
+		t.Fatal(err)
+	}
+	if c.Name != "billing" || c.DB.Host != "db.internal" || c.DB.Replica.Host != "replica.internal" || c.DB.Port != 5432 {
+		t.Errorf("unexpected values: %+v", c)
+	}
+	keys := EnvKeys()
+	if keys["db.port"] != "DATABASE_PORT" || keys["cache.peers"] != "APP_CACHE.PEERS" {
+		t.Errorf("EnvKeys() = %v", keys)
+	}
+}
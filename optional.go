@@ -0,0 +1,217 @@
+package flag
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// Optional holds a value that is only meaningful when some source (command
+// line, environment, secret, config or Set) supplied it. The zero Optional is
+// unset, which lets callers tell "explicitly false/0/empty" apart from "not
+// provided" and only override behavior when the operator asked for it.
+type Optional[T any] struct {
+	value T
+	set   bool
+}
+
+// Some returns an Optional holding v.
+func Some[T any](v T) Optional[T] { return Optional[T]{value: v, set: true} }
+
+// Get returns the value and whether it was provided.
+func (o Optional[T]) Get() (T, bool) { return o.value, o.set }
+
+// IsSet reports whether a value was provided.
+func (o Optional[T]) IsSet() bool { return o.set }
+
+// OrElse returns the value if provided, otherwise def.
+func (o Optional[T]) OrElse(def T) T {
+	if o.set {
+		return o.value
+	}
+	return def
+}
+
+// Ptr returns a pointer to a copy of the value, or nil when unset.
+func (o Optional[T]) Ptr() *T {
+	if !o.set {
+		return nil
+	}
+	v := o.value
+	return &v
+}
+
+// String formats the value, or returns "" when unset.
+func (o Optional[T]) String() string {
+	if !o.set {
+		return ""
+	}
+	return fmt.Sprint(o.value)
+}
+
+type optionalValue[T any] struct {
+	p     *Optional[T]
+	parse func(string) (T, error)
+}
+
+func (ov *optionalValue[T]) Set(s string) error {
+	v, err := ov.parse(s)
+	if err != nil {
+		return err
+	}
+	*ov.p = Some(v)
+	return nil
+}
+func (ov *optionalValue[T]) String() string {
+	if ov.p == nil {
+		return ""
+	}
+	return ov.p.String()
+}
+func (ov *optionalValue[T]) Get() interface{} { return *ov.p }
+
+// TypeName names the argument in usage output.
+func (ov *optionalValue[T]) TypeName() string {
+	var zero T
+	switch any(zero).(type) {
+	case time.Duration:
+		return "duration"
+	case float64:
+		return "float"
+	}
+	return reflect.TypeOf(zero).String()
+}
+
+// optionalBoolValue lets -flag on its own mean true, like a plain bool flag.
+type optionalBoolValue struct{ optionalValue[bool] }
+
+func (ob *optionalBoolValue) IsBoolFlag() bool { return true }
+func (ob *optionalBoolValue) TypeName() string { return "" }
+
+// OptionalVar registers a flag on f whose value is parsed by parse and stays
+// unset until a source provides it. p is reset to unset.
+func OptionalVar[T any](f *FlagSet, p *Optional[T], name string, parse func(string) (T, error), usage string) {
+	*p = Optional[T]{}
+	f.Var(&optionalValue[T]{p: p, parse: parse}, name, usage)
+}
+
+// OptionalBoolVar registers a tri-state boolean flag: unset, true or false.
+// As with BoolVar, -name alone means true.
+func (f *FlagSet) OptionalBoolVar(p *Optional[bool], name string, usage string) {
+	*p = Optional[bool]{}
+	f.Var(&optionalBoolValue{optionalValue[bool]{p: p, parse: strconv.ParseBool}}, name, usage)
+}
+func OptionalBoolVar(p *Optional[bool], name string, usage string) {
+	CommandLine.OptionalBoolVar(p, name, usage)
+}
+
+// OptionalBool defines a tri-state boolean flag and returns a pointer to it.
+func (f *FlagSet) OptionalBool(name string, usage string) *Optional[bool] {
+	p := new(Optional[bool])
+	f.OptionalBoolVar(p, name, usage)
+	return p
+}
+func OptionalBool(name string, usage string) *Optional[bool] {
+	return CommandLine.OptionalBool(name, usage)
+}
+
+// registerOptionalHandler lets ParseStruct register Optional[T] fields using
+// parse. Optional fields are unset by definition, so a default tag is rejected.
+func registerOptionalHandler[T any](parse func(string) (T, error)) {
+	registerBuiltinStructHandler(reflect.TypeOf(Optional[T]{}), func(ctx *StructFieldContext) (bool, error) {
+		if ctx.DefaultTag != "" {
+			return true, fmt.Errorf("optional field cannot declare a default (got %q)", ctx.DefaultTag)
+		}
+		fs := ctx.flagSet()
+		p := ctx.Value.Addr().Interface().(*Optional[T])
+		if bp, ok := any(p).(*Optional[bool]); ok {
+			fs.OptionalBoolVar(bp, ctx.FlagName, ctx.Help)
+		} else {
+			OptionalVar(fs, p, ctx.FlagName, parse, ctx.Help)
+		}
+		return true, nil
+	})
+}
+
+// pointerValue backs a pointer scalar struct field: the pointer stays nil until
+// a source sets the flag, then points at the parsed value.
+type pointerValue[T any] struct {
+	p     **T
+	parse func(string) (T, error)
+}
+
+func (pv *pointerValue[T]) Set(s string) error {
+	v, err := pv.parse(s)
+	if err != nil {
+		return err
+	}
+	*pv.p = &v
+	return nil
+}
+func (pv *pointerValue[T]) String() string {
+	if pv.p == nil || *pv.p == nil {
+		return ""
+	}
+	return fmt.Sprint(**pv.p)
+}
+
+// Get returns a copy of the value, so a caller such as Unmarshal cannot write
+// through to the registered field.
+func (pv *pointerValue[T]) Get() interface{} {
+	if *pv.p == nil {
+		return *pv.p
+	}
+	v := **pv.p
+	return &v
+}
+
+// TypeName names the argument in usage output.
+func (pv *pointerValue[T]) TypeName() string {
+	return (&optionalValue[T]{}).TypeName()
+}
+
+type pointerBoolValue struct{ pointerValue[bool] }
+
+func (pb *pointerBoolValue) IsBoolFlag() bool { return true }
+func (pb *pointerBoolValue) TypeName() string { return "" }
+
+// registerPointerHandler lets ParseStruct register *T fields using parse. The
+// field is left nil unless a default tag or some source provides a value.
+func registerPointerHandler[T any](parse func(string) (T, error)) {
+	registerBuiltinStructHandler(reflect.TypeOf((*T)(nil)), func(ctx *StructFieldContext) (bool, error) {
+		p := ctx.Value.Addr().Interface().(**T)
+		if ctx.Required {
+			*p = nil
+		} else if ctx.DefaultTag != "" {
+			v, err := parse(ctx.DefaultTag)
+			if err != nil {
+				return true, fmt.Errorf("invalid default %s %q: %v", reflect.TypeOf(v), ctx.DefaultTag, err)
+			}
+			*p = &v
+		}
+		var value Value = &pointerValue[T]{p: p, parse: parse}
+		if bp, ok := any(p).(**bool); ok {
+			value = &pointerBoolValue{pointerValue[bool]{p: bp, parse: strconv.ParseBool}}
+		}
+		ctx.Register(value)
+		return true, nil
+	})
+}
+
+// registerScalarHandlers adds the Optional[T] and *T struct field handlers.
+func registerScalarHandlers[T any](parse func(string) (T, error)) {
+	registerOptionalHandler(parse)
+	registerPointerHandler(parse)
+}
+
+func init() {
+	registerScalarHandlers(strconv.ParseBool)
+	registerScalarHandlers(func(s string) (string, error) { return s, nil })
+	registerScalarHandlers(func(s string) (int, error) { v, err := strconv.ParseInt(s, 0, 64); return int(v), err })
+	registerScalarHandlers(func(s string) (int64, error) { return strconv.ParseInt(s, 0, 64) })
+	registerScalarHandlers(func(s string) (uint, error) { v, err := strconv.ParseUint(s, 0, 64); return uint(v), err })
+	registerScalarHandlers(func(s string) (uint64, error) { return strconv.ParseUint(s, 0, 64) })
+	registerScalarHandlers(func(s string) (float64, error) { return strconv.ParseFloat(s, 64) })
+	registerScalarHandlers(time.ParseDuration)
+}
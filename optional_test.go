@@ -0,0 +1,80 @@
+package flag_test
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/machship/flag"
+)
+
+func TestOptionalBool(t *testing.T) {
+	f := NewFlagSet("test", ContinueOnError)
+	cache := f.OptionalBool("cache", "")
+	gzip := f.OptionalBool("gzip", "")
+	verbose := f.OptionalBool("verbose", "")
+	if err := f.Parse([]string{"-cache=false", "-gzip"}); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if v, ok := cache.Get(); !ok || v {
+		t.Errorf("cache = %v, %v; want explicitly false", v, ok)
+	}
+	if p := gzip.Ptr(); p == nil || !*p {
+		t.Errorf("gzip = %v; want true", p)
+	}
+	if verbose.IsSet() || verbose.Ptr() != nil || verbose.OrElse(true) != true {
+		t.Errorf("verbose should be unset, got %+v", *verbose)
+	}
+	if name, _ := UnquoteUsage(f.Lookup("cache")); name != "" {
+		t.Errorf("usage name = %q, want none for bool", name)
+	}
+}
+
+func TestOptionalVarGeneric(t *testing.T) {
+	f := NewFlagSet("test", ContinueOnError)
+	var retries Optional[int]
+	OptionalVar(f, &retries, "retries", strconv.Atoi, "")
+	if err := f.ParseEnv([]string{"RETRIES=0"}); err != nil {
+		t.Fatalf("ParseEnv: %v", err)
+	}
+	if v, ok := retries.Get(); !ok || v != 0 {
+		t.Fatalf("retries = %v, %v; want explicit 0", v, ok)
+	}
+	if err := f.Set("retries", "x"); err == nil {
+		t.Fatal("expected parse error")
+	}
+	if name, _ := UnquoteUsage(f.Lookup("retries")); name != "int" {
+		t.Errorf("usage name = %q", name)
+	}
+}
+
+func TestOptionalStructFields(t *testing.T) {
+	ResetForTesting(nil)
+	saved := os.Args
+	os.Args = []string{"cmd", "-dry-run=false", "-timeout", "5s"}
+	defer func() { os.Args = saved }()
+	var cfg struct {
+		DryRun  Optional[bool]          `flag:"dry-run"`
+		Timeout Optional[time.Duration] `flag:"timeout"`
+		Region  Optional[string]        `flag:"region"`
+	}
+	if err := ParseStruct(&cfg); err != nil {
+		t.Fatalf("ParseStruct: %v", err)
+	}
+	if v, ok := cfg.DryRun.Get(); !ok || v {
+		t.Errorf("dry-run = %v, %v", v, ok)
+	}
+	if cfg.Timeout.OrElse(0) != 5*time.Second || cfg.Region.IsSet() {
+		t.Errorf("got %+v", cfg)
+	}
+
+	ResetForTesting(nil)
+	var bad struct {
+		N Optional[int] `flag:"n" default:"3"`
+	}
+	if err := ParseStructWithOptions(&bad, ParseStructOptions{}); err == nil || !strings.Contains(err.Error(), "cannot declare a default") {
+		t.Errorf("expected default error, got %v", err)
+	}
+}
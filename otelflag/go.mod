@@ -0,0 +1,19 @@
+module github.com/machship/flag/otelflag
+
+go 1.23.2
+
+require (
+	github.com/machship/flag v0.0.0
+	go.opentelemetry.io/otel v1.28.0
+	go.opentelemetry.io/otel/metric v1.28.0
+	go.opentelemetry.io/otel/trace v1.28.0
+)
+
+require (
+	github.com/fsnotify/fsnotify v1.7.0 // indirect
+	github.com/google/uuid v1.5.0 // indirect
+	github.com/shopspring/decimal v1.3.1 // indirect
+	golang.org/x/sys v0.4.0 // indirect
+)
+
+replace github.com/machship/flag => ../
@@ -0,0 +1,67 @@
+package otelflag
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	"github.com/machship/flag"
+)
+
+// RegisterWatcherMetrics reports fs.WatcherStatus through observable gauges
+// on m, read at each collection:
+//
+//	flag.watcher.running          1 while the watcher runs, else 0
+//	flag.watcher.last_reload      Unix time of the last successful reload
+//	flag.watcher.last_error       Unix time of the last reload or watcher error
+//	flag.watcher.source.stale     1 for a source the flags may not match, per source
+//	flag.watcher.source.loaded_at Unix time the flags last matched a source
+//
+// Per source gauges carry the flag.source.path and flag.source.kind
+// attributes. Times that never happened are not reported. Unregister the
+// returned registration to stop reporting.
+func RegisterWatcherMetrics(fs *flag.FlagSet, m metric.Meter) (metric.Registration, error) {
+	running, err1 := m.Int64ObservableGauge("flag.watcher.running",
+		metric.WithDescription("Whether the flag hot reload watcher is running."))
+	lastReload, err2 := m.Float64ObservableGauge("flag.watcher.last_reload", metric.WithUnit("s"),
+		metric.WithDescription("Unix time of the last successful flag reload."))
+	lastError, err3 := m.Float64ObservableGauge("flag.watcher.last_error", metric.WithUnit("s"),
+		metric.WithDescription("Unix time of the last flag reload or watcher error."))
+	stale, err4 := m.Int64ObservableGauge("flag.watcher.source.stale",
+		metric.WithDescription("Whether the flags may not match a watched source."))
+	loadedAt, err5 := m.Float64ObservableGauge("flag.watcher.source.loaded_at", metric.WithUnit("s"),
+		metric.WithDescription("Unix time the flags last matched a watched source."))
+	if err := errors.Join(err1, err2, err3, err4, err5); err != nil {
+		return nil, err
+	}
+	return m.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		st := fs.WatcherStatus()
+		o.ObserveInt64(running, boolInt(st.Running))
+		observeTime(o, lastReload, st.LastReload)
+		observeTime(o, lastError, st.LastErrorAt)
+		for _, src := range st.Sources {
+			attrs := metric.WithAttributes(
+				attribute.String("flag.source.path", src.Path),
+				attribute.String("flag.source.kind", src.Kind))
+			o.ObserveInt64(stale, boolInt(src.Stale), attrs)
+			observeTime(o, loadedAt, src.LoadedAt, attrs)
+		}
+		return nil
+	}, running, lastReload, lastError, stale, loadedAt)
+}
+
+func boolInt(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func observeTime(o metric.Observer, g metric.Float64Observable, t time.Time, opts ...metric.ObserveOption) {
+	if !t.IsZero() {
+		o.ObserveFloat64(g, float64(t.UnixNano())/1e9, opts...)
+	}
+}
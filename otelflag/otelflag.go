@@ -0,0 +1,47 @@
+// Package otelflag reports github.com/machship/flag parse phases as
+// OpenTelemetry spans and the hot reload watcher's health as OpenTelemetry
+// metrics. It lives in its own module so the core flag package does not
+// depend on OpenTelemetry.
+//
+//	fs.SetTracer(otelflag.New(ctx, otel.Tracer("startup")))
+//	otelflag.RegisterWatcherMetrics(fs, otel.Meter("config"))
+package otelflag
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/machship/flag"
+)
+
+// Tracer adapts an OpenTelemetry trace.Tracer to flag.Tracer. Each phase
+// becomes a span named "flag.<phase>" parented to the context given to New.
+type Tracer struct {
+	ctx    context.Context
+	tracer trace.Tracer
+}
+
+var _ flag.Tracer = (*Tracer)(nil)
+
+// New returns a flag.Tracer emitting spans from t under ctx.
+func New(ctx context.Context, t trace.Tracer) *Tracer {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return &Tracer{ctx: ctx, tracer: t}
+}
+
+// StartPhase implements flag.Tracer.
+func (t *Tracer) StartPhase(phase string) func(error) {
+	_, span := t.tracer.Start(t.ctx, "flag."+phase, trace.WithAttributes(attribute.String("flag.phase", phase)))
+	return func(err error) {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}
+}
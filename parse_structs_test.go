@@ -0,0 +1,58 @@
+package flag_test
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	. "github.com/machship/flag"
+)
+
+func TestParseStructs(t *testing.T) {
+	ResetForTesting(nil)
+	type DB struct {
+		Host string `flag:"db-host" default:"localhost"`
+	}
+	type HTTP struct {
+		Port int `flag:"port" default:"80"`
+	}
+	var db DB
+	var web HTTP
+	old := os.Args
+	os.Args = []string{"cmd", "-port", "8080"}
+	defer func() { os.Args = old }()
+	if err := ParseStructs(&db, &web); err != nil {
+		t.Fatalf("parse structs: %v", err)
+	}
+	if db.Host != "localhost" || web.Port != 8080 {
+		t.Fatalf("unexpected values: %+v %+v", db, web)
+	}
+}
+
+func TestParseStructs_DuplicatesAggregated(t *testing.T) {
+	ResetForTesting(nil)
+	String("existing", "", "")
+	type A struct {
+		Host  string `flag:"host"`
+		Port  int    `flag:"port"`
+		Taken string `flag:"existing"`
+	}
+	type B struct {
+		Host string `flag:"host"`
+		Port int    `flag:"port"`
+	}
+	var a A
+	var b B
+	err := ParseStructs(&a, &b)
+	var me *MultiError
+	if !errors.As(err, &me) || len(me.Errors()) != 3 {
+		t.Fatalf("expected 3 aggregated conflicts, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "flag -host defined by both *flag_test.A and *flag_test.B") {
+		t.Fatalf("unexpected message: %v", err)
+	}
+	if Lookup("host") != nil {
+		t.Fatalf("nothing should be registered on conflict")
+	}
+}
@@ -0,0 +1,82 @@
+package flag
+
+import "sort"
+
+// MarkPersistent makes the named flags of f visible to the sets that name f
+// as their parent with SetParent, such as the subcommands of a Command: a
+// global -verbose can then be given before or after the subcommand's name.
+func (f *FlagSet) MarkPersistent(names ...string) {
+	if f.persistent == nil {
+		f.persistent = make(map[string]struct{})
+	}
+	for _, n := range names {
+		f.persistent[n] = struct{}{}
+	}
+}
+
+// MarkPersistent marks flags of the default CommandLine FlagSet as persistent.
+func MarkPersistent(names ...string) { CommandLine.MarkPersistent(names...) }
+
+// SetParent makes f inherit the persistent flags of parent and, through it,
+// of parent's own ancestors. Lookup, Set, Introspect and the command line of
+// f see them; a flag f defines itself shadows an inherited one of the same
+// name. Values given for an inherited flag are stored on the set that
+// defines it, as if given to that set. AddCommand sets the parent of a
+// subcommand's flags.
+func (f *FlagSet) SetParent(parent *FlagSet) {
+	for p := parent; p != nil; p = p.parent {
+		if p == f {
+			panic("flag: SetParent would create a cycle")
+		}
+	}
+	f.parent = parent
+}
+
+// LookupOrigin returns the named flag, like Lookup, together with the set
+// that defines it: f itself or, for an inherited persistent flag, one of its
+// parents. Both are nil if the flag is not found.
+func (f *FlagSet) LookupOrigin(name string) (*Flag, *FlagSet) {
+	if fl := f.formal[f.resolveAlias(name)]; fl != nil {
+		return fl, f
+	}
+	return f.inheritedFlag(name)
+}
+
+// inheritedFlag finds name among the persistent flags of f's ancestors,
+// nearest first.
+func (f *FlagSet) inheritedFlag(name string) (*Flag, *FlagSet) {
+	for p := f.parent; p != nil; p = p.parent {
+		n := p.resolveAlias(name)
+		if _, ok := p.persistent[n]; !ok {
+			continue
+		}
+		if fl := p.formal[n]; fl != nil {
+			return fl, p
+		}
+	}
+	return nil, nil
+}
+
+type inheritedFlag struct {
+	flag  *Flag
+	owner *FlagSet
+}
+
+// inheritedFlags lists the persistent flags f inherits and does not shadow,
+// sorted by name.
+func (f *FlagSet) inheritedFlags() []inheritedFlag {
+	var out []inheritedFlag
+	seen := make(map[string]bool)
+	for p := f.parent; p != nil; p = p.parent {
+		for name := range p.persistent {
+			fl := p.formal[name]
+			if fl == nil || f.formal[name] != nil || seen[name] {
+				continue
+			}
+			seen[name] = true
+			out = append(out, inheritedFlag{flag: fl, owner: p})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].flag.Name < out[j].flag.Name })
+	return out
+}
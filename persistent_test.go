@@ -0,0 +1,85 @@
+package flag_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/machship/flag"
+)
+
+func TestPersistentFlagsInCommands(t *testing.T) {
+	var out strings.Builder
+	root := NewCommand("tool", "", nil)
+	verbose := root.Flags.Bool("verbose", false, "verbose output")
+	region := root.Flags.String("region", "us", "region")
+	root.Flags.String("profile", "", "local to tool")
+	root.Flags.MarkPersistent("verbose", "region")
+
+	migrate := NewCommand("migrate", "", nil)
+	up := NewCommand("up", "", nil)
+	upRegion := up.Flags.String("region", "eu", "shadows -region of tool")
+	var ran bool
+	up.Run = func(args []string) error { ran = true; return nil }
+	migrate.AddCommand(up)
+	root.AddCommand(migrate)
+	for _, c := range []*Command{root, migrate, up} {
+		c.Flags.SetOutput(&out)
+	}
+
+	if err := root.Execute([]string{"migrate", "up", "-verbose", "-region", "ap"}); err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	if !ran || !*verbose || *upRegion != "ap" || *region != "us" {
+		t.Fatalf("ran=%t verbose=%t up region=%q root region=%q", ran, *verbose, *upRegion, *region)
+	}
+	if err := root.Execute([]string{"migrate", "up", "-profile", "x"}); err == nil || !strings.Contains(err.Error(), "not defined: -profile") {
+		t.Fatalf("local parent flag err = %v", err)
+	}
+
+	fl, owner := up.Flags.LookupOrigin("verbose")
+	if fl == nil || owner != root.Flags || up.Flags.Lookup("verbose") != fl {
+		t.Fatalf("LookupOrigin(verbose) = %v, %v", fl, owner)
+	}
+	if fl, owner := up.Flags.LookupOrigin("region"); fl == nil || owner != up.Flags {
+		t.Fatalf("shadowed -region resolved to %v", owner)
+	}
+	if fl, _ := up.Flags.LookupOrigin("profile"); fl != nil {
+		t.Fatal("non-persistent flag was inherited")
+	}
+
+	metas := map[string]FlagMeta{}
+	for _, m := range up.Flags.Introspect() {
+		metas[m.Name] = m
+	}
+	if m := metas["verbose"]; m.InheritedFrom != "tool" || !m.Set || m.Source != "cli" || m.Value != "true" {
+		t.Fatalf("verbose meta = %+v", m)
+	}
+	if m := metas["region"]; m.InheritedFrom != "" || m.Value != "ap" {
+		t.Fatalf("region meta = %+v", m)
+	}
+	if _, ok := metas["profile"]; ok || len(metas) != 2 {
+		t.Fatalf("metas = %v", metas)
+	}
+
+	if err := up.Flags.Set("verbose", "false"); err != nil || *verbose {
+		t.Fatalf("Set inherited flag: %v, verbose=%t", err, *verbose)
+	}
+
+	out.Reset()
+	up.PrintUsage()
+	if !strings.Contains(out.String(), "Inherited flags:\n  -verbose") || strings.Contains(out.String(), "Flags of tool migrate") {
+		t.Fatalf("usage:\n%s", out.String())
+	}
+}
+
+func TestSetParentCycle(t *testing.T) {
+	a := NewFlagSet("a", ContinueOnError)
+	b := NewFlagSet("b", ContinueOnError)
+	b.SetParent(a)
+	defer func() {
+		if recover() == nil {
+			t.Fatal("cycle did not panic")
+		}
+	}()
+	a.SetParent(b)
+}
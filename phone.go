@@ -0,0 +1,152 @@
+package flag
+
+import (
+	"fmt"
+	"strings"
+)
+
+// phoneCallingCodes maps ISO 3166-1 alpha-2 regions to their ITU calling codes,
+// used to complete national numbers given a default region.
+var phoneCallingCodes = map[string]string{
+	"US": "1", "CA": "1", "AG": "1", "AI": "1", "AS": "1", "BB": "1", "BM": "1", "BS": "1", "DM": "1",
+	"DO": "1", "GD": "1", "GU": "1", "JM": "1", "KN": "1", "KY": "1", "LC": "1", "MP": "1", "MS": "1",
+	"PR": "1", "SX": "1", "TC": "1", "TT": "1", "VC": "1", "VG": "1", "VI": "1",
+	"RU": "7", "KZ": "7",
+	"EG": "20", "ZA": "27", "GR": "30", "NL": "31", "BE": "32", "FR": "33", "ES": "34", "HU": "36",
+	"IT": "39", "VA": "39", "RO": "40", "CH": "41", "AT": "43", "GB": "44", "DK": "45", "SE": "46",
+	"NO": "47", "PL": "48", "DE": "49", "PE": "51", "MX": "52", "CU": "53", "AR": "54", "BR": "55",
+	"CL": "56", "CO": "57", "VE": "58", "MY": "60", "AU": "61", "ID": "62", "PH": "63", "NZ": "64",
+	"SG": "65", "TH": "66", "JP": "81", "KR": "82", "VN": "84", "CN": "86", "TR": "90", "IN": "91",
+	"PK": "92", "AF": "93", "LK": "94", "MM": "95", "IR": "98",
+	"MA": "212", "DZ": "213", "TN": "216", "LY": "218", "GM": "220", "SN": "221", "MR": "222", "ML": "223",
+	"GN": "224", "CI": "225", "BF": "226", "NE": "227", "TG": "228", "BJ": "229", "MU": "230", "LR": "231",
+	"SL": "232", "GH": "233", "NG": "234", "TD": "235", "CF": "236", "CM": "237", "CV": "238", "ST": "239",
+	"GQ": "240", "GA": "241", "CG": "242", "CD": "243", "AO": "244", "GW": "245", "SC": "248", "SD": "249",
+	"RW": "250", "ET": "251", "SO": "252", "DJ": "253", "KE": "254", "TZ": "255", "UG": "256", "BI": "257",
+	"MZ": "258", "ZM": "260", "MG": "261", "RE": "262", "ZW": "263", "NA": "264", "MW": "265", "LS": "266",
+	"BW": "267", "SZ": "268", "KM": "269", "SH": "290", "ER": "291", "AW": "297", "FO": "298", "GL": "299",
+	"GI": "350", "PT": "351", "LU": "352", "IE": "353", "IS": "354", "AL": "355", "MT": "356", "CY": "357",
+	"FI": "358", "BG": "359", "LT": "370", "LV": "371", "EE": "372", "MD": "373", "AM": "374", "BY": "375",
+	"AD": "376", "MC": "377", "SM": "378", "UA": "380", "RS": "381", "ME": "382", "HR": "385", "SI": "386",
+	"BA": "387", "MK": "389", "CZ": "420", "SK": "421", "LI": "423",
+	"FK": "500", "BZ": "501", "GT": "502", "SV": "503", "HN": "504", "NI": "505", "CR": "506", "PA": "507",
+	"PM": "508", "HT": "509", "GP": "590", "BO": "591", "GY": "592", "EC": "593", "GF": "594", "PY": "595",
+	"MQ": "596", "SR": "597", "UY": "598", "CW": "599",
+	"TL": "670", "NF": "672", "BN": "673", "NR": "674", "PG": "675", "TO": "676", "SB": "677", "VU": "678",
+	"FJ": "679", "PW": "680", "WF": "681", "CK": "682", "NU": "683", "WS": "685", "KI": "686", "NC": "687",
+	"TV": "688", "PF": "689", "TK": "690", "FM": "691", "MH": "692",
+	"KP": "850", "HK": "852", "MO": "853", "KH": "855", "LA": "856", "BD": "880", "TW": "886",
+	"MV": "960", "LB": "961", "JO": "962", "SY": "963", "IQ": "964", "KW": "965", "SA": "966", "YE": "967",
+	"OM": "968", "PS": "970", "AE": "971", "IL": "972", "BH": "973", "QA": "974", "BT": "975", "MN": "976",
+	"NP": "977", "TJ": "992", "TM": "993", "AZ": "994", "GE": "995", "KG": "996", "UZ": "998",
+}
+
+// normalizePhone converts s to E.164 (+<country code><number>). Spaces, dashes,
+// dots, parentheses and an international "(0)" are ignored; "00" is read as the
+// international prefix. Numbers without one are completed with the calling
+// code of region after dropping the national trunk prefix; an empty region
+// requires international form.
+func normalizePhone(s, region string) (string, error) {
+	in := strings.TrimSpace(s)
+	digits := strings.ReplaceAll(in, "(0)", "")
+	digits = strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '.', '(', ')', '\t':
+			return -1
+		}
+		return r
+	}, digits)
+	international := false
+	switch {
+	case strings.HasPrefix(digits, "+"):
+		digits, international = digits[1:], true
+	case strings.HasPrefix(digits, "00"):
+		digits, international = digits[2:], true
+	}
+	if digits == "" || strings.Trim(digits, "0123456789") != "" {
+		return "", fmt.Errorf("invalid phone number %q", s)
+	}
+	if !international {
+		if region == "" {
+			return "", fmt.Errorf("phone number %q needs an international prefix (+<country code>)", s)
+		}
+		code, ok := phoneCallingCodes[strings.ToUpper(region)]
+		if !ok {
+			return "", fmt.Errorf("no calling code known for region %q", region)
+		}
+		digits = code + stripTrunkPrefix(digits, strings.ToUpper(region), code)
+	}
+	if digits[0] == '0' {
+		return "", fmt.Errorf("invalid phone number %q: country code cannot start with 0", s)
+	}
+	if len(digits) < 7 || len(digits) > 15 {
+		return "", fmt.Errorf("invalid phone number %q: E.164 numbers have 7 to 15 digits", s)
+	}
+	return "+" + digits, nil
+}
+
+// stripTrunkPrefix removes the national dialling prefix from a national number:
+// a leading 1 on 11-digit NANP numbers, 8 in Russia and Kazakhstan, and 0
+// elsewhere except Italy and its enclaves, where the 0 is part of the number.
+func stripTrunkPrefix(national, region, code string) string {
+	switch {
+	case code == "1":
+		if len(national) == 11 && national[0] == '1' {
+			return national[1:]
+		}
+		return national
+	case code == "7":
+		if len(national) == 11 && national[0] == '8' {
+			return national[1:]
+		}
+	case region == "IT" || region == "VA" || region == "SM":
+		return national
+	}
+	return strings.TrimPrefix(national, "0")
+}
+
+type phoneValue struct {
+	p      *string
+	region string
+}
+
+func newPhoneValue(val, region string, p *string) *phoneValue {
+	*p = val
+	return &phoneValue{p: p, region: region}
+}
+func (pv *phoneValue) Set(s string) error {
+	n, err := normalizePhone(s, pv.region)
+	if err != nil {
+		return err
+	}
+	*pv.p = n
+	return nil
+}
+func (pv *phoneValue) String() string {
+	if pv.p == nil {
+		return ""
+	}
+	return *pv.p
+}
+func (pv *phoneValue) Get() interface{} { return *pv.p }
+
+// PhoneVar registers a phone number flag normalized to E.164 ("+61412345678").
+// National numbers are completed using defaultRegion (an ISO 3166-1 alpha-2
+// code such as "AU"); with an empty defaultRegion only international numbers
+// are accepted. The default value is stored as given.
+func (f *FlagSet) PhoneVar(p *string, name string, value string, defaultRegion string, usage string) {
+	f.Var(newPhoneValue(value, defaultRegion, p), name, usage)
+}
+func PhoneVar(p *string, name string, value string, defaultRegion string, usage string) {
+	CommandLine.PhoneVar(p, name, value, defaultRegion, usage)
+}
+
+// Phone defines a phone number flag and returns a pointer to it.
+func (f *FlagSet) Phone(name string, value string, defaultRegion string, usage string) *string {
+	p := new(string)
+	f.PhoneVar(p, name, value, defaultRegion, usage)
+	return p
+}
+func Phone(name string, value string, defaultRegion string, usage string) *string {
+	return CommandLine.Phone(name, value, defaultRegion, usage)
+}
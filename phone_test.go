@@ -0,0 +1,66 @@
+package flag_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	. "github.com/machship/flag"
+)
+
+func TestPhoneNormalization(t *testing.T) {
+	cases := []struct{ region, in, want string }{
+		{"AU", "0412 345 678", "+61412345678"},
+		{"AU", "+61 (0)412-345-678", "+61412345678"},
+		{"AU", "0061 412 345 678", "+61412345678"},
+		{"US", "(415) 555-2671", "+14155552671"},
+		{"US", "1-415-555-2671", "+14155552671"},
+		{"GB", "020 7946 0958", "+442079460958"},
+		{"IT", "06 1234 5678", "+390612345678"},
+		{"RU", "8 912 345 67 89", "+79123456789"},
+		{"", "+64 21 123 4567", "+64211234567"},
+	}
+	for _, c := range cases {
+		f := NewFlagSet("test", ContinueOnError)
+		p := f.Phone("to", "", c.region, "")
+		if err := f.Parse([]string{"-to", c.in}); err != nil {
+			t.Errorf("%s %q: %v", c.region, c.in, err)
+			continue
+		}
+		if *p != c.want {
+			t.Errorf("%s %q = %q, want %q", c.region, c.in, *p, c.want)
+		}
+	}
+
+	bad := []struct{ region, in, want string }{
+		{"", "0412 345 678", "needs an international prefix"},
+		{"ZZ", "0412 345 678", "no calling code known"},
+		{"AU", "04x2", "invalid phone number"},
+		{"", "+1234", "7 to 15 digits"},
+		{"", "+0412345678", "cannot start with 0"},
+	}
+	for _, c := range bad {
+		f := NewFlagSet("test", ContinueOnError)
+		f.Phone("to", "", c.region, "")
+		if err := f.Set("to", c.in); err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("%s %q: expected %q, got %v", c.region, c.in, c.want, err)
+		}
+	}
+}
+
+func TestPhoneStructTag(t *testing.T) {
+	ResetForTesting(nil)
+	saved := os.Args
+	os.Args = []string{"cmd", "-sms", "0412 345 678"}
+	defer func() { os.Args = saved }()
+	var cfg struct {
+		SMS     string `flag:"sms" phone:"true" region:"AU"`
+		Support string `flag:"support" phone:"true" region:"AU" default:"(02) 9876 5432"`
+	}
+	if err := ParseStruct(&cfg); err != nil {
+		t.Fatalf("ParseStruct: %v", err)
+	}
+	if cfg.SMS != "+61412345678" || cfg.Support != "+61298765432" {
+		t.Fatalf("got %+v", cfg)
+	}
+}
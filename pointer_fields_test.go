@@ -0,0 +1,83 @@
+package flag_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/machship/flag"
+)
+
+func TestPointerScalarFields(t *testing.T) {
+	ResetForTesting(nil)
+	saved := os.Args
+	os.Args = []string{"cmd", "-workers", "0", "-debug"}
+	defer func() { os.Args = saved }()
+	os.Setenv("NAME", "edge")
+	defer os.Unsetenv("NAME")
+	var cfg struct {
+		Workers *int           `flag:"workers"`
+		Name    *string        `flag:"name"`
+		Debug   *bool          `flag:"debug"`
+		Timeout *time.Duration `flag:"timeout"`
+		Limit   *uint64        `flag:"limit" default:"10"`
+	}
+	if err := ParseStruct(&cfg); err != nil {
+		t.Fatalf("ParseStruct: %v", err)
+	}
+	if cfg.Workers == nil || *cfg.Workers != 0 {
+		t.Errorf("workers = %v, want pointer to 0", cfg.Workers)
+	}
+	if cfg.Name == nil || *cfg.Name != "edge" {
+		t.Errorf("name = %v, want edge from env", cfg.Name)
+	}
+	if cfg.Debug == nil || !*cfg.Debug {
+		t.Errorf("debug = %v, want true", cfg.Debug)
+	}
+	if cfg.Timeout != nil {
+		t.Errorf("timeout = %v, want nil", *cfg.Timeout)
+	}
+	if cfg.Limit == nil || *cfg.Limit != 10 {
+		t.Errorf("limit = %v, want default 10", cfg.Limit)
+	}
+	if got := Lookup("timeout").DefValue; got != "" {
+		t.Errorf("timeout DefValue = %q", got)
+	}
+}
+
+func TestPointerFieldInvalid(t *testing.T) {
+	ResetForTesting(nil)
+	var cfg struct {
+		N *int `flag:"n" default:"many"`
+	}
+	if err := ParseStructWithOptions(&cfg, ParseStructOptions{}); err == nil || !strings.Contains(err.Error(), `invalid default int "many"`) {
+		t.Fatalf("expected default error, got %v", err)
+	}
+}
+
+func TestPointerFieldUnmarshalCopies(t *testing.T) {
+	ResetForTesting(nil)
+	type config struct {
+		Workers *int `flag:"workers" default:"4"`
+		Limit   *int `flag:"limit"`
+	}
+	var cfg config
+	if err := ParseStructWithOptions(&cfg, ParseStructOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := CommandLine.Parse(nil); err != nil {
+		t.Fatal(err)
+	}
+	var out config
+	if err := Unmarshal(&out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Workers == nil || *out.Workers != 4 || out.Limit != nil {
+		t.Fatalf("unmarshalled workers=%v limit=%v", out.Workers, out.Limit)
+	}
+	*out.Workers = 8
+	if *cfg.Workers != 4 || Lookup("workers").Value.String() != "4" {
+		t.Fatalf("writing the unmarshalled field changed the flag: %d", *cfg.Workers)
+	}
+}
@@ -0,0 +1,158 @@
+package flag
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// postcodeRule validates the postcodes of one country. The pattern is
+// matched against the compact form: upper case with spaces and dashes
+// removed. format, when set, turns the compact form into the usual written
+// one.
+type postcodeRule struct {
+	re     *regexp.Regexp
+	want   string // what the error says was expected
+	format func(compact string) string
+}
+
+// splitAt returns a format inserting sep before the last n characters of
+// the compact postcode.
+func splitAt(n int, sep string) func(string) string {
+	return func(c string) string { return c[:len(c)-n] + sep + c[len(c)-n:] }
+}
+
+var (
+	fourDigits  = postcodeRule{re: regexp.MustCompile(`^\d{4}$`), want: "4 digits"}
+	fiveDigits  = postcodeRule{re: regexp.MustCompile(`^\d{5}$`), want: "5 digits"}
+	sixDigits   = postcodeRule{re: regexp.MustCompile(`^\d{6}$`), want: "6 digits"}
+	sevenDigits = regexp.MustCompile(`^\d{7}$`)
+
+	postcodeRules = map[string]postcodeRule{
+		"AU": fourDigits, "NZ": fourDigits, "AT": fourDigits, "BE": fourDigits, "CH": fourDigits,
+		"DK": fourDigits, "NO": fourDigits, "HU": fourDigits, "ZA": fourDigits, "PH": fourDigits,
+		"DE": fiveDigits, "FR": fiveDigits, "ES": fiveDigits, "IT": fiveDigits, "FI": fiveDigits,
+		"MX": fiveDigits, "MY": fiveDigits, "TH": fiveDigits, "ID": fiveDigits,
+		"CN": sixDigits, "IN": sixDigits, "SG": sixDigits, "RU": sixDigits,
+		"US": {re: regexp.MustCompile(`^\d{5}(\d{4})?$`), want: "a ZIP code such as 94105 or 94105-1234", format: func(c string) string {
+			if len(c) == 9 {
+				return c[:5] + "-" + c[5:]
+			}
+			return c
+		}},
+		"CA": {re: regexp.MustCompile(`^[ABCEGHJ-NPRSTVXY]\d[ABCEGHJ-NPRSTV-Z]\d[ABCEGHJ-NPRSTV-Z]\d$`), want: "a postal code such as K1A 0B1", format: splitAt(3, " ")},
+		"GB": {re: regexp.MustCompile(`^(GIR0AA|[A-PR-UWYZ][A-HK-Y]?\d[A-Z\d]?\d[ABD-HJLNP-UW-Z]{2})$`), want: "a postcode such as SW1A 1AA", format: splitAt(3, " ")},
+		"IE": {re: regexp.MustCompile(`^([AC-FHKNPRTV-Y]\d{2}|D6W)[AC-FHKNPRTV-Y\d]{4}$`), want: "an Eircode such as D02 X285", format: splitAt(4, " ")},
+		"NL": {re: regexp.MustCompile(`^[1-9]\d{3}[A-Z]{2}$`), want: "4 digits and 2 letters such as 1012 AB", format: splitAt(2, " ")},
+		"SE": {re: fiveDigits.re, want: "5 digits such as 114 55", format: splitAt(2, " ")},
+		"PL": {re: fiveDigits.re, want: "5 digits such as 00-950", format: splitAt(3, "-")},
+		"PT": {re: sevenDigits, want: "7 digits such as 1000-001", format: splitAt(3, "-")},
+		"JP": {re: sevenDigits, want: "7 digits such as 100-0001", format: splitAt(4, "-")},
+		"BR": {re: regexp.MustCompile(`^\d{8}$`), want: "8 digits such as 01310-100", format: splitAt(3, "-")},
+	}
+
+	genericPostcode = regexp.MustCompile(`^[A-Z0-9]{2,10}$`)
+)
+
+// normalizePostcode validates s as a postcode of country (ISO 3166-1
+// alpha-2, any case) and returns it upper-cased in its usual written form,
+// e.g. "sw1a1aa" becomes "SW1A 1AA" for GB. Countries without a rule, and an
+// empty country, only get a plausibility check: 2 to 10 letters and digits,
+// ignoring spaces and dashes.
+func normalizePostcode(s, country string) (string, error) {
+	in := strings.ToUpper(strings.TrimSpace(s))
+	compact := strings.Map(func(r rune) rune {
+		if r == ' ' || r == '-' {
+			return -1
+		}
+		return r
+	}, in)
+	country = strings.ToUpper(strings.TrimSpace(country))
+	rule, ok := postcodeRules[country]
+	if !ok {
+		if !genericPostcode.MatchString(compact) {
+			return "", fmt.Errorf("invalid postcode %q", s)
+		}
+		return strings.Join(strings.Fields(in), " "), nil
+	}
+	if !rule.re.MatchString(compact) {
+		return "", fmt.Errorf("invalid postcode %q for %s: expected %s", s, country, rule.want)
+	}
+	if rule.format != nil {
+		return rule.format(compact), nil
+	}
+	return compact, nil
+}
+
+type postcodeValue struct {
+	p       *string
+	country string // "" when a country flag is checked after Parse instead
+}
+
+func (pv *postcodeValue) Set(s string) error {
+	pc, err := normalizePostcode(s, pv.country)
+	if err != nil {
+		return err
+	}
+	*pv.p = pc
+	return nil
+}
+func (pv *postcodeValue) String() string {
+	if pv.p == nil {
+		return ""
+	}
+	return *pv.p
+}
+func (pv *postcodeValue) Get() interface{} { return *pv.p }
+
+// PostcodeVar registers a postcode flag validated against the rules of
+// country, an ISO 3166-1 alpha-2 code such as "AU" (4 digits) or "GB", and
+// stored in the country's usual written form ("SW1A 1AA"). Countries without
+// built-in rules, and an empty country, accept any 2 to 10 letters and
+// digits. The default value is stored as given.
+func (f *FlagSet) PostcodeVar(p *string, name string, value string, country string, usage string) {
+	*p = value
+	f.Var(&postcodeValue{p: p, country: country}, name, usage)
+}
+func PostcodeVar(p *string, name string, value string, country string, usage string) {
+	CommandLine.PostcodeVar(p, name, value, country, usage)
+}
+
+// Postcode defines a postcode flag and returns a pointer to it.
+func (f *FlagSet) Postcode(name string, value string, country string, usage string) *string {
+	p := new(string)
+	f.PostcodeVar(p, name, value, country, usage)
+	return p
+}
+func Postcode(name string, value string, country string, usage string) *string {
+	return CommandLine.Postcode(name, value, country, usage)
+}
+
+// PostcodeVarWithCountryFlag is like PostcodeVar, but validates against the
+// country held by countryFlag, another flag of f such as a CountryCodeVar.
+// As that flag may be set later on the command line or by a later source,
+// Set only checks plausibility and the country's rules are applied once
+// Parse has applied every source. An empty country gets the plausibility
+// check only.
+func (f *FlagSet) PostcodeVarWithCountryFlag(p *string, name string, value string, countryFlag string, usage string) {
+	*p = value
+	f.Var(&postcodeValue{p: p}, name, usage)
+	f.afterParse = append(f.afterParse, func() error {
+		cf := f.Lookup(countryFlag)
+		if cf == nil {
+			return fmt.Errorf("postcode flag -%s refers to undefined country flag -%s", name, countryFlag)
+		}
+		if *p == "" {
+			return nil
+		}
+		pc, err := normalizePostcode(*p, cf.Value.String())
+		if err != nil {
+			return fmt.Errorf("invalid value for flag -%s: %v", name, err)
+		}
+		*p = pc
+		return nil
+	})
+}
+func PostcodeVarWithCountryFlag(p *string, name string, value string, countryFlag string, usage string) {
+	CommandLine.PostcodeVarWithCountryFlag(p, name, value, countryFlag, usage)
+}
@@ -0,0 +1,107 @@
+package flag_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	. "github.com/machship/flag"
+)
+
+func TestPostcodeFlag(t *testing.T) {
+	cases := []struct{ country, in, want string }{
+		{"AU", " 2000 ", "2000"},
+		{"au", "3000", "3000"},
+		{"GB", "sw1a1aa", "SW1A 1AA"},
+		{"GB", "EC1A  1BB", "EC1A 1BB"},
+		{"GB", "gir 0aa", "GIR 0AA"},
+		{"US", "94105", "94105"},
+		{"US", "941051234", "94105-1234"},
+		{"CA", "k1a0b1", "K1A 0B1"},
+		{"NL", "1012ab", "1012 AB"},
+		{"IE", "d02x285", "D02 X285"},
+		{"JP", "1000001", "100-0001"},
+		{"PL", "00950", "00-950"},
+		{"", "ab 12-3", "AB 12-3"},
+		{"XX", "12345", "12345"},
+	}
+	for _, c := range cases {
+		f := NewFlagSet("test", ContinueOnError)
+		pc := f.Postcode("postcode", "", c.country, "")
+		if err := f.Set("postcode", c.in); err != nil {
+			t.Errorf("%s Set(%q): %v", c.country, c.in, err)
+			continue
+		}
+		if *pc != c.want {
+			t.Errorf("%s Set(%q) = %q, want %q", c.country, c.in, *pc, c.want)
+		}
+	}
+
+	errs := []struct{ country, in, want string }{
+		{"AU", "200", `invalid postcode "200" for AU: expected 4 digits`},
+		{"AU", "20000", "expected 4 digits"},
+		{"GB", "QA1 1AA", "such as SW1A 1AA"},
+		{"US", "9410", "ZIP code"},
+		{"CA", "D1A 0B1", "K1A 0B1"},
+		{"", "x", `invalid postcode "x"`},
+		{"", "#1234", "invalid postcode"},
+	}
+	for _, c := range errs {
+		f := NewFlagSet("test", ContinueOnError)
+		f.Postcode("postcode", "", c.country, "")
+		if err := f.Set("postcode", c.in); err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("%s Set(%q): expected %q, got %v", c.country, c.in, c.want, err)
+		}
+	}
+}
+
+func TestPostcodeCountryFlag(t *testing.T) {
+	newSet := func() (*FlagSet, *string, *strings.Builder) {
+		var out strings.Builder
+		f := NewFlagSet("test", ContinueOnError)
+		f.SetOutput(&out)
+		var pc string
+		f.PostcodeVarWithCountryFlag(&pc, "postcode", "", "country", "")
+		f.CountryCodeVar(new(string), "country", "AU", "")
+		return f, &pc, &out
+	}
+
+	f, pc, _ := newSet()
+	if err := f.Parse([]string{"-postcode", "sw1a1aa", "-country", "GB"}); err != nil || *pc != "SW1A 1AA" {
+		t.Fatalf("GB: %v, %q", err, *pc)
+	}
+	f, pc, _ = newSet()
+	if err := f.Parse([]string{"-postcode", "2000"}); err != nil || *pc != "2000" {
+		t.Fatalf("AU default: %v, %q", err, *pc)
+	}
+	f, _, out := newSet()
+	err := f.Parse([]string{"-postcode", "SW1A 1AA"})
+	if err == nil || err.Error() != `invalid value for flag -postcode: invalid postcode "SW1A 1AA" for AU: expected 4 digits` || !strings.Contains(out.String(), "Usage") {
+		t.Fatalf("mismatch err = %v\n%s", err, out.String())
+	}
+
+	g := NewFlagSet("test", ContinueOnError)
+	g.SetOutput(&strings.Builder{})
+	g.PostcodeVarWithCountryFlag(new(string), "postcode", "", "ship-country", "")
+	if err := g.Parse(nil); err == nil || !strings.Contains(err.Error(), "undefined country flag -ship-country") {
+		t.Fatalf("missing country flag err = %v", err)
+	}
+}
+
+func TestPostcodeStructField(t *testing.T) {
+	ResetForTesting(nil)
+	saved := os.Args
+	os.Args = []string{"cmd", "-pickup-postcode", "3000", "-delivery-postcode", "k1a0b1", "-delivery-country", "CA"}
+	defer func() { os.Args = saved }()
+	var cfg struct {
+		PickupPostcode   string `flag:"pickup-postcode" postcode:"true" region:"AU"`
+		DeliveryCountry  string `flag:"delivery-country" iso:"country"`
+		DeliveryPostcode string `flag:"delivery-postcode" postcode:"true" countryFlag:"delivery-country"`
+	}
+	if err := ParseStruct(&cfg); err != nil {
+		t.Fatalf("ParseStruct: %v", err)
+	}
+	if cfg.PickupPostcode != "3000" || cfg.DeliveryPostcode != "K1A 0B1" {
+		t.Fatalf("got %+v", cfg)
+	}
+}
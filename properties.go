@@ -0,0 +1,154 @@
+package flag
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// parseProperties reads a Java-style .properties file and applies its keys
+// like ParseFile does; dotted keys name dotted flags directly. Keys end at the
+// first unescaped '=', ':' or whitespace, lines starting with '#' or '!' are
+// comments, a line ending in an odd number of backslashes continues on the
+// next, and \uXXXX, \t, \n, \r, \f and \\ escapes are decoded in keys and
+// values. A key repeated in the file takes its last value, as in Java.
+func (f *FlagSet) parseProperties(r io.Reader) error {
+	type entry struct {
+		key, value string
+		hasValue   bool
+	}
+	var entries []entry
+	index := make(map[string]int)
+
+	scanner := bufio.NewScanner(r)
+	n := 0
+	for scanner.Scan() {
+		n++
+		start := n
+		line := strings.TrimLeft(scanner.Text(), " \t\f")
+		if line == "" || line[0] == '#' || line[0] == '!' {
+			continue
+		}
+		for continuesLine(line) && scanner.Scan() {
+			n++
+			line = line[:len(line)-1] + strings.TrimLeft(scanner.Text(), " \t\f")
+		}
+		key, value, hasValue, err := splitProperty(line)
+		if err != nil {
+			return f.failf("invalid property on line %d: %v", start, err)
+		}
+		if i, ok := index[key]; ok {
+			entries[i].value, entries[i].hasValue = value, hasValue
+			continue
+		}
+		index[key] = len(entries)
+		entries = append(entries, entry{key, value, hasValue})
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		if err := f.applyConfigValue(e.key, e.value, e.hasValue); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// continuesLine reports whether a properties line ends in an odd number of
+// backslashes, joining it with the next line.
+func continuesLine(line string) bool {
+	n := 0
+	for i := len(line) - 1; i >= 0 && line[i] == '\\'; i-- {
+		n++
+	}
+	return n%2 == 1
+}
+
+// splitProperty separates a logical properties line into its unescaped key
+// and value. hasValue reports whether the line gives a value, even an empty
+// one as in "debug=", rather than the key alone.
+func splitProperty(line string) (key, value string, hasValue bool, err error) {
+	end := len(line)
+	for i := 0; i < len(line); i++ {
+		if line[i] == '\\' {
+			i++
+			continue
+		}
+		if strings.IndexByte("=: \t\f", line[i]) >= 0 {
+			end = i
+			break
+		}
+	}
+	rest := strings.TrimLeft(line[end:], " \t\f")
+	hasValue = rest != ""
+	if hasValue && (rest[0] == '=' || rest[0] == ':') {
+		rest = strings.TrimLeft(rest[1:], " \t\f")
+	}
+	if key, err = unescapeProperty(line[:end]); err != nil {
+		return "", "", false, err
+	}
+	if key == "" {
+		return "", "", false, errors.New("missing key")
+	}
+	if value, err = unescapeProperty(rest); err != nil {
+		return "", "", false, err
+	}
+	return key, value, hasValue, nil
+}
+
+// unescapeProperty decodes the backslash escapes of a properties key or value.
+func unescapeProperty(s string) (string, error) {
+	if !strings.Contains(s, `\`) {
+		return s, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '\\' || i+1 == len(s) {
+			b.WriteByte(c)
+			continue
+		}
+		i++
+		switch c = s[i]; c {
+		case 't':
+			b.WriteByte('\t')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 'f':
+			b.WriteByte('\f')
+		case 'u':
+			if i+5 > len(s) {
+				return "", fmt.Errorf("malformed \\u escape %q", s[i-1:])
+			}
+			r, err := strconv.ParseUint(s[i+1:i+5], 16, 16)
+			if err != nil {
+				return "", fmt.Errorf("malformed \\u escape %q", s[i-1:i+5])
+			}
+			i += 4
+			// combine UTF-16 surrogate pairs written as two \u escapes
+			if r >= 0xD800 && r < 0xDC00 && i+6 < len(s) && s[i+1] == '\\' && s[i+2] == 'u' {
+				if lo, err := strconv.ParseUint(s[i+3:i+7], 16, 16); err == nil && lo >= 0xDC00 && lo < 0xE000 {
+					b.WriteRune(rune((r-0xD800)<<10|(lo-0xDC00)) + 0x10000)
+					i += 6
+					continue
+				}
+			}
+			if r >= 0xD800 && r < 0xE000 {
+				b.WriteRune(utf8.RuneError)
+				continue
+			}
+			b.WriteRune(rune(r))
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String(), nil
+}
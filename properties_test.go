@@ -0,0 +1,109 @@
+package flag_test
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	. "github.com/machship/flag"
+)
+
+func TestParseFileProperties(t *testing.T) {
+	path := writeTempFile(t, "application.properties", `# migrated from the JVM service
+! bang comments too
+db.host = db.internal
+db.port: 5433
+greeting   Gr\u00fc\u00df dich
+motd = first line\nsecond line
+servers = a:11211,\
+          b:11211
+path\ with\ space = C:\\data
+debug
+db.port = 5434
+`)
+	f := NewFlagSet("test", ContinueOnError)
+	f.SetOutput(io.Discard)
+	host := f.String("db.host", "", "")
+	port := f.Int("db.port", 0, "")
+	greeting := f.String("greeting", "", "")
+	motd := f.String("motd", "", "")
+	servers := f.StringSlice("servers", ",", nil, "")
+	spaced := f.String("path with space", "", "")
+	debug := f.Bool("debug", false, "")
+
+	if err := f.ParseFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if *host != "db.internal" || *port != 5434 {
+		t.Errorf("db.host=%q db.port=%d", *host, *port)
+	}
+	if *greeting != "Grüß dich" {
+		t.Errorf("greeting = %q", *greeting)
+	}
+	if *motd != "first line\nsecond line" {
+		t.Errorf("motd = %q", *motd)
+	}
+	if want := []string{"a:11211", "b:11211"}; !reflect.DeepEqual(*servers, want) {
+		t.Errorf("servers = %q, want %q", *servers, want)
+	}
+	if *spaced != `C:\data` {
+		t.Errorf("path with space = %q", *spaced)
+	}
+	if !*debug {
+		t.Error("bare key should set boolean flag")
+	}
+}
+
+func TestParsePropertiesSurrogatePair(t *testing.T) {
+	path := writeTempFile(t, "application.properties", `icon=\ud83d\ude9a`)
+	f := NewFlagSet("test", ContinueOnError)
+	icon := f.String("icon", "", "")
+	if err := f.ParseFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if *icon != "🚚" {
+		t.Errorf("icon = %q", *icon)
+	}
+}
+
+func TestParsePropertiesEmptyValue(t *testing.T) {
+	path := writeTempFile(t, "application.properties", "label=\nnote :\nverbose\n")
+	f := NewFlagSet("test", ContinueOnError)
+	label := f.String("label", "default", "")
+	note := f.String("note", "default", "")
+	verbose := f.Bool("verbose", false, "")
+	if err := f.ParseFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if *label != "" || *note != "" || !*verbose {
+		t.Errorf("label=%q note=%q verbose=%v", *label, *note, *verbose)
+	}
+
+	g := NewFlagSet("test", ContinueOnError)
+	g.SetOutput(io.Discard)
+	debug := g.Bool("debug", false, "")
+	if err := g.ParseFile(writeTempFile(t, "application.properties", "debug=\n")); err == nil || *debug {
+		t.Errorf("explicit empty boolean: err=%v debug=%v", err, *debug)
+	}
+}
+
+func TestParsePropertiesErrors(t *testing.T) {
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{"name=\\u00zz\n", `line 1: malformed \u escape`},
+		{"# c\n= value\n", "line 2: missing key"},
+		{"other=x\n", "not defined: other"},
+	}
+	for _, tt := range tests {
+		f := NewFlagSet("test", ContinueOnError)
+		f.SetOutput(io.Discard)
+		f.String("name", "", "")
+		err := f.ParseFile(writeTempFile(t, "application.properties", tt.content))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ParseFile(%q) error = %v, want %q", tt.content, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package flag_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/machship/flag"
+)
+
+// literalValues are values that must survive every source untouched when
+// written with the documented escapes.
+var literalValues = map[string]string{
+	"handle":  "@ops",
+	"marker":  "raw:x",
+	"dash":    "-v",
+	"sep":     "--",
+	"comment": "#general",
+}
+
+func newQuotingSet() (*FlagSet, map[string]*string) {
+	f := NewFlagSet("test", ContinueOnError)
+	got := make(map[string]*string)
+	for name := range literalValues {
+		got[name] = f.String(name, "", "")
+	}
+	return f, got
+}
+
+func checkLiterals(t *testing.T, source string, got map[string]*string) {
+	t.Helper()
+	for name, want := range literalValues {
+		if *got[name] != want {
+			t.Errorf("%s: -%s = %q, want %q", source, name, *got[name], want)
+		}
+	}
+}
+
+func TestRawPrefixEnv(t *testing.T) {
+	f, got := newQuotingSet()
+	env := []string{
+		"HANDLE=raw:@ops",
+		"MARKER=raw:raw:x",
+		"DASH=-v",
+		"SEP=--",
+		"COMMENT=#general",
+	}
+	if err := f.ParseEnv(env); err != nil {
+		t.Fatalf("ParseEnv: %v", err)
+	}
+	checkLiterals(t, "env", got)
+}
+
+func TestRawPrefixConfig(t *testing.T) {
+	f, got := newQuotingSet()
+	path := filepath.Join(t.TempDir(), "app.conf")
+	cfg := "# values that look like syntax\nhandle=@@ops\nmarker=raw:raw:x\ndash -v\nsep=--\ncomment=#general\n"
+	if err := os.WriteFile(path, []byte(cfg), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.ParseFile(path); err != nil {
+		t.Fatalf("ParseFile: %v", err)
+	}
+	checkLiterals(t, "config", got)
+}
+
+func TestRawPrefixSecretDir(t *testing.T) {
+	f, got := newQuotingSet()
+	dir := t.TempDir()
+	files := map[string]string{
+		"handle":  "raw:@ops\n",
+		"marker":  "raw:raw:x",
+		"dash":    "-v",
+		"sep":     "--",
+		"comment": "#general",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := f.ParseSecretDir(dir); err != nil {
+		t.Fatalf("ParseSecretDir: %v", err)
+	}
+	checkLiterals(t, "secret", got)
+}
+
+func TestCommandLineValuesAreLiteral(t *testing.T) {
+	f, got := newQuotingSet()
+	args := []string{"-handle=@ops", "-marker", "raw:x", "-dash", "-v", "-sep", "--", "-comment", "#general"}
+	if err := f.Parse(args); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	checkLiterals(t, "cli", got)
+}
+
+func TestRawPrefixSkipsFileExpansion(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "token")
+	if err := os.WriteFile(path, []byte("from-file\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	f := NewFlagSet("test", ContinueOnError)
+	a := f.String("a", "", "")
+	b := f.String("b", "", "")
+	if err := f.ParseEnv([]string{"A=@" + path, "B=raw:@" + path}); err != nil {
+		t.Fatalf("ParseEnv: %v", err)
+	}
+	if *a != "from-file" || *b != "@"+path {
+		t.Fatalf("a=%q b=%q", *a, *b)
+	}
+}
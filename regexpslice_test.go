@@ -0,0 +1,67 @@
+package flag_test
+
+import (
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+
+	. "github.com/machship/flag"
+)
+
+func TestRegexpSliceFlag(t *testing.T) {
+	f := NewFlagSet("test", ContinueOnError)
+	rs := f.RegexpSlice("rx", ";", nil, "")
+	if err := f.Parse([]string{"-rx", `^a{1,3}$;^b+$`}); err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(*rs) != 2 || !(*rs)[0].MatchString("aa") || !(*rs)[1].MatchString("bbb") {
+		t.Fatalf("unexpected regexps: %v", *rs)
+	}
+	if s := f.Lookup("rx").Value.String(); s != `^a{1,3}$;^b+$` {
+		t.Fatalf("unexpected String(): %s", s)
+	}
+	err := f.Set("rx", `ok;(bad;fine`)
+	if err == nil || !strings.Contains(err.Error(), "element 1") {
+		t.Fatalf("expected error identifying element 1, got %v", err)
+	}
+	if len(*rs) != 2 {
+		t.Fatalf("failed Set must not modify value")
+	}
+}
+
+func TestMatcherFlag(t *testing.T) {
+	f := NewFlagSet("test", ContinueOnError)
+	m := f.MatcherFlag("skip", "|||", Matcher{regexp.MustCompile("^tmp")}, "")
+	if !m.MatchString("tmpfile") || m.MatchString("file") {
+		t.Fatalf("default matcher not applied")
+	}
+	if err := f.Set("skip", `\.log$|||^vendor/`); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	if !m.MatchString("app.log") || !m.Match([]byte("vendor/x")) || m.MatchString("tmpfile") {
+		t.Fatalf("unexpected matcher behavior: %v", *m)
+	}
+	var empty Matcher
+	if empty.MatchString("anything") {
+		t.Fatalf("empty matcher must not match")
+	}
+}
+
+func TestParseStruct_RegexpSliceAndMatcher(t *testing.T) {
+	ResetForTesting(nil)
+	type C struct {
+		Include []*regexp.Regexp `flag:"include" default:"^a;^b" sep:";"`
+		Exclude Matcher          `flag:"exclude" default:"xx+"`
+	}
+	var c C
+	old := os.Args
+	os.Args = []string{"cmd"}
+	defer func() { os.Args = old }()
+	if err := ParseStruct(&c); err != nil {
+		t.Fatalf("parse struct: %v", err)
+	}
+	if len(c.Include) != 2 || !c.Exclude.MatchString("xxx") {
+		t.Fatalf("unexpected defaults: %v %v", c.Include, c.Exclude)
+	}
+}
@@ -3,10 +3,13 @@ package flag
 import (
 	"encoding/json"
 	"fmt"
+	"image/color"
+	"math/big"
 	"net"
 	neturl "net/url"
 	"reflect"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -22,7 +25,7 @@ type FieldHandler func(ctx *StructFieldContext) (handled bool, err error)
 
 // StructFieldContext provides information & helpers for a struct field registration.
 type StructFieldContext struct {
-	FS         *FlagSet
+	FS         *FlagSet // define the flag here, not on CommandLine
 	Field      reflect.StructField
 	Value      reflect.Value
 	FlagName   string
@@ -32,15 +35,96 @@ type StructFieldContext struct {
 	Deprecated string
 	DefaultTag string
 	Tags       map[string]string // raw tag values (layout, sep, enum, etc.)
+
+	chain []FieldHandler // handlers for this field's type, in invocation order
+	next  int            // index of the handler Next will invoke
+}
+
+// Next invokes the next handler in the chain for the field's type, typically
+// the built-in one. A custom handler can use it to decorate built-in behavior
+// (e.g. adjust ctx.DefaultTag, then delegate). It returns (false, nil) when
+// the chain is exhausted.
+func (ctx *StructFieldContext) Next() (bool, error) {
+	if ctx.next >= len(ctx.chain) {
+		return false, nil
+	}
+	h := ctx.chain[ctx.next]
+	ctx.next++
+	return h(ctx)
+}
+
+// ResolveDefault applies the required/default rules shared by all handlers
+// and stores the result in the field: required fields are zeroed, otherwise a
+// non-empty default tag is converted with parse, otherwise the field keeps its
+// current value. parse must return a value assignable to the field's type, or
+// of the same kind and convertible to it.
+// The resolved value is returned for handlers that need it.
+func (ctx *StructFieldContext) ResolveDefault(parse func(string) (any, error)) (any, error) {
+	ft := ctx.Field.Type
+	switch {
+	case ctx.Required:
+		ctx.Value.Set(reflect.Zero(ft))
+	case ctx.DefaultTag != "":
+		v, err := parse(ctx.DefaultTag)
+		if err != nil {
+			return nil, fmt.Errorf("invalid default %s %q: %v", ft, ctx.DefaultTag, err)
+		}
+		rv := reflect.ValueOf(v)
+		if !rv.IsValid() {
+			rv = reflect.Zero(ft)
+		}
+		if !rv.Type().AssignableTo(ft) {
+			// only between types of one kind, such as a named string type
+			// and string, never int to string, which would make a rune
+			if rv.Kind() != ft.Kind() || !rv.Type().ConvertibleTo(ft) {
+				return nil, fmt.Errorf("invalid default %s %q: parsed %s is not assignable", ft, ctx.DefaultTag, rv.Type())
+			}
+			rv = rv.Convert(ft)
+		}
+		ctx.Value.Set(rv)
+	}
+	return ctx.Value.Interface(), nil
+}
+
+// Register defines the flag for this field on ctx.FS (CommandLine when FS is
+// nil) using the field's name and help text. value should store into the field,
+// typically via ctx.Value.Addr(); its current String() becomes the default.
+func (ctx *StructFieldContext) Register(value Value) {
+	ctx.flagSet().Var(value, ctx.FlagName, ctx.Help)
+}
+
+// flagSet returns the FlagSet the field's flag is defined on.
+func (ctx *StructFieldContext) flagSet() *FlagSet {
+	if ctx.FS == nil {
+		return CommandLine
+	}
+	return ctx.FS
+}
+
+type structHandlerEntry struct {
+	h       FieldHandler
+	builtin bool
+}
+
+type interfaceHandlerEntry struct {
+	iface reflect.Type
+	h     FieldHandler
 }
 
 var (
-	structTypeHandlers = make(map[reflect.Type]FieldHandler)
+	// structTypeHandlers holds, per concrete type, the handler chain in
+	// invocation order. Built-in handlers sit at the end of their chain.
+	structTypeHandlers = make(map[reflect.Type][]structHandlerEntry)
+	// structInterfaceHandlers holds interface-matched handlers, most recently
+	// registered first. They are consulted after the concrete type's chain.
+	structInterfaceHandlers []interfaceHandlerEntry
 )
 
 // RegisterStructHandler allows users to plug in custom struct field handling for
 // ParseStruct. The handler is invoked before built-in logic. If it returns
-// (handled=true) no further processing occurs for that field.
+// (handled=true) no further processing occurs for that field; if it returns
+// (handled=false) the next handler in the chain (ultimately the built-in one)
+// is tried.
 //
 // Typical usage (example: base64-decoded string field):
 //
@@ -50,23 +134,143 @@ var (
 //	    flag.RegisterStructHandler(reflect.TypeOf(B64String("")), func(ctx *flag.StructFieldContext) (bool, error) {
 //	        def := string(ctx.Value.String())
 //	        if ctx.DefaultTag != "" { def = ctx.DefaultTag }
-//	        // store raw default first
-//	        if err := flag.CommandLine.Set(ctx.FlagName, def); err != nil { return true, err }
-//	        // but ParseStruct registers via XXXVar helpers; emulate:
 //	        p := (*string)(ctx.Value.Addr().Interface().(*B64String))
-//	        flag.StringVar(p, ctx.FlagName, def, ctx.Help)
+//	        ctx.FS.StringVar(p, ctx.FlagName, def, ctx.Help)
 //	        return true, nil
 //	    })
 //	}
 //
-// Handlers run before legacy switch/case fallback. If multiple handlers are
-// registered for the same concrete type, the last wins.
-func RegisterStructHandler(t reflect.Type, h FieldHandler) { structTypeHandlers[t] = h }
+// If multiple handlers are registered for the same concrete type, the last
+// registered runs first. It is equivalent to RegisterStructHandlerBefore.
+func RegisterStructHandler(t reflect.Type, h FieldHandler) { RegisterStructHandlerBefore(t, h) }
+
+// RegisterStructHandlerBefore places h at the front of the handler chain for t,
+// so it runs before every handler already registered (including the built-in).
+func RegisterStructHandlerBefore(t reflect.Type, h FieldHandler) {
+	structTypeHandlers[t] = append([]structHandlerEntry{{h: h}}, structTypeHandlers[t]...)
+}
+
+// RegisterStructHandlerAfter places h at the end of the handler chain for t,
+// so it only runs when every earlier handler declines the field. Built-in
+// handlers always claim their fields, so this is mostly useful for types
+// without a built-in handler or as a fallback behind other custom handlers.
+func RegisterStructHandlerAfter(t reflect.Type, h FieldHandler) {
+	structTypeHandlers[t] = append(structTypeHandlers[t], structHandlerEntry{h: h})
+}
+
+// RegisterStructHandlerForInterface registers h for every field whose type,
+// or a pointer to it, implements the interface type iface (for example
+// reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()). Interface handlers
+// run after any handlers registered for the field's concrete type, so built-in
+// types keep their existing behavior. It panics if iface is not an interface.
+func RegisterStructHandlerForInterface(iface reflect.Type, h FieldHandler) {
+	if iface == nil || iface.Kind() != reflect.Interface {
+		panic(fmt.Sprintf("flag: RegisterStructHandlerForInterface: %v is not an interface type", iface))
+	}
+	structInterfaceHandlers = append([]interfaceHandlerEntry{{iface: iface, h: h}}, structInterfaceHandlers...)
+}
+
+// UnregisterStructHandler removes all user-registered handlers for t, leaving
+// any built-in handler in place. If t is an interface type, handlers registered
+// with RegisterStructHandlerForInterface for it are removed. Intended for test
+// cleanup.
+func UnregisterStructHandler(t reflect.Type) {
+	if t != nil && t.Kind() == reflect.Interface {
+		kept := structInterfaceHandlers[:0:0]
+		for _, e := range structInterfaceHandlers {
+			if e.iface != t {
+				kept = append(kept, e)
+			}
+		}
+		structInterfaceHandlers = kept
+		return
+	}
+	var kept []structHandlerEntry
+	for _, e := range structTypeHandlers[t] {
+		if e.builtin {
+			kept = append(kept, e)
+		}
+	}
+	if len(kept) == 0 {
+		delete(structTypeHandlers, t)
+		return
+	}
+	structTypeHandlers[t] = kept
+}
+
+// structHandlerChain returns the handlers applicable to t: its concrete
+// type chain followed by any matching interface handlers.
+func structHandlerChain(t reflect.Type) []FieldHandler {
+	var chain []FieldHandler
+	for _, e := range structTypeHandlers[t] {
+		chain = append(chain, e.h)
+	}
+	for _, e := range structInterfaceHandlers {
+		if t.Implements(e.iface) || reflect.PointerTo(t).Implements(e.iface) {
+			chain = append(chain, e.h)
+		}
+	}
+	return chain
+}
+
+// HandlerInfo describes a type ParseStruct can register flags for through the
+// handler registry.
+type HandlerInfo struct {
+	Type      reflect.Type // concrete type, or the interface type for interface handlers
+	Name      string       // Type.String(), e.g. "time.Duration" or "[]string"
+	Builtin   bool         // a built-in handler is registered for Type
+	Custom    int          // number of user-registered handlers for Type
+	Interface bool         // matches any type implementing Type rather than Type itself
+}
+
+// StructHandlers reports the types with registered struct field handlers,
+// built-in and user-registered, sorted by Name. Fields whose kind is handled
+// by ParseStruct's kind fallback (e.g. a named int type) are not listed.
+func StructHandlers() []HandlerInfo {
+	out := make([]HandlerInfo, 0, len(structTypeHandlers))
+	for t, entries := range structTypeHandlers {
+		info := HandlerInfo{Type: t, Name: t.String()}
+		for _, e := range entries {
+			if e.builtin {
+				info.Builtin = true
+			} else {
+				info.Custom++
+			}
+		}
+		out = append(out, info)
+	}
+	ifaces := make(map[reflect.Type]int)
+	for _, e := range structInterfaceHandlers {
+		ifaces[e.iface]++
+	}
+	for t, n := range ifaces {
+		out = append(out, HandlerInfo{Type: t, Name: t.String(), Custom: n, Interface: true})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Name != out[j].Name {
+			return out[i].Name < out[j].Name
+		}
+		return !out[i].Interface
+	})
+	return out
+}
+
+func registerBuiltinStructHandler(t reflect.Type, h FieldHandler) {
+	structTypeHandlers[t] = append(structTypeHandlers[t], structHandlerEntry{h: h, builtin: true})
+}
 
-// tryHandleStructField attempts to locate a handler for the field's concrete type.
+// tryHandleStructField walks the handler chain for the field's type until one
+// handles the field or returns an error.
 func tryHandleStructField(ctx *StructFieldContext) (bool, error) {
-	if h, ok := structTypeHandlers[ctx.Field.Type]; ok {
-		return h(ctx)
+	ctx.chain = structHandlerChain(ctx.Field.Type)
+	if len(ctx.chain) == 0 {
+		return false, nil
+	}
+	ctx.next = 0
+	for ctx.next < len(ctx.chain) {
+		if handled, err := ctx.Next(); err != nil || handled {
+			return handled, err
+		}
 	}
 	return false, nil
 }
@@ -74,26 +278,34 @@ func tryHandleStructField(ctx *StructFieldContext) (bool, error) {
 // init registers built-in handlers replicating existing ParseStruct switch logic.
 func init() {
 	// time.Time
-	RegisterStructHandler(reflect.TypeOf(time.Time{}), func(ctx *StructFieldContext) (bool, error) {
-		layout := ctx.Tags["layout"]
-		if layout == "" {
-			layout = time.RFC3339
+	registerBuiltinStructHandler(reflect.TypeOf(time.Time{}), func(ctx *StructFieldContext) (bool, error) {
+		layouts := structTimeLayouts(ctx.Tags["layout"], ctx.Tags["layouts"])
+		fs := ctx.flagSet()
+		relative := ctx.Tags["relative"] == "true"
+		var now func() time.Time
+		if relative {
+			now = fs.now
 		}
 		def := ctx.Value.Interface().(time.Time)
 		if ctx.Required {
 			def = time.Time{}
 		} else if ctx.DefaultTag != "" {
-			v, err := time.Parse(layout, ctx.DefaultTag)
+			v, err := parseTimeDefault(ctx.DefaultTag, layouts, now)
 			if err != nil {
 				return true, fmt.Errorf("invalid default time %q: %v", ctx.DefaultTag, err)
 			}
 			def = v
 		}
-		TimeVar(ctx.Value.Addr().Interface().(*time.Time), ctx.FlagName, layout, def, ctx.Help)
+		p := ctx.Value.Addr().Interface().(*time.Time)
+		if relative {
+			fs.RelativeTimeVar(p, ctx.FlagName, layouts, def, ctx.Help)
+		} else {
+			fs.TimeLayoutsVar(p, ctx.FlagName, layouts, def, ctx.Help)
+		}
 		return true, nil
 	})
 	// decimal.Decimal
-	RegisterStructHandler(reflect.TypeOf(decimal.Decimal{}), func(ctx *StructFieldContext) (bool, error) {
+	registerBuiltinStructHandler(reflect.TypeOf(decimal.Decimal{}), func(ctx *StructFieldContext) (bool, error) {
 		def := ctx.Value.Interface().(decimal.Decimal)
 		if ctx.Required {
 			def = decimal.Decimal{}
@@ -104,11 +316,11 @@ func init() {
 			}
 			def = d
 		}
-		DecimalVar(ctx.Value.Addr().Interface().(*decimal.Decimal), ctx.FlagName, def, ctx.Help)
+		ctx.flagSet().DecimalVar(ctx.Value.Addr().Interface().(*decimal.Decimal), ctx.FlagName, def, ctx.Help)
 		return true, nil
 	})
 	// net.IP
-	RegisterStructHandler(reflect.TypeOf(net.IP(nil)), func(ctx *StructFieldContext) (bool, error) {
+	registerBuiltinStructHandler(reflect.TypeOf(net.IP(nil)), func(ctx *StructFieldContext) (bool, error) {
 		def := ctx.Value.Interface().(net.IP)
 		if ctx.Required {
 			def = nil
@@ -119,11 +331,11 @@ func init() {
 			}
 			def = ip
 		}
-		IPVar(ctx.Value.Addr().Interface().(*net.IP), ctx.FlagName, def, ctx.Help)
+		ctx.flagSet().IPVar(ctx.Value.Addr().Interface().(*net.IP), ctx.FlagName, def, ctx.Help)
 		return true, nil
 	})
 	// net.IPNet
-	RegisterStructHandler(reflect.TypeOf(net.IPNet{}), func(ctx *StructFieldContext) (bool, error) {
+	registerBuiltinStructHandler(reflect.TypeOf(net.IPNet{}), func(ctx *StructFieldContext) (bool, error) {
 		def := ctx.Value.Interface().(net.IPNet)
 		if ctx.Required {
 			def = net.IPNet{}
@@ -134,11 +346,11 @@ func init() {
 			}
 			def = *n
 		}
-		IPNetVar(ctx.Value.Addr().Interface().(*net.IPNet), ctx.FlagName, &def, ctx.Help)
+		ctx.flagSet().IPNetVar(ctx.Value.Addr().Interface().(*net.IPNet), ctx.FlagName, &def, ctx.Help)
 		return true, nil
 	})
 	// url.URL
-	RegisterStructHandler(reflect.TypeOf(neturl.URL{}), func(ctx *StructFieldContext) (bool, error) {
+	registerBuiltinStructHandler(reflect.TypeOf(neturl.URL{}), func(ctx *StructFieldContext) (bool, error) {
 		def := ctx.Value.Interface().(neturl.URL)
 		if ctx.Required {
 			def = neturl.URL{}
@@ -149,11 +361,26 @@ func init() {
 			}
 			def = *u
 		}
-		URLVar(ctx.Value.Addr().Interface().(*neturl.URL), ctx.FlagName, &def, ctx.Help)
+		ctx.flagSet().URLVar(ctx.Value.Addr().Interface().(*neturl.URL), ctx.FlagName, &def, ctx.Help)
+		return true, nil
+	})
+	// url.Values
+	registerBuiltinStructHandler(reflect.TypeOf(neturl.Values(nil)), func(ctx *StructFieldContext) (bool, error) {
+		def := ctx.Value.Interface().(neturl.Values)
+		if ctx.Required {
+			def = nil
+		} else if ctx.DefaultTag != "" {
+			q, err := neturl.ParseQuery(ctx.DefaultTag)
+			if err != nil {
+				return true, fmt.Errorf("invalid default query %q: %v", ctx.DefaultTag, err)
+			}
+			def = q
+		}
+		ctx.flagSet().URLValuesVar(ctx.Value.Addr().Interface().(*neturl.Values), ctx.FlagName, def, ctx.Help)
 		return true, nil
 	})
 	// uuid.UUID
-	RegisterStructHandler(reflect.TypeOf(uuid.UUID{}), func(ctx *StructFieldContext) (bool, error) {
+	registerBuiltinStructHandler(reflect.TypeOf(uuid.UUID{}), func(ctx *StructFieldContext) (bool, error) {
 		def := ctx.Value.Interface().(uuid.UUID)
 		if ctx.Required {
 			def = uuid.UUID{}
@@ -164,11 +391,11 @@ func init() {
 			}
 			def = id
 		}
-		UUIDVar(ctx.Value.Addr().Interface().(*uuid.UUID), ctx.FlagName, def, ctx.Help)
+		ctx.flagSet().UUIDVar(ctx.Value.Addr().Interface().(*uuid.UUID), ctx.FlagName, def, ctx.Help)
 		return true, nil
 	})
 	// ByteSize
-	RegisterStructHandler(reflect.TypeOf(ByteSize(0)), func(ctx *StructFieldContext) (bool, error) {
+	registerBuiltinStructHandler(reflect.TypeOf(ByteSize(0)), func(ctx *StructFieldContext) (bool, error) {
 		def := ctx.Value.Interface().(ByteSize)
 		if ctx.Required {
 			def = 0
@@ -179,11 +406,164 @@ func init() {
 			}
 			def = bs
 		}
-		ByteSizeVar(ctx.Value.Addr().Interface().(*ByteSize), ctx.FlagName, def, ctx.Help)
+		ctx.flagSet().ByteSizeVar(ctx.Value.Addr().Interface().(*ByteSize), ctx.FlagName, def, ctx.Help)
+		return true, nil
+	})
+	// Credentials
+	registerBuiltinStructHandler(reflect.TypeOf(Credentials{}), func(ctx *StructFieldContext) (bool, error) {
+		def := ctx.Value.Interface().(Credentials)
+		if ctx.Required {
+			def = Credentials{}
+		} else if ctx.DefaultTag != "" {
+			user, pass, _ := strings.Cut(ctx.DefaultTag, ":")
+			if user == "" {
+				return true, fmt.Errorf("invalid default credentials: empty username")
+			}
+			def = Credentials{Username: user, Password: pass}
+		}
+		ctx.flagSet().CredentialsVar(ctx.Value.Addr().Interface().(*Credentials), ctx.FlagName, def, ctx.Help)
+		return true, nil
+	})
+	// Digest
+	registerBuiltinStructHandler(reflect.TypeOf(Digest{}), func(ctx *StructFieldContext) (bool, error) {
+		def := ctx.Value.Interface().(Digest)
+		if ctx.Required {
+			def = Digest{}
+		} else if ctx.DefaultTag != "" {
+			d, err := ParseDigest(ctx.DefaultTag)
+			if err != nil {
+				return true, fmt.Errorf("invalid default digest %q: %v", ctx.DefaultTag, err)
+			}
+			def = d
+		}
+		ctx.flagSet().DigestVar(ctx.Value.Addr().Interface().(*Digest), ctx.FlagName, def, ctx.Help)
+		return true, nil
+	})
+	// RetryPolicy
+	registerBuiltinStructHandler(reflect.TypeOf(RetryPolicy{}), func(ctx *StructFieldContext) (bool, error) {
+		def := ctx.Value.Interface().(RetryPolicy)
+		if ctx.Required {
+			def = RetryPolicy{}
+		} else if ctx.DefaultTag != "" {
+			p, err := ParseRetryPolicy(ctx.DefaultTag)
+			if err != nil {
+				return true, fmt.Errorf("invalid default: %v", err)
+			}
+			def = p
+		}
+		ctx.flagSet().RetryPolicyVar(ctx.Value.Addr().Interface().(*RetryPolicy), ctx.FlagName, def, ctx.Help)
+		return true, nil
+	})
+	// color.RGBA
+	registerBuiltinStructHandler(reflect.TypeOf(color.RGBA{}), func(ctx *StructFieldContext) (bool, error) {
+		def := ctx.Value.Interface().(color.RGBA)
+		if ctx.Required {
+			def = color.RGBA{}
+		} else if ctx.DefaultTag != "" {
+			c, err := parseColor(ctx.DefaultTag)
+			if err != nil {
+				return true, fmt.Errorf("invalid default: %v", err)
+			}
+			def = c
+		}
+		ctx.flagSet().ColorVar(ctx.Value.Addr().Interface().(*color.RGBA), ctx.FlagName, def, ctx.Help)
+		return true, nil
+	})
+	// LabelFormat
+	registerBuiltinStructHandler(reflect.TypeOf(LabelFormat{}), func(ctx *StructFieldContext) (bool, error) {
+		p := ctx.Value.Addr().Interface().(*LabelFormat)
+		def := *p
+		if ctx.Required {
+			def = LabelFormat{}
+		} else if ctx.DefaultTag != "" {
+			lf, ok := LookupLabelFormat(ctx.DefaultTag)
+			if !ok {
+				return true, fmt.Errorf("invalid default: unknown label format %q", ctx.DefaultTag)
+			}
+			def = lf
+		}
+		ctx.flagSet().Var(newLabelFormatValue(def, p), ctx.FlagName, ctx.Help)
+		return true, nil
+	})
+	// LatLon
+	registerBuiltinStructHandler(reflect.TypeOf(LatLon{}), func(ctx *StructFieldContext) (bool, error) {
+		def := ctx.Value.Interface().(LatLon)
+		if ctx.Required {
+			def = LatLon{}
+		} else if ctx.DefaultTag != "" {
+			ll, err := ParseLatLon(ctx.DefaultTag)
+			if err != nil {
+				return true, fmt.Errorf("invalid default: %v", err)
+			}
+			def = ll
+		}
+		ctx.flagSet().LatLonVar(ctx.Value.Addr().Interface().(*LatLon), ctx.FlagName, def, ctx.Help)
+		return true, nil
+	})
+	// BoundingBox
+	registerBuiltinStructHandler(reflect.TypeOf(BoundingBox{}), func(ctx *StructFieldContext) (bool, error) {
+		def := ctx.Value.Interface().(BoundingBox)
+		if ctx.Required {
+			def = BoundingBox{}
+		} else if ctx.DefaultTag != "" {
+			bb, err := ParseBoundingBox(ctx.DefaultTag)
+			if err != nil {
+				return true, fmt.Errorf("invalid default: %v", err)
+			}
+			def = bb
+		}
+		ctx.flagSet().BoundingBoxVar(ctx.Value.Addr().Interface().(*BoundingBox), ctx.FlagName, def, ctx.Help)
+		return true, nil
+	})
+	// Dimensions
+	registerBuiltinStructHandler(reflect.TypeOf(Dimensions{}), func(ctx *StructFieldContext) (bool, error) {
+		def := ctx.Value.Interface().(Dimensions)
+		if ctx.Required {
+			def = Dimensions{}
+		} else if ctx.DefaultTag != "" {
+			d, err := ParseDimensions(ctx.DefaultTag)
+			if err != nil {
+				return true, fmt.Errorf("invalid default: %v", err)
+			}
+			def = d
+		}
+		ctx.flagSet().DimensionsVar(ctx.Value.Addr().Interface().(*Dimensions), ctx.FlagName, def, ctx.Help)
+		return true, nil
+	})
+	// big.Float (precision and rounding via `prec` and `rounding` tags)
+	registerBuiltinStructHandler(reflect.TypeOf(big.Float{}), func(ctx *StructFieldContext) (bool, error) {
+		var prec uint64
+		if t := ctx.Tags["prec"]; t != "" {
+			n, err := strconv.ParseUint(t, 10, 32)
+			if err != nil {
+				return true, fmt.Errorf("invalid prec tag %q: %v", t, err)
+			}
+			prec = n
+		}
+		mode := big.ToNearestEven
+		if t := ctx.Tags["rounding"]; t != "" {
+			m, err := parseRoundingMode(t)
+			if err != nil {
+				return true, err
+			}
+			mode = m
+		}
+		p := ctx.Value.Addr().Interface().(*big.Float)
+		def := new(big.Float).Set(p)
+		if ctx.Required {
+			def = new(big.Float)
+		} else if ctx.DefaultTag != "" {
+			d, ok := new(big.Float).SetPrec(uint(prec)).SetMode(mode).SetString(ctx.DefaultTag)
+			if !ok {
+				return true, fmt.Errorf("invalid default big.Float %q", ctx.DefaultTag)
+			}
+			def = d
+		}
+		ctx.flagSet().BigFloatVar(p, ctx.FlagName, def, uint(prec), mode, ctx.Help)
 		return true, nil
 	})
 	// []time.Duration
-	RegisterStructHandler(reflect.TypeOf([]time.Duration(nil)), func(ctx *StructFieldContext) (bool, error) {
+	registerBuiltinStructHandler(reflect.TypeOf([]time.Duration(nil)), func(ctx *StructFieldContext) (bool, error) {
 		sep := ctx.Tags["sep"]
 		if sep == "" {
 			sep = ","
@@ -203,16 +583,75 @@ func init() {
 			}
 			def = tmp
 		}
-		DurationSliceVar(ctx.Value.Addr().Interface().(*[]time.Duration), ctx.FlagName, sep, def, ctx.Help)
+		ctx.flagSet().DurationSliceVar(ctx.Value.Addr().Interface().(*[]time.Duration), ctx.FlagName, sep, def, ctx.Help)
+		return true, nil
+	})
+	// []TimeWindow
+	registerBuiltinStructHandler(reflect.TypeOf([]TimeWindow(nil)), func(ctx *StructFieldContext) (bool, error) {
+		loc := time.Local
+		if t := ctx.Tags["tz"]; t != "" {
+			l, err := time.LoadLocation(t)
+			if err != nil {
+				return true, fmt.Errorf("invalid tz tag %q: %v", t, err)
+			}
+			loc = l
+		}
+		def := ctx.Value.Interface().([]TimeWindow)
+		if ctx.Required {
+			def = nil
+		} else if ctx.DefaultTag != "" {
+			var tmp []TimeWindow
+			if err := newTimeWindowsValue(nil, &tmp, loc).Set(ctx.DefaultTag); err != nil {
+				return true, fmt.Errorf("invalid default: %v", err)
+			}
+			def = tmp
+		}
+		ctx.flagSet().TimeWindowsVar(ctx.Value.Addr().Interface().(*[]TimeWindow), ctx.FlagName, def, loc, ctx.Help)
 		return true, nil
 	})
 	// []string
-	RegisterStructHandler(reflect.TypeOf([]string(nil)), func(ctx *StructFieldContext) (bool, error) {
+	registerBuiltinStructHandler(reflect.TypeOf([]string(nil)), func(ctx *StructFieldContext) (bool, error) {
 		sep := ctx.Tags["sep"]
 		if sep == "" {
 			sep = ","
 		}
 		def := ctx.Value.Interface().([]string)
+		if ctx.Tags["ext"] == "true" {
+			if ctx.Required {
+				def = nil
+			} else if ctx.DefaultTag != "" {
+				exts, err := parseExtList(strings.Split(ctx.DefaultTag, ","))
+				if err != nil {
+					return true, fmt.Errorf("invalid default: %v", err)
+				}
+				def = exts
+			}
+			ctx.flagSet().ExtListVar(ctx.Value.Addr().Interface().(*[]string), ctx.FlagName, def, ctx.Help)
+			return true, nil
+		}
+		if ctx.Tags["brokers"] == "true" {
+			var opts BrokerListOptions
+			if t := ctx.Tags["defaultPort"]; t != "" {
+				n, err := strconv.Atoi(t)
+				if err != nil || n < 1 || n > 65535 {
+					return true, fmt.Errorf("invalid defaultPort tag %q", t)
+				}
+				opts.DefaultPort = n
+			}
+			opts.ResolveDNS = ctx.Tags["resolve"] == "true"
+			if ctx.Required {
+				def = nil
+			} else if ctx.DefaultTag != "" {
+				// defaults are not looked up, so registration never waits on DNS
+				b, err := parseBrokerList(strings.Split(ctx.DefaultTag, ","), BrokerListOptions{DefaultPort: opts.DefaultPort})
+				if err != nil {
+					return true, fmt.Errorf("invalid default: %v", err)
+				}
+				def = b
+			}
+			ctx.flagSet().BrokerListVarWithOptions(ctx.Value.Addr().Interface().(*[]string), ctx.FlagName, def, opts, ctx.Help)
+			return true, nil
+		}
 		if ctx.Required {
 			def = nil
 		} else if ctx.DefaultTag != "" {
@@ -222,11 +661,11 @@ func init() {
 			}
 			def = parts
 		}
-		StringSliceVar(ctx.Value.Addr().Interface().(*[]string), ctx.FlagName, sep, def, ctx.Help)
+		ctx.flagSet().StringSliceVar(ctx.Value.Addr().Interface().(*[]string), ctx.FlagName, sep, def, ctx.Help)
 		return true, nil
 	})
 	// map[string]string
-	RegisterStructHandler(reflect.TypeOf(map[string]string(nil)), func(ctx *StructFieldContext) (bool, error) {
+	registerBuiltinStructHandler(reflect.TypeOf(map[string]string(nil)), func(ctx *StructFieldContext) (bool, error) {
 		def := ctx.Value.Interface().(map[string]string)
 		if ctx.Required {
 			def = nil
@@ -244,27 +683,41 @@ func init() {
 			}
 			def = m
 		}
-		StringMapVar(ctx.Value.Addr().Interface().(*map[string]string), ctx.FlagName, def, ctx.Help)
+		ctx.flagSet().StringMapVar(ctx.Value.Addr().Interface().(*map[string]string), ctx.FlagName, def, ctx.Help)
 		return true, nil
 	})
 	// json.RawMessage
-	RegisterStructHandler(reflect.TypeOf(json.RawMessage{}), func(ctx *StructFieldContext) (bool, error) {
+	registerBuiltinStructHandler(reflect.TypeOf(json.RawMessage{}), func(ctx *StructFieldContext) (bool, error) {
 		def := ctx.Value.Interface().(json.RawMessage)
 		if ctx.Required {
 			def = json.RawMessage{}
 		} else if ctx.DefaultTag != "" {
 			jm := json.RawMessage([]byte(ctx.DefaultTag))
-			var tmp interface{}
-			if err := json.Unmarshal(jm, &tmp); err != nil {
+			if err := validateJSON(jm); err != nil {
 				return true, fmt.Errorf("invalid default json %q: %v", ctx.DefaultTag, err)
 			}
 			def = jm
 		}
-		JSONVar(ctx.Value.Addr().Interface().(*json.RawMessage), ctx.FlagName, def, ctx.Help)
+		ctx.flagSet().JSONVar(ctx.Value.Addr().Interface().(*json.RawMessage), ctx.FlagName, def, ctx.Help)
+		return true, nil
+	})
+	// []json.RawMessage (JSON Lines)
+	registerBuiltinStructHandler(reflect.TypeOf([]json.RawMessage(nil)), func(ctx *StructFieldContext) (bool, error) {
+		def := ctx.Value.Interface().([]json.RawMessage)
+		if ctx.Required {
+			def = nil
+		} else if ctx.DefaultTag != "" {
+			docs, err := parseJSONLines(ctx.DefaultTag)
+			if err != nil {
+				return true, fmt.Errorf("invalid default json lines: %v", err)
+			}
+			def = docs
+		}
+		ctx.flagSet().JSONLinesVar(ctx.Value.Addr().Interface().(*[]json.RawMessage), ctx.FlagName, def, ctx.Help)
 		return true, nil
 	})
 	// *regexp.Regexp (represented as pointer type in struct)
-	RegisterStructHandler(reflect.TypeOf((*regexp.Regexp)(nil)), func(ctx *StructFieldContext) (bool, error) {
+	registerBuiltinStructHandler(reflect.TypeOf((*regexp.Regexp)(nil)), func(ctx *StructFieldContext) (bool, error) {
 		def := ctx.Value.Interface().(*regexp.Regexp)
 		if ctx.Required {
 			def = nil
@@ -275,11 +728,49 @@ func init() {
 			}
 			def = r
 		}
-		RegexpVar(ctx.Value.Addr().Interface().(**regexp.Regexp), ctx.FlagName, def, ctx.Help)
+		ctx.flagSet().RegexpVar(ctx.Value.Addr().Interface().(**regexp.Regexp), ctx.FlagName, def, ctx.Help)
+		return true, nil
+	})
+	// []*regexp.Regexp
+	registerBuiltinStructHandler(reflect.TypeOf([]*regexp.Regexp(nil)), func(ctx *StructFieldContext) (bool, error) {
+		sep := ctx.Tags["sep"]
+		if sep == "" {
+			sep = ","
+		}
+		def := ctx.Value.Interface().([]*regexp.Regexp)
+		if ctx.Required {
+			def = nil
+		} else if ctx.DefaultTag != "" {
+			rs, err := compileRegexpList(ctx.DefaultTag, sep)
+			if err != nil {
+				return true, fmt.Errorf("invalid default regexp slice: %v", err)
+			}
+			def = rs
+		}
+		ctx.flagSet().RegexpSliceVar(ctx.Value.Addr().Interface().(*[]*regexp.Regexp), ctx.FlagName, sep, def, ctx.Help)
+		return true, nil
+	})
+	// Matcher
+	registerBuiltinStructHandler(reflect.TypeOf(Matcher(nil)), func(ctx *StructFieldContext) (bool, error) {
+		sep := ctx.Tags["sep"]
+		if sep == "" {
+			sep = ","
+		}
+		def := ctx.Value.Interface().(Matcher)
+		if ctx.Required {
+			def = nil
+		} else if ctx.DefaultTag != "" {
+			rs, err := compileRegexpList(ctx.DefaultTag, sep)
+			if err != nil {
+				return true, fmt.Errorf("invalid default matcher: %v", err)
+			}
+			def = rs
+		}
+		ctx.flagSet().MatcherVar(ctx.Value.Addr().Interface().(*Matcher), ctx.FlagName, sep, def, ctx.Help)
 		return true, nil
 	})
 	// numeric & primitive kinds registered via exact type mapping
-	RegisterStructHandler(reflect.TypeOf(true), func(ctx *StructFieldContext) (bool, error) {
+	registerBuiltinStructHandler(reflect.TypeOf(true), func(ctx *StructFieldContext) (bool, error) {
 		def := ctx.Value.Bool()
 		if ctx.Required {
 			def = false
@@ -290,10 +781,10 @@ func init() {
 			}
 			def = b
 		}
-		BoolVar(ctx.Value.Addr().Interface().(*bool), ctx.FlagName, def, ctx.Help)
+		ctx.flagSet().BoolVar(ctx.Value.Addr().Interface().(*bool), ctx.FlagName, def, ctx.Help)
 		return true, nil
 	})
-	RegisterStructHandler(reflect.TypeOf(int(0)), func(ctx *StructFieldContext) (bool, error) {
+	registerBuiltinStructHandler(reflect.TypeOf(int(0)), func(ctx *StructFieldContext) (bool, error) {
 		def := ctx.Value.Int()
 		if ctx.Required {
 			def = 0
@@ -304,10 +795,10 @@ func init() {
 			}
 			def = iv
 		}
-		IntVar(ctx.Value.Addr().Interface().(*int), ctx.FlagName, int(def), ctx.Help)
+		ctx.flagSet().IntVar(ctx.Value.Addr().Interface().(*int), ctx.FlagName, int(def), ctx.Help)
 		return true, nil
 	})
-	RegisterStructHandler(reflect.TypeOf(int64(0)), func(ctx *StructFieldContext) (bool, error) {
+	registerBuiltinStructHandler(reflect.TypeOf(int64(0)), func(ctx *StructFieldContext) (bool, error) {
 		// time.Duration is handled separately by type; this catch-all covers other int64 fields
 		if ctx.Field.Type == reflect.TypeOf(time.Duration(0)) { // handled as duration
 			d := ctx.Value.Interface().(time.Duration)
@@ -320,7 +811,7 @@ func init() {
 				}
 				d = dv
 			}
-			DurationVar(ctx.Value.Addr().Interface().(*time.Duration), ctx.FlagName, d, ctx.Help)
+			ctx.flagSet().DurationVar(ctx.Value.Addr().Interface().(*time.Duration), ctx.FlagName, d, ctx.Help)
 			return true, nil
 		}
 		def := ctx.Value.Int()
@@ -333,10 +824,10 @@ func init() {
 			}
 			def = iv
 		}
-		Int64Var(ctx.Value.Addr().Interface().(*int64), ctx.FlagName, def, ctx.Help)
+		ctx.flagSet().Int64Var(ctx.Value.Addr().Interface().(*int64), ctx.FlagName, def, ctx.Help)
 		return true, nil
 	})
-	RegisterStructHandler(reflect.TypeOf(uint(0)), func(ctx *StructFieldContext) (bool, error) {
+	registerBuiltinStructHandler(reflect.TypeOf(uint(0)), func(ctx *StructFieldContext) (bool, error) {
 		def := ctx.Value.Uint()
 		if ctx.Required {
 			def = 0
@@ -347,10 +838,10 @@ func init() {
 			}
 			def = uv
 		}
-		UintVar(ctx.Value.Addr().Interface().(*uint), ctx.FlagName, uint(def), ctx.Help)
+		ctx.flagSet().UintVar(ctx.Value.Addr().Interface().(*uint), ctx.FlagName, uint(def), ctx.Help)
 		return true, nil
 	})
-	RegisterStructHandler(reflect.TypeOf(uint64(0)), func(ctx *StructFieldContext) (bool, error) {
+	registerBuiltinStructHandler(reflect.TypeOf(uint64(0)), func(ctx *StructFieldContext) (bool, error) {
 		def := ctx.Value.Uint()
 		if ctx.Required {
 			def = 0
@@ -361,11 +852,116 @@ func init() {
 			}
 			def = uv
 		}
-		Uint64Var(ctx.Value.Addr().Interface().(*uint64), ctx.FlagName, def, ctx.Help)
+		ctx.flagSet().Uint64Var(ctx.Value.Addr().Interface().(*uint64), ctx.FlagName, def, ctx.Help)
 		return true, nil
 	})
-	RegisterStructHandler(reflect.TypeOf(""), func(ctx *StructFieldContext) (bool, error) {
+	registerBuiltinStructHandler(reflect.TypeOf(""), func(ctx *StructFieldContext) (bool, error) {
 		def := ctx.Value.String()
+		if ctx.Tags["phone"] == "true" {
+			region := ctx.Tags["region"]
+			if ctx.Required {
+				def = ""
+			} else if ctx.DefaultTag != "" {
+				n, err := normalizePhone(ctx.DefaultTag, region)
+				if err != nil {
+					return true, fmt.Errorf("invalid default: %v", err)
+				}
+				def = n
+			}
+			ctx.flagSet().PhoneVar(ctx.Value.Addr().Interface().(*string), ctx.FlagName, def, region, ctx.Help)
+			return true, nil
+		}
+		if kind := ctx.Tags["businessNumber"]; kind != "" {
+			if _, ok := lookupBusinessNumber(kind); !ok {
+				return true, fmt.Errorf("unknown businessNumber tag %q", kind)
+			}
+			if ctx.Required {
+				def = ""
+			} else if ctx.DefaultTag != "" {
+				n, err := normalizeBusinessNumber(ctx.DefaultTag, kind)
+				if err != nil {
+					return true, fmt.Errorf("invalid default: %v", err)
+				}
+				def = n
+			}
+			ctx.flagSet().BusinessNumberVar(ctx.Value.Addr().Interface().(*string), ctx.FlagName, def, kind, ctx.Help)
+			return true, nil
+		}
+		if ctx.Tags["postcode"] == "true" {
+			region := ctx.Tags["region"]
+			if ctx.Required {
+				def = ""
+			} else if ctx.DefaultTag != "" {
+				pc, err := normalizePostcode(ctx.DefaultTag, region)
+				if err != nil {
+					return true, fmt.Errorf("invalid default: %v", err)
+				}
+				def = pc
+			}
+			if cf := ctx.Tags["countryFlag"]; cf != "" {
+				ctx.flagSet().PostcodeVarWithCountryFlag(ctx.Value.Addr().Interface().(*string), ctx.FlagName, def, cf, ctx.Help)
+			} else {
+				ctx.flagSet().PostcodeVar(ctx.Value.Addr().Interface().(*string), ctx.FlagName, def, region, ctx.Help)
+			}
+			return true, nil
+		}
+		if ctx.Tags["mime"] == "true" {
+			if ctx.Required {
+				def = ""
+			} else if ctx.DefaultTag != "" {
+				t, err := normalizeMIMEType(ctx.DefaultTag)
+				if err != nil {
+					return true, fmt.Errorf("invalid default: %v", err)
+				}
+				def = t
+			}
+			ctx.flagSet().MIMETypeVar(ctx.Value.Addr().Interface().(*string), ctx.FlagName, def, ctx.Help)
+			return true, nil
+		}
+		if ctx.Tags["grpc"] == "true" {
+			port := 0
+			if t := ctx.Tags["defaultPort"]; t != "" {
+				n, err := strconv.Atoi(t)
+				if err != nil || n < 1 || n > 65535 {
+					return true, fmt.Errorf("invalid defaultPort tag %q", t)
+				}
+				port = n
+			}
+			if ctx.Required {
+				def = ""
+			} else if ctx.DefaultTag != "" {
+				t, err := normalizeGRPCTarget(ctx.DefaultTag, port)
+				if err != nil {
+					return true, fmt.Errorf("invalid default: %v", err)
+				}
+				def = t
+			}
+			ctx.flagSet().GRPCTargetVar(ctx.Value.Addr().Interface().(*string), ctx.FlagName, def, port, ctx.Help)
+			return true, nil
+		}
+		if kind := ctx.Tags["iso"]; kind != "" {
+			var register func(p *string, name string, value string, usage string)
+			var codes map[string]struct{}
+			switch kind {
+			case "country":
+				register, codes = CountryCodeVar, countryCodes
+			case "currency":
+				register, codes = CurrencyCodeVar, currencyCodes
+			default:
+				return true, fmt.Errorf("unknown iso tag %q (want country or currency)", kind)
+			}
+			if ctx.Required {
+				def = ""
+			} else if ctx.DefaultTag != "" {
+				c, err := normalizeISOCode(ctx.DefaultTag, kind, codes)
+				if err != nil {
+					return true, fmt.Errorf("invalid default: %v", err)
+				}
+				def = c
+			}
+			register(ctx.Value.Addr().Interface().(*string), ctx.FlagName, def, ctx.Help)
+			return true, nil
+		}
 		if enumList := ctx.Tags["enum"]; enumList != "" {
 			allowed := strings.Split(enumList, ",")
 			for i := range allowed {
@@ -376,7 +972,7 @@ func init() {
 			} else if ctx.DefaultTag != "" {
 				def = ctx.DefaultTag
 			}
-			EnumVar(ctx.Value.Addr().Interface().(*string), ctx.FlagName, def, allowed, ctx.Help)
+			ctx.flagSet().EnumVar(ctx.Value.Addr().Interface().(*string), ctx.FlagName, def, allowed, ctx.Help)
 			return true, nil
 		}
 		if ctx.Required {
@@ -384,11 +980,35 @@ func init() {
 		} else if ctx.DefaultTag != "" {
 			def = ctx.DefaultTag
 		}
-		StringVar(ctx.Value.Addr().Interface().(*string), ctx.FlagName, def, ctx.Help)
+		ctx.flagSet().StringVar(ctx.Value.Addr().Interface().(*string), ctx.FlagName, def, ctx.Help)
 		return true, nil
 	})
-	RegisterStructHandler(reflect.TypeOf(float64(0)), func(ctx *StructFieldContext) (bool, error) {
+	registerBuiltinStructHandler(reflect.TypeOf(float64(0)), func(ctx *StructFieldContext) (bool, error) {
 		def := ctx.Value.Float()
+		if ctx.Tags["weight"] != "" || ctx.Tags["length"] != "" {
+			kind, unit, factors := "weight", ctx.Tags["weight"], gramsPer
+			if unit == "" {
+				kind, unit, factors = "length", ctx.Tags["length"], millimetresPer
+			}
+			if _, ok := factors[unit]; !ok {
+				return true, fmt.Errorf("unknown %s unit %q", kind, unit)
+			}
+			if ctx.Required {
+				def = 0
+			} else if ctx.DefaultTag != "" {
+				v, err := parseMeasure(ctx.DefaultTag, kind, factors, unit)
+				if err != nil {
+					return true, fmt.Errorf("invalid default: %v", err)
+				}
+				def = v
+			}
+			if kind == "weight" {
+				ctx.flagSet().WeightVar(ctx.Value.Addr().Interface().(*float64), ctx.FlagName, def, WeightUnit(unit), ctx.Help)
+			} else {
+				ctx.flagSet().LengthVar(ctx.Value.Addr().Interface().(*float64), ctx.FlagName, def, LengthUnit(unit), ctx.Help)
+			}
+			return true, nil
+		}
 		if ctx.Required {
 			def = 0
 		} else if ctx.DefaultTag != "" {
@@ -398,7 +1018,7 @@ func init() {
 			}
 			def = fv
 		}
-		Float64Var(ctx.Value.Addr().Interface().(*float64), ctx.FlagName, def, ctx.Help)
+		ctx.flagSet().Float64Var(ctx.Value.Addr().Interface().(*float64), ctx.FlagName, def, ctx.Help)
 		return true, nil
 	})
 }
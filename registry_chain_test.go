@@ -0,0 +1,96 @@
+package flag_test
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	. "github.com/machship/flag"
+)
+
+func parseStructNoArgs(t *testing.T, s any) error {
+	t.Helper()
+	ResetForTesting(nil)
+	old := os.Args
+	os.Args = []string{"cmd"}
+	defer func() { os.Args = old }()
+	return ParseStruct(s)
+}
+
+func TestStructHandler_NextDelegatesToBuiltin(t *testing.T) {
+	strType := reflect.TypeOf("")
+	defer UnregisterStructHandler(strType)
+	RegisterStructHandler(strType, func(ctx *StructFieldContext) (bool, error) {
+		if ctx.Tags["enum"] == "" {
+			ctx.DefaultTag = strings.ToUpper(ctx.DefaultTag)
+		}
+		return ctx.Next()
+	})
+	type C struct {
+		Name string `flag:"name" default:"alice"`
+	}
+	var c C
+	if err := parseStructNoArgs(t, &c); err != nil {
+		t.Fatalf("parse struct: %v", err)
+	}
+	if c.Name != "ALICE" {
+		t.Fatalf("expected decorated default, got %q", c.Name)
+	}
+}
+
+func TestStructHandler_DeclineFallsThrough(t *testing.T) {
+	strType := reflect.TypeOf("")
+	defer UnregisterStructHandler(strType)
+	var calls []string
+	RegisterStructHandler(strType, func(ctx *StructFieldContext) (bool, error) {
+		calls = append(calls, "first")
+		return false, nil
+	})
+	RegisterStructHandlerBefore(strType, func(ctx *StructFieldContext) (bool, error) {
+		calls = append(calls, "before")
+		return false, nil
+	})
+	type C struct {
+		Name string `flag:"name" default:"bob"`
+	}
+	var c C
+	if err := parseStructNoArgs(t, &c); err != nil {
+		t.Fatalf("parse struct: %v", err)
+	}
+	if c.Name != "bob" || strings.Join(calls, ",") != "before,first" {
+		t.Fatalf("unexpected result name=%q calls=%v", c.Name, calls)
+	}
+}
+
+type chainCustom int32
+
+func TestStructHandler_AfterAndUnregister(t *testing.T) {
+	typ := reflect.TypeOf(chainCustom(0))
+	defer UnregisterStructHandler(typ)
+	var order []string
+	RegisterStructHandlerAfter(typ, func(ctx *StructFieldContext) (bool, error) {
+		order = append(order, "fallback")
+		IntVar(new(int), ctx.FlagName, 0, ctx.Help)
+		return true, nil
+	})
+	RegisterStructHandler(typ, func(ctx *StructFieldContext) (bool, error) {
+		order = append(order, "primary")
+		return false, nil
+	})
+	type C struct {
+		V chainCustom `flag:"v"`
+	}
+	var c C
+	if err := parseStructNoArgs(t, &c); err != nil {
+		t.Fatalf("parse struct: %v", err)
+	}
+	if strings.Join(order, ",") != "primary,fallback" {
+		t.Fatalf("unexpected order %v", order)
+	}
+	UnregisterStructHandler(typ)
+	var c2 C
+	if err := parseStructNoArgs(t, &c2); err == nil || !strings.Contains(err.Error(), "unsupported field type") {
+		t.Fatalf("expected unsupported type after unregister, got %v", err)
+	}
+}
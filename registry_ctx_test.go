@@ -0,0 +1,99 @@
+package flag_test
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	. "github.com/machship/flag"
+)
+
+type percent float64
+
+type percentValue struct{ p *percent }
+
+func (pv percentValue) Set(s string) error {
+	v, err := parsePercent(s)
+	if err != nil {
+		return err
+	}
+	*pv.p = v
+	return nil
+}
+func (pv percentValue) String() string {
+	if pv.p == nil {
+		return ""
+	}
+	return fmt.Sprintf("%g%%", float64(*pv.p))
+}
+
+func parsePercent(s string) (percent, error) {
+	v, err := strconv.ParseFloat(strings.TrimSuffix(s, "%"), 64)
+	return percent(v), err
+}
+
+func registerPercentHandler() {
+	RegisterStructHandler(reflect.TypeOf(percent(0)), func(ctx *StructFieldContext) (bool, error) {
+		if _, err := ctx.ResolveDefault(func(s string) (any, error) { return parsePercent(s) }); err != nil {
+			return true, err
+		}
+		ctx.Register(percentValue{p: ctx.Value.Addr().Interface().(*percent)})
+		return true, nil
+	})
+}
+
+func TestStructFieldContext_ResolveDefaultAndRegister(t *testing.T) {
+	typ := reflect.TypeOf(percent(0))
+	defer UnregisterStructHandler(typ)
+	registerPercentHandler()
+	type C struct {
+		Ratio    percent `flag:"ratio" default:"12.5%"`
+		Kept     percent `flag:"kept"`
+		Required percent `flag:"req" default:"99%" required:"true"`
+	}
+	c := C{Kept: 7, Required: 50}
+	err := parseStructNoArgs(t, &c)
+	if err == nil || !strings.Contains(err.Error(), "missing required flags: req") {
+		t.Fatalf("expected missing required flag, got %v", err)
+	}
+	if c.Ratio != 12.5 || c.Kept != 7 || c.Required != 0 {
+		t.Fatalf("unexpected resolution: %+v", c)
+	}
+	if fl := Lookup("ratio"); fl == nil || fl.DefValue != "12.5%" {
+		t.Fatalf("expected flag registered with default, got %+v", fl)
+	}
+
+	type Bad struct {
+		Ratio percent `flag:"ratio" default:"lots"`
+	}
+	var b Bad
+	if err := parseStructNoArgs(t, &b); err == nil || !strings.Contains(err.Error(), `invalid default flag_test.percent "lots"`) {
+		t.Fatalf("expected wrapped default error, got %v", err)
+	}
+}
+
+type codeName string
+
+func TestStructFieldContext_ResolveDefaultKindMismatch(t *testing.T) {
+	typ := reflect.TypeOf(codeName(""))
+	defer UnregisterStructHandler(typ)
+	RegisterStructHandler(typ, func(ctx *StructFieldContext) (bool, error) {
+		// a buggy parse returning an int, which Convert would turn into a rune
+		_, err := ctx.ResolveDefault(func(s string) (any, error) { return strconv.Atoi(s) })
+		if err != nil {
+			return true, err
+		}
+		p := (*string)(ctx.Value.Addr().Interface().(*codeName))
+		ctx.FS.StringVar(p, ctx.FlagName, *p, ctx.Help)
+		return true, nil
+	})
+	type C struct {
+		Code codeName `flag:"code" default:"65"`
+	}
+	var c C
+	if err := parseStructNoArgs(t, &c); err == nil || !strings.Contains(err.Error(), "parsed int is not assignable") || c.Code != "" {
+		t.Fatalf("expected kind mismatch error, got %v (code %q)", err, c.Code)
+	}
+}
@@ -0,0 +1,44 @@
+package flag_test
+
+import (
+	"encoding"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	. "github.com/machship/flag"
+)
+
+func TestStructHandlers(t *testing.T) {
+	typ := reflect.TypeOf(chainCustom(0))
+	iface := reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	defer UnregisterStructHandler(typ)
+	defer UnregisterStructHandler(iface)
+	noop := func(*StructFieldContext) (bool, error) { return false, nil }
+	RegisterStructHandler(typ, noop)
+	RegisterStructHandler(reflect.TypeOf(time.Duration(0)), noop)
+	defer UnregisterStructHandler(reflect.TypeOf(time.Duration(0)))
+	RegisterStructHandlerForInterface(iface, noop)
+
+	infos := StructHandlers()
+	if !sort.SliceIsSorted(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name }) {
+		t.Fatalf("expected sorted output")
+	}
+	byName := make(map[string]HandlerInfo)
+	for _, hi := range infos {
+		byName[hi.Name] = hi
+	}
+	if hi := byName["time.Time"]; !hi.Builtin || hi.Custom != 0 {
+		t.Fatalf("expected built-in time.Time handler, got %+v", hi)
+	}
+	if hi := byName["flag_test.chainCustom"]; hi.Builtin || hi.Custom != 1 {
+		t.Fatalf("expected custom handler entry, got %+v", hi)
+	}
+	if hi := byName["encoding.TextUnmarshaler"]; !hi.Interface || hi.Custom != 1 {
+		t.Fatalf("expected interface handler entry, got %+v", hi)
+	}
+	if hi := byName["[]string"]; hi.Type != reflect.TypeOf([]string(nil)) {
+		t.Fatalf("expected []string type reported, got %+v", hi)
+	}
+}
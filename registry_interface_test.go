@@ -0,0 +1,91 @@
+package flag_test
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	. "github.com/machship/flag"
+)
+
+type level int
+
+func (l *level) UnmarshalText(b []byte) error {
+	switch string(b) {
+	case "low":
+		*l = 1
+	case "high":
+		*l = 2
+	default:
+		return fmt.Errorf("unknown level %q", b)
+	}
+	return nil
+}
+
+func (l level) MarshalText() ([]byte, error) {
+	return []byte([...]string{"", "low", "high"}[l]), nil
+}
+
+// textValue adapts an encoding.TextUnmarshaler field to the Value interface.
+type textValue struct{ v reflect.Value }
+
+func (tv textValue) Set(s string) error {
+	return tv.v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+}
+func (tv textValue) String() string {
+	if !tv.v.IsValid() {
+		return ""
+	}
+	if m, ok := tv.v.Interface().(encoding.TextMarshaler); ok {
+		b, _ := m.MarshalText()
+		return string(b)
+	}
+	return ""
+}
+
+func TestStructHandlerForInterface(t *testing.T) {
+	iface := reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	defer UnregisterStructHandler(iface)
+	RegisterStructHandlerForInterface(iface, func(ctx *StructFieldContext) (bool, error) {
+		tv := textValue{v: ctx.Value}
+		if ctx.DefaultTag != "" && !ctx.Required {
+			if err := tv.Set(ctx.DefaultTag); err != nil {
+				return true, err
+			}
+		}
+		Var(tv, ctx.FlagName, ctx.Help)
+		return true, nil
+	})
+	type C struct {
+		Level level  `flag:"level" default:"high"`
+		Name  string `flag:"name" default:"x"`
+	}
+	var c C
+	if err := parseStructNoArgs(t, &c); err != nil {
+		t.Fatalf("parse struct: %v", err)
+	}
+	if c.Level != 2 || c.Name != "x" {
+		t.Fatalf("unexpected values: %+v", c)
+	}
+	if err := Set("level", "low"); err != nil || c.Level != 1 {
+		t.Fatalf("expected Set via interface handler, level=%v err=%v", c.Level, err)
+	}
+
+	UnregisterStructHandler(iface)
+	var c2 C
+	// without the interface handler the int kind fallback cannot parse "high"
+	if err := parseStructNoArgs(t, &c2); err == nil || !strings.Contains(err.Error(), "invalid default int") {
+		t.Fatalf("expected kind fallback after unregister, got %v", err)
+	}
+}
+
+func TestStructHandlerForInterface_RejectsConcreteType(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("expected panic for non-interface type")
+		}
+	}()
+	RegisterStructHandlerForInterface(reflect.TypeOf(0), func(*StructFieldContext) (bool, error) { return false, nil })
+}
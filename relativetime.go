@@ -0,0 +1,118 @@
+package flag
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// relativeTimeRE matches expressions such as "now", "now-24h", "yesterday",
+// "monday 09:00" and "today 08:30+90m".
+var relativeTimeRE = regexp.MustCompile(`^(now|today|yesterday|tomorrow|sunday|monday|tuesday|wednesday|thursday|friday|saturday)(?:\s+(\d{1,2}):(\d{2})(?::(\d{2}))?)?\s*([+-].+)?$`)
+
+// relativeDaysRE matches the day and week units accepted in offsets on top of
+// those understood by time.ParseDuration.
+var relativeDaysRE = regexp.MustCompile(`(\d+)([dw])`)
+
+var weekdays = map[string]time.Weekday{
+	"sunday": time.Sunday, "monday": time.Monday, "tuesday": time.Tuesday,
+	"wednesday": time.Wednesday, "thursday": time.Thursday,
+	"friday": time.Friday, "saturday": time.Saturday,
+}
+
+// parseRelativeTime evaluates a relative expression against now. It reports
+// ok=false when s is not a relative expression at all, so callers can fall back
+// to absolute layouts.
+//
+// A weekday name means the most recent such day, today included. Day words may
+// be followed by a time of day (HH:MM or HH:MM:SS); any base may be followed by
+// a signed offset using time.ParseDuration units plus d (days) and w (weeks).
+func parseRelativeTime(s string, now time.Time) (t time.Time, ok bool, err error) {
+	m := relativeTimeRE.FindStringSubmatch(strings.ToLower(strings.TrimSpace(s)))
+	if m == nil {
+		return time.Time{}, false, nil
+	}
+	base, hh, mm, ss, offset := m[1], m[2], m[3], m[4], m[5]
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	switch base {
+	case "now":
+		if hh != "" {
+			return time.Time{}, true, fmt.Errorf("relative time %q: now does not take a time of day", s)
+		}
+		t = now
+	case "today":
+		t = midnight
+	case "yesterday":
+		t = midnight.AddDate(0, 0, -1)
+	case "tomorrow":
+		t = midnight.AddDate(0, 0, 1)
+	default:
+		back := (int(now.Weekday()) - int(weekdays[base]) + 7) % 7
+		t = midnight.AddDate(0, 0, -back)
+	}
+	if hh != "" {
+		h, _ := strconv.Atoi(hh)
+		mi, _ := strconv.Atoi(mm)
+		sec := 0
+		if ss != "" {
+			sec, _ = strconv.Atoi(ss)
+		}
+		if h > 23 || mi > 59 || sec > 59 {
+			return time.Time{}, true, fmt.Errorf("relative time %q: invalid time of day", s)
+		}
+		t = time.Date(t.Year(), t.Month(), t.Day(), h, mi, sec, 0, t.Location())
+	}
+	if offset != "" {
+		d, err := parseRelativeOffset(offset)
+		if err != nil {
+			return time.Time{}, true, fmt.Errorf("relative time %q: %v", s, err)
+		}
+		t = t.Add(d)
+	}
+	return t, true, nil
+}
+
+// parseRelativeOffset parses a signed duration such as "-24h", "+1h30m" or
+// "-7d", converting days and weeks to hours.
+func parseRelativeOffset(s string) (time.Duration, error) {
+	s = strings.ReplaceAll(s, " ", "")
+	s = relativeDaysRE.ReplaceAllStringFunc(s, func(part string) string {
+		sub := relativeDaysRE.FindStringSubmatch(part)
+		n, _ := strconv.Atoi(sub[1])
+		if sub[2] == "w" {
+			n *= 7
+		}
+		return strconv.Itoa(n*24) + "h"
+	})
+	return time.ParseDuration(s)
+}
+
+// SetClock replaces the time source used for time-dependent parsing such as
+// relative time expressions, and for the times recorded in audit events,
+// rejected updates and WatcherStatus, so tests can be deterministic and
+// simulation tools can run at another time. A nil clock restores time.Now.
+func (f *FlagSet) SetClock(clock func() time.Time) { f.clock = clock }
+
+// SetClock replaces the time source of the default CommandLine FlagSet.
+func SetClock(clock func() time.Time) { CommandLine.SetClock(clock) }
+
+// now returns the current time according to the FlagSet's clock.
+func (f *FlagSet) now() time.Time {
+	if f.clock != nil {
+		return f.clock()
+	}
+	return time.Now()
+}
+
+// parseTimeDefault parses a struct default tag for a time.Time field, trying a
+// relative expression first when now is non-nil.
+func parseTimeDefault(s string, layouts []string, now func() time.Time) (time.Time, error) {
+	if now != nil {
+		if t, ok, err := parseRelativeTime(s, now()); ok {
+			return t, err
+		}
+	}
+	return parseTimeLayouts(s, layouts)
+}
@@ -0,0 +1,98 @@
+package flag
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRelativeTime(t *testing.T) {
+	// Wednesday
+	now := time.Date(2024, 3, 13, 15, 4, 5, 0, time.UTC)
+	day := func(d, h, m int) time.Time { return time.Date(2024, 3, d, h, m, 0, 0, time.UTC) }
+	cases := map[string]time.Time{
+		"now":                 now,
+		"NOW-24h":             now.Add(-24 * time.Hour),
+		"now+1h30m":           now.Add(90 * time.Minute),
+		"now-7d":              now.AddDate(0, 0, -7),
+		"now-1w":              now.AddDate(0, 0, -7),
+		"today":               day(13, 0, 0),
+		"yesterday":           day(12, 0, 0),
+		"tomorrow 08:30":      day(14, 8, 30),
+		"monday 09:00":        day(11, 9, 0),
+		"wednesday":           day(13, 0, 0),
+		"thursday":            day(7, 0, 0),
+		"yesterday 17:00-30m": day(12, 16, 30),
+	}
+	for in, want := range cases {
+		got, ok, err := parseRelativeTime(in, now)
+		if !ok || err != nil {
+			t.Errorf("%q: ok=%v err=%v", in, ok, err)
+			continue
+		}
+		if !got.Equal(want) {
+			t.Errorf("%q = %v, want %v", in, got, want)
+		}
+	}
+
+	for _, in := range []string{"2024-03-01", "nowhere", "next monday"} {
+		if _, ok, _ := parseRelativeTime(in, now); ok {
+			t.Errorf("%q unexpectedly treated as relative", in)
+		}
+	}
+	for _, in := range []string{"now 09:00", "today 25:00", "now-3x"} {
+		if _, ok, err := parseRelativeTime(in, now); !ok || err == nil {
+			t.Errorf("%q: expected error, got ok=%v err=%v", in, ok, err)
+		}
+	}
+}
+
+func TestRelativeTimeFlag(t *testing.T) {
+	now := time.Date(2024, 3, 13, 15, 0, 0, 0, time.UTC)
+	f := NewFlagSet("test", ContinueOnError)
+	f.SetClock(func() time.Time { return now })
+	since := f.RelativeTime("since", []string{time.DateOnly}, time.Time{}, "")
+	until := f.RelativeTime("until", []string{time.DateOnly}, time.Time{}, "")
+	fixed := f.TimeLayouts("fixed", []string{time.DateOnly}, time.Time{}, "")
+	if err := f.Parse([]string{"-since", "now-24h", "-until", "2024-03-20"}); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if !since.Equal(now.Add(-24*time.Hour)) || until.Day() != 20 {
+		t.Fatalf("since=%v until=%v", *since, *until)
+	}
+	if err := f.Set("fixed", "now"); err == nil {
+		t.Fatalf("relative expression accepted without opt-in: %v", *fixed)
+	}
+}
+
+func TestRelativeTimeStructTag(t *testing.T) {
+	ResetForTesting(nil)
+	now := time.Date(2024, 3, 13, 15, 0, 0, 0, time.UTC)
+	SetClock(func() time.Time { return now })
+	var cfg struct {
+		Since time.Time `flag:"since" relative:"true" layout:"2006-01-02" default:"yesterday 09:00"`
+	}
+	if err := ParseStructWithOptions(&cfg, ParseStructOptions{}); err != nil {
+		t.Fatalf("ParseStructWithOptions: %v", err)
+	}
+	if want := time.Date(2024, 3, 12, 9, 0, 0, 0, time.UTC); !cfg.Since.Equal(want) {
+		t.Fatalf("default = %v, want %v", cfg.Since, want)
+	}
+	if err := CommandLine.Set("since", "monday"); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Since.Day() != 11 {
+		t.Fatalf("since = %v", cfg.Since)
+	}
+}
+
+func TestSetClockNilRestoresNow(t *testing.T) {
+	f := NewFlagSet("test", ContinueOnError)
+	f.SetClock(func() time.Time { return time.Unix(0, 0) })
+	if !f.now().Equal(time.Unix(0, 0)) {
+		t.Fatalf("clock not used: %v", f.now())
+	}
+	f.SetClock(nil)
+	if time.Since(f.now()) > time.Minute {
+		t.Fatalf("nil clock did not restore time.Now: %v", f.now())
+	}
+}
@@ -0,0 +1,287 @@
+package flag
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"slices"
+	"sync"
+	"time"
+)
+
+// reloadableSource reports whether values from src are re-resolved by
+// Reload. Command-line and environment values never change while the
+// process runs, so they keep their precedence.
+func reloadableSource(src string) bool {
+	switch Source(src) {
+	case SourceSecret, SourceConfig, SourceRemote:
+		return true
+	}
+	return false
+}
+
+// flagSnapshot holds what Reload may change, to put it back if the reload
+// fails.
+type flagSnapshot struct {
+	values  map[string]string
+	actual  map[string]*Flag
+	sources map[string]string
+}
+
+func (f *FlagSet) snapshot() flagSnapshot {
+	s := flagSnapshot{
+		values:  make(map[string]string, len(f.formal)),
+		actual:  make(map[string]*Flag, len(f.actual)),
+		sources: make(map[string]string, len(f.sources)),
+	}
+	for name, fl := range f.formal {
+		s.values[name] = fl.Value.String()
+	}
+	for name, fl := range f.actual {
+		s.actual[name] = fl
+	}
+	for name, src := range f.sources {
+		s.sources[name] = src
+	}
+	return s
+}
+
+// restore puts the values and provenance of s back. Values are restored
+// through Set with their previous string form, and only where they changed.
+func (f *FlagSet) restore(s flagSnapshot) {
+	for name, v := range s.values {
+		if fl := f.formal[name]; fl != nil && fl.Value.String() != v {
+			_ = fl.Value.Set(v)
+		}
+	}
+	f.actual = s.actual
+	f.sources = s.sources
+}
+
+// Change describes a flag whose value a Reload changed. Old and New are
+// "******" for sensitive flags.
+type Change struct {
+	Name      string `json:"name"`
+	Old       string `json:"old"`
+	New       string `json:"new"`
+	Source    string `json:"source"` // where the new value came from, e.g. "config" or "default"
+	Sensitive bool   `json:"sensitive,omitempty"`
+}
+
+// RejectedUpdate describes an update that was not applied because a value
+// did not parse as its flag's type or broke a constraint (min, max, pattern,
+// enum or a Deferred validation). The whole update is rejected and every
+// flag keeps its previous value.
+type RejectedUpdate struct {
+	Source  string    `json:"source"` // the watched path, "remote provider NAME" or "signal NAME"
+	Time    time.Time `json:"time"`
+	Error   string    `json:"error"`
+	Err     error     `json:"-"`
+	Changes []Change  `json:"changes,omitempty"` // what the update would have changed, as far as it got
+}
+
+// OnRejectedUpdate registers fn to be called when an update from a watched
+// file, a remote push or ReloadOnSignal is rejected. Reload returns its error
+// to the caller instead. fn runs in the goroutine that applied the update,
+// after the flags have been restored.
+func (f *FlagSet) OnRejectedUpdate(fn func(RejectedUpdate)) {
+	if fn == nil {
+		return
+	}
+	f.changeMu.Lock()
+	defer f.changeMu.Unlock()
+	f.rejectHandlers = append(f.rejectHandlers, fn)
+}
+
+// OnRejectedUpdate adds a rejected update callback to the default FlagSet.
+func OnRejectedUpdate(fn func(RejectedUpdate)) { CommandLine.OnRejectedUpdate(fn) }
+
+// rejectUpdate records a rejected update from source for WatcherStatus and
+// calls the OnRejectedUpdate callbacks. The caller must not hold watchMu.
+func (f *FlagSet) rejectUpdate(source string, attempted []Change, err error) {
+	r := RejectedUpdate{Source: source, Time: f.now(), Error: err.Error(), Err: err, Changes: attempted}
+	f.watchMu.Lock()
+	f.watchErr, f.watchErrAt = fmt.Errorf("%s: %w", source, err), r.Time
+	f.watchMu.Unlock()
+	f.changeMu.Lock()
+	handlers := slices.Clone(f.rejectHandlers)
+	f.changeMu.Unlock()
+	for _, fn := range handlers {
+		func() {
+			defer func() {
+				if p := recover(); p != nil {
+					f.warnf("OnRejectedUpdate callback panicked: %v", p)
+				}
+			}()
+			fn(r)
+		}()
+	}
+}
+
+// Reload resolves the secret directory, config files and remote providers
+// again, as Parse did, returns the flags whose value changed, sorted by name,
+// and calls their OnChange callbacks. It needs no watcher, so an application
+// can reload from an admin endpoint. Values from the command line and the
+// environment keep their precedence; a flag whose secret, config or remote
+// value was removed falls back to its default. If any source fails, or a new
+// value breaks a flag's constraints, every flag is restored to its value
+// before Reload and the error is returned, so a bad edit never leaves the
+// program half reconfigured.
+func (f *FlagSet) Reload(ctx context.Context) ([]Change, error) {
+	changes, _, err := f.reload(ctx)
+	return changes, err
+}
+
+// reload is Reload, also returning on failure the changes the rejected
+// update would have made.
+func (f *FlagSet) reload(ctx context.Context) (changes, attempted []Change, err error) {
+	f.watchMu.Lock()
+	f.initLastValues()
+	snap := f.snapshot()
+	f.beginAuditBatch()
+	fetched, err := f.reloadSources(ctx)
+	if err == nil {
+		err = f.checkValues()
+	}
+	if err != nil {
+		attempted = f.changesSince(snap)
+		f.restore(snap)
+	} else {
+		changes = f.changesSince(snap)
+	}
+	events := f.endAuditBatch(changes, err == nil)
+	values := f.currentValues()
+	f.watchMu.Unlock()
+	if err != nil {
+		return nil, attempted, err
+	}
+	f.cacheRemote(fetched)
+	f.sendAudit(events)
+	f.changeMu.Lock()
+	f.changeStopped = false // callbacks run even with the watcher stopped
+	f.changeMu.Unlock()
+	f.diffAndDispatch(values)
+	return changes, nil, nil
+}
+
+// initLastValues records the current values as those OnChange callbacks
+// last saw, if nothing has been recorded yet. The caller holds watchMu.
+func (f *FlagSet) initLastValues() {
+	f.changeMu.Lock()
+	defer f.changeMu.Unlock()
+	if f.lastValues != nil {
+		return
+	}
+	f.lastValues = make(map[string]string, len(f.formal))
+	for name, fl := range f.formal {
+		f.lastValues[name] = fl.Value.String()
+	}
+}
+
+// checkValues runs the deferred validations (struct min, max, pattern and
+// precision tags, and functions added with Deferred) against the current
+// values, so a reload cannot apply what Parse would have refused.
+func (f *FlagSet) checkValues() error {
+	var all MultiError
+	for _, fn := range f.deferredValidations {
+		all.Append(fn())
+	}
+	if all.HasErrors() {
+		return &all
+	}
+	return nil
+}
+
+// Reload reloads the default CommandLine FlagSet.
+func Reload(ctx context.Context) ([]Change, error) { return CommandLine.Reload(ctx) }
+
+// changesSince lists the flags whose value differs from s.
+func (f *FlagSet) changesSince(s flagSnapshot) []Change {
+	var changes []Change
+	for _, fl := range sortFlags(f.formal) {
+		old, cur := s.values[fl.Name], fl.Value.String()
+		if old == cur {
+			continue
+		}
+		c := Change{Name: fl.Name, Old: old, New: cur, Source: f.sources[fl.Name]}
+		if fl.Sensitive || f.isSensitive(fl.Name) {
+			c.Old, c.New, c.Sensitive = "******", "******", true
+		}
+		if c.Source == "" {
+			c.Source = string(SourceDefault)
+		}
+		changes = append(changes, c)
+	}
+	return changes
+}
+
+// reloadSources clears the values of reloadable sources and applies those
+// sources again, returning the remote values fetched for the fallback cache.
+// The caller holds watchMu.
+func (f *FlagSet) reloadSources(ctx context.Context) (fetched map[string]map[string]string, err error) {
+	for name, src := range f.sources {
+		if !reloadableSource(src) {
+			continue
+		}
+		if fl := f.formal[name]; fl != nil {
+			_ = fl.Value.Set(fl.DefValue)
+		}
+		delete(f.actual, name)
+		f.sources[name] = string(SourceDefault)
+	}
+	if dir := f.secretDirPath(); dir != "" {
+		if err := f.ParseSecretDir(dir); err != nil {
+			return nil, err
+		}
+	}
+	if files := f.configFilePaths(); len(files) > 0 {
+		if err := f.parseConfigFiles(files); err != nil {
+			return nil, err
+		}
+	}
+	if len(f.remoteProviders) > 0 {
+		if fetched, err = f.parseRemote(ctx); err != nil {
+			return nil, err
+		}
+	}
+	for _, fn := range f.afterParse {
+		if err := fn(); err != nil {
+			return nil, err
+		}
+	}
+	return fetched, nil
+}
+
+// ReloadOnSignal calls Reload whenever one of sigs arrives, typically
+// syscall.SIGHUP, until stop is called. A failed reload keeps the previous
+// values, prints a warning, is reported by WatcherStatus as the last error
+// and to OnRejectedUpdate callbacks.
+func (f *FlagSet) ReloadOnSignal(sigs ...os.Signal) (stop func()) {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, sigs...)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case sig := <-ch:
+				if _, attempted, err := f.reload(context.Background()); err != nil {
+					f.rejectUpdate("signal "+sig.String(), attempted, err)
+					f.warnf("reload on %v failed, keeping the previous values: %v", sig, err)
+				}
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(ch)
+			close(done)
+		})
+	}
+}
+
+// ReloadOnSignal reloads the default CommandLine FlagSet on sigs.
+func ReloadOnSignal(sigs ...os.Signal) (stop func()) { return CommandLine.ReloadOnSignal(sigs...) }
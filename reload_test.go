@@ -0,0 +1,204 @@
+package flag_test
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	. "github.com/machship/flag"
+)
+
+func TestReload(t *testing.T) {
+	cfg := filepath.Join(t.TempDir(), "app.conf")
+	if err := os.WriteFile(cfg, []byte("port 8081\nhost db1\nlevel debug\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	f := NewFlagSet("test", ContinueOnError)
+	f.SetOutput(io.Discard)
+	port := f.Int("port", 8080, "")
+	host := f.String("host", "localhost", "")
+	level := f.String("level", "info", "")
+	f.String(DefaultConfigFlagname, "", "")
+	t.Setenv("LEVEL", "warn")
+	if err := f.Parse([]string{"-config", cfg, "-host", "cli"}); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	var changes []string
+	for _, name := range []string{"port", "host", "level"} {
+		name := name
+		f.OnChange(name, func(v string) { changes = append(changes, name+"="+v) })
+	}
+
+	if err := os.WriteFile(cfg, []byte("host db2\nlevel error\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	changed, err := f.Reload(context.Background())
+	if err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	if len(changed) != 1 || changed[0] != (Change{Name: "port", Old: "8081", New: "8080", Source: "default"}) {
+		t.Fatalf("changes = %+v", changed)
+	}
+	// The port falls back to its default; the command line and environment win.
+	if *port != 8080 || *host != "cli" || *level != "warn" || strings.Join(changes, ",") != "port=8080" {
+		t.Fatalf("port=%d host=%q level=%q changes=%q", *port, *host, *level, changes)
+	}
+
+	if err := os.WriteFile(cfg, []byte("port 9090\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Reload(context.Background()); err != nil || *port != 9090 {
+		t.Fatalf("Reload: %v, port=%d", err, *port)
+	}
+	if err := os.WriteFile(cfg, []byte("port 7070\nport-typo 1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if changed, err := f.Reload(context.Background()); err == nil || changed != nil {
+		t.Fatalf("Reload of a bad config = %v, %v", changed, err)
+	}
+	if *port != 9090 || len(changes) != 2 {
+		t.Fatalf("failed reload left port=%d changes=%q", *port, changes)
+	}
+	for _, m := range f.Introspect() {
+		if m.Name == "port" && m.Source != "config" {
+			t.Fatalf("port source after rollback = %q", m.Source)
+		}
+	}
+}
+
+func TestReloadConcurrent(t *testing.T) {
+	var version atomic.Int64
+	f := NewFlagSet("test", ContinueOnError)
+	f.SetOutput(io.Discard)
+	f.String("version", "", "")
+	f.AddRemoteProvider("counter", RemoteProviderFunc(func(context.Context) (map[string]string, error) {
+		return map[string]string{"version": strconv.FormatInt(version.Add(1), 10)}, nil
+	}))
+	if err := f.Parse(nil); err != nil {
+		t.Fatal(err)
+	}
+	var mu sync.Mutex
+	var last string
+	f.OnChange("version", func(v string) {
+		mu.Lock()
+		last = v
+		mu.Unlock()
+	})
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				if _, err := f.Reload(context.Background()); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if _, err := f.Reload(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if want := f.Lookup("version").Value.String(); last != want {
+		t.Fatalf("last change delivered %q, flag is %q", last, want)
+	}
+}
+
+func TestReloadChangesMasked(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "db-password"), []byte("one"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	f := NewFlagSet("test", ContinueOnError)
+	f.SetOutput(io.Discard)
+	f.String("db-password", "", "")
+	f.MarkSensitive("db-password")
+	f.String(DefaultSecretDirFlagname, "", "")
+	if err := f.Parse([]string{"-" + DefaultSecretDirFlagname, dir}); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if changed, err := f.Reload(context.Background()); err != nil || len(changed) != 0 {
+		t.Fatalf("unchanged Reload = %+v, %v", changed, err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "db-password"), []byte("two"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	changed, err := f.Reload(context.Background())
+	if err != nil || len(changed) != 1 || changed[0] != (Change{Name: "db-password", Old: "******", New: "******", Source: "secret", Sensitive: true}) {
+		t.Fatalf("Reload = %+v, %v", changed, err)
+	}
+	if v := f.Lookup("db-password").Value.String(); v != "two" {
+		t.Fatalf("db-password = %q", v)
+	}
+}
+
+func TestReloadOnSignal(t *testing.T) {
+	cfg := filepath.Join(t.TempDir(), "app.conf")
+	if err := os.WriteFile(cfg, []byte("port 8081\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	f := NewFlagSet("test", ContinueOnError)
+	f.SetOutput(io.Discard)
+	f.Int("port", 8080, "")
+	f.String(DefaultConfigFlagname, "", "")
+	clock := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	f.SetClock(func() time.Time { return clock })
+	if err := f.Parse([]string{"-config", cfg}); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	changed := make(chan string, 1)
+	f.OnChange("port", func(v string) { changed <- v })
+	rejected := make(chan RejectedUpdate, 1)
+	f.OnRejectedUpdate(func(r RejectedUpdate) { rejected <- r })
+	stop := f.ReloadOnSignal(syscall.SIGHUP)
+	defer stop()
+
+	if err := os.WriteFile(cfg, []byte("port 9090\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	p, _ := os.FindProcess(os.Getpid())
+	if err := p.Signal(syscall.SIGHUP); err != nil {
+		t.Skipf("cannot signal self: %v", err)
+	}
+	select {
+	case v := <-changed:
+		if v != "9090" {
+			t.Fatalf("changed to %q", v)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no reload after SIGHUP")
+	}
+
+	if err := os.WriteFile(cfg, []byte("port nine\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	p.Signal(syscall.SIGHUP)
+	select {
+	case r := <-rejected:
+		if r.Source != "signal "+syscall.SIGHUP.String() || r.Err == nil || !strings.Contains(r.Error, `"nine"`) || !r.Time.Equal(clock) {
+			t.Fatalf("rejected update = %+v", r)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("failed reload not reported to OnRejectedUpdate")
+	}
+	if st := f.WatcherStatus(); st.LastError == "" || !st.LastErrorAt.Equal(clock) {
+		t.Fatalf("failed reload not reported by WatcherStatus: %+v", st)
+	}
+	if v := f.Lookup("port").Value.String(); v != "9090" {
+		t.Fatalf("port after failed reload = %s", v)
+	}
+	stop()
+	stop()
+}
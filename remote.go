@@ -0,0 +1,135 @@
+package flag
+
+import (
+	"context"
+	"sort"
+)
+
+// RemoteProvider fetches flag values from a remote system such as Vault,
+// Consul or an HTTP endpoint. Fetch returns values keyed by flag name.
+type RemoteProvider interface {
+	Fetch(ctx context.Context) (map[string]string, error)
+}
+
+// RemoteProviderFunc adapts an ordinary function to a RemoteProvider.
+type RemoteProviderFunc func(ctx context.Context) (map[string]string, error)
+
+// Fetch calls fn(ctx).
+func (fn RemoteProviderFunc) Fetch(ctx context.Context) (map[string]string, error) { return fn(ctx) }
+
+type remoteProvider struct {
+	name string
+	p    RemoteProvider
+}
+
+// AddRemoteProvider registers p under name, which identifies it in errors,
+// warnings and the fallback cache. Parse consults remote providers after the
+// config file, so they have the lowest precedence above defaults; among
+// providers, the one registered first wins.
+func (f *FlagSet) AddRemoteProvider(name string, p RemoteProvider) {
+	f.remoteProviders = append(f.remoteProviders, remoteProvider{name, p})
+}
+
+// AddRemoteProvider registers a remote provider on the default CommandLine
+// FlagSet.
+func AddRemoteProvider(name string, p RemoteProvider) { CommandLine.AddRemoteProvider(name, p) }
+
+// ParseRemote fetches every registered remote provider and applies the
+// values to flags not already set. Unlike the other sources, remote values
+// are never expanded as @file references. A key naming no flag is an error.
+//
+// A value of the form {"value": "on", "rollout": 25, "salt": "name"} is a
+// gradual rollout: it applies only on the given percentage of instances,
+// chosen by a stable hash of the salt (default: the flag name) and the
+// instance's identity (see SetIdentity). Elsewhere the flag keeps its lower
+// precedence value. JSON flags always receive the object verbatim.
+//
+// Values fetched are written to the fallback cache only once every provider
+// has been verified and applied without error.
+func (f *FlagSet) ParseRemote(ctx context.Context) error {
+	fetched, err := f.parseRemote(ctx)
+	if err != nil {
+		return err
+	}
+	f.cacheRemote(fetched)
+	return nil
+}
+
+// parseRemote is ParseRemote without caching, returning the values freshly
+// fetched for the caller to cache once it has accepted them.
+func (f *FlagSet) parseRemote(ctx context.Context) (fetched map[string]map[string]string, err error) {
+	for _, rp := range f.remoteProviders {
+		raw, fresh, err := f.fetchRemote(ctx, rp)
+		var values map[string]string
+		if err == nil {
+			values, err = f.verifyRemoteValues(raw)
+		}
+		if err != nil {
+			return nil, f.failf("remote provider %s: %w", rp.name, err)
+		}
+		names := make([]string, 0, len(values))
+		for name := range values {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		restore := f.setAuditActor(rp.name)
+		for _, name := range names {
+			if err := f.applyRemoteValue(rp.name, name, values[name]); err != nil {
+				restore()
+				return nil, err
+			}
+		}
+		restore()
+		if fresh {
+			if fetched == nil {
+				fetched = make(map[string]map[string]string)
+			}
+			fetched[rp.name] = raw
+		}
+	}
+	return fetched, nil
+}
+
+// applyRemoteValue sets the named flag from a remote provider, honouring
+// precedence and recording the "remote" source.
+func (f *FlagSet) applyRemoteValue(provider, name, value string) error {
+	if fl := f.actual[name]; fl != nil {
+		f.audit(fl, SourceRemote, value, false, nil)
+		return nil
+	}
+	flag := f.formal[name]
+	if flag == nil {
+		return f.failf("remote provider %s: variable provided but not defined: %s", provider, name)
+	}
+	if _, isJSON := flag.Value.(*jsonValue); !isJSON {
+		rv, ok, err := parseRollout(value)
+		if err != nil {
+			return f.failf("remote provider %s: invalid rollout for %s: %v", provider, name, err)
+		}
+		if ok {
+			salt := rv.Salt
+			if salt == "" {
+				salt = name
+			}
+			if !f.inRollout(salt, *rv.Rollout) {
+				f.audit(flag, SourceRemote, *rv.Value, false, nil)
+				return nil
+			}
+			value = *rv.Value
+		}
+	}
+	if err := f.setValue(flag, value, SourceRemote); err != nil {
+		if f.isSensitive(name) {
+			return f.failf("remote provider %s: invalid value for %s: %v", provider, name, err)
+		}
+		return f.failf("remote provider %s: invalid value %q for %s: %v", provider, value, name, err)
+	}
+	if f.actual == nil {
+		f.actual = make(map[string]*Flag)
+	}
+	f.actual[name] = flag
+	if f.sources != nil {
+		f.sources[name] = string(SourceRemote)
+	}
+	return nil
+}
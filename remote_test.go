@@ -0,0 +1,159 @@
+package flag_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/machship/flag"
+)
+
+func TestRemoteProviderPrecedence(t *testing.T) {
+	f := NewFlagSet("test", ContinueOnError)
+	f.SetOutput(&bytes.Buffer{})
+	host := f.String("db.host", "", "")
+	port := f.Int("db.port", 0, "")
+	level := f.String("log-level", "info", "")
+	f.AddRemoteProvider("vault", RemoteProviderFunc(func(context.Context) (map[string]string, error) {
+		return map[string]string{"db.host": "vault", "db.port": "5432"}, nil
+	}))
+	f.AddRemoteProvider("consul", RemoteProviderFunc(func(context.Context) (map[string]string, error) {
+		return map[string]string{"db.host": "consul", "log-level": "debug"}, nil
+	}))
+	if err := f.Parse([]string{"-db.port=6000"}); err != nil {
+		t.Fatal(err)
+	}
+	if *host != "vault" || *port != 6000 || *level != "debug" {
+		t.Errorf("host=%q port=%d log-level=%q", *host, *port, *level)
+	}
+	for _, m := range f.Introspect() {
+		if m.Name == "db.host" && m.Source != "remote" {
+			t.Errorf("db.host source = %q", m.Source)
+		}
+	}
+
+	f = NewFlagSet("test", ContinueOnError)
+	f.SetOutput(&bytes.Buffer{})
+	f.AddRemoteProvider("vault", RemoteProviderFunc(func(context.Context) (map[string]string, error) {
+		return map[string]string{"nope": "x"}, nil
+	}))
+	if err := f.Parse(nil); err == nil || !strings.Contains(err.Error(), "remote provider vault: variable provided but not defined: nope") {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestFallbackCache(t *testing.T) {
+	cache := filepath.Join(t.TempDir(), "remote.cache")
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	var down bool
+	provider := RemoteProviderFunc(func(context.Context) (map[string]string, error) {
+		if down {
+			return nil, errors.New("connection refused")
+		}
+		return map[string]string{"token": "s3cret"}, nil
+	})
+	parse := func() (string, string, error) {
+		var out bytes.Buffer
+		f := NewFlagSet("test", ContinueOnError)
+		f.SetOutput(&out)
+		token := f.String("token", "", "")
+		f.SetClock(func() time.Time { return now })
+		f.SetFallbackCache(cache, time.Hour)
+		f.AddRemoteProvider("vault", provider)
+		err := f.Parse(nil)
+		return *token, out.String(), err
+	}
+
+	if token, _, err := parse(); err != nil || token != "s3cret" {
+		t.Fatalf("token = %q, err = %v", token, err)
+	}
+	b, err := os.ReadFile(cache)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(b, []byte("s3cret")) {
+		t.Error("cache is not encrypted")
+	}
+
+	down = true
+	now = now.Add(30 * time.Minute)
+	token, out, err := parse()
+	if err != nil || token != "s3cret" {
+		t.Fatalf("token = %q, err = %v", token, err)
+	}
+	if !strings.Contains(out, "warning: remote provider vault unavailable (connection refused); using cached values from 2025-01-01T00:00:00Z") {
+		t.Errorf("output = %q", out)
+	}
+
+	now = now.Add(time.Hour)
+	if _, out, err := parse(); err == nil || !strings.Contains(out, "have expired") {
+		t.Errorf("expired cache: err = %v, output = %q", err, out)
+	}
+}
+
+func TestFallbackCacheKey(t *testing.T) {
+	cache := filepath.Join(t.TempDir(), "remote.cache")
+	f := NewFlagSet("test", ContinueOnError)
+	if err := f.SetFallbackCacheKey(make([]byte, 32)); err == nil {
+		t.Error("key accepted without SetFallbackCache")
+	}
+	f.SetFallbackCache(cache, 0)
+	if err := f.SetFallbackCacheKey([]byte("short")); err == nil {
+		t.Error("short key accepted")
+	}
+	if err := f.SetFallbackCacheKey(bytes.Repeat([]byte{1}, 32)); err != nil {
+		t.Fatal(err)
+	}
+	f.String("token", "", "")
+	f.AddRemoteProvider("vault", RemoteProviderFunc(func(context.Context) (map[string]string, error) {
+		return map[string]string{"token": "x"}, nil
+	}))
+	if err := f.Parse(nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(cache + ".key"); !os.IsNotExist(err) {
+		t.Errorf("key file written despite explicit key: %v", err)
+	}
+}
+
+func TestFallbackCacheKeepsLastGood(t *testing.T) {
+	cache := filepath.Join(t.TempDir(), "remote.cache")
+	var values map[string]string
+	parse := func() (int, error) {
+		f := NewFlagSet("test", ContinueOnError)
+		f.SetOutput(&bytes.Buffer{})
+		port := f.Int("port", 8080, "")
+		f.SetFallbackCache(cache, 0)
+		f.AddRemoteProvider("central", RemoteProviderFunc(func(context.Context) (map[string]string, error) {
+			if values == nil {
+				return nil, errors.New("connection refused")
+			}
+			return values, nil
+		}))
+		err := f.Parse(nil)
+		return *port, err
+	}
+
+	values = map[string]string{"port": "9090"}
+	if port, err := parse(); err != nil || port != 9090 {
+		t.Fatalf("port = %d, err = %v", port, err)
+	}
+	// Values that fail to apply must not replace the last good ones.
+	values = map[string]string{"port": "ninety"}
+	if _, err := parse(); err == nil {
+		t.Fatal("bad value accepted")
+	}
+	values = map[string]string{"port": "9091", "nope": "x"}
+	if _, err := parse(); err == nil {
+		t.Fatal("unknown key accepted")
+	}
+	values = nil
+	if port, err := parse(); err != nil || port != 9090 {
+		t.Fatalf("after outage port = %d, err = %v", port, err)
+	}
+}
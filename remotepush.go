@@ -0,0 +1,355 @@
+package flag
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RemoteSubscriber is implemented by remote providers that can push
+// updates instead of waiting to be fetched. Subscribe calls update with the
+// provider's complete current values whenever they change, reconnecting as
+// needed, until ctx is done.
+type RemoteSubscriber interface {
+	Subscribe(ctx context.Context, update func(values map[string]string)) error
+}
+
+// RetryingSubscriber is implemented by RemoteSubscribers that retry on their
+// own after a failed request. SubscribeWithErrors is Subscribe, also calling
+// failed with the error of each failed attempt while it keeps retrying, so a
+// dead endpoint does not go unnoticed.
+type RetryingSubscriber interface {
+	RemoteSubscriber
+	SubscribeWithErrors(ctx context.Context, update func(values map[string]string), failed func(error)) error
+}
+
+// SubscribeRemote subscribes to every registered remote provider that
+// implements RemoteSubscriber. Each update replaces the values of its
+// provider and reloads f as Reload does: precedence is kept, an update with
+// a value that does not parse or breaks a constraint is rejected as a whole,
+// leaving every flag as it was, and OnChange callbacks run for the flags that
+// changed. Failures, including each failed attempt of a RetryingSubscriber,
+// are printed as warnings and reported by WatcherStatus; rejected updates
+// also go to OnRejectedUpdate callbacks.
+//
+// The subscriptions end when ctx is done or stop is called; stop waits for
+// them, and for an update being applied, until its own ctx is done.
+func (f *FlagSet) SubscribeRemote(ctx context.Context) (stop func(context.Context) error) {
+	ctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	for _, rp := range f.remoteProviders {
+		sub, ok := rp.p.(RemoteSubscriber)
+		if !ok {
+			continue
+		}
+		wg.Add(1)
+		go func(rp remoteProvider) {
+			defer wg.Done()
+			update := func(values map[string]string) { f.applyPushed(ctx, rp.name, values) }
+			var err error
+			if rs, ok := sub.(RetryingSubscriber); ok {
+				err = rs.SubscribeWithErrors(ctx, update, func(err error) { f.pushFailed(rp.name, err) })
+			} else {
+				err = sub.Subscribe(ctx, update)
+			}
+			if err != nil && ctx.Err() == nil {
+				f.pushFailed(rp.name, err)
+			}
+		}(rp)
+	}
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	return func(waitCtx context.Context) error {
+		cancel()
+		select {
+		case <-done:
+			return nil
+		case <-waitCtx.Done():
+			return waitCtx.Err()
+		}
+	}
+}
+
+// SubscribeRemote subscribes the default CommandLine FlagSet to its remote
+// providers.
+func SubscribeRemote(ctx context.Context) (stop func(context.Context) error) {
+	return CommandLine.SubscribeRemote(ctx)
+}
+
+// applyPushed makes values the current values of provider and reloads. A
+// rejected update is forgotten, so later reloads keep using the values
+// pushed before it, and is not written to the fallback cache.
+func (f *FlagSet) applyPushed(ctx context.Context, provider string, values map[string]string) {
+	f.remoteMu.Lock()
+	if f.remotePushed == nil {
+		f.remotePushed = make(map[string]map[string]string)
+	}
+	prev, hadPrev := f.remotePushed[provider]
+	f.remotePushed[provider] = values
+	f.remoteMu.Unlock()
+	_, attempted, err := f.reload(ctx)
+	if err != nil {
+		f.remoteMu.Lock()
+		if hadPrev {
+			f.remotePushed[provider] = prev
+		} else {
+			delete(f.remotePushed, provider)
+		}
+		f.remoteMu.Unlock()
+		f.rejectUpdate("remote provider "+provider, attempted, err)
+		f.warnf("remote provider %s: update rejected, keeping the previous values: %v", provider, err)
+		return
+	}
+	if c := f.fallbackCache; c != nil {
+		if err := c.store(provider, values, f.now()); err != nil {
+			f.warnf("remote provider %s: cannot update fallback cache: %v", provider, err)
+		}
+	}
+}
+
+func (f *FlagSet) pushFailed(provider string, err error) {
+	f.watchMu.Lock()
+	f.watchErr, f.watchErrAt = fmt.Errorf("remote provider %s: %w", provider, err), f.now()
+	f.watchMu.Unlock()
+	f.warnf("remote provider %s: update failed, keeping the previous values: %v", provider, err)
+}
+
+// pushedValues returns the values last pushed by provider, if any.
+func (f *FlagSet) pushedValues(provider string) (map[string]string, bool) {
+	f.remoteMu.Lock()
+	defer f.remoteMu.Unlock()
+	values, ok := f.remotePushed[provider]
+	return values, ok
+}
+
+// HTTPPushProvider is a RemoteProvider and RetryingSubscriber reading flag
+// values from an HTTP endpoint, so a fleet can be reconfigured centrally
+// without etcd or Consul. Each payload is a JSON object of flag names to
+// values holding every value the endpoint provides; values other than
+// strings are used in their JSON form.
+//
+// The endpoint may answer with a server-sent events stream
+// (text/event-stream), each event's data being such a payload, the first
+// sent on connect; the Last-Event-ID header resumes a dropped stream. Any
+// other response is read as a single payload and the request repeated once
+// the retry delay has passed since it was made, so a long-poll endpoint
+// should hold the request until the values change. The ETag of the last
+// payload is sent as If-None-Match, and 304 Not Modified is treated as no
+// change.
+type HTTPPushProvider struct {
+	URL        string
+	Client     *http.Client  // nil uses http.DefaultClient; do not set a Timeout for streams
+	Header     http.Header   // added to every request, e.g. Authorization
+	RetryDelay time.Duration // wait after a failed request, and least time between request starts; zero means 5s, and an SSE retry field overrides it
+}
+
+// NewHTTPPushProvider returns an HTTPPushProvider for url.
+func NewHTTPPushProvider(url string) *HTTPPushProvider { return &HTTPPushProvider{URL: url} }
+
+// pushState is what an HTTPPushProvider subscription carries between
+// requests.
+type pushState struct {
+	lastID string
+	etag   string
+	retry  time.Duration
+}
+
+// Fetch returns the endpoint's current values: the first event of a stream
+// or the body of any other response.
+func (p *HTTPPushProvider) Fetch(ctx context.Context) (map[string]string, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var values map[string]string
+	err := p.request(ctx, &pushState{}, func(v map[string]string) bool {
+		values = v
+		return false
+	})
+	if err != nil {
+		return nil, err
+	}
+	if values == nil {
+		return nil, fmt.Errorf("%s: no values received", p.URL)
+	}
+	return values, nil
+}
+
+// Subscribe implements RemoteSubscriber. Requests start at least the retry
+// delay apart, so a long-poll endpoint holding the request, or a stream that
+// ran for a while, is asked again at once, while one that answers
+// immediately, or with 304 Not Modified, is polled once per delay rather
+// than in a tight loop. Failed requests are retried after the delay; see
+// SubscribeWithErrors to learn of them.
+func (p *HTTPPushProvider) Subscribe(ctx context.Context, update func(values map[string]string)) error {
+	return p.SubscribeWithErrors(ctx, update, nil)
+}
+
+// SubscribeWithErrors implements RetryingSubscriber. failed, if not nil, is
+// called with the error of each request that fails: a transport error, a
+// status other than 200 and 304, or a malformed payload.
+func (p *HTTPPushProvider) SubscribeWithErrors(ctx context.Context, update func(values map[string]string), failed func(error)) error {
+	st := &pushState{}
+	for {
+		start := time.Now()
+		err := p.request(ctx, st, func(v map[string]string) bool {
+			update(v)
+			return true
+		})
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		wait := p.retryDelay(st)
+		if err != nil && failed != nil {
+			failed(err)
+		}
+		if err == nil {
+			// long-poll answered or stream closed: ask again, but not
+			// sooner than the delay after the last request began
+			wait -= time.Since(start)
+			if wait <= 0 {
+				continue
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// retryDelay is the wait after a failed request, and the least time between
+// the starts of two requests.
+func (p *HTTPPushProvider) retryDelay(st *pushState) time.Duration {
+	if st.retry > 0 {
+		return st.retry
+	}
+	if p.RetryDelay > 0 {
+		return p.RetryDelay
+	}
+	return 5 * time.Second
+}
+
+// request makes one request, calling handle for every payload received
+// until handle returns false or the response ends.
+func (p *HTTPPushProvider) request(ctx context.Context, st *pushState, handle func(map[string]string) bool) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.URL, nil)
+	if err != nil {
+		return err
+	}
+	for k, vs := range p.Header {
+		for _, v := range vs {
+			req.Header.Add(k, v)
+		}
+	}
+	req.Header.Set("Accept", "text/event-stream, application/json")
+	if st.lastID != "" {
+		req.Header.Set("Last-Event-ID", st.lastID)
+	}
+	if st.etag != "" {
+		req.Header.Set("If-None-Match", st.etag)
+	}
+	client := p.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotModified:
+		return nil
+	default:
+		return fmt.Errorf("%s: %s", p.URL, resp.Status)
+	}
+	if mt, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mt == "text/event-stream" {
+		return readEventStream(resp.Body, st, handle)
+	}
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	values, err := decodePushPayload(b)
+	if err != nil {
+		return fmt.Errorf("%s: %v", p.URL, err)
+	}
+	st.etag = resp.Header.Get("ETag")
+	handle(values)
+	return nil
+}
+
+// readEventStream reads server-sent events from r, passing the payload of
+// each to handle.
+func readEventStream(r io.Reader, st *pushState, handle func(map[string]string) bool) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 4<<20)
+	var data []string
+	for sc.Scan() {
+		line := sc.Text()
+		if line == "" { // end of event
+			if len(data) == 0 {
+				continue
+			}
+			values, err := decodePushPayload([]byte(strings.Join(data, "\n")))
+			data = data[:0]
+			if err != nil {
+				return err
+			}
+			if !handle(values) {
+				return nil
+			}
+			continue
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "data":
+			data = append(data, value)
+		case "id":
+			st.lastID = value
+		case "retry":
+			if ms, err := strconv.Atoi(value); err == nil && ms > 0 {
+				st.retry = time.Duration(ms) * time.Millisecond
+			}
+		}
+	}
+	return sc.Err()
+}
+
+// decodePushPayload decodes a JSON object of flag values. Strings are used
+// as they are, other values in their compact JSON form, so {"port": 8080,
+// "tags": ["a"]} gives "8080" and `["a"]`.
+func decodePushPayload(b []byte) (map[string]string, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return nil, fmt.Errorf("invalid payload: %v", err)
+	}
+	values := make(map[string]string, len(raw))
+	for name, v := range raw {
+		var s string
+		if err := json.Unmarshal(v, &s); err == nil {
+			values[name] = s
+			continue
+		}
+		var buf bytes.Buffer
+		if err := json.Compact(&buf, v); err != nil {
+			return nil, fmt.Errorf("invalid payload: %v", err)
+		}
+		values[name] = buf.String()
+	}
+	return values, nil
+}
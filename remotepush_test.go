@@ -0,0 +1,288 @@
+package flag_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/machship/flag"
+)
+
+// eventFeed serves its payloads as a server-sent events stream, each with
+// its index as the event ID, resuming after the Last-Event-ID a client
+// sends. An empty payload drops the connection.
+type eventFeed struct {
+	mu       sync.Mutex
+	payloads []string
+	wake     chan struct{}
+	resumed  atomic.Bool // a request carried Last-Event-ID
+}
+
+func (ef *eventFeed) push(payloads ...string) {
+	ef.mu.Lock()
+	defer ef.mu.Unlock()
+	ef.payloads = append(ef.payloads, payloads...)
+	close(ef.wake)
+	ef.wake = make(chan struct{})
+}
+
+func (ef *eventFeed) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	next := 0
+	if id, err := strconv.Atoi(r.Header.Get("Last-Event-ID")); err == nil {
+		next = id + 1
+		ef.resumed.Store(true)
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	fmt.Fprint(w, ": connected\n\n")
+	w.(http.Flusher).Flush()
+	for {
+		ef.mu.Lock()
+		if next == len(ef.payloads) {
+			wake := ef.wake
+			ef.mu.Unlock()
+			select {
+			case <-r.Context().Done():
+				return
+			case <-wake:
+			}
+			continue
+		}
+		payload := ef.payloads[next]
+		ef.mu.Unlock()
+		if payload == "" {
+			fmt.Fprintf(w, "id: %d\n\n", next)
+			return
+		}
+		fmt.Fprintf(w, "id: %d\nretry: 10\ndata: %s\n\n", next, payload)
+		w.(http.Flusher).Flush()
+		next++
+	}
+}
+
+func TestHTTPPushProviderEventStream(t *testing.T) {
+	feed := &eventFeed{wake: make(chan struct{})}
+	feed.push(`{"port": 8081, "region": "ap"}`)
+	srv := httptest.NewServer(feed)
+	defer srv.Close()
+
+	f := NewFlagSet("test", ContinueOnError)
+	f.SetOutput(&bytes.Buffer{})
+	port := f.Int("port", 8080, "")
+	region := f.String("region", "", "")
+	f.AddRemoteProvider("push", NewHTTPPushProvider(srv.URL))
+	if err := f.Parse([]string{"-region", "us"}); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if *port != 8081 || *region != "us" {
+		t.Fatalf("after Parse port=%d region=%q", *port, *region)
+	}
+
+	changed := make(chan string, 4)
+	f.OnChange("port", func(v string) { changed <- v })
+	stop := f.SubscribeRemote(context.Background())
+	defer stop(context.Background())
+	feed.push(`{"port": "9090", "region": "eu"}`)
+	select {
+	case v := <-changed:
+		if v != "9090" {
+			t.Fatalf("port changed to %q", v)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no update pushed")
+	}
+
+	// A dropped stream reconnects, resuming after the last event.
+	feed.push("", `{"port": 7070}`)
+	select {
+	case v := <-changed:
+		if v != "7070" {
+			t.Fatalf("port changed to %q", v)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no update after reconnect")
+	}
+	if !feed.resumed.Load() {
+		t.Fatal("reconnect did not send Last-Event-ID")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := stop(ctx); err != nil {
+		t.Fatalf("stop: %v", err)
+	}
+	if *region != "us" {
+		t.Fatalf("pushed region overrode the command line: %q", *region)
+	}
+}
+
+func TestHTTPPushProviderLongPoll(t *testing.T) {
+	var version atomic.Int32
+	version.Store(1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		etag := fmt.Sprintf(`"v%d"`, version.Load())
+		if r.Header.Get("If-None-Match") == etag {
+			time.Sleep(10 * time.Millisecond) // a real server holds the request
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Content-Type", "application/json")
+		if version.Load() == 3 {
+			fmt.Fprint(w, `{"port": "not a number"}`)
+			return
+		}
+		fmt.Fprintf(w, `{"port": %d}`, 8080+version.Load())
+	}))
+	defer srv.Close()
+
+	var out bytes.Buffer
+	f := NewFlagSet("test", ContinueOnError)
+	f.SetOutput(&out)
+	port := f.Int("port", 8080, "")
+	f.AddRemoteProvider("poll", &HTTPPushProvider{URL: srv.URL, RetryDelay: 10 * time.Millisecond})
+	if err := f.Parse(nil); err != nil || *port != 8081 {
+		t.Fatalf("Parse: %v, port=%d", err, *port)
+	}
+	changed := make(chan string, 4)
+	f.OnChange("port", func(v string) { changed <- v })
+	stop := f.SubscribeRemote(context.Background())
+	defer stop(context.Background())
+
+	version.Store(2)
+	select {
+	case v := <-changed:
+		if v != "8082" {
+			t.Fatalf("port changed to %q", v)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no update polled")
+	}
+
+	version.Store(3)
+	deadline := time.Now().Add(2 * time.Second)
+	for !strings.Contains(f.WatcherStatus().LastError, "remote provider poll") {
+		if time.Now().After(deadline) {
+			t.Fatal("bad payload not reported")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if v := f.Lookup("port").Value.String(); v != "8082" {
+		t.Fatalf("port after bad payload = %s", v)
+	}
+}
+
+func TestPushRejectedUpdate(t *testing.T) {
+	feed := &eventFeed{wake: make(chan struct{})}
+	feed.push(`{"port": 8081, "workers": 4}`)
+	srv := httptest.NewServer(feed)
+	defer srv.Close()
+
+	f := NewFlagSet("test", ContinueOnError)
+	f.SetOutput(&bytes.Buffer{})
+	port := f.Int("port", 8080, "")
+	workers := f.Int("workers", 1, "")
+	f.Deferred(func() error {
+		if *workers > 16 {
+			return fmt.Errorf("workers must be at most 16, got %d", *workers)
+		}
+		return nil
+	})
+	f.AddRemoteProvider("push", NewHTTPPushProvider(srv.URL))
+	if err := f.Parse(nil); err != nil || *port != 8081 {
+		t.Fatalf("Parse: %v, port=%d", err, *port)
+	}
+	changed := make(chan string, 4)
+	f.OnChange("port", func(v string) { changed <- v })
+	rejected := make(chan RejectedUpdate, 4)
+	f.OnRejectedUpdate(func(r RejectedUpdate) { rejected <- r })
+	stop := f.SubscribeRemote(context.Background())
+	defer stop(context.Background())
+
+	feed.push(`{"port": 9090, "workers": 64}`)
+	select {
+	case r := <-rejected:
+		if r.Source != "remote provider push" || !strings.Contains(r.Error, "workers must be at most 16") || len(r.Changes) != 2 {
+			t.Fatalf("rejected update = %+v", r)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("bad push not rejected")
+	}
+	if *port != 8081 || *workers != 4 {
+		t.Fatalf("after rejected push port=%d workers=%d", *port, *workers)
+	}
+	// The rejected values are not kept for later reloads.
+	if changes, err := f.Reload(context.Background()); err != nil || len(changes) != 0 {
+		t.Fatalf("Reload after rejected push: %v, %+v", err, changes)
+	}
+
+	feed.push(`{"port": 9091, "workers": 8}`)
+	select {
+	case v := <-changed:
+		if v != "9091" || *workers != 8 {
+			t.Fatalf("port changed to %q, workers=%d", v, *workers)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("good push not applied")
+	}
+	select {
+	case r := <-rejected:
+		t.Fatalf("unexpected rejection %+v", r)
+	default:
+	}
+}
+
+func TestHTTPPushProviderPollInterval(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified) // answers at once
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		fmt.Fprint(w, `{"port": 8081}`)
+	}))
+	defer srv.Close()
+
+	p := &HTTPPushProvider{URL: srv.URL, RetryDelay: 50 * time.Millisecond}
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	p.Subscribe(ctx, func(map[string]string) {})
+	if n := requests.Load(); n < 2 || n > 8 {
+		t.Fatalf("%d requests in 300ms with a 50ms delay", n)
+	}
+}
+
+func TestHTTPPushProviderFailures(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "down", http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	var warnings bytes.Buffer
+	f := NewFlagSet("test", ContinueOnError)
+	f.SetOutput(&warnings)
+	f.Int("port", 8080, "")
+	f.AddRemoteProvider("fleet", &HTTPPushProvider{URL: srv.URL, RetryDelay: 10 * time.Millisecond})
+	stop := f.SubscribeRemote(context.Background())
+	defer stop(context.Background())
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !strings.Contains(f.WatcherStatus().LastError, "500 Internal Server Error") {
+		if time.Now().After(deadline) {
+			t.Fatalf("failing endpoint not reported: %+v", f.WatcherStatus())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	stop(context.Background())
+	if !strings.Contains(warnings.String(), "remote provider fleet: update failed") {
+		t.Fatalf("no warning for failing endpoint: %q", warnings.String())
+	}
+}
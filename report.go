@@ -0,0 +1,169 @@
+package flag
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Report describes the outcome of a parse for wrappers and preflight tools.
+// It is meant to be serialised with encoding/json; sensitive values are
+// masked as in Introspect.
+type Report struct {
+	// Flags is the resolution of every registered flag, sorted by name.
+	Flags []FlagMeta `json:"flags"`
+	// Args are the arguments remaining after the flags.
+	Args []string `json:"args"`
+	// UnknownFlags are command-line flags that matched no registered flag.
+	UnknownFlags []string `json:"unknownFlags,omitempty"`
+	// Suggestions offers registered flags close to each unknown flag.
+	Suggestions []Suggestion `json:"suggestions,omitempty"`
+	// Warnings are the warnings printed while parsing, without the
+	// "warning: " prefix.
+	Warnings []string `json:"warnings,omitempty"`
+	// Timings is the time spent in each parse phase.
+	Timings []PhaseTiming `json:"timings"`
+	// Duration is the wall time of the whole parse.
+	Duration time.Duration `json:"durationNs"`
+	// Error is the parse error, if any.
+	Error string `json:"error,omitempty"`
+}
+
+// Suggestion lists registered flags whose names resemble an unknown flag.
+type Suggestion struct {
+	Flag       string   `json:"flag"`
+	Candidates []string `json:"candidates"`
+}
+
+// ParseReport parses arguments like Parse, including the environment, secret
+// directory and config file, and describes the outcome in a Report. Errors are
+// returned rather than exiting or panicking, whatever the FlagSet's error
+// handling, so a wrapper can always emit the report; the error is also
+// recorded in Report.Error. Usage and warnings are still printed to Output.
+func (f *FlagSet) ParseReport(arguments []string) (Report, error) {
+	rec := NewPhaseRecorder()
+	report := &Report{}
+	saved, savedTracer, savedHandling := f.report, f.tracer, f.errorHandling
+	f.report, f.tracer, f.errorHandling = report, teeTracer{rec, savedTracer}, ContinueOnError
+	defer func() { f.report, f.tracer, f.errorHandling = saved, savedTracer, savedHandling }()
+
+	start := time.Now()
+	err := f.Parse(arguments)
+	report.Duration = time.Since(start)
+	report.Timings = rec.Timings()
+	report.Flags = f.Introspect()
+	report.Args = append([]string{}, f.Args()...)
+	for _, name := range report.UnknownFlags {
+		if c := f.suggestFlags(name); len(c) > 0 {
+			report.Suggestions = append(report.Suggestions, Suggestion{Flag: name, Candidates: c})
+		}
+	}
+	if err != nil {
+		report.Error = err.Error()
+	}
+	return *report, err
+}
+
+// ParseReport parses arguments into the default CommandLine FlagSet and
+// describes the outcome.
+func ParseReport(arguments []string) (Report, error) { return CommandLine.ParseReport(arguments) }
+
+// teeTracer forwards phases to a PhaseRecorder and, if set, another Tracer.
+type teeTracer struct {
+	rec  *PhaseRecorder
+	next Tracer
+}
+
+func (t teeTracer) StartPhase(phase string) func(error) {
+	endRec := t.rec.StartPhase(phase)
+	var endNext func(error)
+	if t.next != nil {
+		endNext = t.next.StartPhase(phase)
+	}
+	return func(err error) {
+		endRec(err)
+		if endNext != nil {
+			endNext(err)
+		}
+	}
+}
+
+// warnf prints a warning to the output and records it in the report of a
+// ParseReport in progress.
+func (f *FlagSet) warnf(format string, a ...interface{}) {
+	msg := fmt.Sprintf(format, a...)
+	fmt.Fprintln(f.out(), "warning: "+msg)
+	if f.report != nil {
+		f.report.Warnings = append(f.report.Warnings, msg)
+	}
+}
+
+// noteUnknownFlag records a command-line flag that matched nothing.
+func (f *FlagSet) noteUnknownFlag(name string) {
+	if f.report != nil {
+		f.report.UnknownFlags = append(f.report.UnknownFlags, name)
+	}
+}
+
+// suggestFlags returns the registered flags within a small edit distance of
+// name, or having it as a prefix, closest first.
+func (f *FlagSet) suggestFlags(name string) []string {
+	names := make([]string, 0, len(f.formal))
+	for fn := range f.formal {
+		names = append(names, fn)
+	}
+	return closestNames(name, names)
+}
+
+// closestNames returns the names within a small edit distance of name, or
+// having it as a prefix, closest first.
+func closestNames(name string, names []string) []string {
+	type cand struct {
+		name string
+		dist int
+	}
+	limit := 2
+	if len(name) <= 3 {
+		limit = 1
+	}
+	var cands []cand
+	for _, fn := range names {
+		d := editDistance(name, fn)
+		if d > limit && !(len(name) >= 3 && len(fn) > len(name) && fn[:len(name)] == name) {
+			continue
+		}
+		cands = append(cands, cand{fn, d})
+	}
+	sort.Slice(cands, func(i, j int) bool {
+		if cands[i].dist != cands[j].dist {
+			return cands[i].dist < cands[j].dist
+		}
+		return cands[i].name < cands[j].name
+	})
+	out := make([]string, len(cands))
+	for i, c := range cands {
+		out[i] = c.name
+	}
+	return out
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,77 @@
+package flag_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	. "github.com/machship/flag"
+)
+
+func TestParseReport(t *testing.T) {
+	var out bytes.Buffer
+	f := NewFlagSet("test", ExitOnError) // ParseReport must not exit
+	f.SetOutput(&out)
+	f.String("listen", ":8080", "")
+	f.String("old-listen", "", "")
+	f.String("password", "", "")
+	f.MarkSensitive("password")
+	f.Deprecate("old-listen", "listen")
+
+	r, err := f.ParseReport([]string{"-old-listen=:9", "-password=s3cret", "serve"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"flag -old-listen is deprecated, use -listen instead"}; !reflect.DeepEqual(r.Warnings, want) {
+		t.Errorf("Warnings = %q, want %q", r.Warnings, want)
+	}
+	if !strings.Contains(out.String(), "warning: flag -old-listen is deprecated") {
+		t.Errorf("output = %q", out.String())
+	}
+	if !reflect.DeepEqual(r.Args, []string{"serve"}) || r.Error != "" || len(r.Timings) == 0 {
+		t.Errorf("Args=%q Error=%q Timings=%v", r.Args, r.Error, r.Timings)
+	}
+	for _, m := range r.Flags {
+		if m.Name == "password" && (m.Value != "******" || m.Source != "cli") {
+			t.Errorf("password = %+v", m)
+		}
+	}
+
+	b, err := json.Marshal(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(b), "s3cret") || !strings.Contains(string(b), `"phase":"cli"`) {
+		t.Errorf("json = %s", b)
+	}
+}
+
+func TestParseReportUnknownFlag(t *testing.T) {
+	f := NewFlagSet("test", PanicOnError)
+	f.SetOutput(&bytes.Buffer{})
+	f.String("listen", "", "")
+	f.String("listen-tls", "", "")
+	f.Bool("verbose", false, "")
+
+	r, err := f.ParseReport([]string{"-lisen=:80"})
+	if err == nil || r.Error != err.Error() {
+		t.Fatalf("err = %v, Error = %q", err, r.Error)
+	}
+	if !reflect.DeepEqual(r.UnknownFlags, []string{"lisen"}) {
+		t.Errorf("UnknownFlags = %q", r.UnknownFlags)
+	}
+	want := []Suggestion{{Flag: "lisen", Candidates: []string{"listen"}}}
+	if !reflect.DeepEqual(r.Suggestions, want) {
+		t.Errorf("Suggestions = %+v, want %+v", r.Suggestions, want)
+	}
+
+	// the error handling is restored afterwards
+	defer func() {
+		if recover() == nil {
+			t.Error("Parse did not panic")
+		}
+	}()
+	f.Parse([]string{"-nope"})
+}
@@ -0,0 +1,66 @@
+package flag_test
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	. "github.com/machship/flag"
+)
+
+type requiredPolicyConfig struct {
+	Region string `flag:"region" required:"true" default:"ap-southeast-2"`
+	Token  string `flag:"token" required:"true"`
+}
+
+func parseWithPolicy(t *testing.T, policy RequiredPolicy, args ...string) (requiredPolicyConfig, string, error) {
+	t.Helper()
+	ResetForTesting(nil)
+	var out bytes.Buffer
+	CommandLine.SetOutput(&out)
+	old := os.Args
+	os.Args = append([]string{"cmd"}, args...)
+	defer func() { os.Args = old }()
+	c := requiredPolicyConfig{}
+	err := ParseStructWithOptions(&c, ParseStructOptions{AutoParse: true, RequiredPolicy: policy})
+	return c, out.String(), err
+}
+
+func TestRequiredPolicy_ZeroesWarns(t *testing.T) {
+	c, out, err := parseWithPolicy(t, RequiredZeroes, "-token", "x")
+	if err == nil || !strings.Contains(err.Error(), "missing required flags: region") {
+		t.Fatalf("expected region missing under legacy policy, got %v", err)
+	}
+	if c.Region != "" {
+		t.Fatalf("expected region zeroed, got %q", c.Region)
+	}
+	if !strings.Contains(out, "warning: required flag -region discards its default") || strings.Contains(out, "-token discards") {
+		t.Fatalf("expected warning only for region, got %q", out)
+	}
+}
+
+func TestRequiredPolicy_KeepsDefault(t *testing.T) {
+	c, out, err := parseWithPolicy(t, RequiredKeepsDefault, "-token", "x")
+	if err != nil {
+		t.Fatalf("default should satisfy requirement: %v", err)
+	}
+	if c.Region != "ap-southeast-2" || strings.Contains(out, "warning") {
+		t.Fatalf("unexpected result region=%q out=%q", c.Region, out)
+	}
+	for _, m := range Introspect() {
+		if m.Name == "region" && !m.Required {
+			t.Fatalf("region should still be reported as required")
+		}
+	}
+	if _, _, err := parseWithPolicy(t, RequiredKeepsDefault); err == nil || !strings.Contains(err.Error(), "missing required flags: token") {
+		t.Fatalf("required flag without default must still be set, got %v", err)
+	}
+}
+
+func TestRequiredPolicy_ErrorsIfDefaultPresent(t *testing.T) {
+	_, _, err := parseWithPolicy(t, RequiredErrorsIfDefaultPresent, "-token", "x")
+	if err == nil || !strings.Contains(err.Error(), `field Region: required flag "region" must not declare a default`) {
+		t.Fatalf("expected registration error, got %v", err)
+	}
+}
@@ -0,0 +1,154 @@
+package flag
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RetryPolicy describes how a client retries a failed call: up to Retries
+// further attempts, waiting Backoff before the first and doubling the wait
+// each time up to Max, optionally with jitter.
+type RetryPolicy struct {
+	Retries int
+	Backoff time.Duration
+	Max     time.Duration // cap on the wait; 0 means no cap
+	Jitter  bool
+}
+
+// ParseRetryPolicy parses the comma-separated form
+// retries=5,backoff=200ms,max=5s,jitter=true. Keys may appear in any order
+// and left out keys are zero.
+func ParseRetryPolicy(s string) (RetryPolicy, error) {
+	return parseRetryPolicyOnto(RetryPolicy{}, s)
+}
+
+// parseRetryPolicyOnto parses s, keeping the fields of base it does not name.
+func parseRetryPolicyOnto(base RetryPolicy, s string) (RetryPolicy, error) {
+	p := base
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(part, "=")
+		k, v = strings.ToLower(strings.TrimSpace(k)), strings.TrimSpace(v)
+		if !ok {
+			return RetryPolicy{}, fmt.Errorf("invalid retry policy %q: expected key=value", part)
+		}
+		if seen[k] {
+			return RetryPolicy{}, fmt.Errorf("invalid retry policy: %s given twice", k)
+		}
+		seen[k] = true
+		var err error
+		switch k {
+		case "retries":
+			p.Retries, err = strconv.Atoi(v)
+			if err == nil && p.Retries < 0 {
+				err = fmt.Errorf("must not be negative")
+			}
+		case "backoff":
+			p.Backoff, err = parseNonNegativeDuration(v)
+		case "max":
+			p.Max, err = parseNonNegativeDuration(v)
+		case "jitter":
+			p.Jitter, err = strconv.ParseBool(v)
+		default:
+			return RetryPolicy{}, fmt.Errorf("invalid retry policy: unknown key %q (want retries, backoff, max, jitter)", k)
+		}
+		if err != nil {
+			return RetryPolicy{}, fmt.Errorf("invalid retry policy %s %q: %v", k, v, err)
+		}
+	}
+	if p.Max > 0 && p.Max < p.Backoff {
+		return RetryPolicy{}, fmt.Errorf("invalid retry policy: max %v is less than backoff %v", p.Max, p.Backoff)
+	}
+	return p, nil
+}
+
+func parseNonNegativeDuration(s string) (time.Duration, error) {
+	d, err := time.ParseDuration(s)
+	if err == nil && d < 0 {
+		err = fmt.Errorf("must not be negative")
+	}
+	return d, err
+}
+
+// String returns the policy in the form ParseRetryPolicy accepts.
+func (p RetryPolicy) String() string {
+	if p == (RetryPolicy{}) {
+		return ""
+	}
+	return fmt.Sprintf("retries=%d,backoff=%v,max=%v,jitter=%t", p.Retries, p.Backoff, p.Max, p.Jitter)
+}
+
+// Delay returns how long to wait before retry number attempt (0 for the
+// first retry): Backoff doubled attempt times and capped at Max. With Jitter
+// the result is drawn uniformly from the upper half of that wait, which
+// spreads out clients that failed together.
+func (p RetryPolicy) Delay(attempt int) time.Duration {
+	d := p.Backoff
+	for i := 0; i < attempt && d > 0; i++ {
+		if p.Max > 0 && d >= p.Max || d > time.Duration(1<<62) {
+			break
+		}
+		d *= 2
+	}
+	if p.Max > 0 && d > p.Max {
+		d = p.Max
+	}
+	if p.Jitter && d > 1 {
+		d = d/2 + rand.N(d-d/2)
+	}
+	return d
+}
+
+// retryPolicyValue sets the keys given and leaves the others at the flag's
+// default, so -retry=retries=3 keeps the default backoff.
+type retryPolicyValue struct {
+	p   *RetryPolicy
+	def RetryPolicy
+}
+
+func newRetryPolicyValue(val RetryPolicy, p *RetryPolicy) *retryPolicyValue {
+	*p = val
+	return &retryPolicyValue{p: p, def: val}
+}
+func (rv *retryPolicyValue) Set(s string) error {
+	p, err := parseRetryPolicyOnto(rv.def, s)
+	if err != nil {
+		return err
+	}
+	*rv.p = p
+	return nil
+}
+func (rv *retryPolicyValue) String() string {
+	if rv.p == nil {
+		return ""
+	}
+	return rv.p.String()
+}
+func (rv *retryPolicyValue) Get() interface{} { return *rv.p }
+
+// RetryPolicyVar registers a retry policy flag such as
+// -retry=retries=5,backoff=200ms,max=5s,jitter=true. Keys not given keep
+// their value from the default.
+func (f *FlagSet) RetryPolicyVar(p *RetryPolicy, name string, value RetryPolicy, usage string) {
+	f.Var(newRetryPolicyValue(value, p), name, usage)
+}
+func RetryPolicyVar(p *RetryPolicy, name string, value RetryPolicy, usage string) {
+	CommandLine.RetryPolicyVar(p, name, value, usage)
+}
+
+// RetryPolicyFlag defines a RetryPolicy flag and returns a pointer to it.
+func (f *FlagSet) RetryPolicyFlag(name string, value RetryPolicy, usage string) *RetryPolicy {
+	p := new(RetryPolicy)
+	f.RetryPolicyVar(p, name, value, usage)
+	return p
+}
+func RetryPolicyFlag(name string, value RetryPolicy, usage string) *RetryPolicy {
+	return CommandLine.RetryPolicyFlag(name, value, usage)
+}
@@ -0,0 +1,74 @@
+package flag_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/machship/flag"
+)
+
+func TestRetryPolicyFlag(t *testing.T) {
+	f := NewFlagSet("test", ContinueOnError)
+	def := RetryPolicy{Retries: 3, Backoff: 100 * time.Millisecond, Max: time.Second}
+	p := f.RetryPolicyFlag("retry", def, "")
+	if err := f.Parse([]string{"-retry", "jitter=true, retries=5,max=5s"}); err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	want := RetryPolicy{Retries: 5, Backoff: 100 * time.Millisecond, Max: 5 * time.Second, Jitter: true}
+	if *p != want {
+		t.Fatalf("policy = %+v, want %+v", *p, want)
+	}
+	if s := f.Lookup("retry").Value.String(); s != "retries=5,backoff=100ms,max=5s,jitter=true" {
+		t.Fatalf("String() = %q", s)
+	}
+	cases := map[string]string{
+		"retries":             "expected key=value",
+		"retries=-1":          "must not be negative",
+		"backoff=soon":        "invalid retry policy backoff",
+		"delay=1s":            "unknown key \"delay\"",
+		"retries=1,retries=2": "retries given twice",
+		"backoff=2s,max=1s":   "max 1s is less than backoff 2s",
+	}
+	for in, want := range cases {
+		if err := f.Set("retry", in); err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("Set(%q): expected error containing %q, got %v", in, want, err)
+		}
+	}
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	p := RetryPolicy{Retries: 10, Backoff: 200 * time.Millisecond, Max: time.Second}
+	for attempt, want := range []time.Duration{200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second, time.Second} {
+		if got := p.Delay(attempt); got != want {
+			t.Errorf("Delay(%d) = %v, want %v", attempt, got, want)
+		}
+	}
+	if got := (RetryPolicy{Backoff: time.Second}).Delay(100); got <= 0 {
+		t.Errorf("uncapped Delay(100) = %v, want a large positive wait", got)
+	}
+	p.Jitter = true
+	for i := 0; i < 100; i++ {
+		if d := p.Delay(2); d < 400*time.Millisecond || d > 800*time.Millisecond {
+			t.Fatalf("jittered Delay(2) = %v, want within [400ms, 800ms]", d)
+		}
+	}
+}
+
+func TestParseStruct_RetryPolicy(t *testing.T) {
+	ResetForTesting(nil)
+	type C struct {
+		Retry RetryPolicy `flag:"retry" default:"retries=2,backoff=50ms"`
+	}
+	var c C
+	old := os.Args
+	os.Args = []string{"cmd", "-retry=jitter=true"}
+	defer func() { os.Args = old }()
+	if err := ParseStruct(&c); err != nil {
+		t.Fatalf("parse struct: %v", err)
+	}
+	if want := (RetryPolicy{Retries: 2, Backoff: 50 * time.Millisecond, Jitter: true}); c.Retry != want {
+		t.Fatalf("policy = %+v, want %+v", c.Retry, want)
+	}
+}
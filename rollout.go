@@ -0,0 +1,71 @@
+package flag
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// rolloutValue is a remote value applied to a percentage of instances:
+//
+//	{"value": "on", "rollout": 25, "salt": "new-checkout"}
+//
+// Salt defaults to the flag name; changing it reshuffles the buckets.
+type rolloutValue struct {
+	Value   *string  `json:"value"`
+	Rollout *float64 `json:"rollout"`
+	Salt    string   `json:"salt"`
+}
+
+// parseRollout reports whether raw is a rollout object and decodes it.
+func parseRollout(raw string) (rolloutValue, bool, error) {
+	var rv rolloutValue
+	raw = strings.TrimSpace(raw)
+	if !strings.HasPrefix(raw, "{") || !strings.Contains(raw, `"rollout"`) {
+		return rv, false, nil
+	}
+	if err := json.Unmarshal([]byte(raw), &rv); err != nil || rv.Rollout == nil {
+		return rv, false, nil
+	}
+	if rv.Value == nil {
+		return rv, true, fmt.Errorf("rollout without a value")
+	}
+	if *rv.Rollout < 0 || *rv.Rollout > 100 {
+		return rv, true, fmt.Errorf("rollout %v outside 0-100", *rv.Rollout)
+	}
+	return rv, true, nil
+}
+
+// SetInstanceID sets the identifier that places this instance in a rollout
+// bucket, the same as setting Identity.Instance with SetIdentity.
+func (f *FlagSet) SetInstanceID(id string) { f.identity.Instance = id }
+
+// SetInstanceID sets the rollout instance identifier of the default
+// CommandLine FlagSet.
+func SetInstanceID(id string) { CommandLine.SetInstanceID(id) }
+
+// rolloutInstance returns the most specific identifier known for this
+// instance: Identity.Instance, Pod or Host, falling back to the host name.
+func (f *FlagSet) rolloutInstance() string {
+	id := f.identity
+	for _, s := range []string{id.Instance, id.Pod, id.Host} {
+		if s != "" {
+			return s
+		}
+	}
+	host, _ := os.Hostname()
+	return host
+}
+
+// inRollout reports whether this instance falls within percent of instances
+// for salt. The bucket is a stable hash of salt and the instance ID, so an
+// instance keeps its decision across restarts and, as percent grows, only
+// gains overrides.
+func (f *FlagSet) inRollout(salt string, percent float64) bool {
+	sum := sha256.Sum256([]byte(salt + "\x00" + f.rolloutInstance()))
+	bucket := binary.BigEndian.Uint64(sum[:8]) % 10000
+	return float64(bucket) < percent*100
+}
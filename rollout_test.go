@@ -0,0 +1,71 @@
+package flag_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	. "github.com/machship/flag"
+)
+
+func rolloutFlagSet(instance, payload string) (*FlagSet, *string) {
+	f := NewFlagSet("test", ContinueOnError)
+	f.SetOutput(&bytes.Buffer{})
+	f.SetInstanceID(instance)
+	checkout := f.String("checkout", "off", "")
+	f.AddRemoteProvider("flags", RemoteProviderFunc(func(context.Context) (map[string]string, error) {
+		return map[string]string{"checkout": payload}, nil
+	}))
+	return f, checkout
+}
+
+func TestRemoteRollout(t *testing.T) {
+	enabled := 0
+	for i := 0; i < 2000; i++ {
+		f, checkout := rolloutFlagSet(fmt.Sprintf("pod-%d", i), `{"value": "on", "rollout": 25}`)
+		if err := f.Parse(nil); err != nil {
+			t.Fatal(err)
+		}
+		if *checkout == "on" {
+			enabled++
+		}
+	}
+	if enabled < 400 || enabled > 600 {
+		t.Errorf("%d of 2000 instances enabled, want about 500", enabled)
+	}
+
+	// the same instance always decides the same way, and growing the
+	// rollout never turns an enabled instance off
+	for i := 0; i < 200; i++ {
+		id := fmt.Sprintf("pod-%d", i)
+		f, small := rolloutFlagSet(id, `{"value": "on", "rollout": 10, "salt": "s"}`)
+		f.Parse(nil)
+		g, large := rolloutFlagSet(id, `{"value": "on", "rollout": 50, "salt": "s"}`)
+		g.Parse(nil)
+		if *small == "on" && *large != "on" {
+			t.Fatalf("%s enabled at 10%% but not at 50%%", id)
+		}
+	}
+
+	for _, tt := range []struct{ payload, want string }{
+		{`{"value": "on", "rollout": 0}`, "off"},
+		{`{"value": "on", "rollout": 100}`, "on"},
+		{`{"not": "a rollout"}`, `{"not": "a rollout"}`},
+	} {
+		f, checkout := rolloutFlagSet("pod-1", tt.payload)
+		if err := f.Parse(nil); err != nil || *checkout != tt.want {
+			t.Errorf("%s: checkout = %q, err = %v", tt.payload, *checkout, err)
+		}
+	}
+}
+
+func TestRemoteRolloutErrors(t *testing.T) {
+	for _, payload := range []string{`{"rollout": 10}`, `{"value": "on", "rollout": 150}`} {
+		f, _ := rolloutFlagSet("pod-1", payload)
+		if err := f.Parse(nil); err == nil || !strings.Contains(err.Error(), "invalid rollout for checkout") {
+			t.Errorf("%s: err = %v", payload, err)
+		}
+	}
+}
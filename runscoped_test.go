@@ -0,0 +1,66 @@
+package flag_test
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	. "github.com/machship/flag"
+)
+
+func TestRunScoped(t *testing.T) {
+	ResetForTesting(nil)
+	global := String("global", "kept", "")
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var port int
+			err := RunScoped([]string{"-port", fmt.Sprint(9000 + i)},
+				func(fs *FlagSet) error {
+					fs.IntVar(&port, "port", 80, "")
+					return nil
+				},
+				func(fs *FlagSet) error {
+					if port != 9000+i {
+						return fmt.Errorf("goroutine %d saw port %d", i, port)
+					}
+					return nil
+				})
+			errs <- err
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Error(err)
+		}
+	}
+	if Lookup("global") == nil || *global != "kept" {
+		t.Error("RunScoped must not touch CommandLine")
+	}
+}
+
+func TestRunScopedErrors(t *testing.T) {
+	errRegister := errors.New("register failed")
+	err := RunScoped(nil, func(*FlagSet) error { return errRegister }, func(*FlagSet) error {
+		t.Error("fn must not run")
+		return nil
+	})
+	if !errors.Is(err, errRegister) {
+		t.Errorf("err = %v", err)
+	}
+
+	err = RunScoped([]string{"-unknown"}, nil, func(*FlagSet) error {
+		t.Error("fn must not run")
+		return nil
+	})
+	if err == nil {
+		t.Error("expected parse error")
+	}
+}
@@ -0,0 +1,263 @@
+package flag
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SymlinkPolicy controls which symbolic links ParseSecretDir follows.
+type SymlinkPolicy int
+
+const (
+	// SymlinksWithinDir follows links whose target resolves inside the secret
+	// directory, as used by Kubernetes secret volumes, and refuses the rest.
+	SymlinksWithinDir SymlinkPolicy = iota
+	// SymlinksNever refuses every symbolic link.
+	SymlinksNever
+	// SymlinksAny follows links wherever they point.
+	SymlinksAny
+)
+
+// SecretDirOptions are the guardrails ParseSecretDir applies to the files it
+// reads. The zero value follows only links within the directory, skips
+// hidden files and imposes no size limit.
+type SecretDirOptions struct {
+	// MaxFileSize is the largest secret file accepted, in bytes; 0 means no
+	// limit. Larger files fail the parse.
+	MaxFileSize int64
+	// Symlinks selects which symbolic links are followed; a refused link
+	// fails the parse.
+	Symlinks SymlinkPolicy
+	// IncludeHidden also reads files whose names begin with '.'.
+	IncludeHidden bool
+	// Allow, when non-empty, limits the files read to names matching one of
+	// these filepath.Match patterns.
+	Allow []string
+	// Deny skips files whose names match one of these filepath.Match patterns.
+	Deny []string
+	// Recursive also reads files in subdirectories, naming each after its
+	// path relative to the secret directory with the separators replaced by
+	// PathSeparator, so db/reader/password fills -db.reader.password. Allow
+	// and Deny patterns then match the slash-separated relative path.
+	Recursive bool
+	// PathSeparator joins the path elements of nested secret files; empty
+	// means ".", matching flagPrefix nesting.
+	PathSeparator string
+}
+
+// SetSecretDirOptions sets the guardrails used when ingesting a secret
+// directory, whether by ParseSecretDir directly, via -secret-dir or on reload.
+func (f *FlagSet) SetSecretDirOptions(opts SecretDirOptions) { f.secretDirOpts = opts }
+
+// SetSecretDirOptions sets the secret directory guardrails on the default
+// CommandLine FlagSet.
+func SetSecretDirOptions(opts SecretDirOptions) { CommandLine.SetSecretDirOptions(opts) }
+
+// SetSecretNameMapper installs fn to translate secret file names into flag
+// names, replacing the default lower-case and '_'-to-'-' candidates. fn
+// receives the file name as it appears on disk (for a recursive walk, the
+// relative path already joined with PathSeparator) and returns the exact flag
+// name to set, or "" to ignore the file. A nil fn restores the defaults.
+func (f *FlagSet) SetSecretNameMapper(fn func(filename string) string) { f.secretNameMapper = fn }
+
+// SetSecretNameMapper installs a secret file name mapper on the default
+// CommandLine FlagSet.
+func SetSecretNameMapper(fn func(filename string) string) { CommandLine.SetSecretNameMapper(fn) }
+
+// secretTarget returns the flag a secret file named name (already joined with
+// PathSeparator) feeds, or nil.
+func (f *FlagSet) secretTarget(name string) *Flag {
+	if f.secretNameMapper != nil {
+		if mapped := f.secretNameMapper(name); mapped != "" {
+			return f.formal[mapped]
+		}
+		return nil
+	}
+	lower := strings.ToLower(name)
+	for _, cand := range []string{lower, strings.ReplaceAll(lower, "_", "-")} {
+		if fl := f.formal[cand]; fl != nil {
+			return fl
+		}
+	}
+	return nil
+}
+
+// secretFileWanted reports whether the hidden, allow and deny settings let a
+// file name (or slash-separated relative path) through.
+func (o SecretDirOptions) secretFileWanted(name string) bool {
+	if !o.IncludeHidden && strings.HasPrefix(name, ".") {
+		return false
+	}
+	for _, pat := range o.Deny {
+		if ok, _ := filepath.Match(pat, name); ok {
+			return false
+		}
+	}
+	if len(o.Allow) == 0 {
+		return true
+	}
+	for _, pat := range o.Allow {
+		if ok, _ := filepath.Match(pat, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// secretFile is a candidate file of a secret directory.
+type secretFile struct {
+	rel   string // slash-separated path relative to the directory
+	entry os.DirEntry
+}
+
+// secretFiles lists the files of dir that pass the hidden, allow and deny
+// settings, descending into subdirectories when Recursive is set. Hidden
+// subdirectories, such as the ..data links of Kubernetes volumes, are skipped
+// unless IncludeHidden is set.
+func (o SecretDirOptions) secretFiles(dir string) ([]secretFile, error) {
+	if !o.Recursive {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, err
+		}
+		var files []secretFile
+		for _, e := range entries {
+			if !e.IsDir() && o.secretFileWanted(e.Name()) {
+				files = append(files, secretFile{e.Name(), e})
+			}
+		}
+		return files, nil
+	}
+	var files []secretFile
+	err := filepath.WalkDir(dir, func(path string, e os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == dir {
+			return nil
+		}
+		if e.IsDir() {
+			if !o.IncludeHidden && strings.HasPrefix(e.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if !o.IncludeHidden && strings.HasPrefix(e.Name(), ".") {
+			return nil
+		}
+		if o.secretFileWanted(rel) {
+			files = append(files, secretFile{rel, e})
+		}
+		return nil
+	})
+	return files, err
+}
+
+// flagName converts the relative path of a secret file into the name its
+// flag candidates are derived from.
+func (o SecretDirOptions) flagName(rel string) string {
+	sep := o.PathSeparator
+	if sep == "" {
+		sep = "."
+	}
+	return strings.ReplaceAll(rel, "/", sep)
+}
+
+// readSecretFile reads a file of dir, enforcing the symlink policy and size
+// limit. Links to directories report ok false and are skipped.
+func (f *FlagSet) readSecretFile(dir string, sf secretFile) (val string, ok bool, err error) {
+	b, ok, err := f.secretDirOpts.readSecretBytes(dir, sf)
+	if !ok || err != nil {
+		return "", ok, err
+	}
+	s, err := valueFromBytes(filepath.Join(dir, filepath.FromSlash(sf.rel)), b)
+	if err != nil {
+		return "", false, err
+	}
+	return s, true, nil
+}
+
+// readSecretBytes reads the raw contents of a file of dir, enforcing the
+// symlink policy and size limit. Anything but a regular file, such as a
+// FIFO, socket or device a shared mount may hold, is refused rather than
+// opened, so it can neither block nor stream into the parse. Links to
+// directories report ok false.
+func (o SecretDirOptions) readSecretBytes(dir string, sf secretFile) (b []byte, ok bool, err error) {
+	path := filepath.Join(dir, filepath.FromSlash(sf.rel))
+	var info os.FileInfo
+	if sf.entry.Type()&os.ModeSymlink != 0 {
+		if o.Symlinks == SymlinksNever {
+			return nil, false, fmt.Errorf("secret file %s: refusing to follow symbolic link", sf.rel)
+		}
+		if o.Symlinks == SymlinksWithinDir {
+			inside, err := linkWithinDir(dir, path)
+			if err != nil {
+				return nil, false, err
+			}
+			if !inside {
+				return nil, false, fmt.Errorf("secret file %s: symbolic link points outside %s", sf.rel, dir)
+			}
+		}
+		if info, err = os.Stat(path); err != nil {
+			return nil, false, err
+		}
+		if info.IsDir() {
+			return nil, false, nil
+		}
+	} else if info, err = sf.entry.Info(); err != nil {
+		return nil, false, err
+	}
+	if !info.Mode().IsRegular() {
+		return nil, false, fmt.Errorf("secret file %s is not a regular file", sf.rel)
+	}
+
+	fp, err := os.Open(path)
+	if err != nil {
+		return nil, false, err
+	}
+	defer fp.Close()
+	if info, err = fp.Stat(); err != nil {
+		return nil, false, err
+	}
+	if !info.Mode().IsRegular() { // replaced since it was listed
+		return nil, false, fmt.Errorf("secret file %s is not a regular file", sf.rel)
+	}
+	var r io.Reader = fp
+	if o.MaxFileSize > 0 {
+		r = io.LimitReader(fp, o.MaxFileSize+1)
+	}
+	b, err = io.ReadAll(r)
+	if err != nil {
+		return nil, false, err
+	}
+	if o.MaxFileSize > 0 && int64(len(b)) > o.MaxFileSize {
+		return nil, false, fmt.Errorf("secret file %s exceeds the %d byte limit", sf.rel, o.MaxFileSize)
+	}
+	return b, true, nil
+}
+
+// linkWithinDir reports whether the symbolic link at path resolves to a file
+// inside dir.
+func linkWithinDir(dir, path string) (bool, error) {
+	root, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return false, err
+	}
+	target, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return false, err
+	}
+	rel, err := filepath.Rel(root, target)
+	if err != nil {
+		return false, nil
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)), nil
+}
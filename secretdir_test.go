@@ -0,0 +1,226 @@
+package flag_test
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	. "github.com/machship/flag"
+)
+
+func writeSecret(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSecretDirMaxFileSize(t *testing.T) {
+	dir := t.TempDir()
+	writeSecret(t, dir, "token", strings.Repeat("x", 65))
+
+	f := NewFlagSet("test", ContinueOnError)
+	f.String("token", "", "")
+	f.SetSecretDirOptions(SecretDirOptions{MaxFileSize: 64})
+	err := f.ParseSecretDir(dir)
+	if err == nil || !strings.Contains(err.Error(), "exceeds the 64 byte limit") {
+		t.Fatalf("err = %v", err)
+	}
+
+	f = NewFlagSet("test", ContinueOnError)
+	token := f.String("token", "", "")
+	f.SetSecretDirOptions(SecretDirOptions{MaxFileSize: 65})
+	if err := f.ParseSecretDir(dir); err != nil || len(*token) != 65 {
+		t.Fatalf("err = %v, len = %d", err, len(*token))
+	}
+}
+
+func TestSecretDirSpecialFiles(t *testing.T) {
+	dir := t.TempDir()
+	if err := syscall.Mkfifo(filepath.Join(dir, "token"), 0600); err != nil {
+		t.Skipf("cannot create a FIFO: %v", err)
+	}
+	f := NewFlagSet("test", ContinueOnError)
+	f.SetOutput(io.Discard)
+	f.String("token", "", "")
+	done := make(chan error, 1)
+	go func() { done <- f.ParseSecretDir(dir) }()
+	select {
+	case err := <-done:
+		if err == nil || !strings.Contains(err.Error(), "secret file token is not a regular file") {
+			t.Fatalf("err = %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("ParseSecretDir blocked on a FIFO")
+	}
+}
+
+func TestSecretDirSymlinks(t *testing.T) {
+	dir := t.TempDir()
+	outside := filepath.Join(t.TempDir(), "elsewhere")
+	if err := os.WriteFile(outside, []byte("stolen"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	// Kubernetes-style layout: file -> ..data/file, ..data -> ..2025_01_01
+	if err := os.Mkdir(filepath.Join(dir, "..2025_01_01"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	writeSecret(t, filepath.Join(dir, "..2025_01_01"), "password", "hunter2")
+	if err := os.Symlink("..2025_01_01", filepath.Join(dir, "..data")); err != nil {
+		t.Skip("symlinks unsupported:", err)
+	}
+	if err := os.Symlink(filepath.Join("..data", "password"), filepath.Join(dir, "password")); err != nil {
+		t.Fatal(err)
+	}
+
+	f := NewFlagSet("test", ContinueOnError)
+	password := f.String("password", "", "")
+	if err := f.ParseSecretDir(dir); err != nil {
+		t.Fatal(err)
+	}
+	if *password != "hunter2" {
+		t.Errorf("password = %q", *password)
+	}
+
+	f = NewFlagSet("test", ContinueOnError)
+	f.String("password", "", "")
+	f.SetSecretDirOptions(SecretDirOptions{Symlinks: SymlinksNever})
+	if err := f.ParseSecretDir(dir); err == nil || !strings.Contains(err.Error(), "refusing to follow") {
+		t.Errorf("SymlinksNever err = %v", err)
+	}
+
+	if err := os.Symlink(outside, filepath.Join(dir, "api-key")); err != nil {
+		t.Fatal(err)
+	}
+	f = NewFlagSet("test", ContinueOnError)
+	f.String("password", "", "")
+	f.String("api-key", "", "")
+	if err := f.ParseSecretDir(dir); err == nil || !strings.Contains(err.Error(), "points outside") {
+		t.Errorf("outside link err = %v", err)
+	}
+
+	f = NewFlagSet("test", ContinueOnError)
+	f.String("password", "", "")
+	apiKey := f.String("api-key", "", "")
+	f.SetSecretDirOptions(SecretDirOptions{Symlinks: SymlinksAny})
+	if err := f.ParseSecretDir(dir); err != nil || *apiKey != "stolen" {
+		t.Errorf("SymlinksAny err = %v, api-key = %q", err, *apiKey)
+	}
+}
+
+func TestSecretDirFilters(t *testing.T) {
+	dir := t.TempDir()
+	writeSecret(t, dir, ".token", "hidden")
+	writeSecret(t, dir, "db-user", "alice")
+	writeSecret(t, dir, "db-pass", "s3cret")
+	writeSecret(t, dir, "debug", "")
+
+	tests := []struct {
+		opts SecretDirOptions
+		want map[string]string
+	}{
+		{SecretDirOptions{}, map[string]string{".token": "", "db-user": "alice", "db-pass": "s3cret", "debug": "true"}},
+		{SecretDirOptions{IncludeHidden: true}, map[string]string{".token": "hidden", "db-user": "alice", "db-pass": "s3cret", "debug": "true"}},
+		{SecretDirOptions{Allow: []string{"db-*"}, Deny: []string{"*-pass"}}, map[string]string{".token": "", "db-user": "alice", "db-pass": "", "debug": "false"}},
+	}
+	for _, tt := range tests {
+		f := NewFlagSet("test", ContinueOnError)
+		f.SetOutput(io.Discard)
+		f.String(".token", "", "")
+		f.String("db-user", "", "")
+		f.String("db-pass", "", "")
+		f.Bool("debug", false, "")
+		f.SetSecretDirOptions(tt.opts)
+		if err := f.ParseSecretDir(dir); err != nil {
+			t.Fatalf("%+v: %v", tt.opts, err)
+		}
+		for name, want := range tt.want {
+			if got := f.Lookup(name).Value.String(); got != want {
+				t.Errorf("%+v: %s = %q, want %q", tt.opts, name, got, want)
+			}
+		}
+	}
+}
+
+func TestSecretDirRecursive(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "db", "reader"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, ".git"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	writeSecret(t, dir, "token", "top")
+	writeSecret(t, filepath.Join(dir, "db", "reader"), "password", "r3ader")
+	writeSecret(t, filepath.Join(dir, "db"), "DB_NAME", "orders")
+	writeSecret(t, filepath.Join(dir, ".git"), "config", "ignored")
+
+	f := NewFlagSet("test", ContinueOnError)
+	token := f.String("token", "", "")
+	password := f.String("db.reader.password", "", "")
+	name := f.String("db.db-name", "", "")
+	f.SetSecretDirOptions(SecretDirOptions{Recursive: true})
+	if err := f.ParseSecretDir(dir); err != nil {
+		t.Fatal(err)
+	}
+	if *token != "top" || *password != "r3ader" || *name != "orders" {
+		t.Errorf("token=%q password=%q name=%q", *token, *password, *name)
+	}
+
+	// without Recursive, subdirectories are ignored
+	f = NewFlagSet("test", ContinueOnError)
+	password = f.String("db.reader.password", "", "")
+	if err := f.ParseSecretDir(dir); err != nil || *password != "" {
+		t.Errorf("err = %v, password = %q", err, *password)
+	}
+
+	f = NewFlagSet("test", ContinueOnError)
+	password = f.String("db-reader-password", "", "")
+	f.String("db-db-name", "", "")
+	f.SetSecretDirOptions(SecretDirOptions{Recursive: true, PathSeparator: "-", Allow: []string{"db/reader/*"}})
+	if err := f.ParseSecretDir(dir); err != nil {
+		t.Fatal(err)
+	}
+	if *password != "r3ader" || f.Lookup("db-db-name").Value.String() != "" {
+		t.Errorf("password=%q db-db-name=%q", *password, f.Lookup("db-db-name").Value.String())
+	}
+}
+
+func TestSecretNameMapper(t *testing.T) {
+	dir := t.TempDir()
+	writeSecret(t, dir, "PAYMENTS.DB.PASSWORD", "s3cret")
+	writeSecret(t, dir, "PAYMENTS.API.KEY", "k3y")
+	writeSecret(t, dir, "OTHER.TEAM.TOKEN", "theirs")
+
+	f := NewFlagSet("test", ContinueOnError)
+	password := f.String("db-password", "", "")
+	apiKey := f.String("api-key", "", "")
+	token := f.String("token", "", "")
+	f.SetSecretNameMapper(func(filename string) string {
+		rest, ok := strings.CutPrefix(filename, "PAYMENTS.")
+		if !ok {
+			return ""
+		}
+		return strings.ToLower(strings.ReplaceAll(rest, ".", "-"))
+	})
+	if err := f.ParseSecretDir(dir); err != nil {
+		t.Fatal(err)
+	}
+	if *password != "s3cret" || *apiKey != "k3y" || *token != "" {
+		t.Errorf("db-password=%q api-key=%q token=%q", *password, *apiKey, *token)
+	}
+
+	// nil restores the default lower-case candidates
+	writeSecret(t, dir, "TOKEN", "mine")
+	f.SetSecretNameMapper(nil)
+	if err := f.ParseSecretDir(dir); err != nil {
+		t.Fatal(err)
+	}
+	if *token != "mine" {
+		t.Errorf("token = %q", *token)
+	}
+}
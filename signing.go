@@ -0,0 +1,242 @@
+package flag
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// ErrSignature is wrapped by the errors for config files and remote values
+// that are unsigned, or whose signature does not verify, once
+// RequireSignedConfig is in effect.
+var ErrSignature = errors.New("invalid or missing signature")
+
+// RemoteSignatureKey is the remote value holding the signature of the other
+// values of a provider; see RequireSignedConfig and SignRemoteValues. It is
+// never applied as a flag.
+const RemoteSignatureKey = "_signature"
+
+// embeddedSignaturePrefix starts the last line of a config file signed in
+// place: "#ed25519:" and the base64 signature of everything before the line.
+const embeddedSignaturePrefix = "#ed25519:"
+
+// RequireSignedConfig makes f refuse config files and remote values that are
+// not signed with the private key of pub, for deployments that must prove
+// their configuration was not tampered with. It applies to Parse, Reload,
+// the watcher and SubscribeRemote; a refused source fails like any other bad
+// source, so reloads keep the previous values. ParseFile, ParseTOMLFile and
+// ParseDotEnv all check signatures.
+//
+// A config file is signed over its exact bytes, either by a detached
+// signature in path+".sig" or path+".minisig" (a raw or base64 ed25519
+// signature, or a minisign signature made with -l, the pure ed25519 "Ed"
+// algorithm), or by a last line "#ed25519:<base64 signature>" covering the
+// lines above it. Remote values are signed by a RemoteSignatureKey value;
+// see SignRemoteValues.
+func (f *FlagSet) RequireSignedConfig(pub ed25519.PublicKey) {
+	if len(pub) != ed25519.PublicKeySize {
+		panic(fmt.Sprintf("flag: RequireSignedConfig: public key has %d bytes, want %d", len(pub), ed25519.PublicKeySize))
+	}
+	f.configKey = pub
+}
+
+// RequireSignedConfig requires signed configuration on the default
+// CommandLine FlagSet.
+func RequireSignedConfig(pub ed25519.PublicKey) { CommandLine.RequireSignedConfig(pub) }
+
+// ParseSigningKey decodes a public key for RequireSignedConfig: a minisign
+// public key, with or without its "untrusted comment:" line, or the base64
+// encoding of a raw ed25519 public key.
+func ParseSigningKey(s string) (ed25519.PublicKey, error) {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[len(lines)-1]))
+	if err != nil {
+		return nil, fmt.Errorf("invalid signing key: %v", err)
+	}
+	switch {
+	case len(b) == ed25519.PublicKeySize:
+		return ed25519.PublicKey(b), nil
+	case len(b) == 2+8+ed25519.PublicKeySize && string(b[:2]) == "Ed":
+		return ed25519.PublicKey(b[10:]), nil
+	}
+	return nil, fmt.Errorf("invalid signing key: not an ed25519 or minisign public key")
+}
+
+// SignRemoteValues returns a copy of values with a RemoteSignatureKey value
+// signing the others, for publishing to a remote provider. The signature is
+// the base64 ed25519 signature of the values as a JSON object with sorted
+// keys, no insignificant space and no HTML escaping, which other languages
+// can reproduce.
+func SignRemoteValues(priv ed25519.PrivateKey, values map[string]string) map[string]string {
+	signed := make(map[string]string, len(values)+1)
+	for k, v := range values {
+		if k != RemoteSignatureKey {
+			signed[k] = v
+		}
+	}
+	signed[RemoteSignatureKey] = base64.StdEncoding.EncodeToString(ed25519.Sign(priv, canonicalValues(signed)))
+	return signed
+}
+
+// canonicalValues encodes values other than the signature for signing.
+func canonicalValues(values map[string]string) []byte {
+	unsigned := make(map[string]string, len(values))
+	for k, v := range values {
+		if k != RemoteSignatureKey {
+			unsigned[k] = v
+		}
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(unsigned) // a map of strings always encodes
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+}
+
+// verifyRemoteValues checks the signature of values when signing is
+// required, and returns them without it.
+func (f *FlagSet) verifyRemoteValues(values map[string]string) (map[string]string, error) {
+	sig, signed := values[RemoteSignatureKey]
+	if f.configKey != nil {
+		if !signed {
+			return nil, fmt.Errorf("values are not signed: %w", ErrSignature)
+		}
+		b, err := base64.StdEncoding.DecodeString(sig)
+		if err != nil || !ed25519.Verify(f.configKey, canonicalValues(values), b) {
+			return nil, fmt.Errorf("values do not match their signature: %w", ErrSignature)
+		}
+	}
+	if !signed {
+		return values, nil
+	}
+	out := make(map[string]string, len(values)-1)
+	for k, v := range values {
+		if k != RemoteSignatureKey {
+			out[k] = v
+		}
+	}
+	return out, nil
+}
+
+// signatureFiles lists the detached signature files of a config file.
+func signatureFiles(path string) []string {
+	return []string{path + ".sig", path + ".minisig"}
+}
+
+// readConfigText reads the config file at path as readTextFile does,
+// verifying its signature first when RequireSignedConfig is in effect.
+// Every reader of configuration files goes through it.
+func (f *FlagSet) readConfigText(path string) (string, error) {
+	if f.configKey == nil {
+		return readTextFile(path)
+	}
+	b, err := f.readSignedFile(path)
+	if err != nil {
+		return "", err
+	}
+	return textFromBytes(path, b)
+}
+
+// readSignedFile reads the config file at path and verifies its signature,
+// returning its contents without an embedded signature line.
+func (f *FlagSet) readSignedFile(path string) ([]byte, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if content, sig, ok := cutEmbeddedSignature(b); ok {
+		if !ed25519.Verify(f.configKey, content, sig) {
+			return nil, fmt.Errorf("%s: contents do not match the embedded signature: %w", path, ErrSignature)
+		}
+		return content, nil
+	}
+	for _, sigPath := range signatureFiles(path) {
+		sig, err := os.ReadFile(sigPath)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := verifySignature(f.configKey, b, sig); err != nil {
+			return nil, fmt.Errorf("%s: %v: %w", sigPath, err, ErrSignature)
+		}
+		return b, nil
+	}
+	return nil, fmt.Errorf("%s: config file is not signed: %w", path, ErrSignature)
+}
+
+// cutEmbeddedSignature splits a last "#ed25519:" line from b, returning the
+// contents above it and the decoded signature.
+func cutEmbeddedSignature(b []byte) (content, sig []byte, ok bool) {
+	trimmed := bytes.TrimRight(b, "\r\n")
+	i := bytes.LastIndexByte(trimmed, '\n') + 1
+	line, found := bytes.CutPrefix(trimmed[i:], []byte(embeddedSignaturePrefix))
+	if !found {
+		return nil, nil, false
+	}
+	sig, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(line)))
+	if err != nil {
+		sig = nil // verifies as a mismatch
+	}
+	return b[:i], sig, true
+}
+
+// verifySignature checks the detached signature sig of msg: minisign, base64
+// or raw ed25519.
+func verifySignature(pub ed25519.PublicKey, msg, sig []byte) error {
+	if bytes.HasPrefix(sig, []byte("untrusted comment:")) {
+		return verifyMinisign(pub, msg, sig)
+	}
+	if b, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(sig))); err == nil && len(b) == ed25519.SignatureSize {
+		sig = b
+	}
+	if len(sig) != ed25519.SignatureSize {
+		return errors.New("unrecognised signature format")
+	}
+	if !ed25519.Verify(pub, msg, sig) {
+		return errors.New("contents do not match the signature")
+	}
+	return nil
+}
+
+// verifyMinisign checks a minisign signature file: the signature line and,
+// when present, the global signature over the trusted comment.
+func verifyMinisign(pub ed25519.PublicKey, msg, file []byte) error {
+	lines := strings.Split(strings.ReplaceAll(string(file), "\r\n", "\n"), "\n")
+	if len(lines) < 2 {
+		return errors.New("truncated minisign signature")
+	}
+	b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[1]))
+	if err != nil || len(b) != 2+8+ed25519.SignatureSize {
+		return errors.New("invalid minisign signature")
+	}
+	switch string(b[:2]) {
+	case "Ed":
+	case "ED":
+		return errors.New("prehashed minisign signatures are not supported; sign with minisign -S -l")
+	default:
+		return fmt.Errorf("unknown minisign algorithm %q", b[:2])
+	}
+	sig := b[10:]
+	if !ed25519.Verify(pub, msg, sig) {
+		return errors.New("contents do not match the signature")
+	}
+	if len(lines) >= 4 {
+		comment, ok := strings.CutPrefix(lines[2], "trusted comment: ")
+		if !ok {
+			return errors.New("invalid minisign trusted comment")
+		}
+		global, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[3]))
+		if err != nil || !ed25519.Verify(pub, append(append([]byte(nil), sig...), comment...), global) {
+			return errors.New("trusted comment does not match its signature")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package flag_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/machship/flag"
+)
+
+func signingKey(t *testing.T) (ed25519.PublicKey, ed25519.PrivateKey) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pub, priv
+}
+
+func parseSignedFile(pub ed25519.PublicKey, path string) (*int, error) {
+	f := NewFlagSet("test", ContinueOnError)
+	f.SetOutput(&bytes.Buffer{})
+	port := f.Int("port", 8080, "")
+	f.RequireSignedConfig(pub)
+	return port, f.ParseFile(path)
+}
+
+func TestRequireSignedConfigFile(t *testing.T) {
+	pub, priv := signingKey(t)
+	dir := t.TempDir()
+	cfg := filepath.Join(dir, "app.conf")
+	content := []byte("port 9090\n")
+	os.WriteFile(cfg, content, 0o600)
+
+	if _, err := parseSignedFile(pub, cfg); !errors.Is(err, ErrSignature) {
+		t.Fatalf("unsigned file: %v", err)
+	}
+
+	sig := base64.StdEncoding.EncodeToString(ed25519.Sign(priv, content))
+	os.WriteFile(cfg+".sig", []byte(sig+"\n"), 0o600)
+	if port, err := parseSignedFile(pub, cfg); err != nil || *port != 9090 {
+		t.Fatalf("detached signature: %v, port=%d", err, *port)
+	}
+
+	os.WriteFile(cfg, []byte("port 9091\n"), 0o600)
+	if _, err := parseSignedFile(pub, cfg); !errors.Is(err, ErrSignature) {
+		t.Fatalf("tampered file: %v", err)
+	}
+
+	other, _ := signingKey(t)
+	os.WriteFile(cfg, content, 0o600)
+	if _, err := parseSignedFile(other, cfg); !errors.Is(err, ErrSignature) {
+		t.Fatalf("other key: %v", err)
+	}
+}
+
+func TestRequireSignedConfigEmbedded(t *testing.T) {
+	pub, priv := signingKey(t)
+	cfg := filepath.Join(t.TempDir(), "app.json")
+	content := []byte("{\"port\": 9090}\n")
+	line := "#ed25519:" + base64.StdEncoding.EncodeToString(ed25519.Sign(priv, content)) + "\n"
+	os.WriteFile(cfg, append(content, line...), 0o600)
+	if port, err := parseSignedFile(pub, cfg); err != nil || *port != 9090 {
+		t.Fatalf("embedded signature: %v, port=%d", err, *port)
+	}
+
+	os.WriteFile(cfg, append([]byte("{\"port\": 9091}\n"), line...), 0o600)
+	if _, err := parseSignedFile(pub, cfg); !errors.Is(err, ErrSignature) {
+		t.Fatalf("tampered file: %v", err)
+	}
+}
+
+func TestRequireSignedConfigMinisign(t *testing.T) {
+	pub, priv := signingKey(t)
+	cfg := filepath.Join(t.TempDir(), "app.conf")
+	content := []byte("port 9090\n")
+	os.WriteFile(cfg, content, 0o600)
+
+	minisig := func(alg, comment string) []byte {
+		sig := ed25519.Sign(priv, content)
+		line := append([]byte(alg+"\x01\x02\x03\x04\x05\x06\x07\x08"), sig...)
+		global := ed25519.Sign(priv, append(append([]byte(nil), sig...), comment...))
+		return []byte("untrusted comment: signature from minisign secret key\n" +
+			base64.StdEncoding.EncodeToString(line) + "\n" +
+			"trusted comment: " + comment + "\n" +
+			base64.StdEncoding.EncodeToString(global) + "\n")
+	}
+	os.WriteFile(cfg+".minisig", minisig("Ed", "timestamp:1700000000"), 0o600)
+	if port, err := parseSignedFile(pub, cfg); err != nil || *port != 9090 {
+		t.Fatalf("minisign signature: %v, port=%d", err, *port)
+	}
+
+	forged := bytes.Replace(minisig("Ed", "timestamp:1700000000"), []byte("1700000000"), []byte("1800000000"), 1)
+	os.WriteFile(cfg+".minisig", forged, 0o600)
+	if _, err := parseSignedFile(pub, cfg); err == nil || !strings.Contains(err.Error(), "trusted comment") {
+		t.Fatalf("forged trusted comment: %v", err)
+	}
+
+	os.WriteFile(cfg+".minisig", minisig("ED", "x"), 0o600)
+	if _, err := parseSignedFile(pub, cfg); err == nil || !strings.Contains(err.Error(), "minisign -S -l") {
+		t.Fatalf("prehashed signature: %v", err)
+	}
+
+	key := append([]byte("Ed\x01\x02\x03\x04\x05\x06\x07\x08"), pub...)
+	parsed, err := ParseSigningKey("untrusted comment: minisign public key\n" + base64.StdEncoding.EncodeToString(key) + "\n")
+	if err != nil || !parsed.Equal(pub) {
+		t.Fatalf("ParseSigningKey: %v", err)
+	}
+}
+
+func TestRequireSignedRemoteValues(t *testing.T) {
+	pub, priv := signingKey(t)
+	var values map[string]string
+	f := NewFlagSet("test", ContinueOnError)
+	f.SetOutput(&bytes.Buffer{})
+	port := f.Int("port", 8080, "")
+	f.RequireSignedConfig(pub)
+	f.AddRemoteProvider("central", RemoteProviderFunc(func(context.Context) (map[string]string, error) {
+		return values, nil
+	}))
+
+	values = map[string]string{"port": "9090"}
+	if err := f.ParseRemote(context.Background()); !errors.Is(err, ErrSignature) {
+		t.Fatalf("unsigned values: %v", err)
+	}
+
+	values = SignRemoteValues(priv, map[string]string{"port": "9090"})
+	if err := f.ParseRemote(context.Background()); err != nil || *port != 9090 {
+		t.Fatalf("signed values: %v, port=%d", err, *port)
+	}
+
+	values["port"] = "9091"
+	if _, err := f.Reload(context.Background()); !errors.Is(err, ErrSignature) {
+		t.Fatalf("tampered values: %v", err)
+	}
+	if *port != 9090 {
+		t.Fatalf("port after tampered reload = %d", *port)
+	}
+}
+
+func TestRequireSignedConfigReaders(t *testing.T) {
+	pub, priv := signingKey(t)
+	dir := t.TempDir()
+	for _, tc := range []struct {
+		name    string
+		content string
+		parse   func(*FlagSet, string) error
+	}{
+		{"app.toml", "port = 9090\n", (*FlagSet).ParseTOMLFile},
+		{".env", "PORT=9090\n", (*FlagSet).ParseDotEnv},
+	} {
+		path := filepath.Join(dir, tc.name)
+		os.WriteFile(path, []byte(tc.content), 0o600)
+		parse := func() (int, error) {
+			f := NewFlagSet("test", ContinueOnError)
+			f.SetOutput(&bytes.Buffer{})
+			port := f.Int("port", 8080, "")
+			f.RequireSignedConfig(pub)
+			err := tc.parse(f, path)
+			return *port, err
+		}
+		if port, err := parse(); !errors.Is(err, ErrSignature) || port != 8080 {
+			t.Fatalf("%s unsigned: %v, port=%d", tc.name, err, port)
+		}
+		sig := base64.StdEncoding.EncodeToString(ed25519.Sign(priv, []byte(tc.content)))
+		os.WriteFile(path+".sig", []byte(sig), 0o600)
+		if port, err := parse(); err != nil || port != 9090 {
+			t.Fatalf("%s signed: %v, port=%d", tc.name, err, port)
+		}
+		os.WriteFile(path, []byte(strings.Replace(tc.content, "9090", "9091", 1)), 0o600)
+		if _, err := parse(); !errors.Is(err, ErrSignature) {
+			t.Fatalf("%s tampered: %v", tc.name, err)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package flag
+
+import (
+	"io"
+	"os"
+	"reflect"
+)
+
+// State is a snapshot of the package-level flag configuration, taken by
+// SaveState and reinstated by RestoreState.
+type State struct {
+	commandLine       *FlagSet
+	usage             func()
+	environmentPrefix string
+	configFlagname    string
+	secretDirFlagname string
+	typeHandlers      map[reflect.Type][]structHandlerEntry
+	ifaceHandlers     []interfaceHandlerEntry
+	configFormats     map[string]func(*FlagSet, io.Reader) error
+	businessNumbers   map[string]BusinessNumberFunc
+}
+
+// SaveState captures the global state tests commonly disturb: CommandLine,
+// Usage, EnvironmentPrefix, DefaultConfigFlagname, DefaultSecretDirFlagname
+// and the struct handler, config format and business number registries. Pair
+// it with RestoreState to sandbox a test, typically as
+//
+//	defer flag.RestoreState(flag.SaveState())
+//	flag.ResetForTesting(nil)
+//
+// The CommandLine FlagSet itself is saved by reference, not copied: flags
+// registered on it before RestoreState remain registered.
+func SaveState() *State {
+	s := &State{
+		commandLine:       CommandLine,
+		usage:             Usage,
+		environmentPrefix: EnvironmentPrefix,
+		configFlagname:    DefaultConfigFlagname,
+		secretDirFlagname: DefaultSecretDirFlagname,
+		typeHandlers:      make(map[reflect.Type][]structHandlerEntry, len(structTypeHandlers)),
+		ifaceHandlers:     append([]interfaceHandlerEntry(nil), structInterfaceHandlers...),
+		configFormats:     make(map[string]func(*FlagSet, io.Reader) error, len(configFormats)),
+		businessNumbers:   make(map[string]BusinessNumberFunc, len(businessNumberSchemes)),
+	}
+	for ext, fn := range configFormats {
+		s.configFormats[ext] = fn
+	}
+	for kind, check := range businessNumberSchemes {
+		s.businessNumbers[kind] = check
+	}
+	for t, chain := range structTypeHandlers {
+		s.typeHandlers[t] = append([]structHandlerEntry(nil), chain...)
+	}
+	return s
+}
+
+// RestoreState reinstates the global state captured by SaveState. The saved
+// State may be restored more than once.
+func RestoreState(s *State) {
+	CommandLine = s.commandLine
+	Usage = s.usage
+	EnvironmentPrefix = s.environmentPrefix
+	DefaultConfigFlagname = s.configFlagname
+	DefaultSecretDirFlagname = s.secretDirFlagname
+	structTypeHandlers = make(map[reflect.Type][]structHandlerEntry, len(s.typeHandlers))
+	for t, chain := range s.typeHandlers {
+		structTypeHandlers[t] = append([]structHandlerEntry(nil), chain...)
+	}
+	structInterfaceHandlers = append([]interfaceHandlerEntry(nil), s.ifaceHandlers...)
+	configFormats = make(map[string]func(*FlagSet, io.Reader) error, len(s.configFormats))
+	for ext, fn := range s.configFormats {
+		configFormats[ext] = fn
+	}
+	businessNumberSchemes = make(map[string]BusinessNumberFunc, len(s.businessNumbers))
+	for kind, check := range s.businessNumbers {
+		businessNumberSchemes[kind] = check
+	}
+}
+
+// ResetForTesting replaces CommandLine with an empty FlagSet named after
+// os.Args[0] using ContinueOnError, so parse errors are returned instead of
+// exiting, and sets Usage to usage. Only CommandLine and Usage change; the
+// struct handler, config format and business number registries,
+// EnvironmentPrefix and the default flag names are left alone, and the
+// previous CommandLine is discarded. Use SaveState and RestoreState to put
+// everything back afterwards.
+func ResetForTesting(usage func()) {
+	CommandLine = NewFlagSet(os.Args[0], ContinueOnError)
+	Usage = usage
+}
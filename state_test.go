@@ -0,0 +1,53 @@
+package flag_test
+
+import (
+	"reflect"
+	"testing"
+
+	. "github.com/machship/flag"
+)
+
+type stateTestType string
+
+func TestSaveRestoreState(t *testing.T) {
+	ResetForTesting(nil)
+	String("before", "", "")
+	origUsage := func() {}
+	Usage = origUsage
+	origCommandLine := CommandLine
+
+	saved := SaveState()
+	ResetForTesting(nil)
+	String("inside", "", "")
+	DefaultConfigFlagname = "settings"
+	EnvironmentPrefix = "SANDBOX"
+	RegisterStructHandler(reflect.TypeOf(stateTestType("")), func(ctx *StructFieldContext) (bool, error) {
+		return true, nil
+	})
+	RegisterBusinessNumber("sandbox-id", func(s string) (string, error) { return s, nil })
+	RestoreState(saved)
+
+	if CommandLine != origCommandLine || Lookup("before") == nil || Lookup("inside") != nil {
+		t.Error("CommandLine not restored")
+	}
+	if reflect.ValueOf(Usage).Pointer() != reflect.ValueOf(origUsage).Pointer() {
+		t.Error("Usage not restored")
+	}
+	if DefaultConfigFlagname != "config" || EnvironmentPrefix != "" {
+		t.Errorf("names not restored: %q %q", DefaultConfigFlagname, EnvironmentPrefix)
+	}
+	for _, info := range StructHandlers() {
+		if info.Type == reflect.TypeOf(stateTestType("")) {
+			t.Error("handler registered in sandbox survived RestoreState")
+		}
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("business number scheme registered in sandbox survived RestoreState")
+			}
+		}()
+		NewFlagSet("test", ContinueOnError).BusinessNumberVar(new(string), "id", "", "SANDBOX-ID", "")
+	}()
+	NewFlagSet("test", ContinueOnError).ABNVar(new(string), "abn", "", "")
+}
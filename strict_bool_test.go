@@ -0,0 +1,42 @@
+package flag_test
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	. "github.com/machship/flag"
+)
+
+func TestStrictBooleans(t *testing.T) {
+	f := NewFlagSet("test", ContinueOnError)
+	f.SetOutput(io.Discard)
+	f.StrictBooleans(true)
+	v := f.Bool("verbose", false, "")
+	err := f.Parse([]string{"-verbose", "false"})
+	if err == nil || !strings.Contains(err.Error(), "-verbose=true or -verbose=false") {
+		t.Fatalf("expected explicit value error, got %v", err)
+	}
+
+	f = NewFlagSet("test", ContinueOnError)
+	f.StrictBooleans(true)
+	v = f.Bool("verbose", true, "")
+	if err := f.Parse([]string{"-verbose=false", "arg"}); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if *v || f.NArg() != 1 {
+		t.Fatalf("verbose=%v args=%v", *v, f.Args())
+	}
+}
+
+func TestStrictBooleansLeavesEnvAlone(t *testing.T) {
+	f := NewFlagSet("test", ContinueOnError)
+	f.StrictBooleans(true)
+	v := f.Bool("debug", false, "")
+	if err := f.ParseEnv([]string{"DEBUG="}); err != nil {
+		t.Fatalf("ParseEnv: %v", err)
+	}
+	if !*v {
+		t.Fatal("expected empty env value to enable debug")
+	}
+}
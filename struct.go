@@ -3,6 +3,7 @@ package flag
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"net"
 	neturl "net/url"
 	"reflect"
@@ -36,6 +37,44 @@ func currentPrefix() string {
 	return strings.Join(prefixStack, ".")
 }
 
+// envScope is an envPrefix tag in effect: flags below it read the env var
+// prefix + "_" + their name relative to the flag prefixes at depth.
+type envScope struct {
+	prefix string
+	depth  int
+}
+
+// envScopes stacks envPrefix tags of nested structs; the innermost applies.
+var envScopes []envScope
+
+// scopedEnvKey returns the env key an envPrefix tag assigns to the flag
+// registered as name, or "" outside any envPrefix scope.
+func scopedEnvKey(name string) string {
+	if len(envScopes) == 0 {
+		return ""
+	}
+	sc := envScopes[len(envScopes)-1]
+	if sc.depth > 0 {
+		name = strings.TrimPrefix(name, strings.Join(prefixStack[:sc.depth], ".")+".")
+	}
+	key := strings.NewReplacer("-", "_", ".", "_").Replace(strings.ToUpper(name))
+	return sc.prefix + "_" + key
+}
+
+// registerNestedStruct registers the fields of a nested struct under its
+// flagPrefix and envPrefix tags.
+func (f *FlagSet) registerNestedStruct(field reflect.StructField, fv reflect.Value, opts ParseStructOptions) ([]string, error) {
+	if p := field.Tag.Get("flagPrefix"); p != "" {
+		pushPrefix(p)
+		defer popPrefix()
+	}
+	if p := field.Tag.Get("envPrefix"); p != "" {
+		envScopes = append(envScopes, envScope{prefix: p, depth: len(prefixStack)})
+		defer func() { envScopes = envScopes[:len(envScopes)-1] }()
+	}
+	return f.registerStructFields(fv, opts)
+}
+
 /*
     In this file, we are going to define a way of users providing a struct that we can use to resolve flags.
 	The idea will be that the user can provide a struct with the following field tags:
@@ -123,8 +162,73 @@ func checkPattern(v reflect.Value, pat, name string) error {
 	return nil
 }
 
+// checkDecimalDigits enforces the `precision` (total significant digits) and
+// `scale` (digits after the decimal point) tags on decimal.Decimal fields, with
+// the usual NUMERIC(precision, scale) meaning. Trailing zeros do not count, so
+// 12.50 satisfies scale 2 and 12.500 does too.
+func checkDecimalDigits(v reflect.Value, precisionTag, scaleTag, name string) error {
+	if precisionTag == "" && scaleTag == "" {
+		return nil
+	}
+	d, ok := v.Interface().(decimal.Decimal)
+	if !ok {
+		return nil
+	}
+	digits := strings.TrimLeft(d.Abs().String(), "0")
+	intDigits, fracDigits := len(digits), 0
+	if i := strings.IndexByte(digits, '.'); i >= 0 {
+		intDigits, fracDigits = i, len(digits)-i-1
+	}
+	scale := -1
+	if scaleTag != "" {
+		n, err := strconv.Atoi(scaleTag)
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid scale tag for %s: %q", name, scaleTag)
+		}
+		scale = n
+		if fracDigits > scale {
+			return fmt.Errorf("flag %s: amount %s has %d decimal places, at most %d allowed", name, d.String(), fracDigits, scale)
+		}
+	}
+	if precisionTag != "" {
+		p, err := strconv.Atoi(precisionTag)
+		if err != nil || p <= 0 {
+			return fmt.Errorf("invalid precision tag for %s: %q", name, precisionTag)
+		}
+		if scale >= 0 {
+			if maxInt := p - scale; intDigits > maxInt {
+				return fmt.Errorf("flag %s: amount %s has %d digits before the decimal point, at most %d allowed (precision %d, scale %d)", name, d.String(), intDigits, maxInt, p, scale)
+			}
+		} else if intDigits+fracDigits > p {
+			return fmt.Errorf("flag %s: amount %s has %d significant digits, at most %d allowed", name, d.String(), intDigits+fracDigits, p)
+		}
+	}
+	return nil
+}
+
 // ParseStructOptions controls ParseStruct behavior.
-type ParseStructOptions struct{ AutoParse bool }
+type ParseStructOptions struct {
+	AutoParse bool
+	// RequiredPolicy decides what happens to the default of a required field.
+	RequiredPolicy RequiredPolicy
+}
+
+// RequiredPolicy controls how a `required:"true"` field treats a `default`
+// tag or a non-zero initial value.
+type RequiredPolicy int
+
+const (
+	// RequiredZeroes discards the default and zeroes the field; some source
+	// must set the flag. This is the historical behavior and prints a warning
+	// when a default is discarded.
+	RequiredZeroes RequiredPolicy = iota
+	// RequiredKeepsDefault keeps the default, which then satisfies the
+	// requirement. Required fields without a default must still be set.
+	RequiredKeepsDefault
+	// RequiredErrorsIfDefaultPresent rejects a required field that declares a
+	// default as a registration error.
+	RequiredErrorsIfDefaultPresent
+)
 
 // ParseStructWithOptions allows disabling automatic final Parse().
 func ParseStructWithOptions(s any, opts ParseStructOptions) error {
@@ -146,6 +250,48 @@ func parseStructInternal(s any, opts ParseStructOptions) error {
 	if v.Kind() != reflect.Struct {
 		return fmt.Errorf("ParseStruct expects a pointer to a struct, got %T", s)
 	}
+	requiredFlags, err := CommandLine.registerStructFields(v, opts)
+	if err != nil {
+		return err
+	}
+	return finishStructParse(requiredFlags, opts)
+}
+
+// finishStructParse runs the post-registration steps shared by ParseStruct and
+// ParseStructs: optional auto parse, deferred validations and required checks.
+func finishStructParse(requiredFlags []string, opts ParseStructOptions) error {
+	if opts.AutoParse && !Parsed() {
+		Parse()
+	}
+	// run deferred validations only if we auto-parsed (otherwise caller will Parse then call Validate manually).
+	if opts.AutoParse && len(CommandLine.deferredValidations) > 0 {
+		endValidate := CommandLine.startPhase(PhaseValidate)
+		var all MultiError
+		for _, fn := range CommandLine.deferredValidations {
+			all.Append(fn())
+		}
+		if all.HasErrors() {
+			endValidate(&all)
+			return &all
+		}
+		endValidate(nil)
+	}
+	var missing []string
+	for _, name := range requiredFlags {
+		if CommandLine.actual == nil || CommandLine.actual[name] == nil {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("missing required flags: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// registerStructFields registers flags on f for the tagged fields of the
+// struct v (recursing into untagged nested structs) and returns the names of
+// required flags.
+func (f *FlagSet) registerStructFields(v reflect.Value, opts ParseStructOptions) ([]string, error) {
 	t := v.Type()
 	var requiredFlags []string
 	regErr := func(fname string, err error) error { return fmt.Errorf("ParseStruct: field %s: %w", fname, err) }
@@ -160,22 +306,44 @@ func parseStructInternal(s any, opts ParseStructOptions) error {
 			if field.Type.Kind() == reflect.Struct {
 				fv := v.Field(i)
 				if fv.Kind() == reflect.Struct && fv.CanAddr() {
-					if err := parseStructInternal(fv.Addr().Interface(), ParseStructOptions{AutoParse: false}); err != nil {
-						return err
+					nested, err := f.registerNestedStruct(field, fv, opts)
+					if err != nil {
+						return nil, err
 					}
+					requiredFlags = append(requiredFlags, nested...)
 				}
 			}
 			continue
 		}
+		if pf := currentPrefix(); pf != "" {
+			flagName = pf + "." + flagName
+		}
+		if key := scopedEnvKey(flagName); key != "" {
+			f.setEnvKey(flagName, key)
+		}
 		help := field.Tag.Get("help")
 		required := strings.EqualFold(field.Tag.Get("required"), "true")
 		sensitiveTag := strings.EqualFold(field.Tag.Get("sensitive"), "true")
 		deprecatedTag := field.Tag.Get("deprecated") // if set, note deprecation after registration
-		defTag := field.Tag.Get("default")
+		defTag, defErr := evalDefaultTag(field.Tag.Get("default"))
+		if defErr != nil {
+			return nil, regErr(field.Name, defErr)
+		}
 		fv := v.Field(i)
+		declaredRequired := required
+		if required && (defTag != "" || !fv.IsZero()) {
+			switch opts.RequiredPolicy {
+			case RequiredErrorsIfDefaultPresent:
+				return nil, regErr(field.Name, fmt.Errorf("required flag %q must not declare a default", flagName))
+			case RequiredKeepsDefault:
+				required = false // the default satisfies the requirement
+			default:
+				f.warnf("required flag -%s discards its default (see ParseStructOptions.RequiredPolicy)", flagName)
+			}
+		}
 		// Build context for registry
 		ctx := &StructFieldContext{
-			FS:         CommandLine,
+			FS:         f,
 			Field:      field,
 			Value:      fv,
 			FlagName:   flagName,
@@ -185,43 +353,68 @@ func parseStructInternal(s any, opts ParseStructOptions) error {
 			Deprecated: deprecatedTag,
 			DefaultTag: defTag,
 			Tags: map[string]string{
-				"layout": field.Tag.Get("layout"),
-				"sep":    field.Tag.Get("sep"),
-				"enum":   field.Tag.Get("enum"),
+				"layout":         field.Tag.Get("layout"),
+				"layouts":        field.Tag.Get("layouts"),
+				"relative":       field.Tag.Get("relative"),
+				"prec":           field.Tag.Get("prec"),
+				"rounding":       field.Tag.Get("rounding"),
+				"iso":            field.Tag.Get("iso"),
+				"phone":          field.Tag.Get("phone"),
+				"region":         field.Tag.Get("region"),
+				"grpc":           field.Tag.Get("grpc"),
+				"defaultPort":    field.Tag.Get("defaultPort"),
+				"brokers":        field.Tag.Get("brokers"),
+				"resolve":        field.Tag.Get("resolve"),
+				"mime":           field.Tag.Get("mime"),
+				"ext":            field.Tag.Get("ext"),
+				"weight":         field.Tag.Get("weight"),
+				"length":         field.Tag.Get("length"),
+				"sep":            field.Tag.Get("sep"),
+				"enum":           field.Tag.Get("enum"),
+				"tz":             field.Tag.Get("tz"),
+				"postcode":       field.Tag.Get("postcode"),
+				"countryFlag":    field.Tag.Get("countryFlag"),
+				"businessNumber": field.Tag.Get("businessNumber"),
 			},
 		}
 		if handled, hErr := tryHandleStructField(ctx); hErr != nil {
-			return regErr(field.Name, hErr)
+			return nil, regErr(field.Name, hErr)
 		} else if handled {
 			if required {
 				requiredFlags = append(requiredFlags, flagName)
 			}
 			if deprecatedTag != "" {
-				Deprecate(flagName, deprecatedTag)
+				f.Deprecate(flagName, deprecatedTag)
 			}
 			if sensitiveTag {
-				CommandLine.MarkSensitive(flagName)
+				f.MarkSensitive(flagName)
 			}
 			goto VALIDATION_TAGS
 		}
 		// Fallback legacy explicit concrete types first
 		switch field.Type {
 		case reflect.TypeOf(time.Time{}):
-			layout := field.Tag.Get("layout")
-			if layout == "" {
-				layout = time.RFC3339
+			layouts := structTimeLayouts(field.Tag.Get("layout"), field.Tag.Get("layouts"))
+			relative := field.Tag.Get("relative") == "true"
+			var now func() time.Time
+			if relative {
+				now = f.now
 			}
 			def := fv.Interface().(time.Time)
 			if required {
 				def = time.Time{}
 			} else if defTag != "" {
-				tv, err := time.Parse(layout, defTag)
+				tv, err := parseTimeDefault(defTag, layouts, now)
 				if err != nil {
-					return regErr(field.Name, fmt.Errorf("invalid default time %q: %v", defTag, err))
+					return nil, regErr(field.Name, fmt.Errorf("invalid default time %q: %v", defTag, err))
 				}
 				def = tv
 			}
-			TimeVar(fv.Addr().Interface().(*time.Time), flagName, layout, def, help)
+			if relative {
+				f.RelativeTimeVar(fv.Addr().Interface().(*time.Time), flagName, layouts, def, help)
+			} else {
+				f.TimeLayoutsVar(fv.Addr().Interface().(*time.Time), flagName, layouts, def, help)
+			}
 		case reflect.TypeOf(decimal.Decimal{}):
 			def := fv.Interface().(decimal.Decimal)
 			if required {
@@ -229,11 +422,11 @@ func parseStructInternal(s any, opts ParseStructOptions) error {
 			} else if defTag != "" {
 				d, err := decimal.NewFromString(defTag)
 				if err != nil {
-					return regErr(field.Name, fmt.Errorf("invalid default decimal %q: %v", defTag, err))
+					return nil, regErr(field.Name, fmt.Errorf("invalid default decimal %q: %v", defTag, err))
 				}
 				def = d
 			}
-			DecimalVar(fv.Addr().Interface().(*decimal.Decimal), flagName, def, help)
+			f.DecimalVar(fv.Addr().Interface().(*decimal.Decimal), flagName, def, help)
 		case reflect.TypeOf(net.IP(nil)):
 			def := fv.Interface().(net.IP)
 			if required {
@@ -241,11 +434,11 @@ func parseStructInternal(s any, opts ParseStructOptions) error {
 			} else if defTag != "" {
 				ip := net.ParseIP(defTag)
 				if ip == nil {
-					return regErr(field.Name, fmt.Errorf("invalid default ip %q", defTag))
+					return nil, regErr(field.Name, fmt.Errorf("invalid default ip %q", defTag))
 				}
 				def = ip
 			}
-			IPVar(fv.Addr().Interface().(*net.IP), flagName, def, help)
+			f.IPVar(fv.Addr().Interface().(*net.IP), flagName, def, help)
 		case reflect.TypeOf(net.IPNet{}):
 			def := fv.Interface().(net.IPNet)
 			if required {
@@ -253,11 +446,11 @@ func parseStructInternal(s any, opts ParseStructOptions) error {
 			} else if defTag != "" {
 				_, n, err := net.ParseCIDR(defTag)
 				if err != nil {
-					return regErr(field.Name, fmt.Errorf("invalid default cidr %q: %v", defTag, err))
+					return nil, regErr(field.Name, fmt.Errorf("invalid default cidr %q: %v", defTag, err))
 				}
 				def = *n
 			}
-			IPNetVar(fv.Addr().Interface().(*net.IPNet), flagName, &def, help)
+			f.IPNetVar(fv.Addr().Interface().(*net.IPNet), flagName, &def, help)
 		case reflect.TypeOf(neturl.URL{}):
 			def := fv.Interface().(neturl.URL)
 			if required {
@@ -265,11 +458,11 @@ func parseStructInternal(s any, opts ParseStructOptions) error {
 			} else if defTag != "" {
 				u, err := neturl.Parse(defTag)
 				if err != nil {
-					return regErr(field.Name, fmt.Errorf("invalid default url %q: %v", defTag, err))
+					return nil, regErr(field.Name, fmt.Errorf("invalid default url %q: %v", defTag, err))
 				}
 				def = *u
 			}
-			URLVar(fv.Addr().Interface().(*neturl.URL), flagName, &def, help)
+			f.URLVar(fv.Addr().Interface().(*neturl.URL), flagName, &def, help)
 		case reflect.TypeOf(uuid.UUID{}):
 			def := fv.Interface().(uuid.UUID)
 			if required {
@@ -277,11 +470,11 @@ func parseStructInternal(s any, opts ParseStructOptions) error {
 			} else if defTag != "" {
 				id, err := uuid.Parse(defTag)
 				if err != nil {
-					return regErr(field.Name, fmt.Errorf("invalid default uuid %q: %v", defTag, err))
+					return nil, regErr(field.Name, fmt.Errorf("invalid default uuid %q: %v", defTag, err))
 				}
 				def = id
 			}
-			UUIDVar(fv.Addr().Interface().(*uuid.UUID), flagName, def, help)
+			f.UUIDVar(fv.Addr().Interface().(*uuid.UUID), flagName, def, help)
 		case reflect.TypeOf(ByteSize(0)):
 			def := fv.Interface().(ByteSize)
 			if required {
@@ -289,11 +482,11 @@ func parseStructInternal(s any, opts ParseStructOptions) error {
 			} else if defTag != "" {
 				bs, err := parseByteSize(defTag)
 				if err != nil {
-					return regErr(field.Name, fmt.Errorf("invalid default bytesize %q: %v", defTag, err))
+					return nil, regErr(field.Name, fmt.Errorf("invalid default bytesize %q: %v", defTag, err))
 				}
 				def = bs
 			}
-			ByteSizeVar(fv.Addr().Interface().(*ByteSize), flagName, def, help)
+			f.ByteSizeVar(fv.Addr().Interface().(*ByteSize), flagName, def, help)
 		case reflect.TypeOf([]time.Duration(nil)):
 			sep := field.Tag.Get("sep")
 			if sep == "" {
@@ -308,24 +501,18 @@ func parseStructInternal(s any, opts ParseStructOptions) error {
 				for _, p := range parts {
 					d, err := time.ParseDuration(strings.TrimSpace(p))
 					if err != nil {
-						return regErr(field.Name, fmt.Errorf("invalid default duration slice element %q: %v", p, err))
+						return nil, regErr(field.Name, fmt.Errorf("invalid default duration slice element %q: %v", p, err))
 					}
 					tmp = append(tmp, d)
 				}
 				def = tmp
 			}
-			DurationSliceVar(fv.Addr().Interface().(*[]time.Duration), flagName, sep, def, help)
+			f.DurationSliceVar(fv.Addr().Interface().(*[]time.Duration), flagName, sep, def, help)
 		case reflect.TypeOf([]string(nil)):
 			sep := field.Tag.Get("sep")
 			if sep == "" {
 				sep = ","
 			}
-			flagName := field.Tag.Get("flag")
-			if flagName != "" {
-				if pf := currentPrefix(); pf != "" {
-					flagName = pf + "." + flagName
-				}
-			}
 			def := fv.Interface().([]string)
 			if required {
 				def = nil
@@ -336,7 +523,7 @@ func parseStructInternal(s any, opts ParseStructOptions) error {
 				}
 				def = parts
 			}
-			StringSliceVar(fv.Addr().Interface().(*[]string), flagName, sep, def, help)
+			f.StringSliceVar(fv.Addr().Interface().(*[]string), flagName, sep, def, help)
 		case reflect.TypeOf(map[string]string(nil)):
 			def := fv.Interface().(map[string]string)
 			if required {
@@ -349,26 +536,25 @@ func parseStructInternal(s any, opts ParseStructOptions) error {
 					}
 					kv := strings.SplitN(pair, "=", 2)
 					if len(kv) != 2 {
-						return regErr(field.Name, fmt.Errorf("invalid default map entry %q", pair))
+						return nil, regErr(field.Name, fmt.Errorf("invalid default map entry %q", pair))
 					}
 					m[kv[0]] = kv[1]
 				}
 				def = m
 			}
-			StringMapVar(fv.Addr().Interface().(*map[string]string), flagName, def, help)
+			f.StringMapVar(fv.Addr().Interface().(*map[string]string), flagName, def, help)
 		case reflect.TypeOf(json.RawMessage{}):
 			def := fv.Interface().(json.RawMessage)
 			if required {
 				def = json.RawMessage{}
 			} else if defTag != "" {
 				jm := json.RawMessage([]byte(defTag))
-				var tmp interface{}
-				if err := json.Unmarshal(jm, &tmp); err != nil {
-					return regErr(field.Name, fmt.Errorf("invalid default json %q: %v", defTag, err))
+				if err := validateJSON(jm); err != nil {
+					return nil, regErr(field.Name, fmt.Errorf("invalid default json %q: %v", defTag, err))
 				}
 				def = jm
 			}
-			JSONVar(fv.Addr().Interface().(*json.RawMessage), flagName, def, help)
+			f.JSONVar(fv.Addr().Interface().(*json.RawMessage), flagName, def, help)
 		case reflect.TypeOf((*regexp.Regexp)(nil)):
 			def := fv.Interface().(*regexp.Regexp)
 			if required {
@@ -376,11 +562,11 @@ func parseStructInternal(s any, opts ParseStructOptions) error {
 			} else if defTag != "" {
 				r, err := regexp.Compile(defTag)
 				if err != nil {
-					return regErr(field.Name, fmt.Errorf("invalid default regexp %q: %v", defTag, err))
+					return nil, regErr(field.Name, fmt.Errorf("invalid default regexp %q: %v", defTag, err))
 				}
 				def = r
 			}
-			RegexpVar(fv.Addr().Interface().(**regexp.Regexp), flagName, def, help)
+			f.RegexpVar(fv.Addr().Interface().(**regexp.Regexp), flagName, def, help)
 		default:
 			// Fall back on kind
 			switch fv.Kind() {
@@ -391,11 +577,11 @@ func parseStructInternal(s any, opts ParseStructOptions) error {
 				} else if defTag != "" {
 					b, err := strconv.ParseBool(defTag)
 					if err != nil {
-						return regErr(field.Name, fmt.Errorf("invalid default bool %q: %v", defTag, err))
+						return nil, regErr(field.Name, fmt.Errorf("invalid default bool %q: %v", defTag, err))
 					}
 					def = b
 				}
-				BoolVar(fv.Addr().Interface().(*bool), flagName, def, help)
+				f.BoolVar(fv.Addr().Interface().(*bool), flagName, def, help)
 			case reflect.Int:
 				def := fv.Int()
 				if required {
@@ -403,11 +589,11 @@ func parseStructInternal(s any, opts ParseStructOptions) error {
 				} else if defTag != "" {
 					iv, err := strconv.ParseInt(defTag, 0, 64)
 					if err != nil {
-						return regErr(field.Name, fmt.Errorf("invalid default int %q: %v", defTag, err))
+						return nil, regErr(field.Name, fmt.Errorf("invalid default int %q: %v", defTag, err))
 					}
 					def = iv
 				}
-				IntVar(fv.Addr().Interface().(*int), flagName, int(def), help)
+				f.IntVar(fv.Addr().Interface().(*int), flagName, int(def), help)
 			case reflect.Int64:
 				if field.Type == reflect.TypeOf(time.Duration(0)) {
 					d := fv.Interface().(time.Duration)
@@ -416,11 +602,11 @@ func parseStructInternal(s any, opts ParseStructOptions) error {
 					} else if defTag != "" {
 						dv, err := time.ParseDuration(defTag)
 						if err != nil {
-							return regErr(field.Name, fmt.Errorf("invalid default duration %q: %v", defTag, err))
+							return nil, regErr(field.Name, fmt.Errorf("invalid default duration %q: %v", defTag, err))
 						}
 						d = dv
 					}
-					DurationVar(fv.Addr().Interface().(*time.Duration), flagName, d, help)
+					f.DurationVar(fv.Addr().Interface().(*time.Duration), flagName, d, help)
 				} else {
 					def := fv.Int()
 					if required {
@@ -428,11 +614,11 @@ func parseStructInternal(s any, opts ParseStructOptions) error {
 					} else if defTag != "" {
 						iv, err := strconv.ParseInt(defTag, 0, 64)
 						if err != nil {
-							return regErr(field.Name, fmt.Errorf("invalid default int64 %q: %v", defTag, err))
+							return nil, regErr(field.Name, fmt.Errorf("invalid default int64 %q: %v", defTag, err))
 						}
 						def = iv
 					}
-					Int64Var(fv.Addr().Interface().(*int64), flagName, def, help)
+					f.Int64Var(fv.Addr().Interface().(*int64), flagName, def, help)
 				}
 			case reflect.Uint:
 				def := fv.Uint()
@@ -441,11 +627,11 @@ func parseStructInternal(s any, opts ParseStructOptions) error {
 				} else if defTag != "" {
 					uv, err := strconv.ParseUint(defTag, 0, 64)
 					if err != nil {
-						return regErr(field.Name, fmt.Errorf("invalid default uint %q: %v", defTag, err))
+						return nil, regErr(field.Name, fmt.Errorf("invalid default uint %q: %v", defTag, err))
 					}
 					def = uv
 				}
-				UintVar(fv.Addr().Interface().(*uint), flagName, uint(def), help)
+				f.UintVar(fv.Addr().Interface().(*uint), flagName, uint(def), help)
 			case reflect.Uint64:
 				def := fv.Uint()
 				if required {
@@ -453,11 +639,11 @@ func parseStructInternal(s any, opts ParseStructOptions) error {
 				} else if defTag != "" {
 					uv, err := strconv.ParseUint(defTag, 0, 64)
 					if err != nil {
-						return regErr(field.Name, fmt.Errorf("invalid default uint64 %q: %v", defTag, err))
+						return nil, regErr(field.Name, fmt.Errorf("invalid default uint64 %q: %v", defTag, err))
 					}
 					def = uv
 				}
-				Uint64Var(fv.Addr().Interface().(*uint64), flagName, def, help)
+				f.Uint64Var(fv.Addr().Interface().(*uint64), flagName, def, help)
 			case reflect.String:
 				def := fv.String()
 				if enumList := field.Tag.Get("enum"); enumList != "" {
@@ -470,14 +656,14 @@ func parseStructInternal(s any, opts ParseStructOptions) error {
 					} else if defTag != "" {
 						def = defTag
 					}
-					EnumVar(fv.Addr().Interface().(*string), flagName, def, allowed, help)
+					f.EnumVar(fv.Addr().Interface().(*string), flagName, def, allowed, help)
 				} else {
 					if required {
 						def = ""
 					} else if defTag != "" {
 						def = defTag
 					}
-					StringVar(fv.Addr().Interface().(*string), flagName, def, help)
+					f.StringVar(fv.Addr().Interface().(*string), flagName, def, help)
 				}
 			case reflect.Float64:
 				def := fv.Float()
@@ -486,33 +672,52 @@ func parseStructInternal(s any, opts ParseStructOptions) error {
 				} else if defTag != "" {
 					fv2, err := strconv.ParseFloat(defTag, 64)
 					if err != nil {
-						return regErr(field.Name, fmt.Errorf("invalid default float64 %q: %v", defTag, err))
+						return nil, regErr(field.Name, fmt.Errorf("invalid default float64 %q: %v", defTag, err))
 					}
 					def = fv2
 				}
-				Float64Var(fv.Addr().Interface().(*float64), flagName, def, help)
+				f.Float64Var(fv.Addr().Interface().(*float64), flagName, def, help)
 			default:
-				return regErr(field.Name, fmt.Errorf("unsupported field type %s for flag %q", field.Type.String(), flagName))
+				return nil, regErr(field.Name, fmt.Errorf("unsupported field type %s for flag %q", field.Type.String(), flagName))
 			}
 		}
 		if required {
 			requiredFlags = append(requiredFlags, flagName)
 		}
 		if deprecatedTag != "" {
-			Deprecate(flagName, deprecatedTag)
+			f.Deprecate(flagName, deprecatedTag)
 		}
 		if sensitiveTag {
-			CommandLine.MarkSensitive(flagName)
+			f.MarkSensitive(flagName)
 		}
 	VALIDATION_TAGS:
+		if short := field.Tag.Get("short"); short != "" {
+			if f.Lookup(short) != nil {
+				return nil, regErr(field.Name, fmt.Errorf("short name -%s of flag %q is already in use", short, flagName))
+			}
+			f.Alias(short, flagName)
+		}
+		if noOpt := field.Tag.Get("noOptDefVal"); noOpt != "" {
+			f.SetNoOptDefVal(flagName, noOpt)
+		}
+		if group, long, example := field.Tag.Get("group"), field.Tag.Get("longHelp"), field.Tag.Get("example"); group != "" || long != "" || example != "" {
+			h := FlagHelp{Group: group, Long: long}
+			if example != "" {
+				h.Examples = []string{example}
+			}
+			f.SetFlagHelp(flagName, h)
+		}
 		// validation tag capture
 		minTag := field.Tag.Get("min")
 		maxTag := field.Tag.Get("max")
 		patTag := field.Tag.Get("pattern")
-		if minTag != "" || maxTag != "" || patTag != "" {
+		precTag := field.Tag.Get("precision")
+		scaleTag := field.Tag.Get("scale")
+		f.recordConstraints(flagName, declaredRequired, flagConstraints{min: minTag, max: maxTag, pattern: patTag, enum: field.Tag.Get("enum")})
+		if minTag != "" || maxTag != "" || patTag != "" || precTag != "" || scaleTag != "" {
 			fname := flagName
 			fvCopy := fv.Addr()
-			CommandLine.deferredValidations = append(CommandLine.deferredValidations, func() error {
+			f.deferredValidations = append(f.deferredValidations, func() error {
 				var m MultiError
 				val := fvCopy.Elem()
 				if err := checkMin(val, minTag, fname); err != nil {
@@ -524,6 +729,9 @@ func parseStructInternal(s any, opts ParseStructOptions) error {
 				if err := checkPattern(val, patTag, fname); err != nil {
 					m.Append(err)
 				}
+				if err := checkDecimalDigits(val, precTag, scaleTag, fname); err != nil {
+					m.Append(err)
+				}
 				if m.HasErrors() {
 					return &m
 				}
@@ -531,27 +739,200 @@ func parseStructInternal(s any, opts ParseStructOptions) error {
 			})
 		}
 	}
-	if opts.AutoParse && !Parsed() {
-		Parse()
+	return requiredFlags, nil
+}
+
+// ParseStructs registers several config structs onto CommandLine in a single
+// pass and then parses, like ParseStruct. Flag names are checked up front: a
+// name declared by more than one struct, or already defined on CommandLine,
+// is reported in one aggregated *MultiError and nothing is registered.
+func ParseStructs(structs ...any) error {
+	if Parsed() {
+		return fmt.Errorf("ParseStructs must be called before flag.Parse()")
 	}
-	// run deferred validations only if we auto-parsed (otherwise caller will Parse then call Validate manually).
-	if opts.AutoParse && len(CommandLine.deferredValidations) > 0 {
-		var all MultiError
-		for _, fn := range CommandLine.deferredValidations {
-			all.Append(fn())
+	owners := make(map[string]string) // flag name -> describing struct type
+	var dup MultiError
+	for _, s := range structs {
+		metas, err := RegisterStructAndDescribe(s)
+		if err != nil {
+			return err
 		}
-		if all.HasErrors() {
-			return &all
+		owner := fmt.Sprintf("%T", s)
+		for _, m := range metas {
+			if prev, ok := owners[m.Name]; ok {
+				dup.Append(fmt.Errorf("flag -%s defined by both %s and %s", m.Name, prev, owner))
+				continue
+			}
+			if CommandLine.formal[m.Name] != nil {
+				dup.Append(fmt.Errorf("flag -%s defined by %s is already registered", m.Name, owner))
+				continue
+			}
+			owners[m.Name] = owner
 		}
 	}
-	var missing []string
-	for _, name := range requiredFlags {
-		if CommandLine.actual == nil || CommandLine.actual[name] == nil {
-			missing = append(missing, name)
+	if dup.HasErrors() {
+		return &dup
+	}
+	var requiredFlags []string
+	for _, s := range structs {
+		req, err := CommandLine.registerStructFields(reflect.ValueOf(s).Elem(), ParseStructOptions{})
+		if err != nil {
+			return err
 		}
+		requiredFlags = append(requiredFlags, req...)
 	}
-	if len(missing) > 0 {
-		return fmt.Errorf("missing required flags: %s", strings.Join(missing, ", "))
+	return finishStructParse(requiredFlags, ParseStructOptions{AutoParse: true})
+}
+
+// RegisterStructAndDescribe reports the flags ParseStruct would register for s
+// (names, env keys, defaults and constraints) without registering them on
+// CommandLine or modifying s. It is intended for tooling, e.g. diffing a config
+// struct against documented configuration in CI.
+func RegisterStructAndDescribe(s any) ([]FlagMeta, error) {
+	v := reflect.ValueOf(s)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("RegisterStructAndDescribe expects a non-nil pointer to a struct, got %T", s)
+	}
+	cp := reflect.New(v.Elem().Type())
+	cloneStructValue(cp.Elem(), v.Elem())
+
+	scratch := NewFlagSet(CommandLine.name, ContinueOnError)
+	scratch.envPrefix = CommandLine.envPrefix
+	scratch.SetOutput(io.Discard)
+	if _, err := scratch.registerStructFields(cp.Elem(), ParseStructOptions{}); err != nil {
+		return nil, err
+	}
+	return scratch.Introspect(), nil
+}
+
+// cloneStructValue copies src into dst, duplicating slice and map fields (at
+// any struct nesting depth) so registering flags against dst cannot write
+// through to src's backing storage.
+func cloneStructValue(dst, src reflect.Value) {
+	dst.Set(src)
+	for i := 0; i < dst.NumField(); i++ {
+		f := dst.Field(i)
+		if !f.CanSet() {
+			continue
+		}
+		switch f.Kind() {
+		case reflect.Struct:
+			cloneStructValue(f, src.Field(i))
+		case reflect.Slice:
+			if !f.IsNil() {
+				c := reflect.MakeSlice(f.Type(), f.Len(), f.Len())
+				reflect.Copy(c, f)
+				f.Set(c)
+			}
+		case reflect.Map:
+			if !f.IsNil() {
+				c := reflect.MakeMapWithSize(f.Type(), f.Len())
+				iter := f.MapRange()
+				for iter.Next() {
+					c.SetMapIndex(iter.Key(), iter.Value())
+				}
+				f.Set(c)
+			}
+		}
+	}
+}
+
+// Unmarshal copies the resolved values of f's flags into target, a pointer to
+// a struct tagged like ParseStruct input. The target need not be the struct
+// the flags were registered from, so a program can register from one struct
+// and hand out an independent copy elsewhere. Slices and maps are copied.
+// Every tagged field must name a defined flag; problems are aggregated into a
+// *MultiError.
+func (f *FlagSet) Unmarshal(target any) error {
+	if !f.parsed {
+		return fmt.Errorf("Unmarshal called before Parse")
+	}
+	v := reflect.ValueOf(target)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("Unmarshal expects a non-nil pointer to a struct, got %T", target)
+	}
+	var errs MultiError
+	f.unmarshalFields(v.Elem(), "", &errs)
+	if errs.HasErrors() {
+		return &errs
 	}
 	return nil
 }
+
+// Unmarshal copies resolved CommandLine flag values into target.
+func Unmarshal(target any) error { return CommandLine.Unmarshal(target) }
+
+func (f *FlagSet) unmarshalFields(v reflect.Value, prefix string, errs *MultiError) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		fv := v.Field(i)
+		name := field.Tag.Get("flag")
+		if name == "" {
+			if fv.Kind() == reflect.Struct {
+				nested := prefix
+				if p := field.Tag.Get("flagPrefix"); p != "" {
+					nested += p + "."
+				}
+				f.unmarshalFields(fv, nested, errs)
+			}
+			continue
+		}
+		name = prefix + name
+		fl := f.formal[name]
+		if fl == nil {
+			errs.Append(fmt.Errorf("field %s: no flag -%s defined", field.Name, name))
+			continue
+		}
+		if err := assignFlagValue(fv, fl.Value); err != nil {
+			errs.Append(fmt.Errorf("field %s: flag -%s: %w", field.Name, name, err))
+		}
+	}
+}
+
+// assignFlagValue stores val's current value in dst, preferring Getter.Get and
+// falling back to String for string fields.
+func assignFlagValue(dst reflect.Value, val Value) error {
+	if g, ok := val.(Getter); ok {
+		got := reflect.ValueOf(g.Get())
+		if !got.IsValid() {
+			dst.Set(reflect.Zero(dst.Type()))
+			return nil
+		}
+		switch {
+		case got.Type().AssignableTo(dst.Type()):
+		case got.Kind() == dst.Kind() && got.Type().ConvertibleTo(dst.Type()):
+			got = got.Convert(dst.Type())
+		default:
+			goto TEXT
+		}
+		switch got.Kind() {
+		case reflect.Slice:
+			if !got.IsNil() {
+				c := reflect.MakeSlice(got.Type(), got.Len(), got.Len())
+				reflect.Copy(c, got)
+				got = c
+			}
+		case reflect.Map:
+			if !got.IsNil() {
+				c := reflect.MakeMapWithSize(got.Type(), got.Len())
+				iter := got.MapRange()
+				for iter.Next() {
+					c.SetMapIndex(iter.Key(), iter.Value())
+				}
+				got = c
+			}
+		}
+		dst.Set(got)
+		return nil
+	}
+TEXT:
+	if dst.Kind() == reflect.String {
+		dst.SetString(val.String())
+		return nil
+	}
+	return fmt.Errorf("cannot assign %T value to %s", val, dst.Type())
+}
@@ -0,0 +1,159 @@
+package flag
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strings"
+	"unicode/utf16"
+)
+
+// Config files read by ParseFile and the other config readers go through
+// readTextFile, so a file saved by a Windows editor reads the same as one
+// written on Linux:
+//
+//   - a UTF-8 byte order mark is dropped;
+//   - UTF-16 is decoded, whether marked by a byte order mark or detected from
+//     the NUL bytes of mostly-ASCII text;
+//   - CRLF and lone CR line endings become LF.
+//
+// Whole-file values (secret files, @file, file()) go through valueFromBytes,
+// which is stricter, since a secret may be binary or hold a meaningful CR:
+// only a byte order mark is acted on, UTF-8 dropped and UTF-16 decoded, and
+// the bytes are otherwise kept as they are.
+//
+// Whitespace policy: line breaks (LF or CRLF) at the end of a whole-file
+// value are removed, but spaces and tabs are kept, since they may be part of
+// a password. Config file lines keep trailing spaces and tabs in the flat
+// format; the INI format trims them.
+
+var (
+	utf8BOM    = []byte{0xEF, 0xBB, 0xBF}
+	utf16LEBOM = []byte{0xFF, 0xFE}
+	utf16BEBOM = []byte{0xFE, 0xFF}
+)
+
+// readTextFile returns the contents of path decoded and with line endings
+// normalised to "\n".
+func readTextFile(path string) (string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return textFromBytes(path, b)
+}
+
+// textFromBytes is readTextFile for contents already read from path.
+func textFromBytes(path string, b []byte) (string, error) {
+	s, err := decodeText(b, true)
+	if err != nil {
+		return "", &os.PathError{Op: "decode", Path: path, Err: err}
+	}
+	return normalizeNewlines(s), nil
+}
+
+// readValueFile returns the contents of path as a whole-file value.
+func readValueFile(path string) (string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return valueFromBytes(path, b)
+}
+
+// valueFromBytes decodes the contents of a whole-file value read from path:
+// a byte order mark is honoured and trailing line breaks are removed.
+func valueFromBytes(path string, b []byte) (string, error) {
+	s, err := decodeText(b, false)
+	if err != nil {
+		return "", &os.PathError{Op: "decode", Path: path, Err: err}
+	}
+	return trimFileValue(s), nil
+}
+
+// decodeText converts file contents to a Go string, removing a UTF-8 byte
+// order mark and decoding UTF-16 marked by a byte order mark or, when sniff
+// is set, detected from its NUL bytes.
+func decodeText(b []byte, sniff bool) (string, error) {
+	switch {
+	case bytes.HasPrefix(b, utf8BOM):
+		return string(b[len(utf8BOM):]), nil
+	case bytes.HasPrefix(b, utf16LEBOM):
+		return decodeUTF16(b[2:], false)
+	case bytes.HasPrefix(b, utf16BEBOM):
+		return decodeUTF16(b[2:], true)
+	}
+	if sniff {
+		if bigEndian, ok := sniffUTF16(b); ok {
+			return decodeUTF16(b, bigEndian)
+		}
+	}
+	return string(b), nil
+}
+
+// sniffUTF16 recognises BOM-less UTF-16 from its first bytes: text that is
+// mostly ASCII has a NUL in every other byte, which UTF-8 text never has.
+func sniffUTF16(b []byte) (bigEndian, ok bool) {
+	if len(b) < 2 || len(b)%2 != 0 {
+		return false, false
+	}
+	n := len(b)
+	if n > 256 {
+		n = 256
+	}
+	var even, odd int
+	for i := 0; i < n; i++ {
+		if b[i] == 0 {
+			if i%2 == 0 {
+				even++
+			} else {
+				odd++
+			}
+		}
+	}
+	pairs := n / 2
+	switch {
+	case odd*2 >= pairs && even == 0:
+		return false, true
+	case even*2 >= pairs && odd == 0:
+		return true, true
+	}
+	return false, false
+}
+
+func decodeUTF16(b []byte, bigEndian bool) (string, error) {
+	if len(b)%2 != 0 {
+		return "", errors.New("truncated UTF-16 text")
+	}
+	u := make([]uint16, len(b)/2)
+	for i := range u {
+		if bigEndian {
+			u[i] = uint16(b[2*i])<<8 | uint16(b[2*i+1])
+		} else {
+			u[i] = uint16(b[2*i+1])<<8 | uint16(b[2*i])
+		}
+	}
+	return string(utf16.Decode(u)), nil
+}
+
+// normalizeNewlines rewrites CRLF and lone CR line endings as LF.
+func normalizeNewlines(s string) string {
+	if !strings.Contains(s, "\r") {
+		return s
+	}
+	return strings.ReplaceAll(strings.ReplaceAll(s, "\r\n", "\n"), "\r", "\n")
+}
+
+// trimFileValue removes the trailing line breaks of a whole-file value.
+func trimFileValue(s string) string {
+	for {
+		switch {
+		case strings.HasSuffix(s, "\r\n"):
+			s = s[:len(s)-2]
+		case strings.HasSuffix(s, "\n"):
+			s = s[:len(s)-1]
+		default:
+			return s
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package flag_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"unicode/utf16"
+
+	. "github.com/machship/flag"
+)
+
+func utf16Bytes(s string, bigEndian, bom bool) []byte {
+	var out []byte
+	if bom {
+		if bigEndian {
+			out = append(out, 0xFE, 0xFF)
+		} else {
+			out = append(out, 0xFF, 0xFE)
+		}
+	}
+	for _, u := range utf16.Encode([]rune(s)) {
+		if bigEndian {
+			out = append(out, byte(u>>8), byte(u))
+		} else {
+			out = append(out, byte(u), byte(u>>8))
+		}
+	}
+	return out
+}
+
+func TestParseFileEncodings(t *testing.T) {
+	const text = "host=db.internal\r\nname=Zürich\r\n"
+	tests := map[string][]byte{
+		"utf8":          []byte(text),
+		"utf8-bom":      append([]byte{0xEF, 0xBB, 0xBF}, text...),
+		"utf16le-bom":   utf16Bytes(text, false, true),
+		"utf16be-bom":   utf16Bytes(text, true, true),
+		"utf16le-plain": utf16Bytes(text, false, false),
+		"utf16be-plain": utf16Bytes(text, true, false),
+		"cr-only":       []byte("host=db.internal\rname=Zürich\r"),
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "app.conf")
+			if err := os.WriteFile(path, content, 0600); err != nil {
+				t.Fatal(err)
+			}
+			f := NewFlagSet("test", ContinueOnError)
+			host := f.String("host", "", "")
+			city := f.String("name", "", "")
+			if err := f.ParseFile(path); err != nil {
+				t.Fatal(err)
+			}
+			if *host != "db.internal" || *city != "Zürich" {
+				t.Errorf("host=%q name=%q", *host, *city)
+			}
+		})
+	}
+}
+
+func TestParseSecretDirEncodings(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string][]byte{
+		"password": append([]byte{0xEF, 0xBB, 0xBF}, "s3cret \r\n"...),
+		"token":    utf16Bytes("abc\r\n", false, true),
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), content, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	f := NewFlagSet("test", ContinueOnError)
+	password := f.String("password", "", "")
+	token := f.String("token", "", "")
+	if err := f.ParseSecretDir(dir); err != nil {
+		t.Fatal(err)
+	}
+	// trailing spaces are kept; only line breaks are removed
+	if *password != "s3cret " {
+		t.Errorf("password = %q", *password)
+	}
+	if *token != "abc" {
+		t.Errorf("token = %q", *token)
+	}
+}
+
+func TestAtFileUTF16(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "key")
+	if err := os.WriteFile(path, utf16Bytes("line1\r\nline2\r\n", true, true), 0600); err != nil {
+		t.Fatal(err)
+	}
+	f := NewFlagSet("test", ContinueOnError)
+	key := f.String("key", "", "")
+	if err := f.ParseEnv([]string{"KEY=@" + path}); err != nil {
+		t.Fatal(err)
+	}
+	// only the trailing line break goes; a value's own line endings are kept
+	if *key != "line1\r\nline2" {
+		t.Errorf("key = %q", *key)
+	}
+}
+
+func TestSecretBytesKept(t *testing.T) {
+	dir := t.TempDir()
+	// BOM-less, with a NUL in every other byte: UTF-16 to a sniffer
+	raw := []byte{'k', 0, 'e', 0, 'y', 0, '\r', 0}
+	files := map[string][]byte{
+		"binary":  raw,
+		"cr-only": []byte("a\rb\r"),
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), content, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	f := NewFlagSet("test", ContinueOnError)
+	binary := f.String("binary", "", "")
+	crOnly := f.String("cr-only", "", "")
+	if err := f.ParseSecretDir(dir); err != nil {
+		t.Fatal(err)
+	}
+	if *binary != string(raw) {
+		t.Errorf("binary = %q", *binary)
+	}
+	if *crOnly != "a\rb\r" {
+		t.Errorf("cr-only = %q", *crOnly)
+	}
+	at := f.String("at", "", "")
+	if err := f.ParseEnv([]string{"AT=@" + filepath.Join(dir, "binary")}); err != nil {
+		t.Fatal(err)
+	}
+	if *at != string(raw) {
+		t.Errorf("@file = %q", *at)
+	}
+}
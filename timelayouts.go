@@ -0,0 +1,77 @@
+package flag
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// namedTimeLayouts maps time package constant names to their layouts so that
+// struct tags and configuration can refer to them by name.
+var namedTimeLayouts = map[string]string{
+	"ANSIC":       time.ANSIC,
+	"UnixDate":    time.UnixDate,
+	"RubyDate":    time.RubyDate,
+	"RFC822":      time.RFC822,
+	"RFC822Z":     time.RFC822Z,
+	"RFC850":      time.RFC850,
+	"RFC1123":     time.RFC1123,
+	"RFC1123Z":    time.RFC1123Z,
+	"RFC3339":     time.RFC3339,
+	"RFC3339Nano": time.RFC3339Nano,
+	"Kitchen":     time.Kitchen,
+	"DateTime":    time.DateTime,
+	"DateOnly":    time.DateOnly,
+	"TimeOnly":    time.TimeOnly,
+}
+
+// resolveTimeLayouts replaces constant names with their layouts and drops
+// empty entries, defaulting to RFC3339 when nothing remains.
+func resolveTimeLayouts(layouts []string) []string {
+	out := make([]string, 0, len(layouts))
+	for _, l := range layouts {
+		l = strings.TrimSpace(l)
+		if l == "" {
+			continue
+		}
+		if named, ok := namedTimeLayouts[l]; ok {
+			l = named
+		}
+		out = append(out, l)
+	}
+	if len(out) == 0 {
+		out = append(out, time.RFC3339)
+	}
+	return out
+}
+
+// structTimeLayouts combines the `layout` and `|`-separated `layouts` tags.
+func structTimeLayouts(layout, layouts string) []string {
+	var all []string
+	if layout != "" {
+		all = append(all, layout)
+	}
+	if layouts != "" {
+		all = append(all, strings.Split(layouts, "|")...)
+	}
+	return resolveTimeLayouts(all)
+}
+
+// parseTimeLayouts parses s with each layout in turn. With a single layout the
+// time package error is returned unchanged.
+func parseTimeLayouts(s string, layouts []string) (time.Time, error) {
+	var firstErr error
+	for _, l := range layouts {
+		t, err := time.Parse(l, s)
+		if err == nil {
+			return t, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	if len(layouts) == 1 {
+		return time.Time{}, firstErr
+	}
+	return time.Time{}, fmt.Errorf("time %q matches none of the layouts %q", s, layouts)
+}
@@ -0,0 +1,56 @@
+package flag_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/machship/flag"
+)
+
+func TestTimeLayouts(t *testing.T) {
+	f := NewFlagSet("test", ContinueOnError)
+	since := f.TimeLayouts("since", []string{"RFC3339", time.DateOnly, time.RFC1123}, time.Time{}, "")
+	until := f.TimeLayouts("until", []string{time.RFC3339, time.DateOnly, time.RFC1123}, time.Time{}, "")
+	if err := f.Parse([]string{"-since", "2024-03-01", "-until", "Mon, 04 Mar 2024 10:00:00 UTC"}); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if want := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC); !since.Equal(want) {
+		t.Errorf("since = %v, want %v", *since, want)
+	}
+	if until.Hour() != 10 || until.Day() != 4 {
+		t.Errorf("until = %v", *until)
+	}
+	if got := f.Lookup("since").Value.String(); got != "2024-03-01T00:00:00Z" {
+		t.Errorf("String() = %q, want first layout", got)
+	}
+
+	err := f.Set("since", "03/01/2024")
+	if err == nil || !strings.Contains(err.Error(), "matches none of the layouts") {
+		t.Fatalf("expected layouts error, got %v", err)
+	}
+}
+
+func TestStructLayoutsTag(t *testing.T) {
+	ResetForTesting(nil)
+	saved := os.Args
+	os.Args = []string{"cmd", "-start", "2024-05-06"}
+	defer func() { os.Args = saved }()
+	var cfg struct {
+		Start time.Time `flag:"start" layouts:"RFC3339|DateOnly"`
+		End   time.Time `flag:"end" layout:"2006-01-02 15:04" layouts:"DateOnly" default:"2024-06-01"`
+	}
+	if err := ParseStruct(&cfg); err != nil {
+		t.Fatalf("ParseStruct: %v", err)
+	}
+	if cfg.Start.Format(time.DateOnly) != "2024-05-06" {
+		t.Errorf("Start = %v", cfg.Start)
+	}
+	if cfg.End.Format(time.DateOnly) != "2024-06-01" {
+		t.Errorf("End = %v", cfg.End)
+	}
+	if got := Lookup("end").Value.String(); got != "2024-06-01 00:00" {
+		t.Errorf("end String() = %q, want layout tag format", got)
+	}
+}
@@ -0,0 +1,269 @@
+package flag
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TimeWindow is a weekly recurring span of time such as depot opening hours
+// or a pickup window: Start to End on each of Days, in Location. Start and
+// End are offsets from midnight; End may be 24h, and an End before Start
+// makes the window run past midnight into the next day.
+type TimeWindow struct {
+	Days       []time.Weekday
+	Start, End time.Duration
+	Location   *time.Location
+}
+
+// Contains reports whether t falls inside the window.
+func (w TimeWindow) Contains(t time.Time) bool {
+	if w.Location != nil {
+		t = t.In(w.Location)
+	}
+	h, m, sec := t.Clock()
+	tod := time.Duration(h)*time.Hour + time.Duration(m)*time.Minute + time.Duration(sec)*time.Second + time.Duration(t.Nanosecond())
+	day := t.Weekday()
+	if w.Start < w.End {
+		return w.hasDay(day) && tod >= w.Start && tod < w.End
+	}
+	// Overnight: the evening part belongs to day, the morning part to the
+	// day before.
+	return (w.hasDay(day) && tod >= w.Start) || (w.hasDay((day+6)%7) && tod < w.End)
+}
+
+func (w TimeWindow) hasDay(d time.Weekday) bool {
+	for _, wd := range w.Days {
+		if wd == d {
+			return true
+		}
+	}
+	return false
+}
+
+// String formats the window as "Mon-Fri 09:00-17:00", followed by the
+// location's name when it has one.
+func (w TimeWindow) String() string {
+	s := formatWeekdays(w.Days) + " " + formatTimeOfDay(w.Start) + "-" + formatTimeOfDay(w.End)
+	if w.Location != nil {
+		s += " " + w.Location.String()
+	}
+	return s
+}
+
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// parseWeekday accepts English day names, full or abbreviated to three
+// letters, in any case.
+func parseWeekday(s string) (time.Weekday, bool) {
+	s = strings.ToLower(s)
+	if len(s) < 3 {
+		return 0, false
+	}
+	d, ok := weekdayNames[s[:3]]
+	if !ok || (len(s) > 3 && !strings.EqualFold(s, d.String())) {
+		return 0, false
+	}
+	return d, true
+}
+
+// parseWeekdays reads "Mon-Fri", "Mon,Wed,Fri", "Fri-Mon" (wrapping past
+// Sunday), "daily" or a combination such as "Mon-Wed,Sat", returning the
+// days Monday first.
+func parseWeekdays(s string) ([]time.Weekday, error) {
+	var set [7]bool
+	if strings.EqualFold(s, "daily") {
+		set = [7]bool{true, true, true, true, true, true, true}
+	} else {
+		for _, part := range strings.Split(s, ",") {
+			from, to, isRange := strings.Cut(part, "-")
+			first, ok := parseWeekday(from)
+			if !ok {
+				return nil, fmt.Errorf("unknown day %q", from)
+			}
+			last := first
+			if isRange {
+				if last, ok = parseWeekday(to); !ok {
+					return nil, fmt.Errorf("unknown day %q", to)
+				}
+			}
+			for d := first; ; d = (d + 1) % 7 {
+				set[d] = true
+				if d == last {
+					break
+				}
+			}
+		}
+	}
+	var days []time.Weekday
+	for i := 1; i <= 7; i++ {
+		if d := time.Weekday(i % 7); set[d] {
+			days = append(days, d)
+		}
+	}
+	return days, nil
+}
+
+// formatWeekdays writes days Monday first, collapsing runs of three or more
+// consecutive days into ranges.
+func formatWeekdays(days []time.Weekday) string {
+	var set [7]bool
+	for _, d := range days {
+		set[d] = true
+	}
+	if set == [7]bool{true, true, true, true, true, true, true} {
+		return "Daily"
+	}
+	var parts []string
+	for i := 1; i <= 7; {
+		if !set[i%7] {
+			i++
+			continue
+		}
+		j := i
+		for j+1 <= 7 && set[(j+1)%7] {
+			j++
+		}
+		first, last := time.Weekday(i % 7).String()[:3], time.Weekday(j % 7).String()[:3]
+		switch j - i {
+		case 0:
+			parts = append(parts, first)
+		case 1:
+			parts = append(parts, first, last)
+		default:
+			parts = append(parts, first+"-"+last)
+		}
+		i = j + 1
+	}
+	return strings.Join(parts, ",")
+}
+
+// parseTimeOfDay reads H:MM or HH:MM as an offset from midnight; 24:00 is
+// accepted as the end of the day.
+func parseTimeOfDay(s string) (time.Duration, error) {
+	hs, ms, ok := strings.Cut(s, ":")
+	h, herr := strconv.Atoi(hs)
+	m, merr := strconv.Atoi(ms)
+	if !ok || herr != nil || merr != nil || len(hs) > 2 || len(ms) != 2 || h < 0 || m < 0 || m > 59 || h > 24 || (h == 24 && m != 0) {
+		return 0, fmt.Errorf("invalid time of day %q: expected HH:MM", s)
+	}
+	return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute, nil
+}
+
+func formatTimeOfDay(d time.Duration) string {
+	return fmt.Sprintf("%02d:%02d", int(d/time.Hour), int(d%time.Hour/time.Minute))
+}
+
+// parseTimeWindow reads "DAYS HH:MM-HH:MM[,HH:MM-HH:MM...] [ZONE]", where
+// ZONE is an IANA name such as Australia/Sydney and defaults to loc. Each
+// time range gives one window.
+func parseTimeWindow(s string, loc *time.Location) ([]TimeWindow, error) {
+	fields := strings.Fields(s)
+	if len(fields) != 2 && len(fields) != 3 {
+		return nil, fmt.Errorf("invalid time window %q: expected \"Mon-Fri 09:00-17:00 [zone]\"", s)
+	}
+	days, err := parseWeekdays(fields[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid time window %q: %v", s, err)
+	}
+	if len(fields) == 3 {
+		if loc, err = time.LoadLocation(fields[2]); err != nil {
+			return nil, fmt.Errorf("invalid time window %q: unknown time zone %q", s, fields[2])
+		}
+	}
+	var out []TimeWindow
+	for _, span := range strings.Split(fields[1], ",") {
+		from, to, ok := strings.Cut(span, "-")
+		if !ok {
+			return nil, fmt.Errorf("invalid time window %q: expected HH:MM-HH:MM, got %q", s, span)
+		}
+		start, err := parseTimeOfDay(from)
+		if err == nil && start == 24*time.Hour {
+			err = fmt.Errorf("window cannot start at 24:00")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid time window %q: %v", s, err)
+		}
+		end, err := parseTimeOfDay(to)
+		if err != nil {
+			return nil, fmt.Errorf("invalid time window %q: %v", s, err)
+		}
+		if start == end {
+			return nil, fmt.Errorf("invalid time window %q: %s is empty", s, span)
+		}
+		out = append(out, TimeWindow{Days: days, Start: start, End: end, Location: loc})
+	}
+	return out, nil
+}
+
+type timeWindowsValue struct {
+	p   *[]TimeWindow
+	loc *time.Location
+}
+
+func newTimeWindowsValue(val []TimeWindow, p *[]TimeWindow, loc *time.Location) *timeWindowsValue {
+	*p = val
+	return &timeWindowsValue{p: p, loc: loc}
+}
+func (tv *timeWindowsValue) Set(s string) error {
+	return tv.setElements(strings.Split(s, ";"))
+}
+func (tv *timeWindowsValue) setElements(elems []string) error {
+	var out []TimeWindow
+	for _, e := range elems {
+		if strings.TrimSpace(e) == "" {
+			continue
+		}
+		ws, err := parseTimeWindow(e, tv.loc)
+		if err != nil {
+			return err
+		}
+		out = append(out, ws...)
+	}
+	*tv.p = out
+	return nil
+}
+func (tv *timeWindowsValue) String() string {
+	if tv.p == nil {
+		return ""
+	}
+	parts := make([]string, len(*tv.p))
+	for i, w := range *tv.p {
+		if w.Location == tv.loc {
+			w.Location = nil // the flag's own zone goes without saying
+		}
+		parts[i] = w.String()
+	}
+	return strings.Join(parts, ";")
+}
+func (tv *timeWindowsValue) Get() interface{} { return *tv.p }
+
+// TimeWindowsVar registers a flag holding weekly time windows, given as
+// "Mon-Fri 09:00-17:00;Sat 09:00-12:00". Each window names its days (a day,
+// a range such as Fri-Mon, a comma list or "daily"), one or more
+// comma-separated HH:MM-HH:MM spans, and optionally an IANA time zone
+// ("Mon-Fri 07:00-15:00 Australia/Perth"); windows without one are in loc,
+// or time.Local when loc is nil. Config arrays give one window per element.
+func (f *FlagSet) TimeWindowsVar(p *[]TimeWindow, name string, value []TimeWindow, loc *time.Location, usage string) {
+	if loc == nil {
+		loc = time.Local
+	}
+	f.Var(newTimeWindowsValue(value, p, loc), name, usage)
+}
+func TimeWindowsVar(p *[]TimeWindow, name string, value []TimeWindow, loc *time.Location, usage string) {
+	CommandLine.TimeWindowsVar(p, name, value, loc, usage)
+}
+
+// TimeWindows defines a time windows flag and returns a pointer to it.
+func (f *FlagSet) TimeWindows(name string, value []TimeWindow, loc *time.Location, usage string) *[]TimeWindow {
+	p := new([]TimeWindow)
+	f.TimeWindowsVar(p, name, value, loc, usage)
+	return p
+}
+func TimeWindows(name string, value []TimeWindow, loc *time.Location, usage string) *[]TimeWindow {
+	return CommandLine.TimeWindows(name, value, loc, usage)
+}
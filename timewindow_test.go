@@ -0,0 +1,111 @@
+package flag_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/machship/flag"
+)
+
+func TestTimeWindowsFlag(t *testing.T) {
+	sydney, err := time.LoadLocation("Australia/Sydney")
+	if err != nil {
+		t.Skipf("no tzdata: %v", err)
+	}
+	f := NewFlagSet("test", ContinueOnError)
+	ws := f.TimeWindows("depot-hours", nil, time.UTC, "")
+	if err := f.Set("depot-hours", "Mon-Fri 09:00-17:00; sat 9:00-12:00 ;Sun 22:00-06:00 Australia/Sydney"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if len(*ws) != 3 {
+		t.Fatalf("got %d windows: %v", len(*ws), *ws)
+	}
+	weekdays := (*ws)[0]
+	if len(weekdays.Days) != 5 || weekdays.Days[0] != time.Monday || weekdays.Start != 9*time.Hour || weekdays.End != 17*time.Hour || weekdays.Location != time.UTC {
+		t.Fatalf("first window = %+v", weekdays)
+	}
+	if (*ws)[2].Location.String() != sydney.String() {
+		t.Fatalf("zone = %v", (*ws)[2].Location)
+	}
+	if s := f.Lookup("depot-hours").Value.String(); s != "Mon-Fri 09:00-17:00;Sat 09:00-12:00;Sun 22:00-06:00 Australia/Sydney" {
+		t.Fatalf("String() = %q", s)
+	}
+
+	contains := map[string]bool{
+		"2026-10-12T09:00:00Z": true,  // Monday opening
+		"2026-10-12T17:00:00Z": false, // closing time is exclusive
+		"2026-10-17T11:59:00Z": true,  // Saturday morning
+		"2026-10-18T11:59:00Z": false, // Sunday
+	}
+	for ts, want := range contains {
+		tm, _ := time.Parse(time.RFC3339, ts)
+		got := (*ws)[0].Contains(tm) || (*ws)[1].Contains(tm)
+		if got != want {
+			t.Errorf("Contains(%s) = %t, want %t", ts, got, want)
+		}
+	}
+	overnight := (*ws)[2]
+	for ts, want := range map[string]bool{
+		"2026-10-18T23:00:00+11:00": true,  // Sunday evening in Sydney
+		"2026-10-19T05:59:00+11:00": true,  // Monday early morning
+		"2026-10-19T06:00:00+11:00": false, // closed
+		"2026-10-18T05:00:00+11:00": false, // Sunday morning: Saturday has no window
+		"2026-10-18T12:30:00Z":      true,  // 23:30 in Sydney
+	} {
+		tm, _ := time.Parse(time.RFC3339, ts)
+		if got := overnight.Contains(tm); got != want {
+			t.Errorf("overnight Contains(%s) = %t, want %t", ts, got, want)
+		}
+	}
+
+	f.Set("depot-hours", "Fri-Mon 08:00-12:00,13:00-24:00;daily 00:00-01:00")
+	if s := f.Lookup("depot-hours").Value.String(); s != "Mon,Fri-Sun 08:00-12:00;Mon,Fri-Sun 13:00-24:00;Daily 00:00-01:00" {
+		t.Fatalf("String() = %q", s)
+	}
+
+	errs := map[string]string{
+		"Mon-Fri":                        "expected \"Mon-Fri 09:00-17:00 [zone]\"",
+		"Mon-Fro 09:00-17:00":            `unknown day "Fro"`,
+		"Mon 9-17":                       "expected HH:MM",
+		"Mon 09:00-25:00":                "expected HH:MM",
+		"Mon 24:00-01:00":                "cannot start at 24:00",
+		"Mon 09:00-09:00":                "is empty",
+		"Mon 09:00-17:00 Mars/Olympus":   `unknown time zone "Mars/Olympus"`,
+		"Mon 09:00-17:00 UTC extra text": "expected",
+	}
+	for in, want := range errs {
+		if err := f.Set("depot-hours", in); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Set(%q): expected %q, got %v", in, want, err)
+		}
+	}
+}
+
+func TestTimeWindowsStructField(t *testing.T) {
+	perth, err := time.LoadLocation("Australia/Perth")
+	if err != nil {
+		t.Skipf("no tzdata: %v", err)
+	}
+	ResetForTesting(nil)
+	saved := os.Args
+	os.Args = []string{"cmd", "-pickup", "Tue,Thu 10:00-14:00"}
+	defer func() { os.Args = saved }()
+	var cfg struct {
+		Pickup     []TimeWindow `flag:"pickup" tz:"Australia/Perth"`
+		DepotHours []TimeWindow `flag:"depot-hours" tz:"Australia/Perth" default:"Mon-Fri 07:00-15:00"`
+	}
+	if err := ParseStruct(&cfg); err != nil {
+		t.Fatalf("ParseStruct: %v", err)
+	}
+	if len(cfg.Pickup) != 1 || len(cfg.Pickup[0].Days) != 2 || cfg.Pickup[0].Location.String() != perth.String() {
+		t.Fatalf("pickup = %v", cfg.Pickup)
+	}
+	if len(cfg.DepotHours) != 1 || cfg.DepotHours[0].End != 15*time.Hour {
+		t.Fatalf("depot hours = %v", cfg.DepotHours)
+	}
+	// 08:00 in Perth is 00:00 UTC on the same Wednesday.
+	if !cfg.DepotHours[0].Contains(time.Date(2026, 10, 14, 0, 0, 0, 0, time.UTC)) {
+		t.Fatal("depot hours do not contain Wednesday 08:00 in Perth")
+	}
+}
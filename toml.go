@@ -0,0 +1,421 @@
+package flag
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ParseTOMLFile parses flags from the TOML file in path. Tables and dotted
+// keys name dotted flags, so "[db]" followed by "host = 'x'" sets -db.host,
+// matching flagPrefix nesting. Arrays fill slice flags one element per item,
+// and a table (or inline table) whose name is a string map flag fills that
+// map. Arrays of tables and nested arrays are not supported. Flags already
+// set will be ignored. ParseFile calls it for files ending in ".toml". With
+// RequireSignedConfig the file must carry a valid signature.
+func (f *FlagSet) ParseTOMLFile(path string) error {
+	text, err := f.readConfigText(path)
+	if err != nil {
+		return err
+	}
+	return f.parseTOML(strings.NewReader(text))
+}
+
+func (f *FlagSet) parseTOML(r io.Reader) error {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	p := &tomlParser{s: string(b), line: 1, seen: make(map[string]bool)}
+	if err := p.parse(); err != nil {
+		return f.failf("%v", err)
+	}
+	return f.applyConfigEntries(p.entries)
+}
+
+// tomlParser turns a TOML document into flat entries. Values are kept as the
+// text the flag's Set method expects: strings unquoted and unescaped,
+// integers in decimal and local date-times joined with 'T'.
+type tomlParser struct {
+	s       string
+	pos     int
+	line    int
+	table   string
+	entries []configEntry
+	seen    map[string]bool
+}
+
+func (p *tomlParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%s on line %d", fmt.Sprintf(format, args...), p.line)
+}
+
+func (p *tomlParser) eof() bool { return p.pos >= len(p.s) }
+
+func (p *tomlParser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.s[p.pos]
+}
+
+// skipSpace skips spaces and tabs.
+func (p *tomlParser) skipSpace() {
+	for !p.eof() && (p.s[p.pos] == ' ' || p.s[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+// skipBlank skips whitespace, newlines and comments.
+func (p *tomlParser) skipBlank() {
+	for !p.eof() {
+		switch p.s[p.pos] {
+		case ' ', '\t':
+			p.pos++
+		case '\n':
+			p.pos++
+			p.line++
+		case '#':
+			for !p.eof() && p.s[p.pos] != '\n' {
+				p.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+// endOfLine consumes trailing spaces and an optional comment up to and
+// including the newline.
+func (p *tomlParser) endOfLine() error {
+	p.skipSpace()
+	if p.peek() == '#' {
+		for !p.eof() && p.s[p.pos] != '\n' {
+			p.pos++
+		}
+	}
+	if p.eof() {
+		return nil
+	}
+	if p.s[p.pos] != '\n' {
+		return p.errorf("unexpected %q after value", p.s[p.pos])
+	}
+	p.pos++
+	p.line++
+	return nil
+}
+
+func (p *tomlParser) parse() error {
+	for {
+		p.skipBlank()
+		if p.eof() {
+			return nil
+		}
+		if p.peek() == '[' {
+			p.pos++
+			if p.peek() == '[' {
+				return p.errorf("arrays of tables are not supported")
+			}
+			p.skipSpace()
+			key, err := p.key()
+			if err != nil {
+				return err
+			}
+			p.skipSpace()
+			if p.peek() != ']' {
+				return p.errorf("invalid table header")
+			}
+			p.pos++
+			p.table = key
+			if err := p.endOfLine(); err != nil {
+				return err
+			}
+			continue
+		}
+		key, err := p.key()
+		if err != nil {
+			return err
+		}
+		p.skipSpace()
+		if p.peek() != '=' {
+			return p.errorf("expected '=' after key %s", key)
+		}
+		p.pos++
+		p.skipSpace()
+		if err := p.value(p.qualify(key)); err != nil {
+			return err
+		}
+		if err := p.endOfLine(); err != nil {
+			return err
+		}
+	}
+}
+
+func (p *tomlParser) qualify(key string) string {
+	if p.table == "" {
+		return key
+	}
+	return p.table + "." + key
+}
+
+// key reads a possibly dotted key of bare and quoted parts.
+func (p *tomlParser) key() (string, error) {
+	var parts []string
+	for {
+		var part string
+		switch c := p.peek(); {
+		case c == '"' || c == '\'':
+			s, err := p.str()
+			if err != nil {
+				return "", err
+			}
+			part = s
+		default:
+			start := p.pos
+			for !p.eof() && isBareKeyChar(p.s[p.pos]) {
+				p.pos++
+			}
+			if p.pos == start {
+				return "", p.errorf("missing key")
+			}
+			part = p.s[start:p.pos]
+		}
+		parts = append(parts, part)
+		p.skipSpace()
+		if p.peek() != '.' {
+			return strings.Join(parts, "."), nil
+		}
+		p.pos++
+		p.skipSpace()
+	}
+}
+
+func isBareKeyChar(c byte) bool {
+	return c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
+
+func (p *tomlParser) add(e configEntry) error {
+	if p.seen[e.name] {
+		return p.errorf("duplicate key %s", e.name)
+	}
+	p.seen[e.name] = true
+	p.entries = append(p.entries, e)
+	return nil
+}
+
+// value reads the value of name: an inline table, an array or a scalar.
+func (p *tomlParser) value(name string) error {
+	switch p.peek() {
+	case '{':
+		p.pos++
+		p.skipSpace()
+		if p.peek() == '}' {
+			p.pos++
+			return nil
+		}
+		for {
+			p.skipSpace()
+			key, err := p.key()
+			if err != nil {
+				return err
+			}
+			p.skipSpace()
+			if p.peek() != '=' {
+				return p.errorf("expected '=' after key %s", key)
+			}
+			p.pos++
+			p.skipSpace()
+			if err := p.value(name + "." + key); err != nil {
+				return err
+			}
+			p.skipSpace()
+			switch p.peek() {
+			case ',':
+				p.pos++
+			case '}':
+				p.pos++
+				return nil
+			default:
+				return p.errorf("unterminated inline table %s", name)
+			}
+		}
+	case '[':
+		p.pos++
+		values := []string{}
+		for {
+			p.skipBlank()
+			if p.peek() == ']' {
+				p.pos++
+				return p.add(configEntry{name: name, values: values, list: true})
+			}
+			if c := p.peek(); c == '[' || c == '{' {
+				return p.errorf("nested arrays and tables in array %s are not supported", name)
+			}
+			v, err := p.scalar()
+			if err != nil {
+				return err
+			}
+			values = append(values, v)
+			p.skipBlank()
+			switch p.peek() {
+			case ',':
+				p.pos++
+			case ']':
+			default:
+				return p.errorf("unterminated array %s", name)
+			}
+		}
+	}
+	v, err := p.scalar()
+	if err != nil {
+		return err
+	}
+	return p.add(configEntry{name: name, values: []string{v}})
+}
+
+// scalar reads a string, boolean, number or date-time.
+func (p *tomlParser) scalar() (string, error) {
+	if c := p.peek(); c == '"' || c == '\'' {
+		return p.str()
+	}
+	start := p.pos
+	for !p.eof() && !strings.ContainsRune(" \t\n,]}#", rune(p.s[p.pos])) {
+		p.pos++
+	}
+	tok := p.s[start:p.pos]
+	// "1979-05-27 07:32:00Z" separates date and time with a space
+	if len(tok) == 10 && tok[4] == '-' && tok[7] == '-' &&
+		p.peek() == ' ' && p.pos+1 < len(p.s) && p.s[p.pos+1] >= '0' && p.s[p.pos+1] <= '9' {
+		p.pos++
+		rest := p.pos
+		for !p.eof() && !strings.ContainsRune(" \t\n,]}#", rune(p.s[p.pos])) {
+			p.pos++
+		}
+		tok += "T" + p.s[rest:p.pos]
+	}
+	switch {
+	case tok == "":
+		return "", p.errorf("missing value")
+	case tok == "true" || tok == "false":
+		return tok, nil
+	case strings.HasPrefix(tok, "0x") || strings.HasPrefix(tok, "0o") || strings.HasPrefix(tok, "0b"):
+		n, err := strconv.ParseInt(tok, 0, 64)
+		if err != nil {
+			return "", p.errorf("invalid integer %s", tok)
+		}
+		return strconv.FormatInt(n, 10), nil
+	case strings.Contains(tok, ":") || len(tok) >= 10 && tok[4] == '-':
+		return tok, nil // date, time or date-time
+	}
+	num := strings.ReplaceAll(tok, "_", "")
+	if _, err := strconv.ParseFloat(num, 64); err != nil {
+		return "", p.errorf("invalid value %s", tok)
+	}
+	return num, nil
+}
+
+// str reads a basic or literal string, single or multi-line.
+func (p *tomlParser) str() (string, error) {
+	q := p.s[p.pos]
+	multi := strings.HasPrefix(p.s[p.pos:], strings.Repeat(string(q), 3))
+	if multi {
+		p.pos += 3
+		// a newline right after the opening delimiter is trimmed
+		if p.peek() == '\n' {
+			p.pos++
+			p.line++
+		}
+	} else {
+		p.pos++
+	}
+	var b strings.Builder
+	for {
+		if p.eof() {
+			return "", p.errorf("unterminated string")
+		}
+		c := p.s[p.pos]
+		switch {
+		case multi && strings.HasPrefix(p.s[p.pos:], strings.Repeat(string(q), 3)):
+			p.pos += 3
+			// up to two quotes may directly precede the closing delimiter
+			for i := 0; i < 2 && p.peek() == q; i++ {
+				b.WriteByte(q)
+				p.pos++
+			}
+			return b.String(), nil
+		case !multi && c == q:
+			p.pos++
+			return b.String(), nil
+		case c == '\n':
+			if !multi {
+				return "", p.errorf("unterminated string")
+			}
+			b.WriteByte(c)
+			p.pos++
+			p.line++
+		case c == '\\' && q == '"':
+			if err := p.escape(&b, multi); err != nil {
+				return "", err
+			}
+		default:
+			b.WriteByte(c)
+			p.pos++
+		}
+	}
+}
+
+// escape decodes the escape sequence at p.pos into b.
+func (p *tomlParser) escape(b *strings.Builder, multi bool) error {
+	p.pos++
+	if p.eof() {
+		return p.errorf("unterminated string")
+	}
+	c := p.s[p.pos]
+	p.pos++
+	switch c {
+	case 'b':
+		b.WriteByte('\b')
+	case 't':
+		b.WriteByte('\t')
+	case 'n':
+		b.WriteByte('\n')
+	case 'f':
+		b.WriteByte('\f')
+	case 'r':
+		b.WriteByte('\r')
+	case 'e':
+		b.WriteByte(0x1b)
+	case '"', '\\':
+		b.WriteByte(c)
+	case 'u', 'U':
+		n := 4
+		if c == 'U' {
+			n = 8
+		}
+		if p.pos+n > len(p.s) {
+			return p.errorf("malformed \\%c escape", c)
+		}
+		code, err := strconv.ParseUint(p.s[p.pos:p.pos+n], 16, 32)
+		if err != nil || !utf8.ValidRune(rune(code)) {
+			return p.errorf("malformed \\%c escape", c)
+		}
+		b.WriteRune(rune(code))
+		p.pos += n
+	case ' ', '\t', '\n':
+		if !multi {
+			return p.errorf("invalid escape \\%c", c)
+		}
+		// line ending backslash: trim whitespace up to the next content
+		p.pos--
+		for !p.eof() && strings.ContainsRune(" \t\n", rune(p.s[p.pos])) {
+			if p.s[p.pos] == '\n' {
+				p.line++
+			}
+			p.pos++
+		}
+	default:
+		return p.errorf("invalid escape \\%c", c)
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package flag_test
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/machship/flag"
+)
+
+func TestParseFileTOML(t *testing.T) {
+	path := writeTempFile(t, "app.toml", `# service settings
+name = "billing"   # inline comments are fine
+verbose = true
+max-body = "10MiB"
+retries = 0x10
+
+[db]
+host = 'db.internal'
+port = 5_433
+"pool size" = 8
+
+[http]
+timeouts = [
+  "1s",
+  "2.5s", # read
+]
+peers = ["a:11211", "b,c:11211"]
+
+[labels]
+team = "payments"
+"cost.center" = "42"
+
+[motd]
+text = """
+Hello,\tworld \
+    again"""
+`)
+	f := NewFlagSet("test", ContinueOnError)
+	name := f.String("name", "", "")
+	verbose := f.Bool("verbose", false, "")
+	maxBody := f.ByteSizeFlag("max-body", 0, "")
+	retries := f.Int("retries", 0, "")
+	host := f.String("db.host", "", "")
+	port := f.Int("db.port", 0, "")
+	pool := f.Int("db.pool size", 0, "")
+	timeouts := f.DurationSlice("http.timeouts", ",", nil, "")
+	peers := f.StringSlice("http.peers", ",", nil, "")
+	labels := f.StringMap("labels", nil, "")
+	motd := f.String("motd.text", "", "")
+	if err := f.ParseFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if *name != "billing" || !*verbose || *maxBody != 10<<20 || *retries != 16 {
+		t.Errorf("name=%q verbose=%v max-body=%d retries=%d", *name, *verbose, *maxBody, *retries)
+	}
+	if *host != "db.internal" || *port != 5433 || *pool != 8 {
+		t.Errorf("host=%q port=%d pool=%d", *host, *port, *pool)
+	}
+	if want := []time.Duration{time.Second, 2500 * time.Millisecond}; !reflect.DeepEqual(*timeouts, want) {
+		t.Errorf("timeouts = %v, want %v", *timeouts, want)
+	}
+	if want := []string{"a:11211", "b,c:11211"}; !reflect.DeepEqual(*peers, want) {
+		t.Errorf("peers = %q, want %q", *peers, want)
+	}
+	if want := map[string]string{"team": "payments", "cost.center": "42"}; !reflect.DeepEqual(*labels, want) {
+		t.Errorf("labels = %v, want %v", *labels, want)
+	}
+	if *motd != "Hello,\tworld again" {
+		t.Errorf("motd = %q", *motd)
+	}
+}
+
+func TestParseTOMLFileInlineTablesAndPrecedence(t *testing.T) {
+	path := writeTempFile(t, "app.toml", `db = { host = "x", port = 1 }
+labels = { env = "prod" }
+start = 1979-05-27 07:32:00Z
+`)
+	f := NewFlagSet("test", ContinueOnError)
+	host := f.String("db.host", "", "")
+	port := f.Int("db.port", 0, "")
+	labels := f.StringMap("labels", nil, "")
+	start := f.Time("start", time.RFC3339, time.Time{}, "")
+	if err := f.Parse([]string{"-db.host=cli"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.ParseTOMLFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if *host != "cli" || *port != 1 || (*labels)["env"] != "prod" {
+		t.Errorf("host=%q port=%d labels=%v", *host, *port, *labels)
+	}
+	if want := time.Date(1979, 5, 27, 7, 32, 0, 0, time.UTC); !start.Equal(want) {
+		t.Errorf("start = %v, want %v", *start, want)
+	}
+}
+
+func TestParseTOMLFileErrors(t *testing.T) {
+	tests := []struct {
+		content, want string
+	}{
+		{"[[servers]]\nname = 'a'\n", "arrays of tables are not supported on line 1"},
+		{"name = 'a'\nname = 'b'\n", "duplicate key name on line 2"},
+		{"name = 'a' 'b'\n", "after value on line 1"},
+		{"name = \"open\n", "unterminated string on line 1"},
+		{"name = nope\n", "invalid value nope on line 1"},
+		{"name = ['a']\n", "configuration variable name is an array but -name is not a list"},
+		{"[labels]\nx = ['a']\n", "is an array but -labels is a map of strings"},
+		{"missing = 1\n", "configuration variable provided but not defined: missing"},
+	}
+	for _, tt := range tests {
+		f := NewFlagSet("test", ContinueOnError)
+		f.SetOutput(io.Discard)
+		f.String("name", "", "")
+		f.StringMap("labels", nil, "")
+		err := f.ParseTOMLFile(writeTempFile(t, "app.toml", tt.content))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%q: err = %v, want %q", tt.content, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package flag
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Parse phases reported to a Tracer.
+const (
+	PhaseCLI       = "cli"
+	PhaseEnv       = "env"
+	PhaseSecretDir = "secret-dir"
+	PhaseConfig    = "config"
+	PhaseRemote    = "remote"
+	PhaseValidate  = "validate"
+)
+
+// Tracer observes the phases of flag resolution, e.g. to attribute slow
+// startups to a particular source. StartPhase is called when a phase begins;
+// the returned function is called exactly once when it ends, with the error
+// the phase produced (nil on success). Phases that do not apply (no secret dir
+// or config file configured) are not reported.
+type Tracer interface {
+	StartPhase(phase string) (end func(err error))
+}
+
+// SetTracer installs t to observe Parse and Validate. A nil t disables tracing.
+func (f *FlagSet) SetTracer(t Tracer) { f.tracer = t }
+
+// SetTracer installs a tracer on the default CommandLine FlagSet.
+func SetTracer(t Tracer) { CommandLine.SetTracer(t) }
+
+// startPhase begins a traced phase; the returned func is safe to call more
+// than once (only the first call is forwarded).
+func (f *FlagSet) startPhase(phase string) func(error) {
+	if f.tracer == nil {
+		return func(error) {}
+	}
+	end := f.tracer.StartPhase(phase)
+	done := false
+	return func(err error) {
+		if done || end == nil {
+			return
+		}
+		done = true
+		end(err)
+	}
+}
+
+// PhaseRecorder is a Tracer that accumulates the wall time spent in each
+// phase, for quick startup diagnostics without an external tracing system.
+type PhaseRecorder struct {
+	mu     sync.Mutex
+	totals map[string]time.Duration
+	counts map[string]int
+	now    func() time.Time
+}
+
+// NewPhaseRecorder returns an empty PhaseRecorder.
+func NewPhaseRecorder() *PhaseRecorder {
+	return &PhaseRecorder{totals: make(map[string]time.Duration), counts: make(map[string]int), now: time.Now}
+}
+
+// StartPhase implements Tracer.
+func (r *PhaseRecorder) StartPhase(phase string) func(error) {
+	start := r.now()
+	return func(error) {
+		d := r.now().Sub(start)
+		r.mu.Lock()
+		r.totals[phase] += d
+		r.counts[phase]++
+		r.mu.Unlock()
+	}
+}
+
+// PhaseTiming is the accumulated time for one phase.
+type PhaseTiming struct {
+	Phase string        `json:"phase"`
+	Total time.Duration `json:"totalNs"`
+	Count int           `json:"count"`
+}
+
+// Timings returns the recorded phases sorted by name.
+func (r *PhaseRecorder) Timings() []PhaseTiming {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]PhaseTiming, 0, len(r.totals))
+	for p, d := range r.totals {
+		out = append(out, PhaseTiming{Phase: p, Total: d, Count: r.counts[p]})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Phase < out[j].Phase })
+	return out
+}
@@ -0,0 +1,81 @@
+package flag_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/machship/flag"
+)
+
+type recordingTracer struct{ events []string }
+
+func (r *recordingTracer) StartPhase(phase string) func(error) {
+	r.events = append(r.events, "start:"+phase)
+	return func(err error) {
+		if err != nil {
+			r.events = append(r.events, "fail:"+phase)
+			return
+		}
+		r.events = append(r.events, "end:"+phase)
+	}
+}
+
+func TestTracer_PhasesInOrder(t *testing.T) {
+	dir := t.TempDir()
+	cfg := filepath.Join(dir, "app.conf")
+	if err := os.WriteFile(cfg, []byte("name from-config\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f := NewFlagSet("test", ContinueOnError)
+	f.String("secret-dir", dir, "")
+	f.String("config", cfg, "")
+	f.String("name", "", "")
+	f.Deferred(func() error { return nil })
+	rt := &recordingTracer{}
+	f.SetTracer(rt)
+	if err := f.Parse(nil); err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if err := f.Validate(); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	want := "start:cli,end:cli,start:env,end:env,start:secret-dir,end:secret-dir,start:config,end:config,start:validate,end:validate"
+	if got := strings.Join(rt.events, ","); got != want {
+		t.Fatalf("unexpected phases:\n got %s\nwant %s", got, want)
+	}
+}
+
+func TestTracer_ReportsFailure(t *testing.T) {
+	f := NewFlagSet("test", ContinueOnError)
+	f.SetOutput(&strings.Builder{})
+	f.Int("n", 0, "")
+	rt := &recordingTracer{}
+	f.SetTracer(rt)
+	if err := f.Parse([]string{"-n", "x"}); err == nil {
+		t.Fatalf("expected parse error")
+	}
+	if got := strings.Join(rt.events, ","); got != "start:cli,fail:cli" {
+		t.Fatalf("unexpected events: %s", got)
+	}
+}
+
+func TestPhaseRecorder(t *testing.T) {
+	f := NewFlagSet("test", ContinueOnError)
+	f.Deferred(func() error { return errors.New("boom") })
+	rec := NewPhaseRecorder()
+	f.SetTracer(rec)
+	_ = f.Parse(nil)
+	_ = f.Validate()
+	timings := rec.Timings()
+	if len(timings) != 3 || timings[0].Phase != PhaseCLI || timings[1].Phase != PhaseEnv || timings[2].Phase != PhaseValidate {
+		t.Fatalf("unexpected timings: %+v", timings)
+	}
+	for _, pt := range timings {
+		if pt.Count != 1 || pt.Total < 0 {
+			t.Fatalf("unexpected timing entry: %+v", pt)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package flag_test
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+
+	. "github.com/machship/flag"
+)
+
+type portValue int
+
+func (p *portValue) String() string   { return "" }
+func (p *portValue) Set(string) error { return nil }
+func (p *portValue) TypeName() string { return "port" }
+
+func TestUnquoteUsageExtendedTypes(t *testing.T) {
+	f := NewFlagSet("test", ContinueOnError)
+	f.ByteSizeFlag("size", 0, "buffer size")
+	f.UUID("id", uuid.Nil, "request id")
+	f.StringMap("labels", nil, "labels")
+	f.StringSlice("tags", ",", nil, "tags")
+	f.Enum("mode", "dev", []string{"prod", "dev"}, "mode")
+	f.Var(new(portValue), "listen", "listen port")
+	f.Var(new(portValue), "admin", "admin `addr`")
+
+	want := map[string]string{
+		"size":   "bytes",
+		"id":     "uuid",
+		"labels": "key=value,...",
+		"tags":   "strings",
+		"mode":   "dev|prod",
+		"listen": "port",
+		"admin":  "addr",
+	}
+	for name, w := range want {
+		got, _ := UnquoteUsage(f.Lookup(name))
+		if got != w {
+			t.Errorf("%s: type name = %q, want %q", name, got, w)
+		}
+	}
+}
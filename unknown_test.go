@@ -0,0 +1,44 @@
+package flag_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	. "github.com/machship/flag"
+)
+
+func TestAllowUnknownFlags(t *testing.T) {
+	for args, want := range map[string]string{
+		"-v --color=auto -x file":      "v [--color=auto -x file] []",
+		"-jobs 4 -v src":               "v [-jobs 4] [src]",
+		"-jobs -v src":                 "v [-jobs] [src]",
+		"-xyz -- -q":                   "[-xyz] [-q]",
+		"-v -name=n --tag a -- -tag b": "v name=n [--tag a] [-tag b]",
+	} {
+		f := NewFlagSet("wrap", ContinueOnError)
+		f.AllowUnknownFlags(true)
+		v := f.Bool("v", false, "")
+		name := f.String("name", "", "")
+		if err := f.Parse(strings.Fields(args)); err != nil {
+			t.Fatalf("%s: %v", args, err)
+		}
+		var got []string
+		if *v {
+			got = append(got, "v")
+		}
+		if *name != "" {
+			got = append(got, "name="+*name)
+		}
+		got = append(got, "["+strings.Join(f.UnknownArgs(), " ")+"]", "["+strings.Join(f.Args(), " ")+"]")
+		if strings.Join(got, " ") != want {
+			t.Errorf("%s: got %q, want %q", args, strings.Join(got, " "), want)
+		}
+	}
+
+	f := NewFlagSet("wrap", ContinueOnError)
+	f.SetOutput(&bytes.Buffer{})
+	if err := f.Parse([]string{"-x"}); err == nil || len(f.UnknownArgs()) != 0 {
+		t.Fatalf("without AllowUnknownFlags: %v, %q", err, f.UnknownArgs())
+	}
+}
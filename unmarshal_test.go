@@ -0,0 +1,68 @@
+package flag_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/machship/flag"
+)
+
+func TestFlagSetUnmarshal(t *testing.T) {
+	f := NewFlagSet("test", ContinueOnError)
+	f.String("host", "localhost", "")
+	f.Int("port", 80, "")
+	f.Duration("timeout", time.Second, "")
+	f.StringSlice("tags", ",", []string{"a"}, "")
+	f.ByteSizeFlag("limit", 0, "")
+	if err := f.Unmarshal(&struct{}{}); err == nil {
+		t.Fatalf("expected error before Parse")
+	}
+	if err := f.Parse([]string{"-port", "8080", "-tags", "x,y", "-limit", "1KiB"}); err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	type Port int
+	type View struct {
+		Host    string        `flag:"host"`
+		Port    Port          `flag:"port"`
+		Timeout time.Duration `flag:"timeout"`
+		Tags    []string      `flag:"tags"`
+		Limit   ByteSize      `flag:"limit"`
+		Nested  struct {
+			Host string `flag:"host"`
+		}
+		Untagged int
+	}
+	var v View
+	if err := f.Unmarshal(&v); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if v.Host != "localhost" || v.Port != 8080 || v.Timeout != time.Second || v.Limit != 1024 || v.Nested.Host != "localhost" {
+		t.Fatalf("unexpected copy: %+v", v)
+	}
+	v.Tags[0] = "mutated"
+	if got := f.Lookup("tags").Value.String(); got != "x,y" {
+		t.Fatalf("copy must not alias flag storage, flag now %q", got)
+	}
+}
+
+func TestFlagSetUnmarshal_Errors(t *testing.T) {
+	f := NewFlagSet("test", ContinueOnError)
+	f.Int("port", 80, "")
+	if err := f.Parse(nil); err != nil {
+		t.Fatal(err)
+	}
+	type Bad struct {
+		Port    time.Time `flag:"port"`
+		Missing string    `flag:"missing"`
+	}
+	err := f.Unmarshal(&Bad{})
+	var me *MultiError
+	if !errors.As(err, &me) || len(me.Errors()) != 2 {
+		t.Fatalf("expected two aggregated errors, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "no flag -missing") || !strings.Contains(err.Error(), "cannot assign") {
+		t.Fatalf("unexpected error text: %v", err)
+	}
+}
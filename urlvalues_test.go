@@ -0,0 +1,49 @@
+package flag_test
+
+import (
+	neturl "net/url"
+	"os"
+	"testing"
+
+	. "github.com/machship/flag"
+)
+
+func TestURLValuesFlag(t *testing.T) {
+	f := NewFlagSet("test", ContinueOnError)
+	q := f.URLValues("query", neturl.Values{"a": {"0"}}, "")
+	if err := f.Parse([]string{"-query", "a=1&b=2&b=3&name=hello%20world&x=a%2Bb"}); err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if got := (*q)["b"]; len(got) != 2 || got[0] != "2" || got[1] != "3" {
+		t.Fatalf("expected repeated b values, got %v", got)
+	}
+	if q.Get("a") != "1" || q.Get("name") != "hello world" || q.Get("x") != "a+b" {
+		t.Fatalf("unexpected decoded values: %v", *q)
+	}
+	if s := f.Lookup("query").Value.String(); s != "a=1&b=2&b=3&name=hello+world&x=a%2Bb" {
+		t.Fatalf("unexpected String(): %s", s)
+	}
+	if err := f.Set("query", "?c=1"); err != nil || q.Get("c") != "1" || q.Has("a") {
+		t.Fatalf("expected leading ? to be accepted and value replaced, got %v err=%v", *q, err)
+	}
+	if err := f.Set("query", "bad=%zz"); err == nil {
+		t.Fatalf("expected decode error")
+	}
+}
+
+func TestParseStruct_URLValues(t *testing.T) {
+	ResetForTesting(nil)
+	type C struct {
+		Params neturl.Values `flag:"params" default:"page=1&tag=a&tag=b"`
+	}
+	var c C
+	old := os.Args
+	os.Args = []string{"cmd"}
+	defer func() { os.Args = old }()
+	if err := ParseStruct(&c); err != nil {
+		t.Fatalf("parse struct: %v", err)
+	}
+	if c.Params.Get("page") != "1" || len(c.Params["tag"]) != 2 {
+		t.Fatalf("unexpected default params: %v", c.Params)
+	}
+}
@@ -0,0 +1,135 @@
+package flag
+
+import (
+	"errors"
+	"path/filepath"
+	"runtime/debug"
+	"strconv"
+	"text/template"
+)
+
+// ErrVersion is returned by Parse after the -version flag registered by
+// SetVersion printed the version. With ExitOnError, Parse exits with status
+// 0 instead.
+var ErrVersion = errors.New("flag: version requested")
+
+// versionValue backs the -version flag registered by SetVersion.
+type versionValue struct{}
+
+func (*versionValue) Set(string) error { return nil }
+func (*versionValue) String() string   { return "false" }
+func (*versionValue) Get() interface{} { return false }
+func (*versionValue) IsBoolFlag() bool { return true }
+func (*versionValue) TypeName() string { return "" }
+
+// cliOnly reports whether v is a help or version flag, which only the
+// command line can set.
+func cliOnly(v Value) bool {
+	switch v.(type) {
+	case *helpValue, *versionValue:
+		return true
+	}
+	return false
+}
+
+// VersionInfo is what -version prints. Commit, Date and Modified come from
+// the version control stamp the go command embeds in the binary; they are
+// empty when it built without one, as under go run or -buildvcs=false.
+type VersionInfo struct {
+	Name      string // program name
+	Version   string // given to SetVersion, else the main module's version
+	Commit    string // vcs.revision
+	Date      string // vcs.time, the commit time in RFC 3339 form
+	Modified  bool   // vcs.modified: built from a tree with local changes
+	GoVersion string
+}
+
+// DefaultVersionTemplate is the template -version prints VersionInfo with
+// until SetVersionTemplate replaces it.
+const DefaultVersionTemplate = `{{.Name}} {{.Version}}
+{{- if .Commit}}
+commit: {{.Commit}}{{if .Modified}} (modified){{end}}{{end}}
+{{- if .Date}}
+built: {{.Date}}{{end}}
+go: {{.GoVersion}}
+`
+
+var defaultVersionTemplate = template.Must(template.New("version").Parse(DefaultVersionTemplate))
+
+// SetVersion sets the program's version and registers a -version flag that
+// prints it, with the commit and build date read by debug.ReadBuildInfo, and
+// makes Parse return ErrVersion. An empty v uses the main module's version.
+// The root of a Command tree also answers "tool version". A -version flag
+// defined beforehand is left alone.
+func (f *FlagSet) SetVersion(v string) {
+	f.version = v
+	f.versionSet = true
+	if f.formal[versionFlagName] == nil {
+		f.Var(&versionValue{}, versionFlagName, "print version information and exit")
+	}
+}
+
+// SetVersion sets the version of the default CommandLine FlagSet.
+func SetVersion(v string) { CommandLine.SetVersion(v) }
+
+const versionFlagName = "version"
+
+// SetVersionTemplate sets the text/template -version prints, executed with
+// a VersionInfo. It panics if tmpl does not parse.
+func (f *FlagSet) SetVersionTemplate(tmpl string) {
+	f.versionTmpl = template.Must(template.New("version").Parse(tmpl))
+}
+
+// SetVersionTemplate sets the version template of the default CommandLine FlagSet.
+func SetVersionTemplate(tmpl string) { CommandLine.SetVersionTemplate(tmpl) }
+
+// Version returns the information -version prints.
+func (f *FlagSet) Version() VersionInfo {
+	vi := VersionInfo{Name: filepath.Base(f.name), Version: f.version}
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return vi
+	}
+	vi.GoVersion = bi.GoVersion
+	if vi.Version == "" {
+		vi.Version = bi.Main.Version
+	}
+	for _, s := range bi.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			vi.Commit = s.Value
+		case "vcs.time":
+			vi.Date = s.Value
+		case "vcs.modified":
+			vi.Modified, _ = strconv.ParseBool(s.Value)
+		}
+	}
+	return vi
+}
+
+// PrintVersion prints the version to the FlagSet's output, as -version does.
+func (f *FlagSet) PrintVersion() error {
+	tmpl := f.versionTmpl
+	if tmpl == nil {
+		tmpl = defaultVersionTemplate
+	}
+	return tmpl.Execute(f.out(), f.Version())
+}
+
+// requestVersion handles -version given on the command line; -version=false
+// is ignored and returns nil.
+func (f *FlagSet) requestVersion(value string, hasValue bool) error {
+	if hasValue {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return f.failf("invalid boolean value %q for -%s: %v", value, versionFlagName, err)
+		}
+		if !b {
+			return nil
+		}
+	}
+	if err := f.PrintVersion(); err != nil {
+		return err
+	}
+	return ErrVersion
+}
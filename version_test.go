@@ -0,0 +1,81 @@
+package flag_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	. "github.com/machship/flag"
+)
+
+func TestVersionFlag(t *testing.T) {
+	var out strings.Builder
+	f := NewFlagSet("/usr/local/bin/labeller", ContinueOnError)
+	f.SetOutput(&out)
+	f.SetVersion("1.4.2")
+	if err := f.Parse([]string{"-version"}); !errors.Is(err, ErrVersion) {
+		t.Fatalf("Parse(-version) = %v", err)
+	}
+	if !strings.HasPrefix(out.String(), "labeller 1.4.2\n") || !strings.Contains(out.String(), "go: go") {
+		t.Fatalf("output:\n%s", out.String())
+	}
+	if vi := f.Version(); vi.Name != "labeller" || vi.Version != "1.4.2" || vi.GoVersion == "" {
+		t.Fatalf("Version() = %+v", vi)
+	}
+
+	out.Reset()
+	if err := f.Parse([]string{"-version=false", "arg"}); err != nil || out.Len() != 0 {
+		t.Fatalf("-version=false: %v, %q", err, out.String())
+	}
+	if err := f.Parse([]string{"-version=maybe"}); err == nil || !strings.Contains(err.Error(), `invalid boolean value "maybe"`) {
+		t.Fatalf("-version=maybe: %v", err)
+	}
+
+	out.Reset()
+	f.SetVersionTemplate("{{.Name}} v{{.Version}}{{if .Modified}}-dirty{{end}}\n")
+	f.Parse([]string{"-version"})
+	if got := out.String(); got != "labeller v1.4.2\n" && got != "labeller v1.4.2-dirty\n" {
+		t.Fatalf("templated output = %q", got)
+	}
+
+	out.Reset()
+	f.PrintDefaults()
+	if !strings.Contains(out.String(), "-version\n    \tprint version information and exit") {
+		t.Fatalf("defaults:\n%s", out.String())
+	}
+	t.Setenv(f.Introspect()[0].EnvKey, "true")
+	out.Reset()
+	if err := f.Parse(nil); err != nil || out.Len() != 0 {
+		t.Fatalf("environment triggered version: %v, %q", err, out.String())
+	}
+}
+
+func TestVersionCommand(t *testing.T) {
+	var out strings.Builder
+	root := NewCommand("tool", "", nil)
+	root.Flags.SetVersion("2.0.0")
+	root.Flags.SetVersionTemplate("{{.Name}} {{.Version}}\n")
+	root.AddCommand(NewCommand("serve", "serve the site", func([]string) error { return nil }))
+	root.Flags.SetOutput(&out)
+
+	if err := root.Execute([]string{"version"}); err != nil || out.String() != "tool 2.0.0\n" {
+		t.Fatalf("version: %v, %q", err, out.String())
+	}
+	out.Reset()
+	if err := root.Execute([]string{"-version"}); !errors.Is(err, ErrVersion) || out.String() != "tool 2.0.0\n" {
+		t.Fatalf("-version: %v, %q", err, out.String())
+	}
+	if h := root.Help(); len(h.Commands) != 2 || h.Commands[1].Name != "version" {
+		t.Fatalf("commands = %+v", h.Commands)
+	}
+
+	own := NewCommand("version", "show versions of deployed services", func([]string) error { return nil })
+	root.AddCommand(own)
+	out.Reset()
+	if err := root.Execute([]string{"version"}); err != nil || out.Len() != 0 {
+		t.Fatalf("own version command: %v, %q", err, out.String())
+	}
+	if h := root.Help(); len(h.Commands) != 2 || h.Commands[1].Usage != own.Usage {
+		t.Fatalf("commands = %+v", h.Commands)
+	}
+}
@@ -1,8 +1,16 @@
 package flag
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
+	"syscall"
 	"testing"
 	"time"
 )
@@ -23,7 +31,7 @@ func TestOnChangeSecretDir(t *testing.T) {
 	}
 	ch := make(chan string, 2)
 	fs.OnChange("db-password", func(v string) { ch <- v })
-	if err := fs.StartWatcher(dir, ""); err != nil {
+	if _, err := fs.StartWatcher(context.Background(), dir, ""); err != nil {
 		t.Fatalf("start watcher: %v", err)
 	}
 	// modify secret
@@ -58,7 +66,7 @@ func TestOnChangeConfigFile(t *testing.T) {
 	}
 	ch := make(chan string, 2)
 	fs.OnChange("port", func(v string) { ch <- v })
-	if err := fs.StartWatcher("", cfg); err != nil {
+	if _, err := fs.StartWatcher(context.Background(), "", cfg); err != nil {
 		t.Fatalf("start watcher: %v", err)
 	}
 	// change config
@@ -76,3 +84,409 @@ func TestOnChangeConfigFile(t *testing.T) {
 	}
 	fs.StopWatcher()
 }
+
+func TestWatcherStatus(t *testing.T) {
+	fs := NewFlagSet("test", ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.Int("port", 8080, "")
+	cfg := filepath.Join(t.TempDir(), "app.conf")
+	if err := os.WriteFile(cfg, []byte("port 8081\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	changed := make(chan struct{}, 1)
+	fs.OnChange("port", func(v string) {
+		if v == "9090" {
+			select {
+			case changed <- struct{}{}:
+			default:
+			}
+		}
+	})
+	if st := fs.WatcherStatus(); st.Running || len(st.Sources) != 0 {
+		t.Fatalf("status before start = %+v", st)
+	}
+	if _, err := fs.StartWatcher(context.Background(), "", cfg); err != nil {
+		t.Fatalf("start watcher: %v", err)
+	}
+	defer fs.StopWatcher()
+	st := fs.WatcherStatus()
+	if !st.Running || len(st.Sources) != 1 || st.Sources[0].Kind != "config-file" || st.Sources[0].Stale {
+		t.Fatalf("status after start = %+v", st)
+	}
+
+	waitFor := func(what string, ok func(WatcherStatus) bool) WatcherStatus {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for {
+			st := fs.WatcherStatus()
+			if ok(st) {
+				return st
+			}
+			if time.Now().After(deadline) {
+				t.Skipf("watch event timing out waiting for %s (flaky environment): %+v", what, st)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	if err := os.WriteFile(cfg, []byte("port eighty\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	st = waitFor("failed reload", func(st WatcherStatus) bool { return st.LastError != "" })
+	if !st.Sources[0].Stale || st.Sources[0].LastError == "" || !st.LastReload.IsZero() {
+		t.Fatalf("status after failed reload = %+v", st)
+	}
+
+	if err := os.WriteFile(cfg, []byte("port 9090\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	// The write may arrive as several events; wait for the one that set the port.
+	select {
+	case <-changed:
+	case <-time.After(2 * time.Second):
+		t.Skip("watch event timing out (flaky environment)")
+	}
+	st = fs.WatcherStatus()
+	if st.Sources[0].Stale || st.Sources[0].LastError != "" || st.Sources[0].LoadedAt != st.LastReload {
+		t.Fatalf("status after reload = %+v", st)
+	}
+
+	// A change the watcher missed shows as contents differing from those loaded.
+	fs.watchMu.Lock()
+	wt := fs.watchPaths[cfg]
+	wt.sum[0]++
+	fs.watchPaths[cfg] = wt
+	fs.watchMu.Unlock()
+	if st := fs.WatcherStatus(); !st.Sources[0].Stale {
+		t.Fatalf("source modified after load not stale: %+v", st)
+	}
+
+	fs.StopWatcher()
+	if st := fs.WatcherStatus(); st.Running || len(st.Sources) != 0 {
+		t.Fatalf("status after stop = %+v", st)
+	}
+}
+
+func TestWatcherStatusHandler(t *testing.T) {
+	fs := NewFlagSet("test", ContinueOnError)
+	fs.SetOutput(io.Discard)
+	cfg := filepath.Join(t.TempDir(), "app.conf")
+	if err := os.WriteFile(cfg, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	get := func() (int, WatcherStatus) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		fs.WatcherStatusHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/debug/flags/watcher", nil))
+		var st WatcherStatus
+		if err := json.Unmarshal(rec.Body.Bytes(), &st); err != nil {
+			t.Fatalf("decode %q: %v", rec.Body, err)
+		}
+		return rec.Code, st
+	}
+	if code, st := get(); code != http.StatusServiceUnavailable || st.Running {
+		t.Fatalf("before start: %d %+v", code, st)
+	}
+	if _, err := fs.StartWatcher(context.Background(), "", cfg); err != nil {
+		t.Fatalf("start watcher: %v", err)
+	}
+	defer fs.StopWatcher()
+	if code, st := get(); code != http.StatusOK || !st.Running || len(st.Sources) != 1 || st.Sources[0].Path != cfg {
+		t.Fatalf("after start: %d %+v", code, st)
+	}
+}
+
+func TestStartWatcherStopDrains(t *testing.T) {
+	fs := NewFlagSet("test", ContinueOnError)
+	fs.Int("port", 8080, "")
+	cfg := filepath.Join(t.TempDir(), "app.conf")
+	if err := os.WriteFile(cfg, []byte("port 8081\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	started, release := make(chan string, 1), make(chan struct{})
+	fs.OnChange("port", func(v string) {
+		started <- v
+		<-release
+	})
+	stop, err := fs.StartWatcher(context.Background(), "", cfg)
+	if err != nil {
+		t.Fatalf("start watcher: %v", err)
+	}
+	if err := os.WriteFile(cfg, []byte("port 9090\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-started:
+	case <-time.After(2 * time.Second):
+		stop(context.Background())
+		t.Skip("watch event timing out (flaky environment)")
+	}
+
+	// The callback is still running, so stop gives up when its context ends.
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := stop(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("stop with a callback running = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if fs.WatcherStatus().Running {
+		t.Fatal("watcher still running after stop")
+	}
+	stopped := make(chan error, 1)
+	go func() { stopped <- stop(context.Background()) }()
+	select {
+	case err := <-stopped:
+		t.Fatalf("stop returned %v before the callback finished", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(release)
+	if err := <-stopped; err != nil {
+		t.Fatalf("stop after drain: %v", err)
+	}
+	if err := stop(context.Background()); err != nil {
+		t.Fatalf("second stop: %v", err)
+	}
+	if err := fs.StopWatcher(); err != nil {
+		t.Fatalf("StopWatcher after stop: %v", err)
+	}
+}
+
+func TestStartWatcherContext(t *testing.T) {
+	fs := NewFlagSet("test", ContinueOnError)
+	ctx, cancel := context.WithCancel(context.Background())
+	stop, err := fs.StartWatcher(ctx, t.TempDir(), "")
+	if err != nil {
+		t.Fatalf("start watcher: %v", err)
+	}
+	cancel()
+	if err := stop(context.Background()); err != nil {
+		t.Fatalf("stop after cancel: %v", err)
+	}
+	if st := fs.WatcherStatus(); st.Running || len(st.Sources) != 0 {
+		t.Fatalf("status after cancel = %+v", st)
+	}
+
+	// A new watcher starts afresh; the old stop function leaves it alone.
+	stop2, err := fs.StartWatcher(context.Background(), t.TempDir(), "")
+	if err != nil {
+		t.Fatalf("restart watcher: %v", err)
+	}
+	defer stop2(context.Background())
+	stop(context.Background())
+	if !fs.WatcherStatus().Running {
+		t.Fatal("old stop function stopped the new watcher")
+	}
+}
+
+func TestStartWatcherSharedContext(t *testing.T) {
+	fs := NewFlagSet("test", ContinueOnError)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if _, err := fs.StartWatcher(ctx, t.TempDir(), ""); err != nil {
+		t.Fatalf("start watcher: %v", err)
+	}
+	ctx2, cancel2 := context.WithCancel(context.Background())
+	if _, err := fs.StartWatcher(ctx2, "", filepath.Join(t.TempDir(), "app.conf")); err == nil {
+		t.Fatal("expected error watching a missing file")
+	}
+	cfg := filepath.Join(t.TempDir(), "app.conf")
+	if err := os.WriteFile(cfg, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fs.StartWatcher(ctx2, "", cfg); err != nil {
+		t.Fatalf("add path: %v", err)
+	}
+	// A later caller's ctx does not own the watcher, nor does a failed call.
+	cancel2()
+	time.Sleep(20 * time.Millisecond)
+	if st := fs.WatcherStatus(); !st.Running || len(st.Sources) != 2 {
+		t.Fatalf("status after second ctx ended = %+v", st)
+	}
+	cancel()
+	deadline := time.Now().Add(2 * time.Second)
+	for fs.WatcherStatus().Running {
+		if time.Now().After(deadline) {
+			t.Fatal("watcher still running after its ctx ended")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestStartWatcherErrorShutsDown(t *testing.T) {
+	fs := NewFlagSet("test", ContinueOnError)
+	missing := filepath.Join(t.TempDir(), "missing.conf")
+	if _, err := fs.StartWatcher(context.Background(), t.TempDir(), missing); err == nil {
+		t.Fatal("expected error watching a missing file")
+	}
+	fs.watchMu.RLock()
+	run := fs.watchRun
+	fs.watchMu.RUnlock()
+	if st := fs.WatcherStatus(); run != nil || st.Running || len(st.Sources) != 0 {
+		t.Fatalf("status after failed start = %+v", st)
+	}
+	stop, err := fs.StartWatcher(context.Background(), t.TempDir(), "")
+	if err != nil {
+		t.Fatalf("start after failure: %v", err)
+	}
+	if err := stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWatcherReloadsOnContentChange(t *testing.T) {
+	fs := NewFlagSet("test", ContinueOnError)
+	port := fs.Int("port", 8080, "")
+	cfg := filepath.Join(t.TempDir(), "app.conf")
+	if err := os.WriteFile(cfg, []byte("port 8081\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	var changes []string
+	fs.OnChange("port", func(v string) { changes = append(changes, v) })
+	stop, err := fs.StartWatcher(context.Background(), "", cfg)
+	if err != nil {
+		t.Fatalf("start watcher: %v", err)
+	}
+	stop(context.Background()) // reloads are driven by hand below
+	fs.watchPaths = map[string]watchTarget{cfg: {path: cfg, kind: "config-file", sum: mustDigest(t, fs, cfg, "config-file")}}
+	fs.changeStopped = false
+	attempted := func() time.Time { return fs.watchPaths[cfg].attempted }
+
+	// A touch or a rewrite of the same bytes does not reload.
+	mtime := time.Now().Add(-time.Minute).Truncate(time.Second)
+	os.Chtimes(cfg, mtime, mtime.Add(time.Hour))
+	os.WriteFile(cfg, []byte("port 8081\n"), 0o600)
+	fs.reloadConfig(cfg)
+	if !attempted().IsZero() {
+		t.Fatalf("unchanged file reloaded at %v", attempted())
+	}
+
+	// A change that keeps the modification time still reloads.
+	os.WriteFile(cfg, []byte("port 9090\n"), 0o600)
+	os.Chtimes(cfg, mtime, mtime)
+	fs.reloadConfig(cfg)
+	if attempted().IsZero() || *port != 9090 || len(changes) != 1 || changes[0] != "9090" {
+		t.Fatalf("attempted=%v port=%d changes=%q", attempted(), *port, changes)
+	}
+
+	// Reordering the file reloads, but no value changed, so no callback runs.
+	os.WriteFile(cfg, []byte("# reordered\nport 9090\n"), 0o600)
+	fs.reloadConfig(cfg)
+	if len(changes) != 1 {
+		t.Fatalf("changes = %q", changes)
+	}
+}
+
+func TestSecretDirDigest(t *testing.T) {
+	fs := NewFlagSet("test", ContinueOnError)
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "db-password"), []byte("one"), 0o600)
+	before := mustDigest(t, fs, dir, "secret-dir")
+	mtime := time.Now().Add(time.Hour)
+	os.Chtimes(filepath.Join(dir, "db-password"), mtime, mtime)
+	if mustDigest(t, fs, dir, "secret-dir") != before {
+		t.Fatal("touch changed the digest")
+	}
+	os.WriteFile(filepath.Join(dir, "db-password"), []byte("two"), 0o600)
+	if mustDigest(t, fs, dir, "secret-dir") == before {
+		t.Fatal("new contents kept the digest")
+	}
+	os.WriteFile(filepath.Join(dir, "db-password"), []byte("one"), 0o600)
+	os.WriteFile(filepath.Join(dir, "api-key"), nil, 0o600)
+	if mustDigest(t, fs, dir, "secret-dir") == before {
+		t.Fatal("new file kept the digest")
+	}
+}
+
+func TestSecretDirDigestSpecialFiles(t *testing.T) {
+	fs := NewFlagSet("test", ContinueOnError)
+	fs.SetSecretDirOptions(SecretDirOptions{MaxFileSize: 4})
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "db-password"), []byte("one"), 0o600)
+	os.WriteFile(filepath.Join(dir, "blob"), []byte("too large"), 0o600)
+	if err := syscall.Mkfifo(filepath.Join(dir, "token"), 0o600); err != nil {
+		t.Skipf("cannot create a FIFO: %v", err)
+	}
+	done := make(chan [32]byte, 1)
+	go func() { done <- mustDigest(t, fs, dir, "secret-dir") }()
+	var before [32]byte
+	select {
+	case before = <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("digest blocked on a FIFO")
+	}
+	os.WriteFile(filepath.Join(dir, "db-password"), []byte("two"), 0o600)
+	if mustDigest(t, fs, dir, "secret-dir") == before {
+		t.Fatal("new contents kept the digest")
+	}
+}
+
+func mustDigest(t *testing.T, fs *FlagSet, path, kind string) [32]byte {
+	t.Helper()
+	sum, err := fs.sourceDigest(watchTarget{path: path, kind: kind})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sum
+}
+
+func TestWatcherRejectsInvalidUpdate(t *testing.T) {
+	fs := NewFlagSet("test", ContinueOnError)
+	fs.SetOutput(io.Discard)
+	port := fs.Int("port", 8080, "")
+	workers := fs.Int("workers", 1, "")
+	fs.Deferred(func() error {
+		if *workers > 16 {
+			return fmt.Errorf("workers must be at most 16, got %d", *workers)
+		}
+		return nil
+	})
+	cfg := filepath.Join(t.TempDir(), "app.conf")
+	if err := os.WriteFile(cfg, []byte("port 8081\nworkers 4\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	var changes []string
+	fs.OnChange("port", func(v string) { changes = append(changes, v) })
+	var rejected []RejectedUpdate
+	fs.OnRejectedUpdate(func(r RejectedUpdate) { rejected = append(rejected, r) })
+	stop, err := fs.StartWatcher(context.Background(), "", cfg)
+	if err != nil {
+		t.Fatalf("start watcher: %v", err)
+	}
+	stop(context.Background()) // reloads are driven by hand below
+	fs.watchPaths = map[string]watchTarget{cfg: {path: cfg, kind: "config-file"}}
+	fs.changeStopped = false
+	fs.reloadConfig(cfg)
+	if *port != 8081 || *workers != 4 || len(rejected) != 0 {
+		t.Fatalf("initial load port=%d workers=%d rejected=%+v", *port, *workers, rejected)
+	}
+	changes = nil
+
+	// A constraint broken by one value rejects the whole file.
+	os.WriteFile(cfg, []byte("port 9090\nworkers 64\n"), 0o600)
+	fs.reloadConfig(cfg)
+	if *port != 8081 || *workers != 4 || len(changes) != 0 {
+		t.Fatalf("after rejected update port=%d workers=%d changes=%q", *port, *workers, changes)
+	}
+	if len(rejected) != 1 {
+		t.Fatalf("rejected = %+v", rejected)
+	}
+	r := rejected[0]
+	if r.Source != cfg || !strings.Contains(r.Error, "workers must be at most 16") || len(r.Changes) != 2 ||
+		r.Changes[0].Name != "port" || r.Changes[0].New != "9090" || r.Changes[1].Name != "workers" {
+		t.Fatalf("rejected update = %+v", r)
+	}
+	if st := fs.WatcherStatus(); !strings.Contains(st.LastError, "workers must be at most 16") {
+		t.Fatalf("status last error = %q", st.LastError)
+	}
+
+	// So does a value that is not of the flag's type.
+	os.WriteFile(cfg, []byte("port 9090\nworkers many\n"), 0o600)
+	fs.reloadConfig(cfg)
+	if *port != 8081 || len(rejected) != 2 {
+		t.Fatalf("port=%d rejected=%d", *port, len(rejected))
+	}
+
+	os.WriteFile(cfg, []byte("port 9090\nworkers 8\n"), 0o600)
+	fs.reloadConfig(cfg)
+	if *port != 9090 || *workers != 8 || len(changes) != 1 || len(rejected) != 2 {
+		t.Fatalf("port=%d workers=%d changes=%q rejected=%d", *port, *workers, changes, len(rejected))
+	}
+}
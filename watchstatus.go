@@ -0,0 +1,207 @@
+package flag
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"time"
+)
+
+// WatcherStatus reports the health of the hot reload watcher started by
+// StartWatcher, so a watcher that died or keeps failing to reload can be
+// told apart from a config that simply has not changed.
+type WatcherStatus struct {
+	Running bool `json:"running"` // started and still receiving events
+
+	LastReload  time.Time `json:"lastReload,omitempty"` // last successful reload of any source
+	LastError   string    `json:"lastError,omitempty"`  // most recent reload or watcher error
+	LastErrorAt time.Time `json:"lastErrorAt,omitempty"`
+
+	Sources []WatchSourceStatus `json:"sources,omitempty"` // sorted by path
+}
+
+// WatchSourceStatus describes one watched secret directory or config file.
+type WatchSourceStatus struct {
+	Path string `json:"path"`
+	Kind string `json:"kind"` // "secret-dir" or "config-file"
+
+	// LoadedAt is when the flags last matched the source: the last
+	// successful reload, or when watching started.
+	LoadedAt   time.Time `json:"loadedAt"`
+	LastReload time.Time `json:"lastReload,omitempty"` // last reload attempt, failed or not
+	LastError  string    `json:"lastError,omitempty"`  // error of that attempt
+
+	// ModTime is the source's modification time, the newest of the
+	// directory and its files for a secret directory.
+	ModTime time.Time `json:"modTime,omitempty"`
+
+	// Stale reports that the flags may not hold what the source says: the
+	// last reload failed, its contents differ from those loaded at LoadedAt,
+	// or it can no longer be read. Contents are compared by digest, so a
+	// touch does not make a source stale and a write in the same second as
+	// the last reload does.
+	Stale bool `json:"stale"`
+}
+
+// WatcherStatus returns the state of the hot reload watcher. It reads each
+// watched source, so it is cheap enough for a health check but not meant for
+// a hot path. Use CommandLine.WatcherStatus() for the default FlagSet.
+func (f *FlagSet) WatcherStatus() WatcherStatus {
+	f.watchMu.RLock()
+	st := WatcherStatus{Running: f.watchRun != nil}
+	if st.Running {
+		select {
+		case <-f.watchRun.done:
+			st.Running = false
+		default:
+		}
+	}
+	if f.watchErr != nil {
+		st.LastError, st.LastErrorAt = f.watchErr.Error(), f.watchErrAt
+	}
+	targets := make([]watchTarget, 0, len(f.watchPaths))
+	for _, wt := range f.watchPaths {
+		targets = append(targets, wt)
+	}
+	f.watchMu.RUnlock()
+
+	sort.Slice(targets, func(i, j int) bool { return targets[i].path < targets[j].path })
+	for _, wt := range targets {
+		ss := WatchSourceStatus{Path: wt.path, Kind: wt.kind, LoadedAt: wt.loaded, LastReload: wt.attempted}
+		if wt.err != nil {
+			ss.LastError, ss.Stale = wt.err.Error(), true
+			if wt.attempted.After(st.LastErrorAt) {
+				st.LastError, st.LastErrorAt = ss.LastError, wt.attempted
+			}
+		} else if wt.attempted.After(st.LastReload) {
+			st.LastReload = wt.attempted
+		}
+		mod, err := sourceModTime(wt)
+		if err == nil {
+			var sum [sha256.Size]byte
+			sum, err = f.sourceDigest(wt)
+			ss.ModTime = mod
+			ss.Stale = ss.Stale || sum != wt.sum
+		}
+		if err != nil {
+			ss.LastError, ss.Stale = err.Error(), true
+		}
+		st.Sources = append(st.Sources, ss)
+	}
+	return st
+}
+
+// WatcherStatusHandler returns an http.Handler serving WatcherStatus as
+// JSON, for mounting on a debug or health endpoint. It responds with 503
+// Service Unavailable when the watcher is not running, so a probe notices a
+// watcher that died.
+func (f *FlagSet) WatcherStatusHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		st := f.WatcherStatus()
+		w.Header().Set("Content-Type", "application/json")
+		if !st.Running {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_ = json.NewEncoder(w).Encode(st)
+	})
+}
+
+// recordReload notes the outcome of a reload of path that began at start,
+// when the source had digest sum. The caller holds watchMu.
+func (f *FlagSet) recordReload(path string, start time.Time, sum [sha256.Size]byte, err error) {
+	wt, ok := f.watchPaths[path]
+	if !ok {
+		return
+	}
+	wt.attempted, wt.err = start, err
+	if err == nil {
+		wt.loaded, wt.sum = start, sum
+	}
+	f.watchPaths[path] = wt
+}
+
+// sourceUnchanged returns the digest of the watched source at path and
+// whether it matches what the flags were last loaded from, in which case an
+// event, such as a touch or a write of the same bytes, needs no reload. The
+// caller holds watchMu.
+func (f *FlagSet) sourceUnchanged(path string) (sum [sha256.Size]byte, same bool) {
+	wt, ok := f.watchPaths[path]
+	if !ok {
+		return sum, false
+	}
+	sum, err := f.sourceDigest(wt)
+	if err != nil {
+		return sum, false
+	}
+	return sum, wt.err == nil && sum == wt.sum
+}
+
+// sourceDigest hashes the contents of a watched source: the config file and,
+// when signing is required, its detached signatures, or the name and
+// contents of every file ParseSecretDir would read from the secret
+// directory.
+func (f *FlagSet) sourceDigest(wt watchTarget) ([sha256.Size]byte, error) {
+	var sum [sha256.Size]byte
+	h := sha256.New()
+	if wt.kind != "secret-dir" {
+		b, err := os.ReadFile(wt.path)
+		if err != nil {
+			return sum, err
+		}
+		h.Write(b)
+		if f.configKey != nil {
+			for _, sig := range signatureFiles(wt.path) {
+				b, _ := os.ReadFile(sig)
+				fmt.Fprintf(h, "\x00%d\x00", len(b))
+				h.Write(b)
+			}
+		}
+	} else {
+		files, err := f.secretDirOpts.secretFiles(wt.path)
+		if err != nil {
+			return sum, err
+		}
+		sort.Slice(files, func(i, j int) bool { return files[i].rel < files[j].rel })
+		for _, sf := range files {
+			// the same checks as ParseSecretDir, so a FIFO or an oversized
+			// file is neither blocked on nor read in full under watchMu
+			b, ok, err := f.secretDirOpts.readSecretBytes(wt.path, sf)
+			if err != nil {
+				fmt.Fprintf(h, "%s\x00error\x00%s\x00", sf.rel, err)
+				continue
+			}
+			if !ok {
+				continue // a link to a directory
+			}
+			fmt.Fprintf(h, "%s\x00%d\x00", sf.rel, len(b))
+			h.Write(b)
+		}
+	}
+	h.Sum(sum[:0])
+	return sum, nil
+}
+
+// sourceModTime returns when a watched source last changed.
+func sourceModTime(wt watchTarget) (time.Time, error) {
+	fi, err := os.Stat(wt.path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	mod := fi.ModTime()
+	if wt.kind != "secret-dir" || !fi.IsDir() {
+		return mod, nil
+	}
+	entries, err := os.ReadDir(wt.path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	for _, e := range entries {
+		if info, err := e.Info(); err == nil && info.ModTime().After(mod) {
+			mod = info.ModTime()
+		}
+	}
+	return mod, nil
+}
@@ -9,6 +9,9 @@ func internalReset() {
 
 // WithArgs temporarily sets os.Args, reparses flags, runs fn, then restores state.
 // Flags must be re-registered prior to calling if required.
+//
+// Deprecated: WithArgs replaces CommandLine, dropping every registered flag,
+// and mutates os.Args, so it is unsafe for concurrent use. Use RunScoped.
 func WithArgs(args []string, fn func() error) error {
 	origArgs := os.Args
 	if len(args) == 0 {
@@ -25,3 +28,22 @@ func WithArgs(args []string, fn func() error) error {
 	os.Args = origArgs
 	return err
 }
+
+// RunScoped runs fn against a private FlagSet: register defines its flags,
+// the set parses args (the arguments only, without a program name) through
+// the usual CLI, environment, secret directory and config file layers, and fn
+// then reads the result. Neither CommandLine nor os.Args is touched, so
+// concurrent tests and embedded callers do not interfere. The set uses
+// ContinueOnError; the first error from register, Parse or fn is returned.
+func RunScoped(args []string, register func(*FlagSet) error, fn func(*FlagSet) error) error {
+	fs := NewFlagSet(os.Args[0], ContinueOnError)
+	if register != nil {
+		if err := register(fs); err != nil {
+			return err
+		}
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	return fn(fs)
+}
@@ -0,0 +1,172 @@
+package flag
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// WriteConfig writes the current value of every flag to w as a configuration
+// file that ParseFile reads back, so a -dump-config mode can produce a
+// starting point for app.conf. format is "conf" (the line-based format, also
+// chosen by ""), "yaml" or "toml"; a leading dot and "yml" are accepted.
+// Flags are sorted by name. Flags with empty values, help flags and the
+// config and secret directory flags are left out, as are sensitive flags,
+// which are listed in a comment instead. Values beginning with '@' or "raw:"
+// are escaped as MarshalStruct does.
+func (f *FlagSet) WriteConfig(w io.Writer, format string) error {
+	var write func(b *bufio.Writer, name, val string, isBool bool) error
+	written := make(map[string]bool)
+	switch strings.TrimPrefix(strings.ToLower(format), ".") {
+	case "", "conf":
+		write = writeFlatEntry
+	case "yaml", "yml":
+		write = writeYAMLEntry
+	case "toml":
+		write = func(b *bufio.Writer, name, val string, isBool bool) error {
+			writeTOMLEntry(b, tomlKey(name, written), val, isBool)
+			return nil
+		}
+	default:
+		return fmt.Errorf("unknown config format %q (want conf, yaml or toml)", format)
+	}
+
+	b := bufio.NewWriter(w)
+	var omitted []string
+	for _, fl := range sortFlags(f.formal) {
+		if cliOnly(fl.Value) || fl.Name == f.configFlagName() || fl.Name == f.secretDirFlagName() {
+			continue
+		}
+		if fl.Sensitive || f.isSensitive(fl.Name) {
+			omitted = append(omitted, fl.Name)
+			continue
+		}
+		val := fl.Value.String()
+		if val == "" {
+			continue
+		}
+		bf, isBool := fl.Value.(boolFlag)
+		isBool = isBool && bf.IsBoolFlag() && (val == "true" || val == "false")
+		if err := write(b, fl.Name, escapeSourceValue(val), isBool); err != nil {
+			return err
+		}
+		written[fl.Name] = true
+	}
+	if len(omitted) > 0 {
+		fmt.Fprintf(b, "# sensitive flags not written: %s\n", strings.Join(omitted, ", "))
+	}
+	return b.Flush()
+}
+
+// WriteConfig writes the effective configuration of the default CommandLine
+// FlagSet.
+func WriteConfig(w io.Writer, format string) error { return CommandLine.WriteConfig(w, format) }
+
+func writeFlatEntry(b *bufio.Writer, name, val string, _ bool) error {
+	if strings.ContainsAny(val, "\r\n") {
+		return fmt.Errorf("flag -%s: value spans multiple lines and cannot be written in this format", name)
+	}
+	fmt.Fprintf(b, "%s=%s\n", name, val)
+	return nil
+}
+
+func writeYAMLEntry(b *bufio.Writer, name, val string, isBool bool) error {
+	key := name
+	if !yamlPlainSafe(name) {
+		key = strconv.QuoteToGraphic(name)
+	}
+	if !isBool && !yamlPlainSafe(val) {
+		// Go's escapes are a subset of those in YAML double-quoted scalars
+		val = strconv.QuoteToGraphic(val)
+	}
+	fmt.Fprintf(b, "%s: %s\n", key, val)
+	return nil
+}
+
+// yamlPlainSafe reports whether s can be written as a plain YAML scalar that
+// every reader takes as the same string.
+func yamlPlainSafe(s string) bool {
+	if s == "" || !isAlnum(s[0]) && s[0] != '/' && s[0] != '.' {
+		return false
+	}
+	switch strings.ToLower(s) {
+	case "true", "false", "yes", "no", "on", "off", "y", "n", "null":
+		return false
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		return false // would read back as a number
+	}
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; !isAlnum(c) && !strings.ContainsRune("_-./", rune(c)) {
+			return false
+		}
+	}
+	return true
+}
+
+func isAlnum(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// tomlKey returns name as a TOML key: dotted bare segments where possible,
+// or a single quoted key when a prefix of name was written as a value, which
+// TOML would otherwise reject as redefining that key as a table.
+func tomlKey(name string, written map[string]bool) string {
+	segments := strings.Split(name, ".")
+	bare := true
+	for i, seg := range segments {
+		if seg == "" || written[strings.Join(segments[:i], ".")] {
+			bare = false
+			break
+		}
+		for j := 0; j < len(seg); j++ {
+			if !isBareKeyChar(seg[j]) {
+				bare = false
+			}
+		}
+	}
+	if bare {
+		return name
+	}
+	return tomlQuote(name)
+}
+
+func writeTOMLEntry(b *bufio.Writer, key, val string, isBool bool) {
+	if !isBool {
+		val = tomlQuote(val)
+	}
+	fmt.Fprintf(b, "%s = %s\n", key, val)
+}
+
+// tomlQuote writes s as a TOML basic string.
+func tomlQuote(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"', '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\r':
+			b.WriteString(`\r`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&b, `\u%04X`, r)
+				continue
+			}
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
@@ -0,0 +1,93 @@
+package flag_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/machship/flag"
+)
+
+func writeConfigFlags() (*FlagSet, map[string]*string) {
+	f := NewFlagSet("test", ContinueOnError)
+	vals := map[string]*string{
+		"db":       f.String("db", "", ""),
+		"db.host":  f.String("db.host", "", ""),
+		"greeting": f.String("greeting", "", ""),
+		"motd":     f.String("motd", "", ""),
+		"key":      f.String("key", "", ""),
+		"port":     f.String("port", "8080", ""),
+		"password": f.String("password", "", ""),
+	}
+	f.Bool("verbose", false, "")
+	f.Duration("timeout", 5*time.Second, "")
+	f.MarkSensitive("password")
+	return f, vals
+}
+
+func TestWriteConfigRoundTrip(t *testing.T) {
+	for _, format := range []string{"conf", "yaml", "toml"} {
+		t.Run(format, func(t *testing.T) {
+			f, _ := writeConfigFlags()
+			args := []string{
+				"-db", "primary", "-db.host", "db.internal", "-greeting", `say "hi" # now`,
+				"-key", "@literal", "-password", "hunter2", "-verbose", "-timeout", "1m30s",
+			}
+			if format != "conf" {
+				args = append(args, "-motd", "line one\n\tline two")
+			}
+			if err := f.Parse(args); err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			var b strings.Builder
+			if err := f.WriteConfig(&b, format); err != nil {
+				t.Fatalf("WriteConfig: %v", err)
+			}
+			out := b.String()
+			if strings.Contains(out, "hunter2") || !strings.Contains(out, "# sensitive flags not written: password") {
+				t.Fatalf("sensitive flag handling wrong:\n%s", out)
+			}
+
+			g, vals := writeConfigFlags()
+			if err := g.ParseFile(writeTempFile(t, "app."+format, out)); err != nil {
+				t.Fatalf("reading back:\n%s\n%v", out, err)
+			}
+			for _, name := range []string{"db", "db.host", "greeting", "motd", "key", "port", "verbose", "timeout"} {
+				if got, want := g.Lookup(name).Value.String(), f.Lookup(name).Value.String(); got != want {
+					t.Errorf("-%s = %q after round trip, want %q\n%s", name, got, want, out)
+				}
+			}
+			if *vals["password"] != "" {
+				t.Errorf("password read back as %q", *vals["password"])
+			}
+		})
+	}
+}
+
+func TestWriteConfigFormats(t *testing.T) {
+	f, _ := writeConfigFlags()
+	if err := f.Parse([]string{"-db", "primary", "-db.host", "db.internal", "-verbose"}); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"":     "db=primary\ndb.host=db.internal\nport=8080\ntimeout=5s\nverbose=true\n",
+		".yml": "db: primary\ndb.host: db.internal\nport: \"8080\"\ntimeout: 5s\nverbose: true\n",
+		"TOML": "db = \"primary\"\n\"db.host\" = \"db.internal\"\nport = \"8080\"\ntimeout = \"5s\"\nverbose = true\n",
+	}
+	for format, w := range want {
+		var b strings.Builder
+		if err := f.WriteConfig(&b, format); err != nil {
+			t.Fatalf("WriteConfig(%q): %v", format, err)
+		}
+		if got := strings.TrimSuffix(b.String(), "# sensitive flags not written: password\n"); got != w {
+			t.Errorf("WriteConfig(%q) =\n%s\nwant\n%s", format, got, w)
+		}
+	}
+	if err := f.WriteConfig(&strings.Builder{}, "hcl"); err == nil || !strings.Contains(err.Error(), "unknown config format") {
+		t.Fatalf("unknown format err = %v", err)
+	}
+	f.Set("motd", "a\nb")
+	if err := f.WriteConfig(&strings.Builder{}, "conf"); err == nil || !strings.Contains(err.Error(), "spans multiple lines") {
+		t.Fatalf("multi-line conf err = %v", err)
+	}
+}
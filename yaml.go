@@ -0,0 +1,409 @@
+package flag
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// parseYAML reads a YAML document and applies it like the other structured
+// formats: nested mappings name dotted flags, so "db:" followed by an
+// indented "host: x" sets -db.host, sequences of scalars fill slice flags and
+// a mapping named after a string map flag fills that map. Flow collections
+// ([a, b] and {k: v}) must fit on one line; "|" and ">" block scalars are
+// supported. Anchors, aliases, tags, multiple documents and sequences of
+// mappings are not. Null values leave a flag alone.
+func (f *FlagSet) parseYAML(r io.Reader) error {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	p := &yamlParser{lines: strings.Split(string(b), "\n"), seen: make(map[string]bool)}
+	if err := p.parse(); err != nil {
+		return f.failf("%v", err)
+	}
+	return f.applyConfigEntries(p.entries)
+}
+
+type yamlParser struct {
+	lines   []string
+	n       int // index of the current line
+	entries []configEntry
+	seen    map[string]bool
+}
+
+func (p *yamlParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%s on line %d", fmt.Sprintf(format, args...), p.n+1)
+}
+
+// next advances to the next line with content and returns its indentation
+// and text without comments, or ok false at the end of the document.
+func (p *yamlParser) next() (indent int, text string, ok bool, err error) {
+	for ; p.n < len(p.lines); p.n++ {
+		line := p.lines[p.n]
+		text := strings.TrimRight(stripYAMLComment(line), " \t")
+		trimmed := strings.TrimLeft(text, " ")
+		if trimmed == "" || trimmed == "---" && p.n == 0 {
+			continue
+		}
+		if trimmed[0] == '\t' {
+			return 0, "", false, p.errorf("tabs are not allowed for indentation")
+		}
+		if trimmed == "---" || trimmed == "..." {
+			return 0, "", false, p.errorf("multiple documents are not supported")
+		}
+		return len(text) - len(trimmed), trimmed, true, nil
+	}
+	return 0, "", false, nil
+}
+
+func (p *yamlParser) parse() error {
+	indent, _, ok, err := p.next()
+	if err != nil || !ok {
+		return err
+	}
+	if err := p.mapping(indent, ""); err != nil {
+		return err
+	}
+	if _, _, ok, err := p.next(); err != nil || ok {
+		if err == nil {
+			err = p.errorf("unexpected indentation")
+		}
+		return err
+	}
+	return nil
+}
+
+func (p *yamlParser) add(e configEntry) error {
+	if p.seen[e.name] {
+		return p.errorf("duplicate key %s", e.name)
+	}
+	p.seen[e.name] = true
+	p.entries = append(p.entries, e)
+	return nil
+}
+
+// mapping reads the block mapping whose keys sit at indent.
+func (p *yamlParser) mapping(indent int, prefix string) error {
+	for {
+		ind, text, ok, err := p.next()
+		if err != nil || !ok || ind < indent {
+			return err
+		}
+		if ind > indent {
+			return p.errorf("unexpected indentation")
+		}
+		if text == "-" || strings.HasPrefix(text, "- ") {
+			return p.errorf("sequence where a mapping key was expected")
+		}
+		key, rest, err := p.splitKey(text)
+		if err != nil {
+			return err
+		}
+		name := prefix + key
+
+		switch {
+		case rest == "":
+			p.n++
+			nind, ntext, ok, err := p.next()
+			if err != nil {
+				return err
+			}
+			isSeq := ok && (ntext == "-" || strings.HasPrefix(ntext, "- "))
+			switch {
+			case isSeq && nind >= indent:
+				err = p.sequence(nind, name)
+			case ok && nind > indent:
+				err = p.mapping(nind, name+".")
+			}
+			// otherwise the value is null
+			if err != nil {
+				return err
+			}
+			continue
+		case rest[0] == '|' || rest[0] == '>':
+			p.n++
+			v, err := p.blockScalar(indent, rest)
+			if err == nil {
+				err = p.add(configEntry{name: name, values: []string{v}})
+			}
+			if err != nil {
+				return err
+			}
+			continue
+		case rest[0] == '[':
+			err = p.flowSequence(name, rest)
+		case rest[0] == '{':
+			err = p.flowMapping(name, rest)
+		default:
+			var v string
+			var null bool
+			v, null, err = p.scalar(rest)
+			if err == nil && !null {
+				err = p.add(configEntry{name: name, values: []string{v}})
+			}
+		}
+		if err != nil {
+			return err
+		}
+		p.n++
+	}
+}
+
+// flowSequence reads a one-line "[a, b]" value of name.
+func (p *yamlParser) flowSequence(name, s string) error {
+	items, err := p.flowItems(s, ']')
+	if err != nil {
+		return err
+	}
+	values := make([]string, 0, len(items))
+	for _, item := range items {
+		v, _, err := p.scalar(item)
+		if err != nil {
+			return err
+		}
+		values = append(values, v)
+	}
+	return p.add(configEntry{name: name, values: values, list: true})
+}
+
+// flowMapping reads a one-line "{k: v}" value of name.
+func (p *yamlParser) flowMapping(name, s string) error {
+	items, err := p.flowItems(s, '}')
+	if err != nil {
+		return err
+	}
+	for _, item := range items {
+		k, v, err := p.splitKey(item)
+		if err != nil {
+			return err
+		}
+		v, null, err := p.scalar(v)
+		if err != nil {
+			return err
+		}
+		if !null {
+			if err := p.add(configEntry{name: name + "." + k, values: []string{v}}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// sequence reads the block sequence of scalars whose dashes sit at indent.
+func (p *yamlParser) sequence(indent int, name string) error {
+	var values []string
+	for {
+		ind, text, ok, err := p.next()
+		if err != nil {
+			return err
+		}
+		if !ok || ind != indent || !(text == "-" || strings.HasPrefix(text, "- ")) {
+			if ok && ind > indent {
+				return p.errorf("unexpected indentation")
+			}
+			return p.add(configEntry{name: name, values: values, list: true})
+		}
+		item := strings.TrimSpace(strings.TrimPrefix(text, "-"))
+		if item == "" || item[0] == '-' || item[0] == '[' || item[0] == '{' || item[0] == '|' || item[0] == '>' {
+			return p.errorf("only scalars are supported in the sequence %s", name)
+		}
+		if _, _, err := p.splitKey(item); err == nil {
+			return p.errorf("sequences of mappings are not supported (%s)", name)
+		}
+		v, _, err := p.scalar(item)
+		if err != nil {
+			return err
+		}
+		values = append(values, v)
+		p.n++
+	}
+}
+
+// splitKey splits "key: value" at the first colon followed by a space or the
+// end of the text, unquoting the key.
+func (p *yamlParser) splitKey(text string) (key, rest string, err error) {
+	i := -1
+	if text[0] == '"' || text[0] == '\'' {
+		if end := yamlQuoteEnd(text); end > 0 && strings.HasPrefix(text[end+1:], ":") {
+			i = end + 1
+		}
+	} else {
+		for j := 0; j < len(text); j++ {
+			if text[j] == ':' && (j+1 == len(text) || text[j+1] == ' ') {
+				i = j
+				break
+			}
+		}
+	}
+	if i < 0 || (i+1 < len(text) && text[i+1] != ' ') {
+		return "", "", p.errorf("expected \"key: value\"")
+	}
+	key = strings.TrimSpace(text[:i])
+	if key != "" && (key[0] == '"' || key[0] == '\'') {
+		if key, _, err = p.scalar(key); err != nil {
+			return "", "", err
+		}
+	}
+	if key == "" {
+		return "", "", p.errorf("missing key")
+	}
+	return key, strings.TrimSpace(text[i+1:]), nil
+}
+
+// scalar converts a plain, single-quoted or double-quoted scalar to text.
+// null reports "~", "null" and the empty scalar.
+func (p *yamlParser) scalar(s string) (v string, null bool, err error) {
+	s = strings.TrimSpace(s)
+	switch {
+	case s == "" || s == "~" || s == "null" || s == "Null" || s == "NULL":
+		return "", true, nil
+	case s[0] == '"':
+		if yamlQuoteEnd(s) != len(s)-1 {
+			return "", false, p.errorf("invalid quoted string %s", s)
+		}
+		v, err := strconv.Unquote(s)
+		if err != nil {
+			return "", false, p.errorf("invalid quoted string %s", s)
+		}
+		return v, false, nil
+	case s[0] == '\'':
+		if yamlQuoteEnd(s) != len(s)-1 {
+			return "", false, p.errorf("invalid quoted string %s", s)
+		}
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'"), false, nil
+	case s[0] == '&' || s[0] == '*' || s[0] == '!':
+		return "", false, p.errorf("anchors, aliases and tags are not supported")
+	}
+	return s, false, nil
+}
+
+// flowItems splits a one-line flow collection into its items.
+func (p *yamlParser) flowItems(s string, close byte) ([]string, error) {
+	if s[len(s)-1] != close {
+		return nil, p.errorf("flow collections must fit on one line")
+	}
+	body := strings.TrimSpace(s[1 : len(s)-1])
+	if body == "" {
+		return nil, nil
+	}
+	var items []string
+	start := 0
+	for i := 0; i < len(body); i++ {
+		switch body[i] {
+		case '"', '\'':
+			end := yamlQuoteEnd(body[i:])
+			if end < 0 {
+				return nil, p.errorf("unterminated quoted string")
+			}
+			i += end
+		case '[', '{', ']', '}':
+			return nil, p.errorf("nested flow collections are not supported")
+		case ',':
+			items = append(items, strings.TrimSpace(body[start:i]))
+			start = i + 1
+		}
+	}
+	if last := strings.TrimSpace(body[start:]); last != "" {
+		items = append(items, last)
+	}
+	return items, nil
+}
+
+// blockScalar reads the "|" or ">" scalar introduced by header, whose content
+// is indented more than parent.
+func (p *yamlParser) blockScalar(parent int, header string) (string, error) {
+	folded := header[0] == '>'
+	chomp := header[1:]
+	if chomp != "" && chomp != "-" && chomp != "+" {
+		return "", p.errorf("unsupported block scalar header %s", header)
+	}
+	var lines []string
+	indent := -1
+	for ; p.n < len(p.lines); p.n++ {
+		line := p.lines[p.n]
+		trimmed := strings.TrimLeft(line, " ")
+		if trimmed == "" {
+			lines = append(lines, "")
+			continue
+		}
+		ind := len(line) - len(trimmed)
+		if ind <= parent {
+			break
+		}
+		if indent < 0 {
+			indent = ind
+		}
+		if ind < indent {
+			return "", p.errorf("inconsistent block scalar indentation")
+		}
+		lines = append(lines, line[indent:])
+	}
+	// trailing blank lines belong to the chomping, not the content
+	trailing := 0
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+		trailing++
+	}
+
+	var b strings.Builder
+	for i, l := range lines {
+		if i > 0 {
+			if folded && l != "" && lines[i-1] != "" && l[0] != ' ' && lines[i-1][0] != ' ' {
+				b.WriteByte(' ')
+			} else if !folded || lines[i-1] != "" {
+				b.WriteByte('\n')
+			}
+		}
+		b.WriteString(l)
+	}
+	v := b.String()
+	switch {
+	case chomp == "-" || v == "":
+	case chomp == "+":
+		v += strings.Repeat("\n", trailing+1)
+	default:
+		v += "\n"
+	}
+	return v, nil
+}
+
+// yamlQuoteEnd returns the index of the quote closing the string that starts
+// s, or -1.
+func yamlQuoteEnd(s string) int {
+	q := s[0]
+	for i := 1; i < len(s); i++ {
+		switch {
+		case q == '"' && s[i] == '\\':
+			i++
+		case q == '\'' && s[i] == '\'' && i+1 < len(s) && s[i+1] == '\'':
+			i++
+		case s[i] == q:
+			return i
+		}
+	}
+	return -1
+}
+
+// stripYAMLComment removes a '#' comment that starts the line or follows
+// whitespace, outside quoted strings.
+func stripYAMLComment(line string) string {
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '"', '\'':
+			if i > 0 && line[i-1] != ' ' && line[i-1] != '[' && line[i-1] != '{' && line[i-1] != ',' {
+				continue // an apostrophe inside a plain scalar
+			}
+			if end := yamlQuoteEnd(line[i:]); end > 0 {
+				i += end
+			}
+		case '#':
+			if i == 0 || line[i-1] == ' ' || line[i-1] == '\t' {
+				return line[:i]
+			}
+		}
+	}
+	return line
+}
@@ -0,0 +1,105 @@
+package flag_test
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/machship/flag"
+)
+
+func TestParseFileYAML(t *testing.T) {
+	path := writeTempFile(t, "app.yaml", `---
+# service settings
+name: billing   # trailing comment
+verbose: true
+owner: null
+db:
+  host: "db.internal"
+  port: 5432
+  "pool size": 8
+http:
+  timeouts: [1s, "2.5s"]
+  peers:
+    - a:11211
+    - 'b, c:11211'
+labels:
+  team: payments
+  it's: fine
+tags: {env: prod}
+motd: |
+  Hello, world
+    indented # kept
+
+note: >-
+  folded
+  text
+`)
+	f := NewFlagSet("test", ContinueOnError)
+	name := f.String("name", "", "")
+	verbose := f.Bool("verbose", false, "")
+	owner := f.String("owner", "nobody", "")
+	host := f.String("db.host", "", "")
+	port := f.Int("db.port", 0, "")
+	pool := f.Int("db.pool size", 0, "")
+	timeouts := f.DurationSlice("http.timeouts", ",", nil, "")
+	peers := f.StringSlice("http.peers", ",", nil, "")
+	labels := f.StringMap("labels", nil, "")
+	tags := f.StringMap("tags", nil, "")
+	motd := f.String("motd", "", "")
+	note := f.String("note", "", "")
+	if err := f.ParseFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if *name != "billing" || !*verbose || *owner != "nobody" {
+		t.Errorf("name=%q verbose=%v owner=%q", *name, *verbose, *owner)
+	}
+	if *host != "db.internal" || *port != 5432 || *pool != 8 {
+		t.Errorf("host=%q port=%d pool=%d", *host, *port, *pool)
+	}
+	if want := []time.Duration{time.Second, 2500 * time.Millisecond}; !reflect.DeepEqual(*timeouts, want) {
+		t.Errorf("timeouts = %v, want %v", *timeouts, want)
+	}
+	if want := []string{"a:11211", "b, c:11211"}; !reflect.DeepEqual(*peers, want) {
+		t.Errorf("peers = %q, want %q", *peers, want)
+	}
+	if want := map[string]string{"team": "payments", "it's": "fine"}; !reflect.DeepEqual(*labels, want) {
+		t.Errorf("labels = %v, want %v", *labels, want)
+	}
+	if (*tags)["env"] != "prod" {
+		t.Errorf("tags = %v", *tags)
+	}
+	if *motd != "Hello, world\n  indented # kept\n" {
+		t.Errorf("motd = %q", *motd)
+	}
+	if *note != "folded text" {
+		t.Errorf("note = %q", *note)
+	}
+}
+
+func TestParseFileYAMLErrors(t *testing.T) {
+	tests := []struct {
+		content, want string
+	}{
+		{"name: a\nname: b\n", "duplicate key name on line 2"},
+		{"name: a\n  extra: b\n", "unexpected indentation on line 2"},
+		{"- a\n", "sequence where a mapping key was expected on line 1"},
+		{"list:\n  - name: a\n", "sequences of mappings are not supported"},
+		{"name: *alias\n", "anchors, aliases and tags are not supported on line 1"},
+		{"name: [a,\n  b]\n", "flow collections must fit on one line"},
+		{"a: 1\n---\nb: 2\n", "multiple documents are not supported on line 2"},
+		{"name: [a]\n", "configuration variable name is an array but -name is not a list"},
+	}
+	for _, tt := range tests {
+		f := NewFlagSet("test", ContinueOnError)
+		f.SetOutput(&bytes.Buffer{})
+		f.String("name", "", "")
+		f.String("list", "", "")
+		err := f.ParseFile(writeTempFile(t, "app.yml", tt.content))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%q: err = %v, want %q", tt.content, err, tt.want)
+		}
+	}
+}